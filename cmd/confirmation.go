@@ -7,6 +7,7 @@ import (
 	"github.com/dustin/go-humanize"
 	"github.com/nlewo/comin/pkg/client"
 	"github.com/nlewo/comin/internal/manager"
+	"github.com/nlewo/comin/internal/store"
 	"github.com/nlewo/comin/pkg/protobuf"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -63,6 +64,22 @@ var confirmationShowCmd = &cobra.Command{
 		}
 		confirmerShow(status.BuildConfirmer, "building")
 		confirmerShow(status.DeployConfirmer, "deploying")
+		// The closure diff helps to decide whether a built
+		// generation should be deployed
+		if g := status.Builder.Generation; g != nil && g.Uuid == status.DeployConfirmer.Submitted && g.ClosureDiff != nil {
+			fmt.Printf("Changes: %s\n", store.ClosureDiffSummary(g.ClosureDiff))
+			for _, l := range store.ClosureDiffLines(g.ClosureDiff) {
+				fmt.Printf("  %s\n", l)
+			}
+		}
+		if g := status.Builder.Generation; g != nil && g.Uuid == status.DeployConfirmer.Submitted {
+			if lines := store.UnitChangesLines(g.UnitChanges); len(lines) > 0 {
+				fmt.Printf("Units:\n")
+				for _, l := range lines {
+					fmt.Printf("  %s\n", l)
+				}
+			}
+		}
 	},
 }
 
@@ -75,6 +92,9 @@ func confirmerShow(c *protobuf.Confirmer, for_ string) {
 	if c.Submitted != "" {
 		empty = false
 		fmt.Printf("Confirmation needed for %s: %s\n", for_, c.Submitted)
+		if c.HeldReason != "" {
+			fmt.Printf("  Manual confirmation required: %s\n", c.HeldReason)
+		}
 		if c.Mode == int64(manager.Auto) {
 			fmt.Printf("  Auto confirmation in %s\n", humanize.Time(c.AutoconfirmStartedAt.AsTime().Add(time.Duration(c.AutoconfirmDuration*int64(time.Second)))))
 
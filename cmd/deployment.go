@@ -6,10 +6,12 @@ import (
 	"strings"
 	"time"
 
+	storePkg "github.com/nlewo/comin/internal/store"
 	"github.com/nlewo/comin/pkg/client"
 	"github.com/nlewo/comin/pkg/protobuf"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 var deploymentCmd = &cobra.Command{
@@ -18,10 +20,51 @@ var deploymentCmd = &cobra.Command{
 
 var bootOnly bool
 
+var deploymentListOpts struct {
+	status    string
+	operation string
+	branch    string
+	commitId  string
+	since     string
+	until     string
+	order     string
+	limit     int
+	output    string
+}
+
 var deploymentListCmd = &cobra.Command{
-	Use:  "list",
+	Use:   "list",
+	Short: "List the deployments",
+	Long: `List the deployments, oldest first, or newest first with --boot. They are read from the deployment history when it is enabled, otherwise from the state.
+The --since and --until flags accept a duration, such as 24h, a date, a date time or a RFC 3339 time.`,
 	Args: cobra.MinimumNArgs(0),
 	Run: func(cmd *cobra.Command, args []string) {
+		o := deploymentListOpts
+		if err := checkOutputFormat(o.output); err != nil {
+			logrus.Fatal(err)
+		}
+		req := &protobuf.ListDeploymentsRequest{
+			Status:      o.status,
+			Operation:   o.operation,
+			Branch:      o.branch,
+			CommitId:    o.commitId,
+			Order:       o.order,
+			BootEntries: bootOnly,
+		}
+		if o.since != "" {
+			t, err := parseTime(o.since)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			req.Since = timestamppb.New(t)
+		}
+		if o.until != "" {
+			t, err := parseTime(o.until)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			req.Until = timestamppb.New(t)
+		}
 		opts := client.ClientOpts{
 			UnixSocketPath: "/var/lib/comin/grpc.sock",
 		}
@@ -29,15 +72,58 @@ var deploymentListCmd = &cobra.Command{
 		if err != nil {
 			logrus.Fatal(err)
 		}
+		dpls, err := c.ListDeployments(req, o.limit)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if o.output != "text" {
+			if err := outputDeployments(o.output, dpls, false); err != nil {
+				logrus.Fatal(err)
+			}
+			return
+		}
+		// The state is only used to show the retention lists
 		status, err := c.GetManagerState()
 		if err != nil {
 			logrus.Fatal(err)
 		}
-		dpls := status.Store.Deployments
-		if bootOnly {
-			dpls = filterBootEntryDeployments(dpls, status.Store)
+		deploymentList(dpls, status.Store)
+	},
+}
+
+var deploymentGetOutput string
+
+var deploymentGetCmd = &cobra.Command{
+	Use:   "get DEPLOYMENT_UUID",
+	Short: "Show a deployment",
+	Long:  "Show a deployment of the state or of the deployment history.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := checkOutputFormat(deploymentGetOutput); err != nil {
+			logrus.Fatal(err)
+		}
+		opts := client.ClientOpts{
+			UnixSocketPath: "/var/lib/comin/grpc.sock",
+		}
+		c, err := client.New(opts)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		dpl, err := c.GetDeployment(args[0])
+		if err != nil {
+			logrus.Fatal(err)
 		}
-		deploymentList(dpls, status.Store, bootOnly)
+		if deploymentGetOutput != "text" {
+			if err := outputDeployments(deploymentGetOutput, []*protobuf.Deployment{dpl}, true); err != nil {
+				logrus.Fatal(err)
+			}
+			return
+		}
+		status, err := c.GetManagerState()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		deploymentList([]*protobuf.Deployment{dpl}, status.Store)
 	},
 }
 
@@ -80,15 +166,7 @@ func filterBootEntryDeployments(dpls []*protobuf.Deployment, store *protobuf.Sto
 	return filtered
 }
 
-func deploymentList(dpls []*protobuf.Deployment, store *protobuf.Store, newestFirst bool) {
-	endedAtCmp := func(a, b *protobuf.Deployment) int {
-		return a.EndedAt.AsTime().Compare(b.EndedAt.AsTime())
-	}
-	slices.SortFunc(dpls, endedAtCmp)
-	if newestFirst {
-		slices.Reverse(dpls)
-	}
-
+func deploymentList(dpls []*protobuf.Deployment, store *protobuf.Store) {
 	for _, dpl := range dpls {
 		fmt.Printf("%s\n", dpl.Uuid)
 		fmt.Printf("  status             %s\n", dpl.Status)
@@ -100,6 +178,24 @@ func deploymentList(dpls []*protobuf.Deployment, store *protobuf.Store, newestFi
 		fmt.Printf("  out path           %s\n", dpl.Generation.OutPath)
 		fmt.Printf("  generation uuid    %s\n", dpl.Generation.Uuid)
 		fmt.Printf("    commit id        %s\n", dpl.Generation.SelectedCommitId)
+		if dpl.ErrorMsg != "" {
+			fmt.Printf("  error              %s\n", dpl.ErrorMsg)
+		}
+		if lines := storePkg.InhibitorChangeLines(dpl.InhibitorChanges); len(lines) > 0 {
+			fmt.Printf("  inhibitor changes\n")
+			for _, line := range lines {
+				fmt.Printf("    %s\n", line)
+			}
+		}
+		if o := dpl.InhibitorOverride; o != nil {
+			fmt.Printf("  inhibitors override %s: %s\n", o.CreatedAt.AsTime().Format(time.DateTime), o.Comment)
+		}
+		if lines := storePkg.HookResultLines(dpl.Hooks); len(lines) > 0 {
+			fmt.Printf("  hooks\n")
+			for _, line := range lines {
+				fmt.Printf("    %s\n", line)
+			}
+		}
 		if lists := retentionListsForDeployment(dpl.Uuid, store); len(lists) > 0 {
 			fmt.Printf("  part of retention  %s\n", strings.Join(lists, ", "))
 		}
@@ -131,7 +227,7 @@ var deploymentLatestCmd = &cobra.Command{
 			return a.EndedAt.AsTime().Compare(b.EndedAt.AsTime())
 		})
 		latest := dpls[len(dpls)-1]
-		deploymentList([]*protobuf.Deployment{latest}, status.Store, false)
+		deploymentList([]*protobuf.Deployment{latest}, status.Store)
 	},
 }
 
@@ -219,6 +315,48 @@ var deploymentLatestSubmitCmd = &cobra.Command{
 	},
 }
 
+var deploymentOverrideWindowCmd = &cobra.Command{
+	Use:   "override-window",
+	Short: "Deploy the generation waiting for a deployment window now",
+	Long:  "Deploy now the generation which has been postponed because it is outside of the deployment windows of its branch.",
+	Args:  cobra.MinimumNArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := client.ClientOpts{
+			UnixSocketPath: "/var/lib/comin/grpc.sock",
+		}
+		c, err := client.New(opts)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		err = c.DeploymentWindowOverride()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+var deploymentOverrideInhibitorsCmd = &cobra.Command{
+	Use:   "override-inhibitors GENERATION_UUID",
+	Short: "Switch to a generation despite its different switch inhibitors",
+	Long:  "Accept the switch inhibitor changes of a generation, such as a systemd or kernel change. When the generation has been deployed with the boot operation because of these changes, it is redeployed with the submitted operation. The override is recorded in the deployment.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		keys, _ := cmd.Flags().GetStringSlice("inhibitor")
+		comment, _ := cmd.Flags().GetString("comment")
+		opts := client.ClientOpts{
+			UnixSocketPath: "/var/lib/comin/grpc.sock",
+		}
+		c, err := client.New(opts)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		err = c.InhibitorOverride(args[0], keys, comment)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
 // TODO: remove this two releases after v0.12.0
 var deploymentLatestSwitchCmd = &cobra.Command{
 	Use:        "switch-latest",
@@ -229,10 +367,25 @@ var deploymentLatestSwitchCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(deploymentCmd)
 	deploymentCmd.AddCommand(deploymentListCmd)
-	deploymentListCmd.Flags().BoolVar(&bootOnly, "boot", false, "only show boot entry deployments, newest first")
+	deploymentListCmd.Flags().BoolVar(&bootOnly, "boot", false, "only show boot entry deployments")
+	deploymentListCmd.Flags().StringVar(&deploymentListOpts.status, "status", "", "only show the deployments with this status")
+	deploymentListCmd.Flags().StringVar(&deploymentListOpts.operation, "operation", "", "only show the deployments with this operation: [boot, test, switch]")
+	deploymentListCmd.Flags().StringVar(&deploymentListOpts.branch, "branch", "", "only show the deployments of this branch")
+	deploymentListCmd.Flags().StringVar(&deploymentListOpts.commitId, "commit", "", "only show the deployments of this commit ID prefix")
+	deploymentListCmd.Flags().StringVar(&deploymentListOpts.since, "since", "", "only show the deployments created after this time")
+	deploymentListCmd.Flags().StringVar(&deploymentListOpts.until, "until", "", "only show the deployments created before this time")
+	deploymentListCmd.Flags().StringVar(&deploymentListOpts.order, "order", "", "the order of the deployments: [newest-first, oldest-first] (default oldest-first, newest-first with --boot)")
+	deploymentListCmd.Flags().IntVar(&deploymentListOpts.limit, "limit", 0, "the maximal number of deployments, all of them when 0")
+	deploymentListCmd.Flags().StringVarP(&deploymentListOpts.output, "output", "o", "text", "the output format: [text, json, yaml, table]")
+	deploymentCmd.AddCommand(deploymentGetCmd)
+	deploymentGetCmd.Flags().StringVarP(&deploymentGetOutput, "output", "o", "text", "the output format: [text, json, yaml, table]")
 	deploymentCmd.AddCommand(deploymentLatestCmd)
 	deploymentLatestSubmitCmd.Flags().StringP("operation", "", "", "the deployment operation: [boot, test, switch]")
 	deploymentCmd.AddCommand(deploymentLatestSubmitCmd)
 	deploymentCmd.AddCommand(deploymentLatestSwitchCmd)
 	deploymentCmd.AddCommand(deploymentRetentionList)
+	deploymentCmd.AddCommand(deploymentOverrideWindowCmd)
+	deploymentOverrideInhibitorsCmd.Flags().StringSliceP("inhibitor", "", nil, "the switch inhibitor to override, all of them by default")
+	deploymentOverrideInhibitorsCmd.Flags().StringP("comment", "", "", "the reason of the override, recorded in the deployment")
+	deploymentCmd.AddCommand(deploymentOverrideInhibitorsCmd)
 }
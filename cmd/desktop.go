@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/gen2brain/beeep"
 	"github.com/nlewo/comin/internal/builder"
 	"github.com/nlewo/comin/pkg/client"
@@ -17,6 +19,12 @@ import (
 
 var title string
 
+// progressNotificationPeriod is the minimal period between two build
+// progress notifications.
+const progressNotificationPeriod = 5 * time.Minute
+
+var lastProgressNotification time.Time
+
 var desktopCmd = &cobra.Command{
 	Use:   "desktop",
 	Short: "Send desktop notifications ",
@@ -80,7 +88,7 @@ func scenario() {
 	handler(&e) // nolint: errcheck
 
 	time.Sleep(time.Second)
-	e = protobuf.Event{Type: &protobuf.Event_RebootRequired_{RebootRequired: &protobuf.Event_RebootRequired{Deployment: &d}}}
+	e = protobuf.Event{Type: &protobuf.Event_RebootRequired_{RebootRequired: &protobuf.Event_RebootRequired{Deployment: &d, Reasons: []string{"the kernel has changed"}}}}
 	handler(&e) // nolint: errcheck
 }
 
@@ -106,8 +114,13 @@ func handler(event *protobuf.Event) error {
 		}
 	case *protobuf.Event_BuildStartedType:
 		g := event.Type.(*protobuf.Event_BuildStartedType).BuildStartedType.Generation
-		if g.BuildReason == builder.BuildReasonNeedBuild {
+		switch g.BuildReason {
+		case builder.BuildReasonNeedBuild:
 			message = fmt.Sprintf("A new commit from %s/%s is building.", g.SelectedRemoteName, g.SelectedBranchName)
+		case builder.BuildReasonNeedSubstitution:
+			message = fmt.Sprintf("A new commit from %s/%s is fetched from binary caches.", g.SelectedRemoteName, g.SelectedBranchName)
+		case builder.BuildReasonCacheDeadline:
+			message = "The binary cache deadline is reached, the commit is building locally."
 		}
 	case *protobuf.Event_BuildFinishedType:
 		dpl := event.Type.(*protobuf.Event_BuildFinishedType).BuildFinishedType.Generation
@@ -118,6 +131,17 @@ func handler(event *protobuf.Event) error {
 		default:
 			logrus.Errorf("unexpected deployment status: %s", dpl.BuildStatus)
 		}
+	case *protobuf.Event_BuildProgressType:
+		summary := builder.ProgressSummary(event.Type.(*protobuf.Event_BuildProgressType).BuildProgressType.Progress)
+		if summary != "" && time.Since(lastProgressNotification) >= progressNotificationPeriod {
+			lastProgressNotification = time.Now()
+			message = fmt.Sprintf("Building: %s.", summary)
+		}
+	case *protobuf.Event_BuildWaitingForCacheType:
+		message = "The build is waiting for the binary cache."
+	case *protobuf.Event_BuildSkippedType:
+		g := event.Type.(*protobuf.Event_BuildSkippedType).BuildSkippedType.Generation
+		message = fmt.Sprintf("The build has been skipped: %s.", g.BuildReason)
 	case *protobuf.Event_DeploymentStartedType:
 		message = "A deployment started."
 	case *protobuf.Event_DeploymentFinishedType:
@@ -130,8 +154,13 @@ func handler(event *protobuf.Event) error {
 		default:
 			logrus.Errorf("unexpected deployment status: %s", dpl.Status)
 		}
+	case *protobuf.Event_RebootPlannedType:
+		message = fmt.Sprintf("The machine will be rebooted %s.", humanize.Time(v.RebootPlannedType.Reboot.PlannedAt.AsTime()))
 	case *protobuf.Event_RebootRequired_:
 		message = "The machine needs to be rebooted to take the deployment into account."
+		if reasons := v.RebootRequired.Reasons; len(reasons) > 0 {
+			message = fmt.Sprintf("The machine needs to be rebooted: %s.", strings.Join(reasons, ", "))
+		}
 	}
 	if message != "" {
 		err := beeep.Notify(title, message, []byte{})
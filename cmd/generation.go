@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/nlewo/comin/internal/store"
+	"github.com/nlewo/comin/pkg/client"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var generationCmd = &cobra.Command{
+	Use: "generation",
+}
+
+var logsEval bool
+var logsFollow bool
+
+var generationLogsCmd = &cobra.Command{
+	Use:   "logs UUID",
+	Short: "Show the build or evaluation logs of a generation",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := client.ClientOpts{
+			UnixSocketPath: "/var/lib/comin/grpc.sock",
+		}
+		c, err := client.New(opts)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		kind := store.LogsBuild
+		if logsEval {
+			kind = store.LogsEval
+		}
+		if err := c.GenerationLogs(cmd.Context(), args[0], kind, logsFollow, os.Stdout); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+var generationListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the generations retained in the store",
+	Long:  "List the generations retained in the store, newest first: the last evaluated and built generations as well as the last built generations kept with a GC root.",
+	Args:  cobra.MinimumNArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := client.ClientOpts{
+			UnixSocketPath: "/var/lib/comin/grpc.sock",
+		}
+		c, err := client.New(opts)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		state, err := c.GetManagerState()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		generations := state.Store.Generations
+		fmt.Printf("Retained generations (built capacity: %d)\n\n", state.Store.GenerationBuiltCapacity)
+		for _, g := range slices.Backward(generations) {
+			fmt.Printf("%s\n", g.Uuid)
+			fmt.Printf("  commit id          %s from %s/%s\n", g.SelectedCommitId, g.SelectedRemoteName, g.SelectedBranchName)
+			fmt.Printf("  eval status        %s\n", g.EvalStatus)
+			fmt.Printf("  build status       %s\n", g.BuildStatus)
+			if g.BuildStatus == store.Built.String() {
+				fmt.Printf("  built at           %s\n", g.BuildEndedAt.AsTime().Format(time.DateTime))
+			}
+			if g.OutPath != "" {
+				fmt.Printf("  out path           %s\n", g.OutPath)
+			}
+			if g.GcRoot != "" {
+				fmt.Printf("  gc root            %s\n", g.GcRoot)
+			}
+			fmt.Print("\n")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(generationCmd)
+	generationLogsCmd.Flags().BoolVarP(&logsEval, "eval", "", false, "show the evaluation logs instead of the build logs")
+	generationLogsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "follow the logs until the end of the evaluation or build")
+	generationCmd.AddCommand(generationLogsCmd)
+	generationCmd.AddCommand(generationListCmd)
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nlewo/comin/pkg/protobuf"
+	"google.golang.org/protobuf/encoding/protojson"
+	"gopkg.in/yaml.v2"
+)
+
+var outputFormats = []string{"text", "json", "yaml", "table"}
+
+func checkOutputFormat(output string) error {
+	if !slices.Contains(outputFormats, output) {
+		return fmt.Errorf("the output format is '%s' while it must be one of [%s]", output, strings.Join(outputFormats, ", "))
+	}
+	return nil
+}
+
+// deploymentsToJSON returns the deployments as a JSON array, or as a
+// JSON object if single is true
+func deploymentsToJSON(dpls []*protobuf.Deployment, single bool) ([]byte, error) {
+	marshaler := protojson.MarshalOptions{
+		UseProtoNames:   true,
+		EmitUnpopulated: true,
+	}
+	items := make([]json.RawMessage, 0, len(dpls))
+	for _, d := range dpls {
+		buf, err := marshaler.Marshal(d)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, buf)
+	}
+	if single && len(items) == 1 {
+		return items[0], nil
+	}
+	return json.Marshal(items)
+}
+
+// outputDeployments prints the deployments in the json, yaml or table
+// output format. If single is true, a single deployment is printed as
+// an object instead of a list.
+func outputDeployments(output string, dpls []*protobuf.Deployment, single bool) error {
+	switch output {
+	case "json":
+		buf, err := deploymentsToJSON(dpls, single)
+		if err != nil {
+			return err
+		}
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, buf, "", "  "); err != nil {
+			return err
+		}
+		fmt.Println(indented.String())
+	case "yaml":
+		buf, err := deploymentsToJSON(dpls, single)
+		if err != nil {
+			return err
+		}
+		// The JSON representation is converted to keep the proto
+		// field names
+		var value any
+		if err := yaml.Unmarshal(buf, &value); err != nil {
+			return err
+		}
+		out, err := yaml.Marshal(value)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(out))
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "UUID\tSTATUS\tOPERATION\tCREATED AT\tENDED AT\tBRANCH\tCOMMIT") // nolint: errcheck
+		for _, d := range dpls {
+			var endedAt string
+			if d.EndedAt != nil {
+				endedAt = d.EndedAt.AsTime().Local().Format(time.DateTime)
+			}
+			commitId := d.Generation.GetSelectedCommitId()
+			if len(commitId) > 8 {
+				commitId = commitId[:8]
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", // nolint: errcheck
+				d.Uuid, d.Status, d.Operation,
+				d.CreatedAt.AsTime().Local().Format(time.DateTime), endedAt,
+				d.Generation.GetSelectedBranchName(), commitId)
+		}
+		return w.Flush()
+	}
+	return nil
+}
+
+// parseTime parses an RFC 3339 time, a date, a date time or a
+// duration, such as 24h, which is subtracted from the current time
+func parseTime(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{time.DateTime, time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("the time '%s' must be a duration, a date, a date time or a RFC 3339 time", value)
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/nlewo/comin/pkg/client"
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var rebootCmd = &cobra.Command{
+	Use:   "reboot",
+	Short: "Manage the automatic reboots",
+}
+
+var rebootStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the planned automatic reboot",
+	Args:  cobra.MinimumNArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := client.ClientOpts{
+			UnixSocketPath: "/var/lib/comin/grpc.sock",
+		}
+		c, err := client.New(opts)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		state, err := c.GetManagerState()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		rebootShow(state)
+	},
+}
+
+var rebootCancelCmd = &cobra.Command{
+	Use:   "cancel",
+	Short: "Cancel the planned automatic reboot",
+	Long:  "This command cancels the planned automatic reboot. A new reboot is planned by the next deployment requiring a reboot.",
+	Args:  cobra.MinimumNArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := client.ClientOpts{
+			UnixSocketPath: "/var/lib/comin/grpc.sock",
+		}
+		c, err := client.New(opts)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		err = c.RebootCancel()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		fmt.Printf("The planned reboot has been cancelled\n")
+	},
+}
+
+func rebootShow(state *protobuf.State) {
+	r := state.Reboot
+	if r == nil {
+		fmt.Printf("Automatic reboots are not enabled\n")
+		return
+	}
+	if len(r.Windows) > 0 {
+		fmt.Printf("Maintenance windows: %s\n", strings.Join(r.Windows, ", "))
+	} else {
+		fmt.Printf("Maintenance windows: always\n")
+	}
+	switch {
+	case r.PlannedAt != nil:
+		fmt.Printf("Reboot planned %s (%s)\n", humanize.Time(r.PlannedAt.AsTime()), r.PlannedAt.AsTime().Local().Format("2006-01-02 15:04:05"))
+		for _, reason := range r.Reasons {
+			fmt.Printf("  %s\n", reason)
+		}
+		if r.InhibitedBy != "" {
+			fmt.Printf("  Postponed because %s\n", r.InhibitedBy)
+		}
+		if r.Error != "" {
+			fmt.Printf("  The last attempt failed: %s\n", r.Error)
+		}
+	case r.Cancelled.GetValue():
+		fmt.Printf("The planned reboot has been cancelled\n")
+	default:
+		fmt.Printf("No reboot is planned\n")
+	}
+	if r.LastReboot != nil {
+		fmt.Printf("Last reboot %s: %s\n", humanize.Time(r.LastReboot.RebootedAt.AsTime()), strings.Join(r.LastReboot.Reasons, ", "))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(rebootCmd)
+	rebootCmd.AddCommand(rebootStatusCmd)
+	rebootCmd.AddCommand(rebootCancelCmd)
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path"
 	"runtime"
@@ -10,16 +12,27 @@ import (
 	"github.com/nlewo/comin/internal/builder"
 	"github.com/nlewo/comin/internal/config"
 	"github.com/nlewo/comin/internal/deployer"
+	"github.com/nlewo/comin/internal/diskspace"
 	executorPkg "github.com/nlewo/comin/internal/executor"
 	"github.com/nlewo/comin/internal/fetcher"
+	"github.com/nlewo/comin/internal/forge"
+	"github.com/nlewo/comin/internal/gc"
+	"github.com/nlewo/comin/internal/history"
+	"github.com/nlewo/comin/internal/hooks"
 	"github.com/nlewo/comin/internal/http"
 	"github.com/nlewo/comin/internal/manager"
+	"github.com/nlewo/comin/internal/manifest"
+	"github.com/nlewo/comin/internal/notifier"
 	"github.com/nlewo/comin/internal/prometheus"
 	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/nlewo/comin/internal/reboot"
+	"github.com/nlewo/comin/internal/rollout"
 	"github.com/nlewo/comin/internal/repository"
 	"github.com/nlewo/comin/internal/scheduler"
 	"github.com/nlewo/comin/internal/server"
 	storePkg "github.com/nlewo/comin/internal/store"
+	"github.com/nlewo/comin/internal/types"
+	"github.com/nlewo/comin/internal/window"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -67,6 +80,9 @@ var runCmd = &cobra.Command{
 			logrus.Errorf("Failed to create the executor: %s", err)
 			return
 		}
+		if len(cfg.Builder.Builders) > 0 || cfg.Builder.BuildHost != "" {
+			executor = executorPkg.NewRemote(executor, cfg.Builder.Builders, cfg.Builder.BuildHost)
+		}
 
 		machineId, err := executor.ReadMachineId()
 		if err != nil {
@@ -79,13 +95,36 @@ var runCmd = &cobra.Command{
 		gcRootsDir := path.Join(cfg.StateDir, "gcroots")
 		broker := brokerPkg.New()
 		broker.Start()
-		store, err := storePkg.New(broker, storeFilename, gcRootsDir, cfg.Retention.DeploymentBootEntryCapacity, cfg.Retention.DeploymentSuccessfulCapacity, cfg.Retention.DeploymentAnyCapacity)
+		store, err := storePkg.New(broker, storeFilename, gcRootsDir, cfg.Retention.DeploymentBootEntryCapacity, cfg.Retention.DeploymentSuccessfulCapacity, cfg.Retention.DeploymentAnyCapacity, cfg.Retention.GenerationBuiltCapacity)
 		if err != nil {
 			logrus.Error(err)
 			os.Exit(1)
 		}
 		if err := store.Load(); err != nil {
-			logrus.Errorf("Ignoring the state file %s because of the loading error: %s", storeFilename, err)
+			if !errors.Is(err, storePkg.ErrCorrupted) || cfg.StateOnCorruption != "quarantine" {
+				logrus.Errorf("Failed to load the state file %s: %s", storeFilename, err)
+				os.Exit(1)
+			}
+			logrus.Errorf("Quarantining the state file %s because of the loading error: %s", storeFilename, err)
+			if _, err := store.Quarantine(); err != nil {
+				logrus.Error(err)
+				os.Exit(1)
+			}
+			if err := store.Load(); err != nil {
+				logrus.Error(err)
+				os.Exit(1)
+			}
+		}
+		if cfg.History.Enable {
+			historyFilename := path.Join(cfg.StateDir, "history", "history.db")
+			h, err := history.OpenBolt(historyFilename, time.Duration(cfg.History.MaxAge)*time.Second)
+			if err != nil {
+				logrus.Errorf("Failed to open the history %s: %s", historyFilename, err)
+				os.Exit(1)
+			}
+			// The history is initialized with the deployments and
+			// generations of the state file
+			store.SetHistory(h)
 		}
 		metrics.SetBuildInfo(cmd.Version)
 
@@ -104,13 +143,89 @@ var runCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
+		var lifecycleHooks []hooks.Hook
+		for _, hookCfg := range cfg.Hooks {
+			h, err := hooks.New(hookCfg)
+			if err != nil {
+				logrus.Error(err)
+				os.Exit(1)
+			}
+			lifecycleHooks = append(lifecycleHooks, h)
+		}
+		bootId, err := storePkg.BootId()
+		if err != nil {
+			logrus.Errorf("Failed to read the boot ID, the post-reboot hooks are disabled: %s", err)
+		}
+		hooks.NewRunner(broker, store, cfg.Hostname, lifecycleHooks).Start(cmd.Context(), bootId)
+
 		fetcher := fetcher.NewFetcher(repository, broker)
 		fetcher.Start(cmd.Context())
 		sched := scheduler.New()
 		sched.FetchRemotes(fetcher, cfg.Remotes)
+		var collector *gc.Collector
+		if cfg.Gc.Enable || cfg.DiskSpace.CollectGarbage {
+			collector = gc.New(broker, cfg.Gc.AfterDeployment, time.Duration(cfg.Gc.MinInterval)*time.Second, cfg.Gc.MinFree, cfg.Gc.MaxFreed, cfg.Gc.DeleteOlderThan, cfg.Gc.Optimise)
+		}
+		if cfg.Gc.Enable {
+			collector.Start(cmd.Context())
+			if cfg.Gc.Period != 0 {
+				sched.CollectGarbage(cmd.Context(), collector, cfg.Gc.Period)
+			}
+		}
+		var diskSpace *diskspace.Guard
+		if cfg.DiskSpace.Enable {
+			var collectFunc func(ctx context.Context)
+			if cfg.DiskSpace.CollectGarbage {
+				collectFunc = func(ctx context.Context) { collector.Run(ctx, gc.TriggerDiskSpace) }
+			}
+			diskSpace = diskspace.New(cfg.DiskSpace.MinStoreFree, cfg.DiskSpace.MinBootFree, collectFunc)
+		}
 
-		builder := builder.New(store, executor, gitConfig.Path, gitConfig.Dir, cfg.SystemAttr, cfg.Hostname, gitConfig.Submodules, 30*time.Minute, 30*time.Minute)
-		deployer := deployer.New(store, executor.Deploy, lastDeployment, cfg.PostDeploymentCommand)
+		var substituteOnly *builder.SubstituteOnly
+		// The out path of a manifest can only be substituted: the
+		// recheck period and the deadline are also used to wait
+		// for its closure
+		if cfg.Builder.SubstituteOnly.Enable || cfg.Builder.Manifest.Path != "" || cfg.Builder.Manifest.Url != "" {
+			substituteOnly = &builder.SubstituteOnly{
+				RecheckPeriod: time.Duration(cfg.Builder.SubstituteOnly.RecheckPeriod) * time.Second,
+				Deadline:      time.Duration(cfg.Builder.SubstituteOnly.Deadline) * time.Second,
+				Fallback:      cfg.Builder.SubstituteOnly.Fallback,
+			}
+		}
+		var manifestSource *manifest.Source
+		if cfg.Builder.Manifest.Path != "" || cfg.Builder.Manifest.Url != "" {
+			manifestSource, err = manifest.New(cfg.Builder.Manifest.Path, cfg.Builder.Manifest.Url, cfg.Builder.Manifest.GpgPublicKeyPaths)
+			if err != nil {
+				logrus.Error(err)
+				os.Exit(1)
+			}
+		}
+		builder := builder.New(store, executor, gitConfig.Path, gitConfig.Dir, cfg.SystemAttr, cfg.Hostname, gitConfig.Submodules,
+			time.Duration(cfg.Builder.EvalTimeout)*time.Second,
+			time.Duration(cfg.Builder.BuildTimeout)*time.Second,
+			time.Duration(cfg.Builder.MaxSilentTime)*time.Second,
+			substituteOnly,
+			manifestSource,
+			diskSpace)
+		deploymentWindows := deployer.DeploymentWindows{}
+		for _, r := range cfg.Remotes {
+			deploymentWindows[r.Name] = make(map[string]deployer.DeploymentWindow)
+			for _, b := range []types.Branch{r.Branches.Main, r.Branches.Testing} {
+				if len(b.Windows) == 0 {
+					continue
+				}
+				windows, err := window.Parse(b.Windows)
+				if err != nil {
+					logrus.Error(err)
+					os.Exit(1)
+				}
+				deploymentWindows[r.Name][b.Name] = deployer.DeploymentWindow{
+					Windows: windows,
+					Boot:    b.OutsideWindow == "boot",
+				}
+			}
+		}
+		deployer := deployer.New(store, executor.Deploy, lastDeployment, cfg.PostDeploymentCommand, deploymentWindows, lifecycleHooks, diskSpace)
 
 		mode, err := manager.ParseMode(cfg.BuildConfirmer.Mode)
 		if err != nil {
@@ -133,7 +248,33 @@ var runCmd = &cobra.Command{
 			configurationOperations[r.Name][r.Branches.Main.Name] = r.Branches.Main.Operation
 			configurationOperations[r.Name][r.Branches.Testing.Name] = r.Branches.Testing.Operation
 		}
-		manager := manager.New(store, metrics, sched, fetcher, builder, deployer, machineId, cfg.Hostname, executor, buildConfirmer, deployConfirmer, broker, configurationOperations)
+		var rebooter *reboot.Rebooter
+		if cfg.Reboot.Enable {
+			windows, err := window.Parse(cfg.Reboot.Windows)
+			if err != nil {
+				logrus.Error(err)
+				os.Exit(1)
+			}
+			rebooter = reboot.New(store, broker, windows,
+				time.Duration(cfg.Reboot.RandomDelay)*time.Second,
+				reboot.Inhibitors{
+					LoggedInUsers: cfg.Reboot.Inhibitors.LoggedInUsers,
+					LockFile:      cfg.Reboot.Inhibitors.LockFile,
+				})
+		}
+		var rollouter *rollout.Rollout
+		if cfg.Rollout.Enable {
+			var source rollout.Source = rollout.NewURLSource(cfg.Rollout.Url)
+			if cfg.Rollout.Directory != "" {
+				source = rollout.NewDirectorySource(cfg.Rollout.Directory)
+			}
+			wave := rollout.WaveOf(cfg.Hostname, cfg.Rollout.Waves)
+			if cfg.Rollout.Wave != nil {
+				wave = *cfg.Rollout.Wave
+			}
+			rollouter = rollout.New(cfg.Hostname, wave, source, time.Duration(cfg.Rollout.CheckPeriod)*time.Second)
+		}
+		manager := manager.New(store, metrics, sched, fetcher, builder, deployer, machineId, cfg.Hostname, executor, buildConfirmer, deployConfirmer, broker, configurationOperations, cfg.DeployConfirmer.CriticalUnits, rebooter, rollouter)
 
 		http.Serve(manager,
 			metrics,
@@ -144,6 +285,40 @@ var runCmd = &cobra.Command{
 
 		prometheus.Subscribe(broker, &metrics)
 
+		forges := make(map[string]forge.Forge)
+		for _, r := range cfg.Remotes {
+			if !r.CommitStatus.Enable {
+				continue
+			}
+			f, err := forge.New(r)
+			if err != nil {
+				logrus.Error(err)
+				os.Exit(1)
+			}
+			forges[r.Name] = f
+		}
+		if len(forges) > 0 {
+			forge.NewReporter(broker, cfg.Hostname, forges).Start(cmd.Context())
+		}
+
+		if len(cfg.Notifier.Sinks) > 0 {
+			var sinks []*notifier.Sink
+			for _, sinkCfg := range cfg.Notifier.Sinks {
+				sink, err := notifier.NewSink(sinkCfg)
+				if err != nil {
+					logrus.Error(err)
+					os.Exit(1)
+				}
+				sinks = append(sinks, sink)
+			}
+			n, err := notifier.New(broker, cfg.Hostname, sinks, notifier.OutboxPath(cfg.StateDir))
+			if err != nil {
+				logrus.Error(err)
+				os.Exit(1)
+			}
+			n.Start(cmd.Context())
+		}
+
 		manager.Run(cmd.Context())
 	},
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/dustin/go-humanize"
 	"google.golang.org/protobuf/encoding/protojson"
 
+	"github.com/nlewo/comin/internal/builder"
 	"github.com/nlewo/comin/pkg/client"
 	"github.com/nlewo/comin/internal/deployer"
 	pb "github.com/nlewo/comin/pkg/protobuf"
@@ -22,6 +23,12 @@ func longStatus(status *pb.State) {
 	fmt.Printf("Status of the machine %s\n", status.Builder.Hostname)
 	if status.NeedToReboot.GetValue() {
 		fmt.Printf("  Need to reboot: yes\n")
+		for _, r := range status.RebootReasons {
+			fmt.Printf("    %s\n", r)
+		}
+		if status.Reboot != nil && status.Reboot.PlannedAt != nil {
+			fmt.Printf("    Reboot planned %s\n", humanize.Time(status.Reboot.PlannedAt.AsTime()))
+		}
 	}
 	if status.IsSuspended.GetValue() {
 		fmt.Printf("  Is suspended: yes\n")
@@ -42,10 +49,25 @@ func longStatus(status *pb.State) {
 	fmt.Printf("  Builder\n")
 	if status.Builder.Generation != nil {
 		store.GenerationShow(status.Builder.Generation)
+		if status.Builder.IsBuilding.GetValue() && status.Builder.BuildProgress != nil {
+			if summary := builder.ProgressSummary(status.Builder.BuildProgress); summary != "" {
+				fmt.Printf("      Progress: %s\n", summary)
+			}
+			if status.Builder.BuildProgress.Phase != "" {
+				fmt.Printf("      Phase: %s\n", status.Builder.BuildProgress.Phase)
+			}
+		}
 	} else {
 		fmt.Printf("    No build available\n")
 	}
 	deployer.Show(status.Deployer, "    ")
+	if status.Rollout != nil {
+		fmt.Printf("  Rollout\n")
+		fmt.Printf("    Wave %d with the %s\n", status.Rollout.Wave, status.Rollout.Source)
+		if status.Rollout.GenerationUuid != "" {
+			fmt.Printf("    Commit %s is held: %s\n", status.Rollout.CommitId, status.Rollout.WaitingReason)
+		}
+	}
 }
 
 func jsonStatus(status *pb.State) {
@@ -78,12 +100,21 @@ func onelineStatus(status *pb.State) {
 	if status.Builder.Generation != nil && status.Builder.IsEvaluating.GetValue() {
 		fmt.Printf(" eval   %s/%s (%s)", status.Builder.Generation.SelectedRemoteName, status.Builder.Generation.SelectedBranchName,
 			humanize.Time(status.Builder.Generation.EvalStartedAt.AsTime()))
+	} else if status.Builder.Generation != nil && status.Builder.Generation.BuildStatus == store.BuildWaitingForCache.String() {
+		fmt.Printf(" cache  %s/%s (%s)", status.Builder.Generation.SelectedRemoteName, status.Builder.Generation.SelectedBranchName,
+			humanize.Time(status.Builder.Generation.BuildStartedAt.AsTime()))
 	} else if status.Builder.Generation != nil && status.Builder.IsBuilding.GetValue() {
 		fmt.Printf(" build  %s/%s (%s)", status.Builder.Generation.SelectedRemoteName, status.Builder.Generation.SelectedBranchName,
 			humanize.Time(status.Builder.Generation.BuildStartedAt.AsTime()))
+		if p := status.Builder.BuildProgress; p != nil && p.DerivationsExpected > 0 {
+			fmt.Printf(" [%d/%d]", p.DerivationsBuilt, p.DerivationsExpected)
+		}
 	} else if status.Builder.Generation != nil && status.Builder.Generation.EvalStatus == store.EvalFailed.String() {
 		fmt.Printf(" %s/%s (%s)", status.Builder.Generation.SelectedRemoteName, status.Builder.Generation.SelectedBranchName,
 			humanize.Time(status.Builder.Generation.EvalEndedAt.AsTime()))
+	} else if status.Builder.Generation != nil && status.Builder.Generation.BuildStatus == store.BuildSkipped.String() {
+		fmt.Printf(" skipped %s/%s (%s)", status.Builder.Generation.SelectedRemoteName, status.Builder.Generation.SelectedBranchName,
+			humanize.Time(status.Builder.Generation.BuildEndedAt.AsTime()))
 	} else if status.Builder.Generation != nil && status.Builder.Generation.BuildStatus == store.BuildFailed.String() {
 		fmt.Printf(" %s/%s (%s)", status.Builder.Generation.SelectedRemoteName, status.Builder.Generation.SelectedBranchName,
 			humanize.Time(status.Builder.Generation.BuildEndedAt.AsTime()))
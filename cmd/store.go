@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nlewo/comin/internal/store"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var stateFilepath string
+
+var storeCmd = &cobra.Command{
+	Use:   "store",
+	Short: "Inspect and recover the comin state file offline",
+}
+
+var storeVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify the state file and its backups",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		valid := verifyStateFile(stateFilepath)
+		for _, backup := range store.Backups(stateFilepath) {
+			verifyStateFile(backup)
+		}
+		if !valid {
+			os.Exit(1)
+		}
+	},
+}
+
+var storeRestoreCmd = &cobra.Command{
+	Use:   "restore [BACKUP]",
+	Short: "Restore the state file from a backup",
+	Long: `Restore the state file from a backup. When no backup is provided, the most recent valid backup is used.
+The replaced state file is kept as a backup. comin must be stopped while the state file is restored.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var backup string
+		if len(args) == 1 {
+			backup = args[0]
+		} else {
+			for _, b := range store.Backups(stateFilepath) {
+				if _, _, err := store.Verify(b); err == nil {
+					backup = b
+					break
+				}
+			}
+			if backup == "" {
+				logrus.Fatalf("No valid backup of %s has been found", stateFilepath)
+			}
+		}
+		if err := store.Restore(stateFilepath, backup); err != nil {
+			logrus.Fatal(err)
+		}
+		fmt.Printf("The state file %s has been restored from %s\n", stateFilepath, backup)
+	},
+}
+
+// verifyStateFile prints the verification result of a state file and
+// returns whether it is valid
+func verifyStateFile(filename string) bool {
+	data, version, err := store.Verify(filename)
+	if err != nil {
+		fmt.Printf("%s: invalid: %s\n", filename, err)
+		return false
+	}
+	fmt.Printf("%s: valid (schema version %d, %d deployments, %d generations)\n", filename, version, len(data.Deployments), len(data.Generations))
+	return true
+}
+
+func init() {
+	rootCmd.AddCommand(storeCmd)
+	storeCmd.PersistentFlags().StringVarP(&stateFilepath, "state-file", "", "/var/lib/comin/store.json", "the path of the comin state file")
+	storeCmd.AddCommand(storeVerifyCmd)
+	storeCmd.AddCommand(storeRestoreCmd)
+}
@@ -13,7 +13,7 @@ func New() *Broker {
 	return &Broker{
 		stopCh:    make(chan struct{}),
 		publishCh: make(chan *protobuf.Event, 1),
-		subCh:     make(chan chan *protobuf.Event, 1),
+		subCh:     make(chan chan *protobuf.Event),
 		unsubCh:   make(chan chan *protobuf.Event, 1),
 	}
 }
@@ -46,6 +46,9 @@ func (b *Broker) Stop() {
 	close(b.stopCh)
 }
 
+// Subscribe returns a channel receiving the events published after
+// its return. The subscription channel is not buffered to guarantee
+// it.
 func (b *Broker) Subscribe() chan *protobuf.Event {
 	msgCh := make(chan *protobuf.Event, 5)
 	b.subCh <- msgCh
@@ -11,12 +11,17 @@ package builder
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/nlewo/comin/internal/diskspace"
 	"github.com/nlewo/comin/internal/executor"
+	"github.com/nlewo/comin/internal/manifest"
 	"github.com/nlewo/comin/internal/store"
 	"github.com/nlewo/comin/pkg/protobuf"
 	"github.com/sirupsen/logrus"
@@ -24,8 +29,11 @@ import (
 )
 
 const (
-	BuildReasonAlreadyBuilt = "already built"
-	BuildReasonNeedBuild    = "need to be built"
+	BuildReasonAlreadyBuilt      = "already built"
+	BuildReasonNeedBuild         = "need to be built"
+	BuildReasonMachineIdMismatch = "machine-id mismatch"
+	BuildReasonNeedSubstitution  = "need to be substituted"
+	BuildReasonCacheDeadline     = "binary cache deadline reached"
 )
 
 type Builder struct {
@@ -38,6 +46,17 @@ type Builder struct {
 	submodules     bool
 	evalTimeout    time.Duration
 	buildTimeout   time.Duration
+	// maxSilentTime cancels a build which doesn't produce any
+	// output during this duration. It is disabled when 0.
+	maxSilentTime time.Duration
+	// substituteOnly is nil when generations are built
+	substituteOnly *SubstituteOnly
+	// manifest replaces the evaluation of configurations when it
+	// is not nil
+	manifest *manifest.Source
+	// diskSpace checks the Nix store free space before building.
+	// It is disabled when nil.
+	diskSpace *diskspace.Guard
 
 	mu           sync.Mutex
 	isEvaluating atomic.Bool
@@ -60,12 +79,22 @@ type Builder struct {
 	buildator   Exec
 	buildatorWg *sync.WaitGroup
 
+	// The closure diff and the unit changes prediction of a built
+	// generation run once its build is finished, before notifying
+	// BuildDone. They are canceled by Stop.
+	analysisMu     sync.Mutex
+	analysisCancel context.CancelFunc
+	analysisWg     *sync.WaitGroup
+
 	isSuspended bool
+
+	progressMu    sync.Mutex
+	buildProgress *protobuf.BuildProgress
 }
 
-func New(store *store.Store, executor executor.Executor, repositoryPath, repositoryDir, systemAttr, hostname string, submodules bool, evalTimeout time.Duration, buildTimeout time.Duration) *Builder {
-	logrus.Infof("builder: initialization with repositoryPath=%s, repositoryDir=%s, systemAttr=%s, hostname=%s, submodules=%v, evalTimeout=%fs, buildTimeout=%fs, )",
-		repositoryPath, repositoryDir, systemAttr, hostname, submodules, evalTimeout.Seconds(), buildTimeout.Seconds())
+func New(store *store.Store, executor executor.Executor, repositoryPath, repositoryDir, systemAttr, hostname string, submodules bool, evalTimeout, buildTimeout, maxSilentTime time.Duration, substituteOnly *SubstituteOnly, manifest *manifest.Source, diskSpace *diskspace.Guard) *Builder {
+	logrus.Infof("builder: initialization with repositoryPath=%s, repositoryDir=%s, systemAttr=%s, hostname=%s, submodules=%v, evalTimeout=%fs, buildTimeout=%fs, maxSilentTime=%fs, substituteOnly=%v, manifest=%v)",
+		repositoryPath, repositoryDir, systemAttr, hostname, submodules, evalTimeout.Seconds(), buildTimeout.Seconds(), maxSilentTime.Seconds(), substituteOnly != nil, manifest != nil)
 	return &Builder{
 		store:          store,
 		executor:       executor,
@@ -76,10 +105,15 @@ func New(store *store.Store, executor executor.Executor, repositoryPath, reposit
 		hostname:       hostname,
 		evalTimeout:    evalTimeout,
 		buildTimeout:   buildTimeout,
+		maxSilentTime:  maxSilentTime,
+		substituteOnly: substituteOnly,
+		manifest:       manifest,
+		diskSpace:      diskSpace,
 		EvaluationDone: make(chan string, 1),
 		BuildDone:      make(chan string, 1),
 		evaluatorWg:    &sync.WaitGroup{},
 		buildatorWg:    &sync.WaitGroup{},
+		analysisWg:     &sync.WaitGroup{},
 	}
 }
 
@@ -104,6 +138,7 @@ func (b *Builder) State() *protobuf.Builder {
 		Generation:     generation,
 		GenerationUuid: generationUUID,
 		IsSuspended:    wrapperspb.Bool(b.isSuspended),
+		BuildProgress:  b.getBuildProgress(),
 	}
 }
 
@@ -132,11 +167,58 @@ func (b *Builder) stopBuild() {
 	b.isBuilding.Store(false)
 }
 
+// analysisContext returns the context of the analysis of the next
+// built generation. The analysis of the previous one is canceled.
+func (b *Builder) analysisContext(ctx context.Context) context.Context {
+	b.analysisMu.Lock()
+	defer b.analysisMu.Unlock()
+	if b.analysisCancel != nil {
+		b.analysisCancel()
+	}
+	ctx, b.analysisCancel = context.WithCancel(ctx)
+	return ctx
+}
+
+func (b *Builder) stopAnalysis() {
+	b.analysisMu.Lock()
+	if b.analysisCancel != nil {
+		b.analysisCancel()
+	}
+	b.analysisMu.Unlock()
+	b.analysisWg.Wait()
+}
+
+// analyze computes the closure diff and predicts the unit changes of
+// a built generation in the background, and then notifies BuildDone.
+// BuildDone is not notified when the analysis has been canceled by
+// Stop since the builder then moves to another generation.
+func (b *Builder) analyze(ctx context.Context, generationUuid, outPath string) {
+	b.analysisWg.Add(1)
+	go func() {
+		defer b.analysisWg.Done()
+		b.closureDiff(ctx, generationUuid, outPath)
+		b.predictUnitChanges(ctx, generationUuid, outPath)
+		if ctx.Err() != nil {
+			logrus.Infof("builder: the analysis of generation %s has been canceled", generationUuid)
+			return
+		}
+		b.notifyBuildDone(generationUuid)
+	}()
+}
+
+func (b *Builder) notifyBuildDone(generationUuid string) {
+	select {
+	case b.BuildDone <- generationUuid:
+	default:
+	}
+}
+
 // Stop stops the evaluator and the builder is required and wait until
 // they have been actually stopped.
 func (b *Builder) Stop() {
 	b.stopEval()
 	b.stopBuild()
+	b.stopAnalysis()
 
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -157,18 +239,81 @@ type Evaluator struct {
 	machineId string
 }
 
-func (r *Evaluator) Run(ctx context.Context) (err error) {
-	r.drvPath, r.outPath, r.machineId, err = r.evalFunc(ctx, r.repositoryPath, r.repostorySubdir, r.commitId, r.systemAttr, r.hostname, r.submodules)
+func (r *Evaluator) Run(ctx context.Context, output Output) (err error) {
+	r.drvPath, r.outPath, r.machineId, err = r.evalFunc(ctx, r.repositoryPath, r.repostorySubdir, r.commitId, r.systemAttr, r.hostname, r.submodules, output)
 	return err
 }
 
 type Buildator struct {
-	drvPath   string
-	buildFunc executor.BuildFunc
+	drvPath      string
+	outPath      string
+	buildFunc    executor.BuildFunc
+	progressFunc executor.ProgressFunc
+
+	// substitutor is used instead of buildFunc when it is not nil
+	substitutor *Substitutor
+	// fallback is called before building locally when the
+	// substitution deadline is reached. When nil, the build fails.
+	fallback func()
+	// diskSpace is checked before building or substituting
+	diskSpace *diskspace.Guard
+
+	builtBy string
 }
 
-func (r *Buildator) Run(ctx context.Context) (err error) {
-	return r.buildFunc(ctx, r.drvPath)
+func (r *Buildator) Run(ctx context.Context, output Output) (err error) {
+	// A substituted out path may have no derivation on this machine
+	drvPath := r.drvPath
+	if r.substitutor != nil {
+		drvPath = ""
+	}
+	if err = r.diskSpace.CheckBuild(ctx, drvPath, r.outPath); err != nil {
+		_, _ = fmt.Fprintf(output, "comin: %s\n", err)
+		return err
+	}
+	if r.substitutor != nil {
+		err = r.substitutor.Run(ctx, output)
+		if err == nil {
+			r.builtBy = executor.BuiltByBinaryCache
+			return nil
+		}
+		if r.fallback == nil || !errors.Is(err, store.ErrCacheDeadline) {
+			return err
+		}
+		_, _ = fmt.Fprintf(output, "comin: %s, falling back to a local build\n", err)
+		r.fallback()
+	}
+	r.builtBy, err = r.buildFunc(ctx, r.drvPath, r.outPath, output, touchingProgress(output, r.progressFunc))
+	return err
+}
+
+// touchingProgress returns a progress function recording an activity
+// on output for each parsed Nix log entry, since downloads and copies
+// can progress without writing any log.
+func touchingProgress(output Output, progress executor.ProgressFunc) executor.ProgressFunc {
+	return func(p executor.BuildProgress) {
+		output.Touch()
+		if progress != nil {
+			progress(p)
+		}
+	}
+}
+
+// logsWriter returns a writer to the logs file of kind of a
+// generation. Logs are also written to stderr to be available in the
+// journal. The returned function has to be called to close the logs
+// file.
+func (b *Builder) logsWriter(generationUuid, kind string) (io.Writer, func()) {
+	f, err := b.store.GenerationLogsCreate(generationUuid, kind)
+	if err != nil {
+		logrus.Errorf("builder: cannot create the %s logs file of the generation %s: %s", kind, generationUuid, err)
+		return os.Stderr, func() {}
+	}
+	return io.MultiWriter(f, os.Stderr), func() {
+		if err := f.Close(); err != nil {
+			logrus.Errorf("builder: cannot close the %s logs file of the generation %s: %s", kind, generationUuid, err)
+		}
+	}
 }
 
 // Eval evaluates a generation. It cancels current any generation
@@ -185,9 +330,16 @@ func (b *Builder) Eval(ctx context.Context, rs *protobuf.RepositoryStatus) error
 	b.mu.Lock()
 	defer b.mu.Unlock()
 	b.isEvaluating.Store(true)
+	b.setBuildProgress(nil)
 
 	g := b.store.NewGeneration(b.hostname, b.repositoryPath, b.repositoryDir, b.systemAttr, rs)
-	if err := b.store.GenerationEvalStarted(g.Uuid); err != nil {
+	evalFunc := b.executor.Eval
+	var evalSource string
+	if b.manifest != nil {
+		evalFunc = b.manifest.Eval
+		evalSource = b.manifest.String()
+	}
+	if err := b.store.GenerationEvalStarted(g.Uuid, evalSource); err != nil {
 		return err
 	}
 	b.GenerationUuid = g.Uuid
@@ -200,9 +352,12 @@ func (b *Builder) Eval(ctx context.Context, rs *protobuf.RepositoryStatus) error
 		submodules:      b.submodules,
 
 		commitId: g.SelectedCommitId,
-		evalFunc: b.executor.Eval,
+		evalFunc: evalFunc,
 	}
-	b.evaluator = NewExec(evaluator, b.evalTimeout)
+	logs, closeLogs := b.logsWriter(g.Uuid, store.LogsEval)
+	// The max silent time is only applied to builds
+	b.evaluator = NewExec(evaluator, logs, b.evalTimeout, 0)
+	analysisCtx := b.analysisContext(ctx)
 
 	// This is to wait until the evaluator is stopped
 	b.evaluatorWg.Add(1)
@@ -211,6 +366,8 @@ func (b *Builder) Eval(ctx context.Context, rs *protobuf.RepositoryStatus) error
 	go func() {
 		defer b.evaluatorWg.Done()
 		b.evaluator.Wait()
+		closeLogs()
+		alreadyBuilt := b.evaluator.getErr() == nil && b.executor.IsStorePathExist(evaluator.outPath)
 		b.mu.Lock()
 		defer b.mu.Unlock()
 		if err := b.store.GenerationEvalFinished(
@@ -224,18 +381,14 @@ func (b *Builder) Eval(ctx context.Context, rs *protobuf.RepositoryStatus) error
 		}
 
 		b.isEvaluating.Store(false)
-		if b.executor.IsStorePathExist(evaluator.outPath) {
+		if alreadyBuilt {
 			if err := b.store.GenerationBuildStart(g.Uuid, BuildReasonAlreadyBuilt); err != nil {
 				logrus.Errorf("builder: %s", err)
 			}
-			if err := b.store.GenerationBuildFinished(g.Uuid, nil); err != nil {
+			if err := b.store.GenerationBuildFinished(g.Uuid, "", nil); err != nil {
 				logrus.Errorf("builder: %s", err)
 			}
-			select {
-			case b.BuildDone <- g.Uuid:
-			default:
-			}
-
+			b.analyze(analysisCtx, g.Uuid, evaluator.outPath)
 		} else {
 			select {
 			case b.EvaluationDone <- g.Uuid:
@@ -288,6 +441,15 @@ func (b *Builder) Resume(ctx context.Context) error {
 	return nil
 }
 
+// SkipBuild marks an evaluated generation as skipped: it will not be
+// built and then not deployed.
+func (b *Builder) SkipBuild(generationUuid, reason string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	logrus.Infof("builder: build of generation %s is skipped because of %s", generationUuid, reason)
+	return b.store.GenerationBuildSkipped(generationUuid, reason)
+}
+
 // SubmitBuild submits a generation for building. If the builder is
 // suspended, the generation is only built once resumed, otherwise, it
 // is built immediately.
@@ -326,15 +488,58 @@ func (b *Builder) build(ctx context.Context, generationUuid string) error {
 		return fmt.Errorf("the generation is already built")
 	}
 
-	if err := b.store.GenerationBuildStart(generationUuid, BuildReasonNeedBuild); err != nil {
+	substituteOnly := b.substituteOnly
+	if generation.EvalSource != "" && substituteOnly == nil {
+		// The out path of a generation which has not been
+		// evaluated can only be substituted
+		substituteOnly = &defaultSubstituteOnly
+	}
+	reason := BuildReasonNeedBuild
+	if substituteOnly != nil {
+		reason = BuildReasonNeedSubstitution
+	}
+	if err := b.store.GenerationBuildStart(generationUuid, reason); err != nil {
 		return err
 	}
 	b.isBuilding.Store(true)
+	b.setBuildProgress(nil)
 	buildator := &Buildator{
-		drvPath:   generation.DrvPath,
-		buildFunc: b.executor.Build,
+		drvPath:      generation.DrvPath,
+		outPath:      generation.OutPath,
+		buildFunc:    b.executor.Build,
+		progressFunc: b.progressFunc(generationUuid),
+		diskSpace:    b.diskSpace,
 	}
-	b.buildator = NewExec(buildator, b.buildTimeout)
+	timeout := b.buildTimeout
+	if substituteOnly != nil {
+		buildator.substitutor = &Substitutor{
+			outPath:        generation.OutPath,
+			substituteFunc: b.executor.Substitute,
+			progressFunc:   buildator.progressFunc,
+			recheckPeriod:  substituteOnly.RecheckPeriod,
+			deadline:       substituteOnly.Deadline,
+			onWaiting: func() {
+				if err := b.store.GenerationBuildWaitingForCache(generationUuid); err != nil {
+					logrus.Errorf("builder: %s", err)
+				}
+			},
+		}
+		// Without derivation, a generation can not be built
+		if substituteOnly.Fallback && generation.DrvPath != "" {
+			buildator.fallback = func() {
+				logrus.Infof("builder: falling back to a local build of generation %s", generationUuid)
+				if err := b.store.GenerationBuildStart(generationUuid, BuildReasonCacheDeadline); err != nil {
+					logrus.Errorf("builder: %s", err)
+				}
+			}
+		}
+		// The build timeout starts once the wait for the
+		// binary cache is over
+		timeout += substituteOnly.Deadline
+	}
+	logs, closeLogs := b.logsWriter(generationUuid, store.LogsBuild)
+	b.buildator = NewExec(buildator, logs, timeout, b.maxSilentTime)
+	analysisCtx := b.analysisContext(ctx)
 
 	// This is to wait until the evaluator is stopped
 	b.buildatorWg.Add(1)
@@ -343,16 +548,18 @@ func (b *Builder) build(ctx context.Context, generationUuid string) error {
 	go func() {
 		defer b.buildatorWg.Done()
 		b.buildator.Wait()
+		closeLogs()
 		b.mu.Lock()
 		defer b.mu.Unlock()
-		err := b.store.GenerationBuildFinished(generationUuid, b.buildator.getErr())
-		if err != nil {
+		buildErr := b.buildator.getErr()
+		if err := b.store.GenerationBuildFinished(generationUuid, buildator.builtBy, buildErr); err != nil {
 			logrus.Error(err)
 		}
 		b.isBuilding.Store(false)
-		select {
-		case b.BuildDone <- generationUuid:
-		default:
+		if buildErr == nil {
+			b.analyze(analysisCtx, generationUuid, generation.OutPath)
+		} else {
+			b.notifyBuildDone(generationUuid)
 		}
 	}()
 	return nil
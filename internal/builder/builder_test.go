@@ -1,12 +1,22 @@
 package builder
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"log"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/nlewo/comin/internal/broker"
+	"github.com/nlewo/comin/internal/executor"
+	"github.com/nlewo/comin/internal/manifest"
 	"github.com/nlewo/comin/pkg/protobuf"
 	"github.com/nlewo/comin/internal/store"
 	"github.com/stretchr/testify/assert"
@@ -19,13 +29,19 @@ type ExecutorMock struct {
 	evalDone     chan struct{}
 	buildDone    chan struct{}
 	alreadyBuilt bool
+	// cached is true when the out path is available in binary caches
+	cached      *atomic.Bool
+	substituted *atomic.Int64
+	// blockDryActivate blocks the unit changes prediction until
+	// it is canceled
+	blockDryActivate bool
 }
 
 func (n ExecutorMock) ReadMachineId() (string, error) {
 	return "", nil
 }
-func (n ExecutorMock) NeedToReboot(_, _ string) bool {
-	return false
+func (n ExecutorMock) NeedToReboot(_, _ string) []string {
+	return nil
 }
 func (n ExecutorMock) IsStorePathExist(storePath string) bool {
 	return n.alreadyBuilt
@@ -33,7 +49,7 @@ func (n ExecutorMock) IsStorePathExist(storePath string) bool {
 func (n ExecutorMock) Deploy(ctx context.Context, outPath, operation string, profilePaths []string) (needToRestartComin bool, profilePath string, err error) {
 	return false, "", nil
 }
-func (n ExecutorMock) Eval(ctx context.Context, repositoryPath, repositorySubdir, commitId, systemAttr, hostname string, submodules bool) (drvPath string, outPath string, machineId string, err error) {
+func (n ExecutorMock) Eval(ctx context.Context, repositoryPath, repositorySubdir, commitId, systemAttr, hostname string, submodules bool, logs io.Writer) (drvPath string, outPath string, machineId string, err error) {
 	select {
 	case <-ctx.Done():
 		return "", "", "", ctx.Err()
@@ -41,19 +57,46 @@ func (n ExecutorMock) Eval(ctx context.Context, repositoryPath, repositorySubdir
 		return "drv-path", "out-path", "", nil
 	}
 }
-func (n ExecutorMock) Build(ctx context.Context, drvPath string) (err error) {
+func (n ExecutorMock) Build(ctx context.Context, drvPath, outPath string, logs io.Writer, progress executor.ProgressFunc) (builtBy string, err error) {
+	_, _ = fmt.Fprintf(logs, "building %s\n", drvPath)
+	if progress != nil {
+		progress(executor.BuildProgress{DerivationsBuilt: 1, DerivationsExpected: 2})
+	}
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return "", ctx.Err()
 	case <-n.buildDone:
-		return nil
+		return executor.BuiltByLocal, nil
+	}
+}
+func (n ExecutorMock) Substitute(ctx context.Context, outPath string, logs io.Writer, progress executor.ProgressFunc) error {
+	n.substituted.Add(1)
+	if !n.cached.Load() {
+		return fmt.Errorf("%s is not available in binary caches", outPath)
+	}
+	return nil
+}
+func (n ExecutorMock) ClosureDiff(ctx context.Context, outPath string) (executor.ClosureDiff, error) {
+	return executor.ClosureDiff{
+		CurrentOutPath:   "current-out-path",
+		Upgraded:         []executor.PackageChange{{Name: "hello", OldVersion: "2.11", NewVersion: "2.12"}},
+		ClosureSizeDelta: 1024,
+	}, nil
+}
+func (n ExecutorMock) DryActivate(ctx context.Context, outPath string) (executor.UnitChanges, error) {
+	if n.blockDryActivate {
+		<-ctx.Done()
+		return executor.UnitChanges{}, ctx.Err()
 	}
+	return executor.UnitChanges{Restart: []string{"sshd.service"}}, nil
 }
 func NewExecutorMock(alreadyBuilt bool) ExecutorMock {
 	return ExecutorMock{
 		evalDone:     make(chan struct{}),
 		buildDone:    make(chan struct{}),
 		alreadyBuilt: alreadyBuilt,
+		cached:       &atomic.Bool{},
+		substituted:  &atomic.Int64{},
 	}
 }
 
@@ -66,10 +109,10 @@ func TestBuilderBuild(t *testing.T) {
 	bk := broker.New()
 	bk.Start()
 
-	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1)
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
 	assert.Nil(t, err)
 	eMock := NewExecutorMock(false)
-	b := New(s, eMock, "", "", "", "my-machine", false, 2*time.Second, 2*time.Second)
+	b := New(s, eMock, "", "", "", "my-machine", false, 2*time.Second, 2*time.Second, 0, nil, nil, nil)
 	ctx := t.Context()
 
 	// Run the evaluator
@@ -119,6 +162,11 @@ func TestBuilderBuild(t *testing.T) {
 	assert.EventuallyWithT(t, func(c *assert.CollectT) {
 		assert.False(c, b.isBuilding.Load())
 	}, 3*time.Second, 100*time.Millisecond)
+	g, _ := b.store.GenerationGet(gUUID)
+	assert.Equal(t, "current-out-path", g.ClosureDiff.CurrentOutPath)
+	assert.Equal(t, "hello", g.ClosureDiff.Upgraded[0].Name)
+	assert.Equal(t, int64(1024), g.ClosureDiff.ClosureSizeDelta)
+	assert.Equal(t, []string{"sshd.service"}, g.UnitChanges.Restart)
 
 	// The generation is already built
 	err = b.build(ctx, gUUID)
@@ -130,10 +178,10 @@ func TestEval(t *testing.T) {
 	bk := broker.New()
 	bk.Start()
 
-	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1)
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
 	assert.Nil(t, err)
 	eMock := NewExecutorMock(false)
-	b := New(s, eMock, "", "", "", "", false, 5*time.Second, 5*time.Second)
+	b := New(s, eMock, "", "", "", "", false, 5*time.Second, 5*time.Second, 0, nil, nil, nil)
 	_ = b.Eval(t.Context(), &protobuf.RepositoryStatus{})
 	assert.True(t, b.isEvaluating.Load())
 	eMock.evalDone <- struct{}{}
@@ -152,10 +200,10 @@ func TestEvalAlreadyBuilt(t *testing.T) {
 	tmp := t.TempDir()
 	bk := broker.New()
 	bk.Start()
-	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1)
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
 	assert.Nil(t, err)
 	eMock := NewExecutorMock(true)
-	b := New(s, eMock, "", "", "", "", false, 5*time.Second, 5*time.Second)
+	b := New(s, eMock, "", "", "", "", false, 5*time.Second, 5*time.Second, 0, nil, nil, nil)
 	_ = b.Eval(t.Context(), &protobuf.RepositoryStatus{})
 	assert.True(t, b.IsEvaluating())
 
@@ -176,10 +224,10 @@ func TestBuilderPreemption(t *testing.T) {
 	bk := broker.New()
 	bk.Start()
 
-	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1)
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
 	assert.Nil(t, err)
 	eMock := NewExecutorMock(false)
-	b := New(s, eMock, "", "", "", "", false, 5*time.Second, 5*time.Second)
+	b := New(s, eMock, "", "", "", "", false, 5*time.Second, 5*time.Second, 0, nil, nil, nil)
 	_ = b.Eval(t.Context(), &protobuf.RepositoryStatus{SelectedCommitId: "commit-1"})
 	assert.True(t, b.isEvaluating.Load())
 	assert.EventuallyWithT(t, func(c *assert.CollectT) {
@@ -199,10 +247,10 @@ func TestBuilderStop(t *testing.T) {
 	tmp := t.TempDir()
 	bk := broker.New()
 	bk.Start()
-	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1)
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
 	assert.Nil(t, err)
 	eMock := NewExecutorMock(false)
-	b := New(s, eMock, "", "", "", "", false, 5*time.Second, 5*time.Second)
+	b := New(s, eMock, "", "", "", "", false, 5*time.Second, 5*time.Second, 0, nil, nil, nil)
 	_ = b.Eval(t.Context(), &protobuf.RepositoryStatus{})
 	assert.True(t, b.isEvaluating.Load())
 	b.Stop()
@@ -212,32 +260,102 @@ func TestBuilderStop(t *testing.T) {
 	}, 2*time.Second, 100*time.Millisecond)
 }
 
+func TestBuilderStopAnalysis(t *testing.T) {
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
+	assert.Nil(t, err)
+	eMock := NewExecutorMock(false)
+	eMock.blockDryActivate = true
+	b := New(s, eMock, "", "", "", "", false, 5*time.Second, 5*time.Second, 0, nil, nil, nil)
+	_ = b.Eval(t.Context(), &protobuf.RepositoryStatus{})
+	eMock.evalDone <- struct{}{}
+	gUUID := <-b.EvaluationDone
+	b.SubmitBuild(t.Context(), gUUID)
+	eMock.buildDone <- struct{}{}
+
+	// The build is finished while the unit changes are still
+	// being predicted
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		g, _ := b.store.GenerationGet(gUUID)
+		assert.Equal(c, store.Built.String(), g.BuildStatus)
+		assert.NotNil(c, g.ClosureDiff)
+	}, 2*time.Second, 100*time.Millisecond)
+	select {
+	case <-b.BuildDone:
+		t.Fatal("the build done is notified before the end of the prediction")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// Stopping the builder cancels the prediction, and the
+	// generation is then not notified
+	stopped := make(chan struct{})
+	go func() {
+		b.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the builder has not been stopped")
+	}
+	select {
+	case <-b.BuildDone:
+		t.Fatal("the build done is notified while the builder has been stopped")
+	default:
+	}
+	g, _ := b.store.GenerationGet(gUUID)
+	assert.Nil(t, g.UnitChanges)
+}
+
 func TestBuilderTimeout(t *testing.T) {
 	tmp := t.TempDir()
 	bk := broker.New()
 	bk.Start()
 
-	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1)
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
 	assert.Nil(t, err)
 	eMock := NewExecutorMock(false)
-	b := New(s, eMock, "", "", "", "", false, 1*time.Second, 5*time.Second)
+	b := New(s, eMock, "", "", "", "", false, 1*time.Second, 5*time.Second, 0, nil, nil, nil)
 	_ = b.Eval(t.Context(), &protobuf.RepositoryStatus{})
 	assert.True(t, b.isEvaluating.Load())
 	assert.EventuallyWithT(t, func(c *assert.CollectT) {
 		g, _ := b.store.GenerationGet(b.GenerationUuid)
 		assert.Contains(c, g.EvalErr, "context deadline exceeded")
+		assert.Equal(c, store.ErrTimeout.Error(), g.EvalErrCause)
 	}, 3*time.Second, 100*time.Millisecond, "builder timeout didn't work")
 }
 
+func TestBuilderBuildTimeout(t *testing.T) {
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
+	assert.Nil(t, err)
+	eMock := NewExecutorMock(false)
+	b := New(s, eMock, "", "", "", "", false, 5*time.Second, 1*time.Second, 0, nil, nil, nil)
+	_ = b.Eval(t.Context(), &protobuf.RepositoryStatus{})
+	eMock.evalDone <- struct{}{}
+	gUUID := <-b.EvaluationDone
+	b.SubmitBuild(t.Context(), gUUID)
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		g, _ := b.store.GenerationGet(gUUID)
+		assert.Equal(c, store.BuildFailed.String(), g.BuildStatus)
+		assert.Equal(c, store.ErrTimeout.Error(), g.BuildErrCause)
+	}, 3*time.Second, 100*time.Millisecond, "build timeout didn't work")
+}
+
 func TestBuilderSuspend(t *testing.T) {
 	tmp := t.TempDir()
 	bk := broker.New()
 	bk.Start()
 
-	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1)
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
 	assert.Nil(t, err)
 	eMock := NewExecutorMock(false)
-	b := New(s, eMock, "", "", "", "", false, 1*time.Second, 5*time.Second)
+	b := New(s, eMock, "", "", "", "", false, 1*time.Second, 5*time.Second, 0, nil, nil, nil)
 	_ = b.Suspend()
 	assert.True(t, b.isSuspended)
 	_ = b.Eval(t.Context(), &protobuf.RepositoryStatus{})
@@ -258,3 +376,197 @@ func TestBuilderSuspend(t *testing.T) {
 		assert.True(c, b.isBuilding.Load())
 	}, 3*time.Second, 100*time.Millisecond)
 }
+
+func TestBuilderLogs(t *testing.T) {
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
+	assert.Nil(t, err)
+	eMock := NewExecutorMock(false)
+	b := New(s, eMock, "", "", "", "", false, 5*time.Second, 5*time.Second, 0, nil, nil, nil)
+	_ = b.Eval(t.Context(), &protobuf.RepositoryStatus{})
+	eMock.evalDone <- struct{}{}
+	gUUID := <-b.EvaluationDone
+	b.SubmitBuild(t.Context(), gUUID)
+
+	// Logs are streamed until the end of the build
+	var buf bytes.Buffer
+	done := make(chan error)
+	go func() {
+		done <- s.GenerationLogsRead(t.Context(), gUUID, store.LogsBuild, true, &buf)
+	}()
+	eMock.buildDone <- struct{}{}
+	assert.Nil(t, <-done)
+	assert.Equal(t, "building drv-path\n", buf.String())
+	g, _ := s.GenerationGet(gUUID)
+	assert.Equal(t, executor.BuiltByLocal, g.BuiltBy)
+
+	buf.Reset()
+	err = s.GenerationLogsRead(t.Context(), gUUID, store.LogsEval, false, &buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "", buf.String())
+}
+
+func TestBuilderProgress(t *testing.T) {
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
+	assert.Nil(t, err)
+	eMock := NewExecutorMock(false)
+	b := New(s, eMock, "", "", "", "", false, 5*time.Second, 5*time.Second, 0, nil, nil, nil)
+	_ = b.Eval(t.Context(), &protobuf.RepositoryStatus{})
+	eMock.evalDone <- struct{}{}
+	gUUID := <-b.EvaluationDone
+	b.SubmitBuild(t.Context(), gUUID)
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		p := b.State().BuildProgress
+		assert.NotNil(c, p)
+		assert.Equal(c, "1/2 derivations built", ProgressSummary(p))
+	}, 3*time.Second, 100*time.Millisecond)
+	eMock.buildDone <- struct{}{}
+	<-b.BuildDone
+
+	// The progress is reset when a new generation is evaluated
+	_ = b.Eval(t.Context(), &protobuf.RepositoryStatus{})
+	assert.Nil(t, b.State().BuildProgress)
+}
+
+func TestBuilderSubstituteOnly(t *testing.T) {
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
+	assert.Nil(t, err)
+	eMock := NewExecutorMock(false)
+	substituteOnly := &SubstituteOnly{RecheckPeriod: 100 * time.Millisecond, Deadline: 10 * time.Second}
+	b := New(s, eMock, "", "", "", "", false, 5*time.Second, 5*time.Second, 0, substituteOnly, nil, nil)
+	_ = b.Eval(t.Context(), &protobuf.RepositoryStatus{})
+	eMock.evalDone <- struct{}{}
+	gUUID := <-b.EvaluationDone
+	b.SubmitBuild(t.Context(), gUUID)
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		g, _ := b.store.GenerationGet(gUUID)
+		assert.Equal(c, store.BuildWaitingForCache.String(), g.BuildStatus)
+		assert.GreaterOrEqual(c, eMock.substituted.Load(), int64(2))
+	}, 3*time.Second, 100*time.Millisecond, "the generation is not waiting for the binary cache")
+
+	eMock.cached.Store(true)
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		g, _ := b.store.GenerationGet(gUUID)
+		assert.Equal(c, store.Built.String(), g.BuildStatus)
+		assert.Equal(c, executor.BuiltByBinaryCache, g.BuiltBy)
+	}, 3*time.Second, 100*time.Millisecond, "the generation has not been substituted")
+}
+
+func TestBuilderSubstituteOnlyDeadline(t *testing.T) {
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
+	assert.Nil(t, err)
+	eMock := NewExecutorMock(false)
+	substituteOnly := &SubstituteOnly{RecheckPeriod: 100 * time.Millisecond, Deadline: 500 * time.Millisecond}
+	b := New(s, eMock, "", "", "", "", false, 5*time.Second, 5*time.Second, 0, substituteOnly, nil, nil)
+	_ = b.Eval(t.Context(), &protobuf.RepositoryStatus{})
+	eMock.evalDone <- struct{}{}
+	gUUID := <-b.EvaluationDone
+	b.SubmitBuild(t.Context(), gUUID)
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		g, _ := b.store.GenerationGet(gUUID)
+		assert.Equal(c, store.BuildFailed.String(), g.BuildStatus)
+		assert.Equal(c, store.ErrCacheDeadline.Error(), g.BuildErrCause)
+	}, 3*time.Second, 100*time.Millisecond, "the substitution deadline didn't work")
+}
+
+func TestBuilderSubstituteOnlyFallback(t *testing.T) {
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
+	assert.Nil(t, err)
+	eMock := NewExecutorMock(false)
+	substituteOnly := &SubstituteOnly{RecheckPeriod: 100 * time.Millisecond, Deadline: 500 * time.Millisecond, Fallback: true}
+	b := New(s, eMock, "", "", "", "", false, 5*time.Second, 5*time.Second, 0, substituteOnly, nil, nil)
+	_ = b.Eval(t.Context(), &protobuf.RepositoryStatus{})
+	eMock.evalDone <- struct{}{}
+	gUUID := <-b.EvaluationDone
+	b.SubmitBuild(t.Context(), gUUID)
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		g, _ := b.store.GenerationGet(gUUID)
+		assert.Equal(c, store.Building.String(), g.BuildStatus)
+		assert.Equal(c, BuildReasonCacheDeadline, g.BuildReason)
+	}, 3*time.Second, 100*time.Millisecond, "the build didn't fall back to a local build")
+
+	eMock.buildDone <- struct{}{}
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		g, _ := b.store.GenerationGet(gUUID)
+		assert.Equal(c, store.Built.String(), g.BuildStatus)
+		assert.Equal(c, executor.BuiltByLocal, g.BuiltBy)
+	}, 3*time.Second, 100*time.Millisecond, "the generation has not been built")
+}
+
+func TestBuilderManifest(t *testing.T) {
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+
+	// The manifest is signed with the key of the repository tests
+	f, err := os.Open("../repository/test.private")
+	assert.Nil(t, err)
+	entities, err := openpgp.ReadArmoredKeyRing(f)
+	_ = f.Close()
+	assert.Nil(t, err)
+	data, _ := json.Marshal(manifest.Manifest{
+		CommitId: "commit-1",
+		Hosts:    map[string]manifest.Host{"my-machine": {OutPath: "/nix/store/aaa-manifest-out-path"}},
+	})
+	var signature bytes.Buffer
+	assert.Nil(t, openpgp.ArmoredDetachSign(&signature, entities[0], bytes.NewReader(data), nil))
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/manifest.json.asc" {
+			_, _ = w.Write(signature.Bytes())
+		} else {
+			_, _ = w.Write(data)
+		}
+	}))
+	defer ts.Close()
+	m, err := manifest.New("", ts.URL+"/manifest.json", []string{"../repository/test.public"})
+	assert.Nil(t, err)
+
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
+	assert.Nil(t, err)
+	eMock := NewExecutorMock(false)
+	b := New(s, eMock, "", "", "", "my-machine", false, 5*time.Second, 5*time.Second, 0, nil, m, nil)
+	_ = b.Eval(t.Context(), &protobuf.RepositoryStatus{SelectedCommitId: "commit-1"})
+	gUUID := <-b.EvaluationDone
+	g, _ := b.store.GenerationGet(gUUID)
+	assert.Equal(t, store.Evaluated.String(), g.EvalStatus)
+	assert.Equal(t, "/nix/store/aaa-manifest-out-path", g.OutPath)
+	assert.Equal(t, "", g.DrvPath)
+	assert.Equal(t, m.String(), g.EvalSource)
+
+	// The closure can not be built: the builder waits for the
+	// closure to be pushed to the binary caches
+	b.SubmitBuild(t.Context(), gUUID)
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		g, _ := b.store.GenerationGet(gUUID)
+		assert.Equal(c, store.BuildWaitingForCache.String(), g.BuildStatus)
+	}, 3*time.Second, 100*time.Millisecond, "the builder is not waiting for the binary caches")
+	b.Stop()
+
+	// The closure is substituted once available
+	eMock.cached.Store(true)
+	b.SubmitBuild(t.Context(), gUUID)
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		g, _ := b.store.GenerationGet(gUUID)
+		assert.Equal(c, store.Built.String(), g.BuildStatus)
+		assert.Equal(c, executor.BuiltByBinaryCache, g.BuiltBy)
+	}, 3*time.Second, 100*time.Millisecond, "the generation has not been substituted")
+}
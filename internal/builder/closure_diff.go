@@ -0,0 +1,51 @@
+package builder
+
+import (
+	"context"
+	"time"
+
+	"github.com/nlewo/comin/internal/executor"
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/sirupsen/logrus"
+)
+
+// closureDiffTimeout is the maximal duration of the closure diff
+// computation.
+var closureDiffTimeout = 2 * time.Minute
+
+// closureDiff computes and stores the package changes between the
+// running system and the out path of a built generation. Since the
+// diff is only informative, errors are only logged.
+func (b *Builder) closureDiff(ctx context.Context, generationUuid, outPath string) {
+	ctx, cancel := context.WithTimeout(ctx, closureDiffTimeout)
+	defer cancel()
+	d, err := b.executor.ClosureDiff(ctx, outPath)
+	if err != nil {
+		logrus.Errorf("builder: cannot compute the closure diff of generation %s: %s", generationUuid, err)
+		return
+	}
+	if err := b.store.GenerationClosureDiff(generationUuid, closureDiffToProtobuf(d)); err != nil {
+		logrus.Errorf("builder: %s", err)
+	}
+}
+
+func closureDiffToProtobuf(d executor.ClosureDiff) *protobuf.ClosureDiff {
+	changes := func(cs []executor.PackageChange) (pcs []*protobuf.PackageChange) {
+		for _, c := range cs {
+			pcs = append(pcs, &protobuf.PackageChange{
+				Name:       c.Name,
+				OldVersion: c.OldVersion,
+				NewVersion: c.NewVersion,
+				SizeDelta:  c.SizeDelta,
+			})
+		}
+		return
+	}
+	return &protobuf.ClosureDiff{
+		CurrentOutPath:   d.CurrentOutPath,
+		Added:            changes(d.Added),
+		Removed:          changes(d.Removed),
+		Upgraded:         changes(d.Upgraded),
+		ClosureSizeDelta: d.ClosureSizeDelta,
+	}
+}
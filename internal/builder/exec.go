@@ -2,35 +2,56 @@ package builder
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/nlewo/comin/internal/store"
 )
 
+// Output is the output of a runnable. It is used to detect silent
+// runnables.
+type Output interface {
+	io.Writer
+	// Touch records an activity of the runnable which doesn't
+	// produce any output, such as waiting for a binary cache
+	Touch()
+}
+
 type Runnable interface {
-	Run(c context.Context) error
+	// Run runs the runnable. The output of the runnable has to be
+	// written to output: it is used to detect silent runnables.
+	Run(c context.Context, output Output) error
 }
 
 // Exec runs a runnable object asyncronously while recording start time, finish time and
 type Exec struct {
-	timeout  time.Duration
-	runnable Runnable
-	started  atomic.Bool
-	finished atomic.Bool
-	done     chan struct{}
+	timeout time.Duration
+	// maxSilentTime is the maximal duration the runnable can run
+	// without writing any output. It is disabled when it is 0.
+	maxSilentTime time.Duration
+	runnable      Runnable
+	output        *activityWriter
+	started       atomic.Bool
+	finished      atomic.Bool
+	done          chan struct{}
 	// err is used to know if the context has been cancelled,
 	// timeouted or if the runnable ends with an error
 	err        error
-	cancelFunc context.CancelFunc
+	cancelFunc context.CancelCauseFunc
 	mu         sync.Mutex
 }
 
-func NewExec(r Runnable, timeout time.Duration) Exec {
+func NewExec(r Runnable, output io.Writer, timeout, maxSilentTime time.Duration) Exec {
 	return Exec{
-		runnable: r,
-		mu:       sync.Mutex{},
-		done:     make(chan struct{}),
-		timeout:  timeout,
+		runnable:      r,
+		output:        &activityWriter{w: output},
+		mu:            sync.Mutex{},
+		done:          make(chan struct{}),
+		timeout:       timeout,
+		maxSilentTime: maxSilentTime,
 	}
 }
 
@@ -38,17 +59,21 @@ func (e *Exec) Start(ctx context.Context) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	e.started.Store(true)
-	ctx, e.cancelFunc = context.WithCancel(ctx)
-	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	ctx, e.cancelFunc = context.WithCancelCause(ctx)
+	ctx, cancel := context.WithTimeoutCause(ctx, e.timeout, fmt.Errorf("%w after %s: %w", store.ErrTimeout, e.timeout, context.DeadlineExceeded))
+	e.output.Touch()
+	if e.maxSilentTime > 0 {
+		go e.watchSilence(ctx)
+	}
 
 	go func() {
-		defer e.cancelFunc()
+		defer e.cancelFunc(nil)
 		defer cancel()
-		err := e.runnable.Run(ctx)
+		err := e.runnable.Run(ctx, e.output)
 		e.mu.Lock()
 		defer e.mu.Unlock()
 		if ctx.Err() != nil {
-			e.err = ctx.Err()
+			e.err = context.Cause(ctx)
 		} else {
 			e.err = err
 		}
@@ -61,6 +86,24 @@ func (e *Exec) Start(ctx context.Context) {
 	}()
 }
 
+// watchSilence cancels the execution if the runnable doesn't write
+// anything during maxSilentTime.
+func (e *Exec) watchSilence(ctx context.Context) {
+	ticker := time.NewTicker(min(e.maxSilentTime/10, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if e.output.silentSince() >= e.maxSilentTime {
+				e.cancelFunc(fmt.Errorf("%w: no output during %s: %w", store.ErrMaxSilentTime, e.maxSilentTime, context.DeadlineExceeded))
+				return
+			}
+		}
+	}
+}
+
 func (e *Exec) Wait() {
 	if e.started.Load() {
 		<-e.done
@@ -71,7 +114,7 @@ func (e *Exec) Stop() {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	if e.started.Load() {
-		e.cancelFunc()
+		e.cancelFunc(nil)
 	}
 }
 
@@ -81,3 +124,22 @@ func (e *Exec) getErr() error {
 	defer e.mu.Unlock()
 	return e.err
 }
+
+// activityWriter records the time of the last write.
+type activityWriter struct {
+	w            io.Writer
+	lastActivity atomic.Int64
+}
+
+func (a *activityWriter) Write(p []byte) (int, error) {
+	a.Touch()
+	return a.w.Write(p)
+}
+
+func (a *activityWriter) Touch() {
+	a.lastActivity.Store(time.Now().UnixNano())
+}
+
+func (a *activityWriter) silentSince() time.Duration {
+	return time.Since(time.Unix(0, a.lastActivity.Load()))
+}
@@ -3,12 +3,15 @@ package builder
 import (
 	"context"
 	"fmt"
+	"io"
 	"os/exec"
 	"testing"
 	"time"
 
 	_ "net/http/pprof"
 
+	"github.com/nlewo/comin/internal/executor"
+	"github.com/nlewo/comin/internal/store"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -16,14 +19,14 @@ type RunnableDummy struct {
 	result int
 }
 
-func (r *RunnableDummy) Run(ctx context.Context) error {
+func (r *RunnableDummy) Run(ctx context.Context, output Output) error {
 	r.result = 1
 	return nil
 }
 
 func TestNewExec(t *testing.T) {
 	r := &RunnableDummy{}
-	e := NewExec(r, time.Second)
+	e := NewExec(r, io.Discard, time.Second, 0)
 	assert.Equal(t, 0, r.result)
 	e.Start(t.Context())
 	e.Wait()
@@ -34,22 +37,23 @@ func TestNewExec(t *testing.T) {
 
 type RunnableContext struct{}
 
-func (r *RunnableContext) Run(ctx context.Context) error {
+func (r *RunnableContext) Run(ctx context.Context, output Output) error {
 	cmd := exec.CommandContext(ctx, "sleep", "3")
 	err := cmd.Run()
 	return err
 }
 func TestExecTimeout(t *testing.T) {
 	r := &RunnableContext{}
-	e := NewExec(r, time.Second)
+	e := NewExec(r, io.Discard, time.Second, 0)
 	e.Start(t.Context())
 	e.Wait()
-	assert.Equal(t, context.DeadlineExceeded, e.getErr())
+	assert.ErrorIs(t, e.getErr(), context.DeadlineExceeded)
+	assert.ErrorIs(t, e.getErr(), store.ErrTimeout)
 }
 
 func TestExecStop(t *testing.T) {
 	r := &RunnableContext{}
-	e := NewExec(r, 5*time.Second)
+	e := NewExec(r, io.Discard, 5*time.Second, 0)
 	e.Start(t.Context())
 	time.Sleep(500 * time.Millisecond)
 	e.Stop()
@@ -59,14 +63,108 @@ func TestExecStop(t *testing.T) {
 
 type RunnableError struct{}
 
-func (r *RunnableError) Run(ctx context.Context) error {
+func (r *RunnableError) Run(ctx context.Context, output Output) error {
 	return fmt.Errorf("An error occurred")
 }
 func TestExecError(t *testing.T) {
 	r := &RunnableError{}
-	e := NewExec(r, 5*time.Second)
+	e := NewExec(r, io.Discard, 5*time.Second, 0)
 	e.Start(t.Context())
 	e.Wait()
 	assert.True(t, e.finished.Load())
 	assert.ErrorContains(t, e.getErr(), "An error occurred")
 }
+
+type RunnableSilent struct {
+	// talkingDuration is the duration during the runnable writes
+	// to its output before getting silent
+	talkingDuration time.Duration
+}
+
+func (r *RunnableSilent) Run(ctx context.Context, output Output) error {
+	talking := time.After(r.talkingDuration)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-talking:
+			talking = nil
+			ticker.Stop()
+		case <-ticker.C:
+			_, _ = output.Write([]byte("building...\n"))
+		}
+	}
+}
+
+func TestExecMaxSilentTime(t *testing.T) {
+	r := &RunnableSilent{talkingDuration: 2 * time.Second}
+	e := NewExec(r, io.Discard, 10*time.Second, time.Second)
+	started := time.Now()
+	e.Start(t.Context())
+	e.Wait()
+	assert.ErrorIs(t, e.getErr(), store.ErrMaxSilentTime)
+	assert.ErrorIs(t, e.getErr(), context.DeadlineExceeded)
+	assert.NotErrorIs(t, e.getErr(), store.ErrTimeout)
+	// The runnable is not cancelled while it is writing
+	assert.Greater(t, time.Since(started), 2*time.Second)
+}
+
+type RunnableTouching struct{}
+
+// Run records an activity without writing anything, such as a
+// substitution waiting for a binary cache
+func (r *RunnableTouching) Run(ctx context.Context, output Output) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	done := time.After(1500 * time.Millisecond)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-done:
+			return nil
+		case <-ticker.C:
+			output.Touch()
+		}
+	}
+}
+
+func TestExecMaxSilentTimeTouch(t *testing.T) {
+	e := NewExec(&RunnableTouching{}, io.Discard, 10*time.Second, 500*time.Millisecond)
+	e.Start(t.Context())
+	e.Wait()
+	assert.Nil(t, e.getErr())
+}
+
+type RunnableProgressing struct{}
+
+// Run only reports a build progress, such as a long download
+func (r *RunnableProgressing) Run(ctx context.Context, output Output) error {
+	var updates int
+	progress := touchingProgress(output, func(executor.BuildProgress) { updates++ })
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	done := time.After(1500 * time.Millisecond)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-done:
+			if updates == 0 {
+				return fmt.Errorf("the progress function has not been called")
+			}
+			return nil
+		case <-ticker.C:
+			progress(executor.BuildProgress{})
+		}
+	}
+}
+
+func TestExecMaxSilentTimeProgress(t *testing.T) {
+	e := NewExec(&RunnableProgressing{}, io.Discard, 10*time.Second, 500*time.Millisecond)
+	e.Start(t.Context())
+	e.Wait()
+	assert.Nil(t, e.getErr())
+}
@@ -0,0 +1,76 @@
+package builder
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/nlewo/comin/internal/executor"
+	"github.com/nlewo/comin/pkg/protobuf"
+)
+
+// progressEventPeriod is the minimal period between two build
+// progress events.
+var progressEventPeriod = 5 * time.Second
+
+// progressFunc returns the function receiving the build progress of
+// a generation. It stores the progress to expose it in the builder
+// state and periodically publishes it.
+func (b *Builder) progressFunc(generationUuid string) executor.ProgressFunc {
+	var lastPublished time.Time
+	return func(p executor.BuildProgress) {
+		progress := &protobuf.BuildProgress{
+			DerivationsBuilt:    p.DerivationsBuilt,
+			DerivationsExpected: p.DerivationsExpected,
+			DerivationsRunning:  p.DerivationsRunning,
+			DerivationsFailed:   p.DerivationsFailed,
+			DownloadsDone:       p.DownloadsDone,
+			DownloadsExpected:   p.DownloadsExpected,
+			BytesDownloaded:     p.BytesDownloaded,
+			BytesExpected:       p.BytesExpected,
+			Phase:               p.Phase,
+		}
+		b.progressMu.Lock()
+		b.buildProgress = progress
+		b.progressMu.Unlock()
+		if time.Since(lastPublished) >= progressEventPeriod {
+			lastPublished = time.Now()
+			b.store.GenerationBuildProgress(generationUuid, progress)
+		}
+	}
+}
+
+func (b *Builder) setBuildProgress(progress *protobuf.BuildProgress) {
+	b.progressMu.Lock()
+	defer b.progressMu.Unlock()
+	b.buildProgress = progress
+}
+
+func (b *Builder) getBuildProgress() *protobuf.BuildProgress {
+	b.progressMu.Lock()
+	defer b.progressMu.Unlock()
+	return b.buildProgress
+}
+
+// ProgressSummary returns a human readable summary of a build
+// progress, such as "37/120 derivations built, 1.2 GiB downloaded".
+func ProgressSummary(p *protobuf.BuildProgress) string {
+	if p == nil {
+		return ""
+	}
+	parts := make([]string, 0)
+	if p.DerivationsExpected > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d derivations built", p.DerivationsBuilt, p.DerivationsExpected))
+	}
+	if p.DerivationsFailed > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed", p.DerivationsFailed))
+	}
+	if p.DownloadsExpected > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d paths downloaded", p.DownloadsDone, p.DownloadsExpected))
+	}
+	if p.BytesDownloaded > 0 {
+		parts = append(parts, fmt.Sprintf("%s downloaded", humanize.IBytes(p.BytesDownloaded)))
+	}
+	return strings.Join(parts, ", ")
+}
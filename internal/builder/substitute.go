@@ -0,0 +1,96 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nlewo/comin/internal/executor"
+	"github.com/nlewo/comin/internal/store"
+	"github.com/sirupsen/logrus"
+)
+
+// SubstituteOnly configures the builder to only substitute the
+// closure of generations from binary caches instead of building them.
+type SubstituteOnly struct {
+	// RecheckPeriod is the period used to recheck if the closure
+	// is available in binary caches.
+	RecheckPeriod time.Duration
+	// Deadline is the maximal duration to wait for the closure to
+	// be available in binary caches.
+	Deadline time.Duration
+	// Fallback builds the generation locally once the deadline is
+	// reached. Otherwise, the build fails.
+	Fallback bool
+}
+
+// defaultSubstituteOnly is used to substitute the out path of the
+// generations which have not been evaluated, such as the ones of a
+// manifest, when the substitute-only mode is not configured.
+var defaultSubstituteOnly = SubstituteOnly{
+	RecheckPeriod: time.Minute,
+	Deadline:      time.Hour,
+}
+
+// Substitutor substitutes an out path from binary caches. While the
+// closure is not available, it is periodically rechecked until the
+// deadline is reached.
+type Substitutor struct {
+	outPath        string
+	substituteFunc executor.SubstituteFunc
+	progressFunc   executor.ProgressFunc
+	recheckPeriod  time.Duration
+	deadline       time.Duration
+	// onWaiting is called when the closure is not available for
+	// the first time
+	onWaiting func()
+}
+
+func (r *Substitutor) Run(ctx context.Context, output Output) error {
+	deadline := time.Now().Add(r.deadline)
+	waiting := false
+	for {
+		err := r.substituteFunc(ctx, r.outPath, output, touchingProgress(output, r.progressFunc))
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("%w: the closure of %s is not available in binary caches after %s", store.ErrCacheDeadline, r.outPath, r.deadline)
+		}
+		logrus.Infof("builder: the closure of %s is not available in binary caches, rechecking in %s", r.outPath, r.recheckPeriod)
+		_, _ = fmt.Fprintf(output, "comin: the closure is not available in binary caches, rechecking in %s\n", r.recheckPeriod)
+		if !waiting {
+			waiting = true
+			if r.onWaiting != nil {
+				r.onWaiting()
+			}
+		}
+		// The last check is done at the deadline
+		if err := r.wait(ctx, output, min(r.recheckPeriod, time.Until(deadline))); err != nil {
+			return err
+		}
+	}
+}
+
+// wait waits for d. Since waiting for the binary cache is not a
+// silent build, the output activity is refreshed to not reach the max
+// silent time.
+func (r *Substitutor) wait(ctx context.Context, output Output, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return nil
+		case <-ticker.C:
+			output.Touch()
+		}
+	}
+}
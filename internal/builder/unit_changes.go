@@ -0,0 +1,42 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nlewo/comin/internal/executor"
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/sirupsen/logrus"
+)
+
+// dryActivateTimeout is the maximal duration of the unit changes
+// prediction.
+var dryActivateTimeout = 2 * time.Minute
+
+// predictUnitChanges predicts and stores the systemd units which
+// would be changed by the activation of the out path of a built
+// generation. Errors are only logged: when critical units are
+// configured, the deployment of a generation without prediction is
+// held.
+func (b *Builder) predictUnitChanges(ctx context.Context, generationUuid, outPath string) {
+	ctx, cancel := context.WithTimeout(ctx, dryActivateTimeout)
+	defer cancel()
+	c, err := b.executor.DryActivate(ctx, outPath)
+	if errors.Is(err, executor.ErrNotSupported) {
+		logrus.Debugf("builder: the unit changes prediction is not supported on this system")
+		return
+	} else if err != nil {
+		logrus.Errorf("builder: cannot predict the unit changes of generation %s: %s", generationUuid, err)
+		return
+	}
+	changes := &protobuf.UnitChanges{
+		Stop:    c.Stop,
+		Restart: c.Restart,
+		Reload:  c.Reload,
+		Start:   c.Start,
+	}
+	if err := b.store.GenerationUnitChanges(generationUuid, changes); err != nil {
+		logrus.Errorf("builder: %s", err)
+	}
+}
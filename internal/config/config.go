@@ -7,7 +7,10 @@ import (
 	"slices"
 	"strings"
 
+	"github.com/nlewo/comin/internal/hooks"
+	"github.com/nlewo/comin/internal/notifier"
 	"github.com/nlewo/comin/internal/types"
+	"github.com/nlewo/comin/internal/window"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
 )
@@ -44,6 +47,25 @@ func Read(path string) (config types.Configuration, err error) {
 		if remote.Branches.Testing.Operation == "" {
 			config.Remotes[i].Branches.Testing.Operation = "test"
 		}
+		if cs := remote.CommitStatus; cs.Enable {
+			if cs.Forge != "" && !slices.Contains([]string{"github", "gitlab", "gitea"}, cs.Forge) {
+				return config, fmt.Errorf("config: the commit_status forge of the remote '%s' is '%s' while it must be 'github', 'gitlab' or 'gitea'", remote.Name, cs.Forge)
+			}
+			if config.Remotes[i].Auth.AccessToken == "" {
+				return config, fmt.Errorf("config: the remote '%s' needs an access token to publish commit statuses", remote.Name)
+			}
+		}
+		for _, b := range []*types.Branch{&config.Remotes[i].Branches.Main, &config.Remotes[i].Branches.Testing} {
+			if b.OutsideWindow == "" {
+				b.OutsideWindow = "wait"
+			}
+			if b.OutsideWindow != "wait" && b.OutsideWindow != "boot" {
+				return config, fmt.Errorf("config: the outside_window of the branch '%s' is '%s' while it must be 'wait' or 'boot'", b.Name, b.OutsideWindow)
+			}
+			if _, err := window.Parse(b.Windows); err != nil {
+				return config, fmt.Errorf("config: the deployment windows of the branch '%s' are invalid: %w", b.Name, err)
+			}
+		}
 
 	}
 
@@ -62,6 +84,12 @@ func Read(path string) (config types.Configuration, err error) {
 	if config.StateFilepath == "" {
 		config.StateFilepath = filepath.Join(config.StateDir, "state.json")
 	}
+	if config.StateOnCorruption == "" {
+		config.StateOnCorruption = "refuse"
+	}
+	if config.StateOnCorruption != "refuse" && config.StateOnCorruption != "quarantine" {
+		return config, fmt.Errorf("config: state_on_corruption is '%s' while it must be 'refuse' or 'quarantine'", config.StateOnCorruption)
+	}
 	if config.RepositorySubdir == "" {
 		config.RepositorySubdir = "."
 	}
@@ -69,6 +97,115 @@ func Read(path string) (config types.Configuration, err error) {
 	if !slices.Contains(supportedRepositoryTypes, config.RepositoryType) {
 		return config, fmt.Errorf("config: repository type is '%s' while it be one of '%s'", config.RepositoryType, supportedRepositoryTypes)
 	}
+	if config.Builder.EvalTimeout < 0 || config.Builder.BuildTimeout < 0 {
+		return config, fmt.Errorf("config: builder eval_timeout and build_timeout must be positive")
+	}
+	if config.Builder.EvalTimeout == 0 {
+		config.Builder.EvalTimeout = 30 * 60
+	}
+	if config.Builder.BuildTimeout == 0 {
+		config.Builder.BuildTimeout = 30 * 60
+	}
+	if config.Builder.MaxSilentTime < 0 {
+		return config, fmt.Errorf("config: builder max_silent_time is '%d' while it must be positive", config.Builder.MaxSilentTime)
+	}
+	if config.Builder.SubstituteOnly.RecheckPeriod == 0 {
+		config.Builder.SubstituteOnly.RecheckPeriod = 60
+	}
+	if config.Builder.SubstituteOnly.Deadline == 0 {
+		config.Builder.SubstituteOnly.Deadline = 60 * 60
+	}
+	if config.Builder.SubstituteOnly.RecheckPeriod < 0 || config.Builder.SubstituteOnly.Deadline < 0 {
+		return config, fmt.Errorf("config: builder substitute_only recheck_period and deadline must be positive")
+	}
+	if _, err := window.Parse(config.Reboot.Windows); err != nil {
+		return config, fmt.Errorf("config: reboot windows are invalid: %w", err)
+	}
+	if config.Reboot.RandomDelay < 0 {
+		return config, fmt.Errorf("config: reboot random_delay is '%d' while it must be positive", config.Reboot.RandomDelay)
+	}
+	if config.Rollout.Waves == 0 {
+		config.Rollout.Waves = 2
+	}
+	if config.Rollout.CheckPeriod == 0 {
+		config.Rollout.CheckPeriod = 60
+	}
+	if config.Rollout.Waves < 0 || config.Rollout.CheckPeriod < 0 {
+		return config, fmt.Errorf("config: rollout waves and check_period must be positive")
+	}
+	if config.Rollout.Wave != nil && *config.Rollout.Wave < 0 {
+		return config, fmt.Errorf("config: rollout wave is '%d' while it must be positive", *config.Rollout.Wave)
+	}
+	if config.Rollout.Enable && (config.Rollout.Directory == "") == (config.Rollout.Url == "") {
+		return config, fmt.Errorf("config: exactly one of the rollout directory and url must be set")
+	}
+	names := make(map[string]bool)
+	for i, sink := range config.Notifier.Sinks {
+		if sink.Name == "" || names[sink.Name] {
+			return config, fmt.Errorf("config: the notifier sinks must have a unique name")
+		}
+		names[sink.Name] = true
+		if sink.TokenPath != "" {
+			content, err := os.ReadFile(sink.TokenPath)
+			if err != nil {
+				return config, err
+			}
+			config.Notifier.Sinks[i].Token = strings.TrimSpace(string(content))
+		}
+		if sink.Smtp.PasswordPath != "" {
+			content, err := os.ReadFile(sink.Smtp.PasswordPath)
+			if err != nil {
+				return config, err
+			}
+			config.Notifier.Sinks[i].Smtp.Password = strings.TrimSpace(string(content))
+		}
+		if sink.Type == "smtp" {
+			if sink.Smtp.Port == 0 {
+				config.Notifier.Sinks[i].Smtp.Port = 25
+			}
+			if sink.Smtp.Host == "" || sink.Smtp.From == "" || len(sink.Smtp.To) == 0 {
+				return config, fmt.Errorf("config: the notifier sink '%s' requires the smtp host, from and to", sink.Name)
+			}
+		} else if sink.Url == "" {
+			return config, fmt.Errorf("config: the notifier sink '%s' requires an url", sink.Name)
+		}
+		if sink.Type == "matrix" && sink.Room == "" {
+			return config, fmt.Errorf("config: the notifier sink '%s' requires a room", sink.Name)
+		}
+		if _, err := notifier.NewSink(config.Notifier.Sinks[i]); err != nil {
+			return config, err
+		}
+	}
+	for i := range config.Hooks {
+		h := &config.Hooks[i]
+		if h.Timeout == 0 {
+			h.Timeout = 300
+		}
+		if h.OnFailure == "" {
+			h.OnFailure = hooks.OnFailureContinue
+			if h.Stage == hooks.PreDeploy {
+				h.OnFailure = hooks.OnFailureVeto
+			}
+		}
+		if h.RetryPeriod == 0 {
+			h.RetryPeriod = 300
+		}
+		if _, err := hooks.New(*h); err != nil {
+			return config, err
+		}
+	}
+	if config.Gc.Period < 0 || config.Gc.MinInterval < 0 || config.Gc.MinFree < 0 || config.Gc.MaxFreed < 0 || config.Gc.DeleteOlderThan < 0 {
+		return config, fmt.Errorf("config: gc period, min_interval, min_free, max_freed and delete_older_than must be positive")
+	}
+	if config.Gc.Enable && !config.Gc.AfterDeployment && config.Gc.Period == 0 {
+		return config, fmt.Errorf("config: gc requires after_deployment or a period")
+	}
+	if config.DiskSpace.MinStoreFree < 0 || config.DiskSpace.MinBootFree < 0 {
+		return config, fmt.Errorf("config: disk_space min_store_free and min_boot_free must be positive")
+	}
+	if config.History.MaxAge < 0 {
+		return config, fmt.Errorf("config: history max_age must be positive")
+	}
 	if config.Grpc.UnixSocketPath == "" {
 		config.Grpc.UnixSocketPath = filepath.Join(config.StateDir, "grpc.sock")
 	}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/nlewo/comin/internal/types"
@@ -27,8 +29,8 @@ func TestConfig(t *testing.T) {
 				},
 				Timeout: 300,
 				Branches: types.Branches{
-					Main:    types.Branch{Operation: "switch"},
-					Testing: types.Branch{Operation: "test"},
+					Main:    types.Branch{Operation: "switch", OutsideWindow: "wait"},
+					Testing: types.Branch{Operation: "test", OutsideWindow: "wait"},
 				},
 			},
 			{
@@ -41,8 +43,8 @@ func TestConfig(t *testing.T) {
 				},
 				Timeout: 300,
 				Branches: types.Branches{
-					Main:    types.Branch{Operation: "switch"},
-					Testing: types.Branch{Operation: "test"},
+					Main:    types.Branch{Operation: "switch", OutsideWindow: "wait"},
+					Testing: types.Branch{Operation: "test", OutsideWindow: "wait"},
 				},
 			},
 		},
@@ -57,8 +59,38 @@ func TestConfig(t *testing.T) {
 		Grpc: types.Grpc{
 			UnixSocketPath: "/var/lib/comin/grpc.sock",
 		},
+		DeployConfirmer: types.DeployConfirmer{
+			Confirmer: types.Confirmer{
+				Mode:         "auto",
+				AutoDuration: 60,
+			},
+			CriticalUnits: []string{"sshd.service"},
+		},
+		Builder: types.Builder{
+			EvalTimeout:  1800,
+			BuildTimeout: 1800,
+			SubstituteOnly: types.SubstituteOnly{
+				RecheckPeriod: 60,
+				Deadline:      3600,
+			},
+		},
+		Rollout: types.Rollout{
+			Waves:       2,
+			CheckPeriod: 60,
+		},
+		StateOnCorruption: "refuse",
 	}
 	config, err := Read(configPath)
 	assert.Nil(t, err)
 	assert.Equal(t, expected, config)
 }
+
+func TestConfigNegativeTimeouts(t *testing.T) {
+	for _, builder := range []string{"eval_timeout: -1", "build_timeout: -60"} {
+		path := filepath.Join(t.TempDir(), "configuration.yaml")
+		content := "hostname: machine\nstate_dir: /var/lib/comin\nrepository_type: flake\nbuilder:\n  " + builder + "\n"
+		assert.Nil(t, os.WriteFile(path, []byte(content), 0o600))
+		_, err := Read(path)
+		assert.ErrorContains(t, err, "eval_timeout and build_timeout must be positive")
+	}
+}
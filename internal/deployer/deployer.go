@@ -9,9 +9,12 @@ import (
 	"time"
 
 	"github.com/dustin/go-humanize"
+	"github.com/nlewo/comin/internal/diskspace"
+	"github.com/nlewo/comin/internal/hooks"
 	"github.com/nlewo/comin/internal/store"
 	"github.com/nlewo/comin/internal/types"
 	"github.com/nlewo/comin/internal/utils"
+	"github.com/nlewo/comin/internal/window"
 	"github.com/nlewo/comin/pkg/protobuf"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -25,6 +28,20 @@ const (
 
 type DeployFunc func(context.Context, string, string, []string) (bool, string, error)
 
+// DeploymentWindow restricts the deployments of a branch to time
+// windows. Outside of the windows, the switch and test operations are
+// postponed to the opening of the next window.
+type DeploymentWindow struct {
+	Windows window.Windows
+	// Boot deploys with the boot operation outside of the windows
+	// instead of postponing a switch
+	Boot bool
+}
+
+// DeploymentWindows are the deployment windows by remote and branch
+// names.
+type DeploymentWindows map[string]map[string]DeploymentWindow
+
 type Deployer struct {
 	GenerationCh       chan *protobuf.Generation
 	deployerFunc       DeployFunc
@@ -42,6 +59,24 @@ type Deployer struct {
 	generationAvailableCh chan struct{}
 	postDeploymentCommand string
 
+	windows DeploymentWindows
+	// pendingReason is the reason why the generation to deploy is
+	// postponed
+	pendingReason string
+	// nextWindowAt is the opening of the next deployment window
+	nextWindowAt time.Time
+	windowTimer  *time.Timer
+	// windowOverride deploys the generation to deploy whatever the
+	// deployment windows
+	windowOverride bool
+
+	preDeployHooks  []hooks.Hook
+	postDeployHooks []hooks.Hook
+
+	// diskSpace checks the Nix store and /boot free space before the
+	// switch and boot operations. It is disabled when nil.
+	diskSpace *diskspace.Guard
+
 	isSuspended atomic.Bool
 	resumeCh    chan struct{}
 	// This is true when the runner is actually suspended. This is
@@ -53,7 +88,13 @@ type Deployer struct {
 func (d *Deployer) State() *protobuf.Deployer {
 	d.mu.Lock()
 	defer d.mu.Unlock()
+	var nextWindowAt *timestamppb.Timestamp
+	if !d.nextWindowAt.IsZero() {
+		nextWindowAt = timestamppb.New(d.nextWindowAt)
+	}
 	return &protobuf.Deployer{
+		PendingReason:      d.pendingReason,
+		NextWindowAt:       nextWindowAt,
 		IsDeploying:        wrapperspb.Bool(d.isDeploying.Load()),
 		GenerationToDeploy: d.GenerationToDeploy,
 		Operation:          d.Operation,
@@ -93,6 +134,12 @@ func showDeployment(padding string, d *protobuf.Deployment) {
 		fmt.Printf("%sOperation %s\n", padding, d.Operation)
 		fmt.Printf("%sProfilePath %s\n", padding, d.ProfilePath)
 	}
+	if d.OperationReason != "" {
+		fmt.Printf("%s  %s\n", padding, d.OperationReason)
+	}
+	for _, l := range store.InhibitorChangeLines(d.InhibitorChanges) {
+		fmt.Printf("%s  Switch inhibitor %s\n", padding, l)
+	}
 	fmt.Printf("%sGeneration %s\n", padding, d.Generation.Uuid)
 	fmt.Printf("%sCommit ID %s from %s/%s\n", padding, d.Generation.SelectedCommitId, d.Generation.SelectedRemoteName, d.Generation.SelectedBranchName)
 	fmt.Printf("%sCommit message %s\n", padding, strings.Trim(d.Generation.SelectedCommitMsg, "\n"))
@@ -101,6 +148,12 @@ func showDeployment(padding string, d *protobuf.Deployment) {
 
 func Show(s *protobuf.Deployer, padding string) {
 	fmt.Printf("  Deployer\n")
+	if s.GenerationToDeploy != nil && s.PendingReason != "" {
+		fmt.Printf("%sGeneration %s is postponed since it is %s\n", padding, s.GenerationToDeploy.Uuid, s.PendingReason)
+		if s.NextWindowAt != nil {
+			fmt.Printf("%sNext deployment window opens %s\n", padding, humanize.Time(s.NextWindowAt.AsTime()))
+		}
+	}
 	if s.Deployment == nil {
 		if s.PreviousDeployment == nil {
 			fmt.Printf("%sNo deployment yet\n", padding)
@@ -112,7 +165,7 @@ func Show(s *protobuf.Deployer, padding string) {
 	showDeployment(padding, s.Deployment)
 }
 
-func New(store *store.Store, deployFunc DeployFunc, previousDeployment *protobuf.Deployment, postDeploymentCommand string) *Deployer {
+func New(store *store.Store, deployFunc DeployFunc, previousDeployment *protobuf.Deployment, postDeploymentCommand string, windows DeploymentWindows, lifecycleHooks []hooks.Hook, diskSpace *diskspace.Guard) *Deployer {
 	if previousDeployment != nil {
 		logrus.Infof("deployer: initializing with previous deployment %s", previousDeployment.Uuid)
 	}
@@ -122,6 +175,10 @@ func New(store *store.Store, deployFunc DeployFunc, previousDeployment *protobuf
 		deployerFunc:          deployFunc,
 		generationAvailableCh: make(chan struct{}, 1),
 		postDeploymentCommand: postDeploymentCommand,
+		windows:               windows,
+		preDeployHooks:        hooks.OfStage(lifecycleHooks, hooks.PreDeploy),
+		postDeployHooks:       hooks.OfStage(lifecycleHooks, hooks.PostDeploy),
+		diskSpace:             diskSpace,
 
 		resumeCh: make(chan struct{}, 1),
 	}
@@ -195,10 +252,117 @@ func (d *Deployer) Submit(generation *protobuf.Generation, operation string, for
 		d.GenerationToDeploy = generation
 		d.Operation = operation
 		d.Reason = reason
-		select {
-		case d.generationAvailableCh <- struct{}{}:
-		default:
-		}
+		d.windowOverride = false
+		d.notify()
+	}
+}
+
+// notify wakes up the runner to deploy the generation to deploy
+func (d *Deployer) notify() {
+	select {
+	case d.generationAvailableCh <- struct{}{}:
+	default:
+	}
+}
+
+// OverrideWindow deploys the generation waiting for a deployment
+// window now.
+func (d *Deployer) OverrideWindow() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.GenerationToDeploy == nil || d.pendingReason == "" {
+		return fmt.Errorf("deployer: no generation is waiting for a deployment window")
+	}
+	logrus.Infof("deployer: the deployment window of the generation %s has been overridden", d.GenerationToDeploy.Uuid)
+	d.windowOverride = true
+	d.notify()
+	return nil
+}
+
+// OverrideInhibitors accepts the switch inhibitor changes of a
+// generation. When keys is empty, all switch inhibitors are
+// overridden. If the current deployment of this generation has been
+// downgraded because of these changes, the generation is redeployed
+// with the submitted operation. The override is persisted in the
+// store and removed with its generation.
+func (d *Deployer) OverrideInhibitors(generationUuid string, keys []string, comment string) error {
+	err := d.store.InhibitorOverrideSet(&protobuf.InhibitorOverride{
+		GenerationUuid: generationUuid,
+		Keys:           keys,
+		Comment:        comment,
+		CreatedAt:      timestamppb.New(time.Now().UTC()),
+	})
+	if err != nil {
+		return err
+	}
+	logrus.Infof("deployer: the switch inhibitors %v of the generation %s have been overridden: %s", keys, generationUuid, comment)
+	current := d.Deployment()
+	if current != nil && current.Generation.Uuid == generationUuid && current.Operation != current.OperationSubmitted {
+		reason := fmt.Sprintf("The switch inhibitors of the deployment %s have been overridden", current.Uuid)
+		d.Submit(current.Generation, current.OperationSubmitted, true, reason)
+	}
+	return nil
+}
+
+// applyWindow returns the operation to deploy the generation with at
+// now, or postpone is true if the generation has to wait for the
+// next deployment window. It must be called with d.mu held.
+func (d *Deployer) applyWindow(g *protobuf.Generation, operation string, now time.Time) (string, bool) {
+	w, ok := d.windows[g.SelectedRemoteName][g.SelectedBranchName]
+	// The boot operation doesn't change the running system
+	if !ok || operation == types.OperationBoot || w.Windows.Contains(now) {
+		return operation, false
+	}
+	if d.windowOverride {
+		logrus.Infof("deployer: deploying generation %s outside of the deployment windows '%s' since they have been overridden", g.Uuid, w.Windows)
+		return operation, false
+	}
+	if w.Boot && operation == types.OperationSwitch {
+		logrus.Infof("deployer: deploying generation %s with the boot operation since it is outside of the deployment windows '%s'", g.Uuid, w.Windows)
+		return types.OperationBoot, false
+	}
+	d.nextWindowAt = w.Windows.Next(now)
+	d.pendingReason = fmt.Sprintf("outside of the deployment windows '%s'", w.Windows)
+	if d.windowTimer != nil {
+		d.windowTimer.Stop()
+	}
+	d.windowTimer = time.AfterFunc(time.Until(d.nextWindowAt), d.notify)
+	logrus.Infof("deployer: the deployment of generation %s is postponed to %s since it is %s", g.Uuid, d.nextWindowAt, d.pendingReason)
+	return operation, true
+}
+
+// postponeForHook postpones the deployment of the generation because
+// a pre-deployment hook failed. The deployment is retried after the
+// retry period of the hook, unless a new generation has been submitted
+// in the meantime.
+func (d *Deployer) postponeForHook(g *protobuf.Generation, hook *hooks.Hook) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.GenerationToDeploy != nil {
+		return
+	}
+	d.GenerationToDeploy = g
+	d.pendingReason = fmt.Sprintf("waiting for the pre-deployment hook %s to succeed", hook.Name)
+	if d.windowTimer != nil {
+		d.windowTimer.Stop()
+	}
+	d.windowTimer = time.AfterFunc(hook.RetryPeriod, d.notify)
+	logrus.Infof("deployer: the deployment of generation %s is postponed by the pre-deployment hook %s, retrying in %s", g.Uuid, hook.Name, hook.RetryPeriod)
+}
+
+// veto records a deployment vetoed by a pre-deployment hook as
+// failed. Since the system is not modified, it doesn't become the
+// current deployment, the post deployment command is not run and the
+// manager is not notified.
+func (d *Deployer) veto(dpl *protobuf.Deployment, result *protobuf.HookResult, booted, current string) {
+	err := fmt.Errorf("the pre-deployment hook %s vetoed the deployment with the exit code %d", result.Name, result.ExitCode)
+	logrus.Infof("deployer: the deployment of generation %s failed: %s", dpl.Generation.Uuid, err)
+	if err := d.store.DeploymentStarted(dpl.Uuid, booted, current); err != nil {
+		logrus.Errorf("deployer: could not update the deployment %s in the store", dpl.Uuid)
+		return
+	}
+	if err := d.store.DeploymentFinished(dpl.Uuid, err, false, "", booted, current); err != nil {
+		logrus.Errorf("deployer: could not update the deployment %s in the store", dpl.Uuid)
 	}
 }
 
@@ -218,12 +382,42 @@ func (d *Deployer) Run(ctx context.Context) {
 
 			d.mu.Lock()
 			g := d.GenerationToDeploy
-			operationSubmitted := d.Operation
+			if g == nil {
+				d.mu.Unlock()
+				continue
+			}
+			operationSubmitted, postponed := d.applyWindow(g, d.Operation, time.Now())
+			if postponed {
+				d.mu.Unlock()
+				continue
+			}
 			d.GenerationToDeploy = nil
+			d.pendingReason = ""
+			d.nextWindowAt = time.Time{}
+			d.windowOverride = false
+			if d.windowTimer != nil {
+				d.windowTimer.Stop()
+			}
 			d.mu.Unlock()
 			logrus.Infof("deployer: deploying generation %s with the submitted operation %s", g.Uuid, operationSubmitted)
+			override := d.store.InhibitorOverrideGet(g.Uuid)
 			booted, current := utils.GetBootedAndCurrentStorepaths()
-			dpl := d.store.NewDeployment(g, operationSubmitted, d.Reason, booted, current)
+			dpl := store.CandidateDeployment(g, operationSubmitted, d.Reason, current, override)
+			var failedHook *hooks.Hook
+			dpl.Hooks, failedHook = runHooks(ctx, d.preDeployHooks, dpl)
+			if failedHook != nil && failedHook.OnFailure == hooks.OnFailurePostpone {
+				d.postponeForHook(g, failedHook)
+				continue
+			}
+			d.store.DeploymentAdd(dpl, booted, current)
+			// Whatever its failure policy, a failed pre-deploy
+			// hook prevents the deployment: "abort" vetoes it
+			// as "veto" does, and "continue" only runs the next
+			// pre-deploy hooks before vetoing it.
+			if failed := failedHookResult(dpl.Hooks); failed != nil {
+				d.veto(dpl, failed, booted, current)
+				continue
+			}
 			operationComputed := dpl.Operation
 			d.mu.Lock()
 			d.previousDeployment.Swap(d.Deployment())
@@ -234,7 +428,13 @@ func (d *Deployer) Run(ctx context.Context) {
 				logrus.Errorf("deployer: could not update the deployment %s in the store", dpl.Uuid)
 				continue
 			}
-			if operationComputed != types.OperationNull {
+			if operationComputed == types.OperationSwitch || operationComputed == types.OperationBoot {
+				err = d.diskSpace.CheckDeploy(ctx, g.OutPath)
+				if err != nil {
+					logrus.Errorf("deployer: the deployment of generation %s is refused: %s", g.Uuid, err)
+				}
+			}
+			if err == nil && operationComputed != types.OperationNull {
 				profilePaths := d.store.GetDeploymentProfilePaths()
 				cominNeedRestart, profilePath, err = d.deployerFunc(
 					ctx,
@@ -256,6 +456,11 @@ func (d *Deployer) Run(ctx context.Context) {
 					logrus.Errorf("deployer: deploying generation %s, post deployment command [%s] failed %v", g.Uuid, cmd, err)
 				}
 			}
+			if results, _ := runHooks(ctx, d.postDeployHooks, deployment); len(results) > 0 {
+				if err := d.store.DeploymentHookResults(dpl.Uuid, results); err != nil {
+					logrus.Errorf("deployer: could not update the deployment %s in the store", dpl.Uuid)
+				}
+			}
 
 			d.isDeploying.Store(false)
 			d.deployment.Store(deployment)
@@ -8,6 +8,7 @@ import (
 	"github.com/nlewo/comin/internal/broker"
 	"github.com/nlewo/comin/pkg/protobuf"
 	"github.com/nlewo/comin/internal/store"
+	"github.com/nlewo/comin/internal/window"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -22,9 +23,9 @@ func TestDeployerBasic(t *testing.T) {
 	bk := broker.New()
 	bk.Start()
 
-	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1)
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
 	assert.Nil(t, err)
-	d := New(s, deployFunc, nil, "")
+	d := New(s, deployFunc, nil, "", nil, nil, nil)
 	d.Run(t.Context())
 	assert.False(t, d.IsDeploying())
 
@@ -58,9 +59,9 @@ func TestDeployerSubmit(t *testing.T) {
 	bk := broker.New()
 	bk.Start()
 
-	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1)
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
 	assert.Nil(t, err)
-	d := New(s, deployFunc, nil, "")
+	d := New(s, deployFunc, nil, "", nil, nil, nil)
 	d.Run(t.Context())
 	assert.False(t, d.IsDeploying())
 
@@ -101,9 +102,9 @@ func TestDeployerSuspend(t *testing.T) {
 	bk := broker.New()
 	bk.Start()
 
-	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1)
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
 	assert.Nil(t, err)
-	d := New(s, deployFunc, nil, "")
+	d := New(s, deployFunc, nil, "", nil, nil, nil)
 	d.Run(t.Context())
 	assert.False(t, d.IsSuspended())
 	d.Suspend("suspended for testing")
@@ -122,3 +123,115 @@ func TestDeployerSuspend(t *testing.T) {
 		assert.True(t, d.IsDeploying())
 	}, 3*time.Second, 100*time.Millisecond)
 }
+
+// closedWindows returns windows which are not open during the test
+func closedWindows(t *testing.T) window.Windows {
+	opening := time.Now().Add(2 * time.Hour)
+	windows, err := window.Parse([]string{opening.Format("15:04") + "-" + opening.Add(time.Minute).Format("15:04")})
+	assert.Nil(t, err)
+	return windows
+}
+
+func TestDeployerWindow(t *testing.T) {
+	operations := make(chan string, 1)
+	var deployFunc = func(_ context.Context, _, operation string, _ []string) (bool, string, error) {
+		operations <- operation
+		return false, "profile-path", nil
+	}
+
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
+	assert.Nil(t, err)
+	windows := DeploymentWindows{
+		"origin": {
+			"main": DeploymentWindow{Windows: closedWindows(t)},
+		},
+	}
+	d := New(s, deployFunc, nil, "", windows, nil, nil)
+	d.Run(t.Context())
+
+	assert.NotNil(t, d.OverrideWindow())
+
+	g := &protobuf.Generation{SelectedCommitId: "commit-1", SelectedRemoteName: "origin", SelectedBranchName: "main"}
+	d.Submit(g, "switch", false, "")
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		state := d.State()
+		assert.Contains(c, state.PendingReason, "outside of the deployment windows")
+		assert.True(c, state.NextWindowAt.AsTime().After(time.Now()))
+		assert.NotNil(c, state.GenerationToDeploy)
+	}, 5*time.Second, 100*time.Millisecond)
+	assert.False(t, d.IsDeploying())
+
+	assert.Nil(t, d.OverrideWindow())
+	assert.Equal(t, "switch", <-operations)
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		state := d.State()
+		assert.Equal(c, "", state.PendingReason)
+		assert.Nil(c, state.NextWindowAt)
+		assert.Nil(c, state.GenerationToDeploy)
+	}, 5*time.Second, 100*time.Millisecond)
+	<-d.DeploymentDoneCh
+
+	// The boot operation is not restricted by windows
+	d.Submit(&protobuf.Generation{SelectedCommitId: "commit-2", SelectedRemoteName: "origin", SelectedBranchName: "main"}, "boot", false, "")
+	assert.Equal(t, "boot", <-operations)
+	<-d.DeploymentDoneCh
+
+	// Branches without windows are not restricted
+	d.Submit(&protobuf.Generation{SelectedCommitId: "commit-3", SelectedRemoteName: "origin", SelectedBranchName: "testing"}, "test", false, "")
+	assert.Equal(t, "test", <-operations)
+	<-d.DeploymentDoneCh
+}
+
+func TestDeployerWindowBoot(t *testing.T) {
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
+	assert.Nil(t, err)
+	d := New(s, nil, nil, "", DeploymentWindows{
+		"origin": {
+			"main": DeploymentWindow{Windows: closedWindows(t), Boot: true},
+		},
+	}, nil, nil)
+	g := &protobuf.Generation{SelectedRemoteName: "origin", SelectedBranchName: "main"}
+	operation, postponed := d.applyWindow(g, "switch", time.Now())
+	assert.False(t, postponed)
+	assert.Equal(t, "boot", operation)
+
+	// A test operation can not be converted
+	operation, postponed = d.applyWindow(g, "test", time.Now())
+	assert.True(t, postponed)
+	assert.Equal(t, "test", operation)
+	d.windowTimer.Stop()
+}
+
+func TestDeployerOverrideInhibitors(t *testing.T) {
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
+	assert.Nil(t, err)
+	d := New(s, nil, nil, "", nil, nil, nil)
+	assert.ErrorContains(t, d.OverrideInhibitors("unknown", nil, ""), "unknown")
+
+	// The generation has been deployed with the boot operation
+	// because of its switch inhibitors
+	g := s.NewGeneration("host", "repo", "", "attr", &protobuf.RepositoryStatus{})
+	d.deployment.Store(&protobuf.Deployment{Uuid: "dpl-1", Generation: &g, OperationSubmitted: "switch", Operation: "boot"})
+	assert.Nil(t, d.OverrideInhibitors(g.Uuid, []string{"systemd"}, "I accept the systemd upgrade"))
+	assert.Equal(t, g.Uuid, d.GenerationToDeploy.Uuid)
+	assert.Equal(t, "switch", d.Operation)
+	override := s.InhibitorOverrideGet(g.Uuid)
+	assert.Equal(t, []string{"systemd"}, override.Keys)
+	assert.Equal(t, "I accept the systemd upgrade", override.Comment)
+
+	// The override is persisted in the store
+	s2, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, s2.Load())
+	assert.Equal(t, "I accept the systemd upgrade", s2.InhibitorOverrideGet(g.Uuid).Comment)
+}
@@ -0,0 +1,36 @@
+package deployer
+
+import (
+	"context"
+
+	"github.com/nlewo/comin/internal/hooks"
+	pb "github.com/nlewo/comin/pkg/protobuf"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// runHooks runs the pre-deploy or post-deploy hooks. They receive the
+// deployment as environment variables and as JSON on their stdin.
+func runHooks(ctx context.Context, hs []hooks.Hook, d *pb.Deployment) ([]*pb.HookResult, *hooks.Hook) {
+	if len(hs) == 0 {
+		return nil, nil
+	}
+	input, err := protojson.Marshal(d)
+	if err != nil {
+		return []*pb.HookResult{{Name: hs[0].Name, Stage: hs[0].Stage, ExitCode: -1, Output: err.Error()}}, &hs[0]
+	}
+	env := append(deploymentEnv(d),
+		"COMIN_DEPLOYMENT="+d.Uuid,
+		"COMIN_OPERATION="+d.Operation,
+	)
+	return hooks.RunAll(ctx, hs, env, input)
+}
+
+// failedHookResult returns the result of the first failed hook, if any
+func failedHookResult(results []*pb.HookResult) *pb.HookResult {
+	for _, r := range results {
+		if r.ExitCode != 0 {
+			return r
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package deployer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nlewo/comin/internal/broker"
+	"github.com/nlewo/comin/internal/hooks"
+	"github.com/nlewo/comin/internal/store"
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeHook(t *testing.T, script string) string {
+	path := filepath.Join(t.TempDir(), "hook")
+	assert.Nil(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755))
+	return path
+}
+
+func TestDeployerHooks(t *testing.T) {
+	operations := make(chan string, 1)
+	var deployFunc = func(_ context.Context, _, operation string, _ []string) (bool, string, error) {
+		operations <- operation
+		return false, "profile-path", nil
+	}
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
+	assert.Nil(t, err)
+
+	// The backup hook succeeds once the backup file exists
+	backup := filepath.Join(tmp, "backup")
+	vetoFile := filepath.Join(tmp, "veto")
+	lifecycleHooks := []hooks.Hook{
+		{Name: "veto", Stage: hooks.PreDeploy, Command: writeHook(t, "test ! -e "+vetoFile+"\n"), OnFailure: hooks.OnFailureVeto},
+		{Name: "backup", Stage: hooks.PreDeploy, Command: writeHook(t, "test -e "+backup+"\n"), OnFailure: hooks.OnFailurePostpone, RetryPeriod: 100 * time.Millisecond},
+		{Name: "notify", Stage: hooks.PostDeploy, Command: writeHook(t, "echo $COMIN_STATUS\n"), OnFailure: hooks.OnFailureContinue},
+	}
+	d := New(s, deployFunc, nil, "", nil, lifecycleHooks, nil)
+	d.Run(t.Context())
+
+	d.Submit(&protobuf.Generation{Uuid: "g-1", SelectedCommitId: "commit-1"}, "switch", false, "")
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		state := d.State()
+		assert.Equal(c, "waiting for the pre-deployment hook backup to succeed", state.PendingReason)
+		assert.NotNil(c, state.GenerationToDeploy)
+	}, 5*time.Second, 100*time.Millisecond)
+	assert.Empty(t, s.GetState().Deployments)
+
+	assert.Nil(t, os.WriteFile(backup, []byte{}, 0644))
+	assert.Equal(t, "switch", <-operations)
+	dpl := <-d.DeploymentDoneCh
+	assert.Equal(t, "done", dpl.Status)
+	if assert.Len(t, dpl.Hooks, 3) {
+		assert.Equal(t, hooks.PostDeploy, dpl.Hooks[2].Stage)
+		assert.Equal(t, "done\n", dpl.Hooks[2].Output)
+	}
+	assert.Equal(t, "", d.State().PendingReason)
+
+	// A vetoed deployment is recorded as failed
+	assert.Nil(t, os.WriteFile(vetoFile, []byte{}, 0644))
+	d.Submit(&protobuf.Generation{Uuid: "g-2", SelectedCommitId: "commit-2", OutPath: "other"}, "switch", false, "")
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		var vetoed *protobuf.Deployment
+		for _, dpl := range s.GetState().Deployments {
+			if dpl.Generation.Uuid == "g-2" {
+				vetoed = dpl
+			}
+		}
+		if assert.NotNil(c, vetoed) {
+			assert.Equal(c, "failed", vetoed.Status)
+			assert.Contains(c, vetoed.ErrorMsg, "the pre-deployment hook veto vetoed the deployment with the exit code 1")
+			assert.Len(c, vetoed.Hooks, 1)
+		}
+	}, 5*time.Second, 100*time.Millisecond)
+	assert.Equal(t, "g-1", d.Deployment().Generation.Uuid)
+	assert.Len(t, operations, 0)
+}
+
+func TestDeployerPreDeployHookFailures(t *testing.T) {
+	for _, tc := range []struct {
+		onFailure string
+		// ran is the number of pre-deploy hooks which have been run
+		ran int
+	}{
+		{hooks.OnFailureAbort, 1},
+		{hooks.OnFailureContinue, 2},
+	} {
+		t.Run(tc.onFailure, func(t *testing.T) {
+			operations := make(chan string, 1)
+			var deployFunc = func(_ context.Context, _, operation string, _ []string) (bool, string, error) {
+				operations <- operation
+				return false, "profile-path", nil
+			}
+			tmp := t.TempDir()
+			bk := broker.New()
+			bk.Start()
+			s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
+			assert.Nil(t, err)
+			lifecycleHooks := []hooks.Hook{
+				{Name: "failing", Stage: hooks.PreDeploy, Command: writeHook(t, "exit 2\n"), OnFailure: tc.onFailure},
+				{Name: "next", Stage: hooks.PreDeploy, Command: writeHook(t, "exit 0\n"), OnFailure: hooks.OnFailureVeto},
+			}
+			d := New(s, deployFunc, nil, "", nil, lifecycleHooks, nil)
+			d.Run(t.Context())
+
+			d.Submit(&protobuf.Generation{Uuid: "g-1", SelectedCommitId: "commit-1"}, "switch", false, "")
+			assert.EventuallyWithT(t, func(c *assert.CollectT) {
+				var vetoed *protobuf.Deployment
+				for _, dpl := range s.GetState().Deployments {
+					if dpl.Generation.Uuid == "g-1" {
+						vetoed = dpl
+					}
+				}
+				if assert.NotNil(c, vetoed) {
+					assert.Equal(c, "failed", vetoed.Status)
+					assert.Contains(c, vetoed.ErrorMsg, "the pre-deployment hook failing vetoed the deployment with the exit code 2")
+					assert.Len(c, vetoed.Hooks, tc.ran)
+				}
+			}, 5*time.Second, 100*time.Millisecond)
+			assert.Len(t, operations, 0)
+		})
+	}
+}
+
+func TestDeployerPostDeployHookAbort(t *testing.T) {
+	var deployFunc = func(_ context.Context, _, operation string, _ []string) (bool, string, error) {
+		return false, "profile-path", nil
+	}
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
+	assert.Nil(t, err)
+	skipped := filepath.Join(tmp, "skipped")
+	lifecycleHooks := []hooks.Hook{
+		{Name: "smoke-tests", Stage: hooks.PostDeploy, Command: writeHook(t, "exit 3\n"), OnFailure: hooks.OnFailureAbort},
+		{Name: "skipped", Stage: hooks.PostDeploy, Command: writeHook(t, "touch "+skipped+"\n"), OnFailure: hooks.OnFailureContinue},
+	}
+	d := New(s, deployFunc, nil, "", nil, lifecycleHooks, nil)
+	d.Run(t.Context())
+
+	d.Submit(&protobuf.Generation{Uuid: "g-1", SelectedCommitId: "commit-1"}, "switch", false, "")
+	dpl := <-d.DeploymentDoneCh
+	// The deployment is finished before the post-deploy hooks
+	// run: an aborting hook only skips the next ones
+	assert.Equal(t, "done", dpl.Status)
+	assert.Equal(t, "", dpl.ErrorMsg)
+	if assert.Len(t, dpl.Hooks, 1) {
+		assert.Equal(t, "smoke-tests", dpl.Hooks[0].Name)
+		assert.Equal(t, int32(3), dpl.Hooks[0].ExitCode)
+	}
+	assert.NoFileExists(t, skipped)
+	assert.Equal(t, "g-1", d.Deployment().Generation.Uuid)
+}
@@ -39,11 +39,10 @@ func envCominErrorMessage(d *pb.Deployment) string {
 	return d.ErrorMsg
 }
 
-func runPostDeploymentCommand(command string, d *pb.Deployment) (string, error) {
-
-	cmd := exec.Command(command)
-
-	cmd.Env = append(os.Environ(),
+// deploymentEnv returns the environment of the commands run around a
+// deployment
+func deploymentEnv(d *pb.Deployment) []string {
+	return append(os.Environ(),
 		"COMIN_GIT_SHA="+envGitSha(d),
 		"COMIN_GIT_REF="+envGitRef(d),
 		"COMIN_GIT_MSG="+envGitMessage(d),
@@ -52,6 +51,13 @@ func runPostDeploymentCommand(command string, d *pb.Deployment) (string, error)
 		"COMIN_STATUS="+envCominStatus(d),
 		"COMIN_ERROR_MSG="+envCominErrorMessage(d),
 	)
+}
+
+func runPostDeploymentCommand(command string, d *pb.Deployment) (string, error) {
+
+	cmd := exec.Command(command)
+
+	cmd.Env = deploymentEnv(d)
 
 	output, err := cmd.CombinedOutput()
 	outputString := string(output)
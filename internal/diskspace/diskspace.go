@@ -0,0 +1,225 @@
+package diskspace
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"syscall"
+
+	"github.com/dustin/go-humanize"
+	"github.com/nlewo/comin/internal/store"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	StoreDir = "/nix/store"
+	BootDir  = "/boot"
+)
+
+// installedSystems are the systems whose kernel and initrd are
+// already installed in /boot
+var installedSystems = []string{"/run/current-system", "/run/booted-system"}
+
+// Guard checks the free space of the Nix store and /boot filesystems
+// before building and deploying generations. A nil Guard doesn't
+// check anything.
+type Guard struct {
+	minStoreFree int64
+	minBootFree  int64
+	// collectFunc collects the Nix store garbage when the Nix
+	// store free space is short. It is disabled when nil.
+	collectFunc func(ctx context.Context)
+
+	bootDir          string
+	installedSystems []string
+	freeSpaceFunc    func(path string) (int64, error)
+	// fetchSizeFunc returns the unpacked size of the store paths
+	// to fetch to realise a path
+	fetchSizeFunc func(ctx context.Context, path string) (int64, error)
+}
+
+func New(minStoreFree, minBootFree int64, collectFunc func(ctx context.Context)) *Guard {
+	logrus.Infof("diskspace: initialization with minStoreFree=%d, minBootFree=%d, collectGarbage=%v", minStoreFree, minBootFree, collectFunc != nil)
+	return &Guard{
+		minStoreFree:     minStoreFree,
+		minBootFree:      minBootFree,
+		collectFunc:      collectFunc,
+		bootDir:          BootDir,
+		installedSystems: installedSystems,
+		freeSpaceFunc:    FreeSpace,
+		fetchSizeFunc:    fetchSize,
+	}
+}
+
+// CheckBuild returns an error wrapping store.ErrInsufficientDiskSpace
+// if the Nix store free space is lower than the threshold plus the
+// estimated size of the closure to build, after collecting the
+// garbage if enabled. The estimate is the unpacked size of the store
+// paths to fetch from the binary caches, reported by nix build
+// --dry-run: the size of the paths built locally is unknown. The
+// derivation is used when drvPath is set, otherwise the outPath.
+func (g *Guard) CheckBuild(ctx context.Context, drvPath, outPath string) error {
+	if g == nil || g.minStoreFree == 0 {
+		return nil
+	}
+	path := outPath
+	if drvPath != "" {
+		path = drvPath + "^*"
+	}
+	estimate, err := g.fetchSizeFunc(ctx, path)
+	if err != nil {
+		logrus.Errorf("diskspace: failed to estimate the size of the closure of %s: %s", path, err)
+	}
+	return g.checkStore(ctx, estimate)
+}
+
+// CheckDeploy returns an error wrapping
+// store.ErrInsufficientDiskSpace if the Nix store or the /boot free
+// space is too low to deploy the system outPath. The space required
+// on /boot is at least the size of the kernel and the initrd of this
+// system. The /boot check is skipped when they are the kernel and the
+// initrd of the current or booted system, which are already
+// installed.
+func (g *Guard) CheckDeploy(ctx context.Context, outPath string) error {
+	if g == nil {
+		return nil
+	}
+	if err := g.checkStore(ctx, 0); err != nil {
+		return err
+	}
+	if g.minBootFree == 0 {
+		return nil
+	}
+	if _, err := os.Stat(g.bootDir); err != nil {
+		return nil
+	}
+	if g.bootFilesInstalled(outPath) {
+		logrus.Infof("diskspace: skipping the %s check since the kernel and initrd of %s are already installed", g.bootDir, outPath)
+		return nil
+	}
+	free, err := g.freeSpaceFunc(g.bootDir)
+	if err != nil {
+		logrus.Errorf("diskspace: failed to get the free space of %s: %s", g.bootDir, err)
+		return nil
+	}
+	required := g.minBootFree
+	estimate := bootFilesSize(outPath)
+	if estimate > required {
+		required = estimate
+	}
+	if free < required {
+		return fmt.Errorf("%w: %s available on %s while %s are required (the kernel and initrd of the new system are %s)",
+			store.ErrInsufficientDiskSpace, humanize.IBytes(uint64(free)), g.bootDir, humanize.IBytes(uint64(required)), humanize.IBytes(uint64(estimate)))
+	}
+	return nil
+}
+
+// checkStore checks the Nix store has minStoreFree bytes available
+// in addition to the estimated size of the paths to add
+func (g *Guard) checkStore(ctx context.Context, estimate int64) error {
+	if g.minStoreFree == 0 {
+		return nil
+	}
+	free, err := g.freeSpaceFunc(StoreDir)
+	if err != nil {
+		logrus.Errorf("diskspace: failed to get the free space of %s: %s", StoreDir, err)
+		return nil
+	}
+	required := g.minStoreFree + estimate
+	if free < required && g.collectFunc != nil {
+		logrus.Infof("diskspace: collecting the garbage since %s are available on %s", humanize.IBytes(uint64(free)), StoreDir)
+		g.collectFunc(ctx)
+		if free, err = g.freeSpaceFunc(StoreDir); err != nil {
+			logrus.Errorf("diskspace: failed to get the free space of %s: %s", StoreDir, err)
+			return nil
+		}
+	}
+	if free < required {
+		return fmt.Errorf("%w: %s available on %s while %s are required (the closure to fetch is %s)",
+			store.ErrInsufficientDiskSpace, humanize.IBytes(uint64(free)), StoreDir, humanize.IBytes(uint64(required)), humanize.IBytes(uint64(estimate)))
+	}
+	return nil
+}
+
+// bootFilesInstalled returns true if the kernel and the initrd of
+// the system outPath are the ones of an installed system
+func (g *Guard) bootFilesInstalled(outPath string) bool {
+	for _, name := range []string{"kernel", "initrd"} {
+		target, err := filepath.EvalSymlinks(filepath.Join(outPath, name))
+		if err != nil {
+			return false
+		}
+		installed := false
+		for _, system := range g.installedSystems {
+			if t, err := filepath.EvalSymlinks(filepath.Join(system, name)); err == nil && t == target {
+				installed = true
+				break
+			}
+		}
+		if !installed {
+			return false
+		}
+	}
+	return true
+}
+
+// bootFilesSize returns the size of the kernel and the initrd of a
+// system, or 0 if they can't be read
+func bootFilesSize(outPath string) (size int64) {
+	for _, name := range []string{"kernel", "initrd"} {
+		if info, err := os.Stat(filepath.Join(outPath, name)); err == nil {
+			size += info.Size()
+		}
+	}
+	return
+}
+
+// FreeSpace returns the space available to unprivileged users on the
+// filesystem of path
+func FreeSpace(path string) (int64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return int64(st.Bavail) * int64(st.Bsize), nil
+}
+
+var fetchedRegexp = regexp.MustCompile(`will be fetched \(([0-9.]+) (B|KiB|MiB|GiB|TiB) download, ([0-9.]+) (B|KiB|MiB|GiB|TiB) unpacked\)`)
+
+var units = map[string]float64{
+	"B":   1,
+	"KiB": 1 << 10,
+	"MiB": 1 << 20,
+	"GiB": 1 << 30,
+	"TiB": 1 << 40,
+}
+
+// parseFetchSize parses the unpacked size of the outputs of nix
+// build --dry-run such as "these 3 paths will be fetched (1.20 MiB
+// download, 5.60 MiB unpacked):". It is 0 when nothing is fetched.
+func parseFetchSize(output string) (int64, error) {
+	m := fetchedRegexp.FindStringSubmatch(output)
+	if m == nil {
+		return 0, nil
+	}
+	value, err := strconv.ParseFloat(m[3], 64)
+	if err != nil {
+		return 0, err
+	}
+	return int64(value * units[m[4]]), nil
+}
+
+func fetchSize(ctx context.Context, path string) (int64, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "nix", "--extra-experimental-features", "nix-command", "build", "--dry-run", "--no-link", path)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return 0, fmt.Errorf("nix build --dry-run failed: %w: %s", err, stderr.String())
+	}
+	return parseFetchSize(stderr.String())
+}
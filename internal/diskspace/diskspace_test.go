@@ -0,0 +1,99 @@
+package diskspace
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nlewo/comin/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckBuild(t *testing.T) {
+	var g *Guard
+	assert.Nil(t, g.CheckBuild(t.Context(), "", ""))
+
+	free := int64(100)
+	collected := 0
+	g = New(1000, 0, func(ctx context.Context) {
+		collected++
+		free += 500
+	})
+	g.freeSpaceFunc = func(string) (int64, error) { return free, nil }
+	var paths []string
+	g.fetchSizeFunc = func(ctx context.Context, path string) (int64, error) {
+		paths = append(paths, path)
+		return 200, nil
+	}
+
+	err := g.CheckBuild(t.Context(), "/nix/store/system.drv", "/nix/store/system")
+	assert.True(t, errors.Is(err, store.ErrInsufficientDiskSpace))
+	assert.ErrorContains(t, err, "600 B available on /nix/store while 1.2 KiB are required (the closure to fetch is 200 B)")
+	assert.Equal(t, 1, collected)
+	assert.Equal(t, []string{"/nix/store/system.drv^*"}, paths)
+
+	// The out path is used without derivation
+	err = g.CheckBuild(t.Context(), "", "/nix/store/system")
+	assert.ErrorContains(t, err, "1.1 KiB available on /nix/store")
+	assert.Equal(t, "/nix/store/system", paths[1])
+	assert.Equal(t, 2, collected)
+
+	assert.Nil(t, g.CheckBuild(t.Context(), "", "/nix/store/system"))
+	assert.Equal(t, 3, collected)
+	assert.Nil(t, g.CheckBuild(t.Context(), "", "/nix/store/system"))
+	assert.Equal(t, 3, collected)
+
+	// The min_store_free threshold is still checked when the
+	// estimation fails
+	free = 900
+	g.collectFunc = nil
+	g.fetchSizeFunc = func(ctx context.Context, path string) (int64, error) { return 0, assert.AnError }
+	assert.ErrorContains(t, g.CheckBuild(t.Context(), "", "/nix/store/system"), "while 1000 B are required")
+}
+
+func TestParseFetchSize(t *testing.T) {
+	size, err := parseFetchSize("these 2 derivations will be built:\n  /nix/store/a.drv\n")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), size)
+	size, err = parseFetchSize("these 3 paths will be fetched (1.20 MiB download, 5.50 MiB unpacked):\n  /nix/store/b\n")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5.5*(1<<20)), size)
+	size, err = parseFetchSize("this path will be fetched (0.05 KiB download, 2.00 KiB unpacked):\n  /nix/store/c\n")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2048), size)
+}
+
+func TestCheckDeploy(t *testing.T) {
+	outPath := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(outPath, "kernel"), make([]byte, 300), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(outPath, "initrd"), make([]byte, 400), 0644))
+
+	g := New(0, 500, nil)
+	g.bootDir = t.TempDir()
+	g.freeSpaceFunc = func(string) (int64, error) { return 600, nil }
+	// The kernel and the initrd require more than min_boot_free
+	err := g.CheckDeploy(t.Context(), outPath)
+	assert.True(t, errors.Is(err, store.ErrInsufficientDiskSpace))
+	assert.ErrorContains(t, err, "600 B available on "+g.bootDir+" while 700 B are required")
+
+	assert.Nil(t, g.CheckDeploy(t.Context(), t.TempDir()))
+
+	// The kernel and the initrd are the ones of the current system
+	current := t.TempDir()
+	newSystem := t.TempDir()
+	for _, name := range []string{"kernel", "initrd"} {
+		assert.Nil(t, os.Symlink(filepath.Join(outPath, name), filepath.Join(current, name)))
+		assert.Nil(t, os.Symlink(filepath.Join(outPath, name), filepath.Join(newSystem, name)))
+	}
+	g.installedSystems = []string{"/nonexistent", current}
+	assert.Nil(t, g.CheckDeploy(t.Context(), newSystem))
+	// Only the kernel is already installed
+	assert.Nil(t, os.Remove(filepath.Join(current, "initrd")))
+	assert.ErrorContains(t, g.CheckDeploy(t.Context(), newSystem), "while 700 B are required")
+
+	// The check is skipped on machines without /boot
+	g.bootDir = filepath.Join(outPath, "boot")
+	assert.Nil(t, g.CheckDeploy(t.Context(), outPath))
+}
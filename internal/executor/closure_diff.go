@@ -0,0 +1,132 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// currentSystem is the out path of the running system
+var currentSystem = "/run/current-system"
+
+// PackageChange is the change of a package between two closures.
+type PackageChange struct {
+	Name string
+	// OldVersion is empty when the package is added
+	OldVersion string
+	// NewVersion is empty when the package is removed
+	NewVersion string
+	// SizeDelta is the size difference in bytes
+	SizeDelta int64
+}
+
+// ClosureDiff is the difference between the closure of the running
+// system and the closure of a new out path.
+type ClosureDiff struct {
+	CurrentOutPath   string
+	Added            []PackageChange
+	Removed          []PackageChange
+	Upgraded         []PackageChange
+	ClosureSizeDelta int64
+}
+
+var (
+	ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	sizeDelta  = regexp.MustCompile(`(?:^|, )([+-][0-9]+(?:\.[0-9]+)?) KiB$`)
+)
+
+// noVersion is the version shown by nix store diff-closures when a
+// package is not in a closure.
+const noVersion = "∅"
+
+// closureDiff computes the difference between the closure of the
+// running system and the closure of outPath.
+func closureDiff(ctx context.Context, outPath string) (diff ClosureDiff, err error) {
+	current, err := filepath.EvalSymlinks(currentSystem)
+	if err != nil {
+		return diff, fmt.Errorf("executor: cannot resolve the current system: %w", err)
+	}
+	var stdout, stderr bytes.Buffer
+	if err = runNixFlakeCommand(ctx, []string{"store", "diff-closures", current, outPath}, &stdout, &stderr); err != nil {
+		return diff, fmt.Errorf("executor: nix store diff-closures failed: %w: %s", err, stderr.String())
+	}
+	diff, err = parseDiffClosures(&stdout)
+	if err != nil {
+		return
+	}
+	diff.CurrentOutPath = current
+
+	stdout.Reset()
+	stderr.Reset()
+	if err = runNixFlakeCommand(ctx, []string{"path-info", "--closure-size", current, outPath}, &stdout, &stderr); err != nil {
+		return diff, fmt.Errorf("executor: nix path-info failed: %w: %s", err, stderr.String())
+	}
+	sizes, err := parseClosureSizes(&stdout)
+	if err != nil {
+		return
+	}
+	diff.ClosureSizeDelta = sizes[outPath] - sizes[current]
+	return
+}
+
+// parseDiffClosures parses the output of nix store diff-closures,
+// whose lines look like "hello: 2.11 → 2.12, +12.3 KiB". Lines
+// without version change are ignored.
+func parseDiffClosures(r io.Reader) (diff ClosureDiff, err error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := ansiEscape.ReplaceAllString(strings.TrimSpace(scanner.Text()), "")
+		name, rest, ok := strings.Cut(line, ": ")
+		if !ok {
+			continue
+		}
+		change := PackageChange{Name: name}
+		if m := sizeDelta.FindStringSubmatch(rest); m != nil {
+			kib, _ := strconv.ParseFloat(m[1], 64)
+			change.SizeDelta = int64(kib * 1024)
+			rest = strings.TrimSuffix(rest, m[0])
+		}
+		oldVersion, newVersion, ok := strings.Cut(rest, " → ")
+		if !ok {
+			continue
+		}
+		switch {
+		case oldVersion == noVersion:
+			change.NewVersion = newVersion
+			diff.Added = append(diff.Added, change)
+		case newVersion == noVersion:
+			change.OldVersion = oldVersion
+			diff.Removed = append(diff.Removed, change)
+		default:
+			change.OldVersion = oldVersion
+			change.NewVersion = newVersion
+			diff.Upgraded = append(diff.Upgraded, change)
+		}
+	}
+	return diff, scanner.Err()
+}
+
+// parseClosureSizes parses the output of nix path-info
+// --closure-size, whose lines look like "/nix/store/...  123456".
+func parseClosureSizes(r io.Reader) (map[string]int64, error) {
+	sizes := make(map[string]int64)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("executor: cannot parse the closure size of %s: %w", fields[0], err)
+		}
+		sizes[fields[0]] = size
+	}
+	return sizes, scanner.Err()
+}
@@ -0,0 +1,34 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDiffClosures(t *testing.T) {
+	output := "firefox: 120.0 → 121.0, \x1b[31;1m+1024.5 KiB\x1b[0m\n" +
+		"hello: ∅ → 2.12, +100.0 KiB\n" +
+		"nano: 7.2 → ∅, -50.0 KiB\n" +
+		"python3: 3.11.6, 3.12.0 → 3.12.1\n" +
+		"zlib: +12.0 KiB\n"
+	diff, err := parseDiffClosures(strings.NewReader(output))
+	assert.Nil(t, err)
+	assert.Equal(t, []PackageChange{{Name: "hello", NewVersion: "2.12", SizeDelta: 102400}}, diff.Added)
+	assert.Equal(t, []PackageChange{{Name: "nano", OldVersion: "7.2", SizeDelta: -51200}}, diff.Removed)
+	assert.Equal(t, []PackageChange{
+		{Name: "firefox", OldVersion: "120.0", NewVersion: "121.0", SizeDelta: 1049088},
+		{Name: "python3", OldVersion: "3.11.6, 3.12.0", NewVersion: "3.12.1"},
+	}, diff.Upgraded)
+}
+
+func TestParseClosureSizes(t *testing.T) {
+	output := "/nix/store/aaa-nixos-system-a\t 1000\n/nix/store/bbb-nixos-system-b   1500\n"
+	sizes, err := parseClosureSizes(strings.NewReader(output))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int64{
+		"/nix/store/aaa-nixos-system-a": 1000,
+		"/nix/store/bbb-nixos-system-b": 1500,
+	}, sizes)
+}
@@ -2,12 +2,14 @@ package executor
 
 import (
 	"context"
+	"io"
 
 	"github.com/sirupsen/logrus"
 )
 
-type EvalFunc func(ctx context.Context, repositoryPath, repositorySubdir, commitId, systemAttr, hostname string, submodules bool) (drvPath string, outPath string, machineId string, err error)
-type BuildFunc func(ctx context.Context, drvPath string) error
+type EvalFunc func(ctx context.Context, repositoryPath, repositorySubdir, commitId, systemAttr, hostname string, submodules bool, logs io.Writer) (drvPath string, outPath string, machineId string, err error)
+type BuildFunc func(ctx context.Context, drvPath, outPath string, logs io.Writer, progress ProgressFunc) (builtBy string, err error)
+type SubstituteFunc func(ctx context.Context, outPath string, logs io.Writer, progress ProgressFunc) error
 
 // Executor contains the function used by comin to actually do actions
 // on the host. This allows us to abstract the way Nix expression are
@@ -15,10 +17,28 @@ type BuildFunc func(ctx context.Context, drvPath string) error
 // Garnix implementation (such as proposed in
 // https://github.com/nlewo/comin/pull/74)
 type Executor interface {
-	Eval(ctx context.Context, repositoryPath, repositorySubdir, commitId, systemAttr, hostname string, submodules bool) (drvPath string, outPath string, machineId string, err error)
-	Build(ctx context.Context, drvPath string) (err error)
+	// Eval evaluates a configuration. The evaluation logs are written to logs.
+	Eval(ctx context.Context, repositoryPath, repositorySubdir, commitId, systemAttr, hostname string, submodules bool, logs io.Writer) (drvPath string, outPath string, machineId string, err error)
+	// Build builds a derivation whose output is outPath. The
+	// build logs are written to logs and the build progress is
+	// reported to progress which can be nil. It returns the
+	// builder which has produced outPath.
+	Build(ctx context.Context, drvPath, outPath string, logs io.Writer, progress ProgressFunc) (builtBy string, err error)
+	// Substitute fetches the closure of outPath from the
+	// configured binary caches. It never builds anything and
+	// fails if the closure is not available in binary caches.
+	Substitute(ctx context.Context, outPath string, logs io.Writer, progress ProgressFunc) error
 	Deploy(ctx context.Context, outPath, operation string, profilePaths []string) (needToRestartComin bool, profilePath string, err error)
-	NeedToReboot(outPath, operation string) bool
+	// ClosureDiff returns the package changes between the running
+	// system and outPath.
+	ClosureDiff(ctx context.Context, outPath string) (ClosureDiff, error)
+	// DryActivate returns the units which would be changed by
+	// the activation of outPath. It returns ErrNotSupported when
+	// the system can not predict them.
+	DryActivate(ctx context.Context, outPath string) (UnitChanges, error)
+	// NeedToReboot returns the reasons why a reboot is required to
+	// take the deployment of outPath into account.
+	NeedToReboot(outPath, operation string) (reasons []string)
 	ReadMachineId() (string, error)
 	// IsStorePathExist returns true if a storepath exists. This
 	// is used to detect if a build will be required or not.
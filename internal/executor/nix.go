@@ -3,6 +3,7 @@ package executor
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path"
 
@@ -26,11 +27,11 @@ func (n *NixLocal) IsStorePathExist(storePath string) bool {
 	return isStorePathExist(storePath)
 }
 
-func (n *NixLocal) NeedToReboot(outPath, operation string) bool {
+func (n *NixLocal) NeedToReboot(outPath, operation string) []string {
 	return utils.NeedToRebootLinux(outPath, operation)
 }
 
-func (n *NixLocal) Eval(ctx context.Context, repositoryPath, repositorySubdir, commitId, systemAttr, hostname string, submodules bool) (drvPath string, outPath string, machineId string, err error) {
+func (n *NixLocal) Eval(ctx context.Context, repositoryPath, repositorySubdir, commitId, systemAttr, hostname string, submodules bool, logs io.Writer) (drvPath string, outPath string, machineId string, err error) {
 	tempDir, err := cloneRepoToTemp(repositoryPath, commitId, submodules)
 	defer os.RemoveAll(tempDir) // nolint: errcheck
 	if err != nil {
@@ -38,11 +39,23 @@ func (n *NixLocal) Eval(ctx context.Context, repositoryPath, repositorySubdir, c
 	}
 	logrus.Debugf("nix: temporary cloned into %s", tempDir)
 	nixDir := path.Join(tempDir, repositorySubdir)
-	return showDerivationWithNix(ctx, nixDir, systemAttr)
+	return showDerivationWithNix(ctx, nixDir, systemAttr, logs)
 }
 
-func (n *NixLocal) Build(ctx context.Context, drvPath string) (err error) {
-	return buildWithNix(ctx, drvPath)
+func (n *NixLocal) Build(ctx context.Context, drvPath, outPath string, logs io.Writer, progress ProgressFunc) (builtBy string, err error) {
+	return buildWithNix(ctx, drvPath, logs, progress)
+}
+
+func (n *NixLocal) Substitute(ctx context.Context, outPath string, logs io.Writer, progress ProgressFunc) error {
+	return substitute(ctx, outPath, logs, progress)
+}
+
+func (n *NixLocal) ClosureDiff(ctx context.Context, outPath string) (ClosureDiff, error) {
+	return closureDiff(ctx, outPath)
+}
+
+func (n *NixLocal) DryActivate(ctx context.Context, outPath string) (UnitChanges, error) {
+	return dryActivate(ctx, outPath)
 }
 
 func (n *NixLocal) Deploy(ctx context.Context, outPath, operation string, profilePaths []string) (needToRestartComin bool, profilePath string, err error) {
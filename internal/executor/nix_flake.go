@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/nlewo/comin/internal/utils"
@@ -25,14 +26,14 @@ func (n *NixFlakeLocal) ReadMachineId() (string, error) {
 	return utils.ReadMachineIdLinux()
 }
 
-func (n *NixFlakeLocal) NeedToReboot(outPath, operation string) bool {
+func (n *NixFlakeLocal) NeedToReboot(outPath, operation string) []string {
 	if n.systemAttr == "darwinConfigurations" {
 		// TODO: Implement proper reboot detection for Darwin
 		// Unlike NixOS which has /run/current-system vs /run/booted-system paths,
 		// Darwin/macOS doesn't have equivalent mechanisms for detecting when
 		// a reboot is needed after nix-darwin configuration changes.
 		// For now, conservatively assume no reboot is needed.
-		return false
+		return nil
 	}
 	return utils.NeedToRebootLinux(outPath, operation)
 }
@@ -42,24 +43,39 @@ func (n *NixFlakeLocal) IsStorePathExist(storePath string) bool {
 }
 
 func (n *NixFlakeLocal) ShowDerivation(ctx context.Context, flakeUrl, hostname string) (drvPath string, outPath string, err error) {
-	return showDerivationWithFlake(ctx, flakeUrl, hostname, n.systemAttr)
+	return showDerivationWithFlake(ctx, flakeUrl, hostname, n.systemAttr, os.Stderr)
 }
 
-func (n *NixFlakeLocal) Eval(ctx context.Context, repositoryPath, repositorySubdir, commitId, systemAttr, hostname string, submodules bool) (drvPath string, outPath string, machineId string, err error) {
+func (n *NixFlakeLocal) Eval(ctx context.Context, repositoryPath, repositorySubdir, commitId, systemAttr, hostname string, submodules bool, logs io.Writer) (drvPath string, outPath string, machineId string, err error) {
 	flakeUrl := fmt.Sprintf("git+file://%s?dir=%s&rev=%s", repositoryPath, repositorySubdir, commitId)
 	if submodules {
 		flakeUrl += "&submodules=1"
 	}
-	drvPath, outPath, err = showDerivationWithFlake(ctx, flakeUrl, hostname, n.systemAttr)
+	drvPath, outPath, err = showDerivationWithFlake(ctx, flakeUrl, hostname, n.systemAttr, logs)
 	if err != nil {
 		return
 	}
-	machineId, err = getExpectedMachineId(ctx, flakeUrl, hostname, n.systemAttr)
+	machineId, err = getExpectedMachineId(ctx, flakeUrl, hostname, n.systemAttr, logs)
 	return
 }
 
-func (n *NixFlakeLocal) Build(ctx context.Context, drvPath string) (err error) {
-	return buildWithFlake(ctx, drvPath)
+func (n *NixFlakeLocal) Build(ctx context.Context, drvPath, outPath string, logs io.Writer, progress ProgressFunc) (builtBy string, err error) {
+	return buildWithFlake(ctx, drvPath, logs, progress)
+}
+
+func (n *NixFlakeLocal) Substitute(ctx context.Context, outPath string, logs io.Writer, progress ProgressFunc) error {
+	return substitute(ctx, outPath, logs, progress)
+}
+
+func (n *NixFlakeLocal) ClosureDiff(ctx context.Context, outPath string) (ClosureDiff, error) {
+	return closureDiff(ctx, outPath)
+}
+
+func (n *NixFlakeLocal) DryActivate(ctx context.Context, outPath string) (UnitChanges, error) {
+	if n.systemAttr == "darwinConfigurations" {
+		return UnitChanges{}, ErrNotSupported
+	}
+	return dryActivate(ctx, outPath)
 }
 
 func (n *NixFlakeLocal) Deploy(ctx context.Context, outPath, operation string, profilePaths []string) (needToRestartComin bool, profilePath string, err error) {
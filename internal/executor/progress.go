@@ -0,0 +1,249 @@
+package executor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Activity and result types of the Nix internal-json log format (see
+// src/libutil/logging.hh in the Nix repository)
+const (
+	actFileTransfer = 101
+	actCopyPaths    = 103
+	actBuilds       = 104
+	actBuild        = 105
+
+	resBuildLogLine = 101
+	resSetPhase     = 104
+	resProgress     = 105
+	resSetExpected  = 106
+
+	// Messages and activities with a higher level are not
+	// written to the logs
+	lvlInfo = 3
+)
+
+// BuildProgress is the progress of a build computed from the Nix
+// internal-json log stream.
+type BuildProgress struct {
+	DerivationsBuilt    uint64
+	DerivationsExpected uint64
+	DerivationsRunning  uint64
+	DerivationsFailed   uint64
+	DownloadsDone       uint64
+	DownloadsExpected   uint64
+	BytesDownloaded     uint64
+	BytesExpected       uint64
+	// Phase is the last phase of a running derivation build,
+	// such as "hello-2.12: buildPhase"
+	Phase string
+}
+
+// ProgressFunc receives the build progress. It is called for each
+// parsed log entry, even when the progress has not changed: Nix can
+// download or copy store paths for a long time without writing any log
+// line, and this allows to know it is still active.
+type ProgressFunc func(BuildProgress)
+
+type nixLogEntry struct {
+	Action string `json:"action"`
+	ID     uint64 `json:"id"`
+	Level  int    `json:"level"`
+	Type   int    `json:"type"`
+	Text   string `json:"text"`
+	Msg    string `json:"msg"`
+	Fields []any  `json:"fields"`
+}
+
+type activity struct {
+	typ int
+	// name is the derivation name of build activities
+	name string
+}
+
+// ProgressWriter parses the Nix internal-json log stream. It writes
+// human readable logs to logs and reports the build progress to the
+// progress function. Lines which are not JSON log entries are
+// written as is to logs.
+type ProgressWriter struct {
+	logs       io.Writer
+	onProgress ProgressFunc
+	buf        []byte
+	activities map[uint64]activity
+	// bytes downloaded by running and finished file transfers
+	transfers     map[uint64]uint64
+	bytesFinished uint64
+	progress      BuildProgress
+	// machines contains the machine which has built a derivation
+	// when it has been built on a remote builder
+	machines map[string]string
+}
+
+func NewProgressWriter(logs io.Writer, onProgress ProgressFunc) *ProgressWriter {
+	return &ProgressWriter{
+		logs:       logs,
+		onProgress: onProgress,
+		activities: make(map[uint64]activity),
+		transfers:  make(map[uint64]uint64),
+		machines:   make(map[string]string),
+	}
+}
+
+func (w *ProgressWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := w.buf[:i]
+		w.buf = w.buf[i+1:]
+		if err := w.processLine(line); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush processes the last line if it is not terminated by a newline.
+func (w *ProgressWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	line := w.buf
+	w.buf = nil
+	return w.processLine(line)
+}
+
+func (w *ProgressWriter) processLine(line []byte) error {
+	data, ok := bytes.CutPrefix(line, []byte("@nix "))
+	if !ok {
+		_, err := fmt.Fprintf(w.logs, "%s\n", line)
+		return err
+	}
+	var e nixLogEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		logrus.Debugf("executor: failed to parse the nix log entry '%s': %s", data, err)
+		_, err := fmt.Fprintf(w.logs, "%s\n", line)
+		return err
+	}
+	text := w.handle(e)
+	if w.onProgress != nil {
+		w.onProgress(w.progress)
+	}
+	if text != "" {
+		_, err := fmt.Fprintf(w.logs, "%s\n", text)
+		return err
+	}
+	return nil
+}
+
+// handle updates the progress from a log entry. It returns the text
+// to write to the logs.
+func (w *ProgressWriter) handle(e nixLogEntry) (text string) {
+	switch e.Action {
+	case "msg":
+		if e.Level <= lvlInfo {
+			text = e.Msg
+		}
+	case "start":
+		a := activity{typ: e.Type}
+		if e.Type == actBuild {
+			drvPath := fieldString(e.Fields, 0)
+			a.name = derivationName(drvPath)
+			if machine := fieldString(e.Fields, 1); machine != "" {
+				w.machines[drvPath] = machine
+			}
+		}
+		w.activities[e.ID] = a
+		if e.Level <= lvlInfo {
+			text = e.Text
+		}
+	case "stop":
+		if n, ok := w.transfers[e.ID]; ok {
+			w.bytesFinished += n
+			delete(w.transfers, e.ID)
+		}
+		delete(w.activities, e.ID)
+	case "result":
+		a := w.activities[e.ID]
+		switch e.Type {
+		case resBuildLogLine:
+			text = fmt.Sprintf("%s> %s", a.name, fieldString(e.Fields, 0))
+		case resSetPhase:
+			w.progress.Phase = fmt.Sprintf("%s: %s", a.name, fieldString(e.Fields, 0))
+		case resProgress:
+			done, expected := fieldUint(e.Fields, 0), fieldUint(e.Fields, 1)
+			switch a.typ {
+			case actBuilds:
+				w.progress.DerivationsBuilt = done
+				w.progress.DerivationsExpected = max(w.progress.DerivationsExpected, expected)
+				w.progress.DerivationsRunning = fieldUint(e.Fields, 2)
+				w.progress.DerivationsFailed = fieldUint(e.Fields, 3)
+			case actCopyPaths:
+				w.progress.DownloadsDone = done
+				w.progress.DownloadsExpected = max(w.progress.DownloadsExpected, expected)
+			case actFileTransfer:
+				w.transfers[e.ID] = done
+				w.progress.BytesDownloaded = w.bytesFinished
+				for _, b := range w.transfers {
+					w.progress.BytesDownloaded += b
+				}
+			}
+		case resSetExpected:
+			expected := fieldUint(e.Fields, 1)
+			switch int(fieldUint(e.Fields, 0)) {
+			case actBuilds:
+				w.progress.DerivationsExpected = expected
+			case actCopyPaths:
+				w.progress.DownloadsExpected = expected
+			case actFileTransfer:
+				w.progress.BytesExpected = expected
+			}
+		}
+	}
+	return
+}
+
+// BuiltBy returns the remote machine which has built a derivation or
+// an empty string if it has not been built by a remote machine.
+func (w *ProgressWriter) BuiltBy(drvPath string) string {
+	return w.machines[drvPath]
+}
+
+// derivationName returns the name of a derivation from its path:
+// /nix/store/<hash>-hello-2.12.drv returns hello-2.12.
+func derivationName(drvPath string) string {
+	if drvPath == "" {
+		return ""
+	}
+	name := strings.TrimSuffix(filepath.Base(drvPath), ".drv")
+	if _, n, ok := strings.Cut(name, "-"); ok {
+		return n
+	}
+	return name
+}
+
+func fieldString(fields []any, i int) string {
+	if i < len(fields) {
+		if s, ok := fields[i].(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+func fieldUint(fields []any, i int) uint64 {
+	if i < len(fields) {
+		if f, ok := fields[i].(float64); ok && f > 0 {
+			return uint64(f)
+		}
+	}
+	return 0
+}
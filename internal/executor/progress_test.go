@@ -0,0 +1,84 @@
+package executor
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressWriter(t *testing.T) {
+	stream := `@nix {"action":"start","id":1,"level":0,"type":102,"text":"","fields":[],"parent":0}
+@nix {"action":"start","id":2,"level":5,"type":104,"text":"","fields":[],"parent":0}
+@nix {"action":"start","id":3,"level":5,"type":103,"text":"","fields":[],"parent":0}
+@nix {"action":"result","id":1,"type":106,"fields":[104,3]}
+@nix {"action":"result","id":1,"type":106,"fields":[101,2048]}
+@nix {"action":"start","id":4,"level":3,"type":101,"text":"downloading 'https://cache.nixos.org/nar/a.nar.xz'","fields":["https://cache.nixos.org/nar/a.nar.xz"],"parent":0}
+@nix {"action":"result","id":4,"type":105,"fields":[1024,1024,0,0]}
+@nix {"action":"stop","id":4}
+@nix {"action":"start","id":5,"level":3,"type":101,"text":"","fields":[],"parent":0}
+@nix {"action":"result","id":5,"type":105,"fields":[512,1024,0,0]}
+@nix {"action":"result","id":3,"type":105,"fields":[1,2,0,0]}
+@nix {"action":"start","id":6,"level":3,"type":105,"text":"building '/nix/store/lb0wy2v4bnyz2yrsppbbxdsx1zy4w0mx-hello-2.12.drv'","fields":["/nix/store/lb0wy2v4bnyz2yrsppbbxdsx1zy4w0mx-hello-2.12.drv","",1,1],"parent":0}
+@nix {"action":"result","id":6,"type":104,"fields":["buildPhase"]}
+@nix {"action":"result","id":6,"type":101,"fields":["compiling hello.c"]}
+@nix {"action":"result","id":2,"type":105,"fields":[1,3,1,0]}
+@nix {"action":"msg","level":0,"msg":"error: builder failed"}
+@nix {"action":"msg","level":6,"msg":"a debug message"}
+not a json line
+@nix {"action":"stop","id":6}`
+
+	var logs bytes.Buffer
+	var updates int
+	var last BuildProgress
+	w := NewProgressWriter(&logs, func(p BuildProgress) {
+		updates++
+		last = p
+	})
+	// Write the stream in small chunks to check lines are correctly split
+	data := []byte(stream)
+	for len(data) > 0 {
+		n := min(7, len(data))
+		_, err := w.Write(data[:n])
+		assert.Nil(t, err)
+		data = data[n:]
+	}
+	assert.Nil(t, w.Flush())
+
+	expected := BuildProgress{
+		DerivationsBuilt:    1,
+		DerivationsExpected: 3,
+		DerivationsRunning:  1,
+		DownloadsDone:       1,
+		DownloadsExpected:   2,
+		BytesDownloaded:     1536,
+		BytesExpected:       2048,
+		Phase:               "hello-2.12: buildPhase",
+	}
+	assert.Equal(t, expected, last)
+	// The progress function is called for each parsed log entry
+	assert.Equal(t, 18, updates)
+	assert.Equal(t, `downloading 'https://cache.nixos.org/nar/a.nar.xz'
+building '/nix/store/lb0wy2v4bnyz2yrsppbbxdsx1zy4w0mx-hello-2.12.drv'
+hello-2.12> compiling hello.c
+error: builder failed
+not a json line
+`, logs.String())
+}
+
+func TestProgressWriterBuiltBy(t *testing.T) {
+	stream := `@nix {"action":"start","id":1,"level":3,"type":105,"text":"building '/nix/store/aaa-a.drv' on 'ssh-ng://builder'","fields":["/nix/store/aaa-a.drv","ssh-ng://builder",1,1],"parent":0}
+@nix {"action":"start","id":2,"level":3,"type":105,"text":"building '/nix/store/bbb-b.drv'","fields":["/nix/store/bbb-b.drv","",1,1],"parent":0}
+`
+	w := NewProgressWriter(io.Discard, nil)
+	_, err := w.Write([]byte(stream))
+	assert.Nil(t, err)
+	assert.Equal(t, "ssh-ng://builder", w.BuiltBy("/nix/store/aaa-a.drv"))
+	assert.Equal(t, "", w.BuiltBy("/nix/store/bbb-b.drv"))
+}
+
+func TestDerivationName(t *testing.T) {
+	assert.Equal(t, "hello-2.12", derivationName("/nix/store/lb0wy2v4bnyz2yrsppbbxdsx1zy4w0mx-hello-2.12.drv"))
+	assert.Equal(t, "", derivationName(""))
+}
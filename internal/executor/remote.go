@@ -0,0 +1,143 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// BuiltByLocal is the builder recorded when a configuration is built
+// by the local Nix daemon.
+const BuiltByLocal = "local"
+
+// BuiltByBinaryCache is the builder recorded when the closure of a
+// configuration is substituted from binary caches.
+const BuiltByBinaryCache = "binary cache"
+
+// remotePingTimeout is the maximal duration to wait for a remote
+// store to answer before considering it unavailable.
+var remotePingTimeout = 10 * time.Second
+
+// Remote is an executor delegating builds to remote machines. All
+// other actions are done by the wrapped local executor.
+//
+// On build, it first tries to copy the prebuilt closure from the
+// build host. If it fails, it builds the configuration on the
+// available remote builders. If no remote builder is available or if
+// the remote build fails, it falls back to a local build.
+type Remote struct {
+	Executor
+	// builders is a list of Nix machine specifications, such as
+	// "ssh-ng://builder aarch64-linux"
+	builders []string
+	// buildHost is a Nix store URI where the closure of
+	// configurations is built, such as "ssh-ng://build-host"
+	buildHost string
+	// runFunc runs a nix command. It is replaced in tests.
+	runFunc func(ctx context.Context, args []string, stdout, stderr io.Writer) error
+}
+
+func NewRemote(local Executor, builders []string, buildHost string) *Remote {
+	logrus.Infof("executor: creating a remote executor with builders=%s buildHost=%s", builders, buildHost)
+	return &Remote{
+		Executor:  local,
+		builders:  builders,
+		buildHost: buildHost,
+		runFunc:   runNixFlakeCommand,
+	}
+}
+
+func (r *Remote) Build(ctx context.Context, drvPath, outPath string, logs io.Writer, progress ProgressFunc) (builtBy string, err error) {
+	if r.buildHost != "" {
+		err = r.copyFromStore(ctx, r.buildHost, outPath, logs)
+		if err == nil {
+			return r.buildHost, nil
+		}
+		if ctx.Err() != nil {
+			return "", err
+		}
+		logrus.Infof("executor: cannot copy %s from the build host %s: %s", outPath, r.buildHost, err)
+	}
+	if available := r.availableBuilders(ctx); len(available) > 0 {
+		builtBy, err = r.buildWithBuilders(ctx, drvPath, available, logs, progress)
+		if err == nil {
+			return builtBy, nil
+		}
+		if ctx.Err() != nil {
+			return "", err
+		}
+		logrus.Infof("executor: the build of %s on remote builders failed: %s", drvPath, err)
+	}
+	logrus.Infof("executor: falling back to a local build of %s", drvPath)
+	_, _ = fmt.Fprintf(logs, "comin: falling back to a local build\n")
+	return r.Executor.Build(ctx, drvPath, outPath, logs, progress)
+}
+
+// availableBuilders returns the builders whose store answers.
+func (r *Remote) availableBuilders(ctx context.Context) (available []string) {
+	for _, b := range r.builders {
+		uri := builderStoreUri(b)
+		if err := r.pingStore(ctx, uri); err != nil {
+			logrus.Infof("executor: the remote builder %s is not available: %s", uri, err)
+			continue
+		}
+		available = append(available, b)
+	}
+	return
+}
+
+// builderStoreUri returns the store URI of a Nix machine
+// specification.
+func builderStoreUri(spec string) string {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+func (r *Remote) pingStore(ctx context.Context, uri string) error {
+	ctx, cancel := context.WithTimeout(ctx, remotePingTimeout)
+	defer cancel()
+	return r.runFunc(ctx, []string{"store", "ping", "--store", uri}, io.Discard, io.Discard)
+}
+
+func (r *Remote) copyFromStore(ctx context.Context, uri, outPath string, logs io.Writer) error {
+	return r.runFunc(ctx, []string{"copy", "--from", uri, outPath}, logs, logs)
+}
+
+// buildWithBuilders builds a derivation on remote builders only. It
+// returns the builder which has built the derivation, or
+// BuiltByBinaryCache when it has been substituted.
+func (r *Remote) buildWithBuilders(ctx context.Context, drvPath string, builders []string, logs io.Writer, progress ProgressFunc) (builtBy string, err error) {
+	args := []string{
+		"build",
+		fmt.Sprintf("%s^*", drvPath),
+		"-L",
+		"--log-format",
+		"internal-json",
+		"--no-link",
+		// This forces the builds to be done on remote builders
+		"--max-jobs", "0",
+		"--builders", strings.Join(builders, "; "),
+	}
+	stderr := NewProgressWriter(logs, progress)
+	err = r.runFunc(ctx, args, logs, stderr)
+	if flushErr := stderr.Flush(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		return
+	}
+	builtBy = stderr.BuiltBy(drvPath)
+	if builtBy == "" {
+		// Since local builds are disabled, the derivation has
+		// been substituted
+		builtBy = BuiltByBinaryCache
+	}
+	return
+}
@@ -0,0 +1,95 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type localMock struct {
+	Executor
+	built bool
+}
+
+func (l *localMock) Build(ctx context.Context, drvPath, outPath string, logs io.Writer, progress ProgressFunc) (string, error) {
+	l.built = true
+	return BuiltByLocal, nil
+}
+
+// runMock replaces the nix commands: the commands whose first
+// argument is in failing fail and the build writes stream to stderr.
+func runMock(failing []string, stream string) func(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	return func(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if slices.Contains(failing, args[0]) {
+			return fmt.Errorf("%s failed", args[0])
+		}
+		if args[0] == "build" {
+			_, err := stderr.Write([]byte(stream))
+			return err
+		}
+		return nil
+	}
+}
+
+func TestRemoteFallbackToLocal(t *testing.T) {
+	local := &localMock{}
+	// The remote builder and build host are not reachable
+	r := NewRemote(local, []string{"ssh-ng://builder aarch64-linux"}, "ssh-ng://build-host")
+	r.runFunc = runMock([]string{"store", "copy"}, "")
+	builtBy, err := r.Build(t.Context(), "/nix/store/aaa-a.drv", "/nix/store/bbb-b", io.Discard, nil)
+	assert.Nil(t, err)
+	assert.True(t, local.built)
+	assert.Equal(t, BuiltByLocal, builtBy)
+}
+
+func TestRemoteBuildHost(t *testing.T) {
+	local := &localMock{}
+	r := NewRemote(local, []string{"ssh-ng://builder aarch64-linux"}, "ssh-ng://build-host")
+	r.runFunc = runMock(nil, "")
+	builtBy, err := r.Build(t.Context(), "/nix/store/aaa-a.drv", "/nix/store/bbb-b", io.Discard, nil)
+	assert.Nil(t, err)
+	assert.False(t, local.built)
+	assert.Equal(t, "ssh-ng://build-host", builtBy)
+}
+
+func TestRemoteBuilders(t *testing.T) {
+	local := &localMock{}
+	r := NewRemote(local, []string{"ssh-ng://builder1 aarch64-linux", "ssh-ng://builder2 aarch64-linux"}, "")
+	stream := `@nix {"action":"start","id":1,"level":3,"type":105,"text":"building","fields":["/nix/store/aaa-a.drv","ssh-ng://builder2",1,1],"parent":0}` + "\n"
+	r.runFunc = runMock(nil, stream)
+	builtBy, err := r.Build(t.Context(), "/nix/store/aaa-a.drv", "/nix/store/bbb-b", io.Discard, nil)
+	assert.Nil(t, err)
+	assert.False(t, local.built)
+	assert.Equal(t, "ssh-ng://builder2", builtBy)
+
+	// The top-level derivation has been substituted: the remote
+	// builders have not built it
+	r.runFunc = runMock(nil, "")
+	builtBy, err = r.Build(t.Context(), "/nix/store/aaa-a.drv", "/nix/store/bbb-b", io.Discard, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, BuiltByBinaryCache, builtBy)
+}
+
+func TestRemoteCancelled(t *testing.T) {
+	local := &localMock{}
+	r := NewRemote(local, nil, "ssh-ng://build-host")
+	r.runFunc = runMock(nil, "")
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	_, err := r.Build(ctx, "/nix/store/aaa-a.drv", "/nix/store/bbb-b", io.Discard, nil)
+	assert.NotNil(t, err)
+	assert.False(t, local.built)
+}
+
+func TestBuilderStoreUri(t *testing.T) {
+	assert.Equal(t, "ssh-ng://builder", builderStoreUri("ssh-ng://builder aarch64-linux /etc/nix/key 4"))
+	assert.Equal(t, "ssh://builder", builderStoreUri("ssh://builder"))
+	assert.Equal(t, "", builderStoreUri(""))
+}
@@ -0,0 +1,70 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrNotSupported is returned when an action is not supported by the
+// system, such as the unit changes prediction on nix-darwin.
+var ErrNotSupported = errors.New("not supported")
+
+// UnitChanges are the systemd units which would be changed by the
+// activation of a configuration.
+type UnitChanges struct {
+	Stop    []string
+	Restart []string
+	Reload  []string
+	Start   []string
+}
+
+// dryActivate runs switch-to-configuration dry-activate to predict
+// the units changed by the activation of outPath.
+func dryActivate(ctx context.Context, outPath string) (changes UnitChanges, err error) {
+	exe := filepath.Join(outPath, "bin", "switch-to-configuration")
+	logrus.Infof("nix: running '%s dry-activate'", exe)
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, exe, "dry-activate")
+	// switch-to-configuration writes to stderr
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err = cmd.Run(); err != nil {
+		return changes, fmt.Errorf("command %s dry-activate fails with %s: %s", exe, err, output.String())
+	}
+	return parseDryActivate(&output)
+}
+
+// parseDryActivate parses the output of switch-to-configuration
+// dry-activate, whose lines look like "would restart the following
+// units: sshd.service, nginx.service".
+func parseDryActivate(r io.Reader) (changes UnitChanges, err error) {
+	prefixes := map[string]*[]string{
+		"would stop the following units: ":    &changes.Stop,
+		"would restart the following units: ": &changes.Restart,
+		"would reload the following units: ":  &changes.Reload,
+		"would start the following units: ":   &changes.Start,
+	}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		for prefix, units := range prefixes {
+			if rest, ok := strings.CutPrefix(line, prefix); ok {
+				for _, u := range strings.Split(rest, ",") {
+					if u = strings.TrimSpace(u); u != "" {
+						*units = append(*units, u)
+					}
+				}
+			}
+		}
+	}
+	return changes, scanner.Err()
+}
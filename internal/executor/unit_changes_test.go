@@ -0,0 +1,28 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDryActivate(t *testing.T) {
+	output := `stopping the following units: ignored.service
+would stop the following units: old.service
+would NOT stop the following changed units: getty@tty1.service
+activating the configuration...
+would restart systemd
+would restart the following units: sshd.service, systemd-networkd.service
+would reload the following units: dbus.service
+would start the following units: new.service, new.timer
+`
+	changes, err := parseDryActivate(strings.NewReader(output))
+	assert.Nil(t, err)
+	assert.Equal(t, UnitChanges{
+		Stop:    []string{"old.service"},
+		Restart: []string{"sshd.service", "systemd-networkd.service"},
+		Reload:  []string{"dbus.service"},
+		Start:   []string{"new.service", "new.timer"},
+	}, changes)
+}
@@ -19,7 +19,7 @@ import (
 
 // GetExpectedMachineId evals nixosConfigurations or darwinConfigurations based on systemAttr
 // returns (machine-id, nil) is comin.machineId is set, ("", nil) otherwise.
-func getExpectedMachineId(ctx context.Context, path, hostname, systemAttr string) (machineId string, err error) {
+func getExpectedMachineId(ctx context.Context, path, hostname, systemAttr string, logs io.Writer) (machineId string, err error) {
 	expr := fmt.Sprintf("%s#%s.\"%s\".config.services.comin.machineId", path, systemAttr, hostname)
 	args := []string{
 		"eval",
@@ -27,7 +27,7 @@ func getExpectedMachineId(ctx context.Context, path, hostname, systemAttr string
 		"--json",
 	}
 	var stdout bytes.Buffer
-	err = runNixFlakeCommand(ctx, args, &stdout, os.Stderr)
+	err = runNixFlakeCommand(ctx, args, &stdout, logs)
 	if err != nil {
 		return
 	}
@@ -77,12 +77,12 @@ func runNixFlakeCommand(ctx context.Context, args []string, stdout, stderr io.Wr
 	return nil
 }
 
-func showDerivationWithNix(ctx context.Context, directory, systemAttr string) (drvPath, outPath, machineId string, err error) {
+func showDerivationWithNix(ctx context.Context, directory, systemAttr string, logs io.Writer) (drvPath, outPath, machineId string, err error) {
 	var stdout bytes.Buffer
 
 	// This is to create the .drv file
 	toplevel := fmt.Sprintf("%s.toplevel", systemAttr)
-	err = runNixCommand(ctx, "nix-instantiate", []string{directory, "-A", toplevel}, &stdout, os.Stderr)
+	err = runNixCommand(ctx, "nix-instantiate", []string{directory, "-A", toplevel}, &stdout, logs)
 	if err != nil {
 		return
 	}
@@ -92,7 +92,7 @@ func showDerivationWithNix(ctx context.Context, directory, systemAttr string) (d
 	expr := fmt.Sprintf(exprTpl, directory, systemAttr, systemAttr, systemAttr, systemAttr)
 
 	// --raw is not supported by Lyx and --json doesn't work with Nix...
-	err = runNixCommand(ctx, "nix-instantiate", []string{"--strict", "--eval", "-E", expr}, &stdout, os.Stderr)
+	err = runNixCommand(ctx, "nix-instantiate", []string{"--strict", "--eval", "-E", expr}, &stdout, logs)
 	if err != nil {
 		return
 	}
@@ -149,7 +149,7 @@ func parseDerivationWithFlake(buf bytes.Buffer) (drvPath string, outPath string,
 	return
 }
 
-func showDerivationWithFlake(ctx context.Context, flakeUrl, hostname, systemAttr string) (drvPath string, outPath string, err error) {
+func showDerivationWithFlake(ctx context.Context, flakeUrl, hostname, systemAttr string, logs io.Writer) (drvPath string, outPath string, err error) {
 	installable := fmt.Sprintf("%s#%s.\"%s\".config.system.build.toplevel", flakeUrl, systemAttr, hostname)
 	args := []string{
 		"derivation",
@@ -159,35 +159,64 @@ func showDerivationWithFlake(ctx context.Context, flakeUrl, hostname, systemAttr
 		"--show-trace",
 	}
 	var stdout bytes.Buffer
-	err = runNixFlakeCommand(ctx, args, &stdout, os.Stderr)
+	err = runNixFlakeCommand(ctx, args, &stdout, logs)
 	if err != nil {
 		return
 	}
 	return parseDerivationWithFlake(stdout)
 }
 
-func buildWithFlake(ctx context.Context, drvPath string) (err error) {
+func buildWithFlake(ctx context.Context, drvPath string, logs io.Writer, progress ProgressFunc) (builtBy string, err error) {
 	args := []string{
 		"build",
 		fmt.Sprintf("%s^*", drvPath),
 		"-L",
+		"--log-format",
+		"internal-json",
 		"--no-link"}
-	err = runNixFlakeCommand(ctx, args, os.Stdout, os.Stderr)
+	stderr := NewProgressWriter(logs, progress)
+	err = runNixFlakeCommand(ctx, args, logs, stderr)
+	if flushErr := stderr.Flush(); err == nil {
+		err = flushErr
+	}
 	if err != nil {
 		return
 	}
-	return
+	return BuiltByLocal, nil
 }
 
-func buildWithNix(ctx context.Context, drvPath string) (err error) {
+func buildWithNix(ctx context.Context, drvPath string, logs io.Writer, progress ProgressFunc) (builtBy string, err error) {
 	args := []string{
+		"--log-format",
+		"internal-json",
 		"-r",
 		drvPath,
 	}
-	err = runNixCommand(ctx, "nix-store", args, os.Stdout, os.Stderr)
+	stderr := NewProgressWriter(logs, progress)
+	err = runNixCommand(ctx, "nix-store", args, logs, stderr)
+	if flushErr := stderr.Flush(); err == nil {
+		err = flushErr
+	}
 	if err != nil {
 		return
 	}
+	return BuiltByLocal, nil
+}
+
+// substitute realises a store path which is not a derivation: Nix
+// can then only substitute it from binary caches.
+func substitute(ctx context.Context, outPath string, logs io.Writer, progress ProgressFunc) (err error) {
+	args := []string{
+		"--log-format",
+		"internal-json",
+		"-r",
+		outPath,
+	}
+	stderr := NewProgressWriter(logs, progress)
+	err = runNixCommand(ctx, "nix-store", args, logs, stderr)
+	if flushErr := stderr.Flush(); err == nil {
+		err = flushErr
+	}
 	return
 }
 
@@ -3,6 +3,7 @@ package executor
 import (
 	"bytes"
 	"context"
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -106,7 +107,7 @@ func TestGetExpectedMachineId(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// We can't actually run nix eval in tests, but we can test that
 			// the function constructs the right expression and doesn't panic
-			_, err := getExpectedMachineId(t.Context(), tt.path, tt.hostname, tt.systemAttr)
+			_, err := getExpectedMachineId(t.Context(), tt.path, tt.hostname, tt.systemAttr, io.Discard)
 
 			// This will likely error because nix eval will fail in test environment,
 			// but that's expected and fine - we're testing the code path
@@ -144,7 +145,7 @@ func TestShowDerivation(t *testing.T) {
 			ctx := context.Background()
 
 			// Test that the function doesn't panic and handles the parameters correctly
-			_, _, err := showDerivationWithFlake(ctx, tt.flakeUrl, tt.hostname, tt.systemAttr)
+			_, _, err := showDerivationWithFlake(ctx, tt.flakeUrl, tt.hostname, tt.systemAttr, io.Discard)
 
 			// This will error in test environment because nix command will fail,
 			// but we're testing the code path and parameter handling
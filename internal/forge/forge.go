@@ -0,0 +1,203 @@
+// Package forge publishes the deployment status of commits to the Git
+// forges hosting the repositories, as GitHub, GitLab or Gitea commit
+// statuses.
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nlewo/comin/internal/types"
+)
+
+type State string
+
+const (
+	Pending State = "pending"
+	Success State = "success"
+	Failure State = "failure"
+)
+
+// maxDescriptionLength is the maximal length of a status description
+// accepted by GitHub
+const maxDescriptionLength = 140
+
+type Status struct {
+	CommitId    string
+	State       State
+	Context     string
+	Description string
+}
+
+// Forge publishes commit statuses to a repository.
+type Forge interface {
+	SetStatus(ctx context.Context, status Status) error
+}
+
+// New returns the forge of a remote. The forge type and its API URL
+// are guessed from the remote URL when they are not configured.
+func New(remote types.Remote) (Forge, error) {
+	host, project, err := parseRemoteURL(remote.URL)
+	if err != nil {
+		return nil, err
+	}
+	kind := remote.CommitStatus.Forge
+	if kind == "" {
+		switch {
+		case strings.Contains(host, "github"):
+			kind = "github"
+		case strings.Contains(host, "gitlab"):
+			kind = "gitlab"
+		case strings.Contains(host, "gitea"), strings.Contains(host, "forgejo"), strings.Contains(host, "codeberg"):
+			kind = "gitea"
+		default:
+			return nil, fmt.Errorf("forge: could not guess the forge of the remote %s, the forge has to be configured", remote.Name)
+		}
+	}
+	apiUrl := strings.TrimSuffix(remote.CommitStatus.ApiUrl, "/")
+	client := &client{
+		http:  &http.Client{Timeout: 30 * time.Second},
+		token: remote.Auth.AccessToken,
+	}
+	switch kind {
+	case "github":
+		if apiUrl == "" {
+			apiUrl = "https://" + host + "/api/v3"
+			if host == "github.com" {
+				apiUrl = "https://api.github.com"
+			}
+		}
+		return &github{client: client, apiUrl: apiUrl, project: project}, nil
+	case "gitlab":
+		if apiUrl == "" {
+			apiUrl = "https://" + host + "/api/v4"
+		}
+		return &gitlab{client: client, apiUrl: apiUrl, project: project}, nil
+	case "gitea":
+		if apiUrl == "" {
+			apiUrl = "https://" + host + "/api/v1"
+		}
+		return &gitea{client: client, apiUrl: apiUrl, project: project}, nil
+	}
+	return nil, fmt.Errorf("forge: the forge '%s' of the remote %s is not supported", kind, remote.Name)
+}
+
+// parseRemoteURL returns the host and the project path, such as
+// "owner/repo", of HTTP(S) and SSH remote URLs.
+func parseRemoteURL(remoteUrl string) (host, project string, err error) {
+	var path string
+	if u, err := url.Parse(remoteUrl); err == nil && u.Scheme != "" && u.Host != "" {
+		host, path = u.Hostname(), u.Path
+	} else if at := strings.Index(remoteUrl, "@"); at >= 0 && strings.Contains(remoteUrl[at:], ":") {
+		// scp-like syntax: git@github.com:owner/repo.git
+		host, path, _ = strings.Cut(remoteUrl[at+1:], ":")
+	} else {
+		return "", "", fmt.Errorf("forge: the remote URL %s is not a forge URL", remoteUrl)
+	}
+	project = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if host == "" || !strings.Contains(project, "/") {
+		return "", "", fmt.Errorf("forge: the remote URL %s is not a forge URL", remoteUrl)
+	}
+	return host, project, nil
+}
+
+func truncate(description string) string {
+	if len(description) <= maxDescriptionLength {
+		return description
+	}
+	return description[:maxDescriptionLength-3] + "..."
+}
+
+type client struct {
+	http  *http.Client
+	token string
+}
+
+func (c *client) post(ctx context.Context, url string, headers map[string]string, body any) error {
+	content, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("forge: failed to post to %s: %w", url, err)
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("forge: failed to post to %s: %s: %s", url, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+type github struct {
+	*client
+	apiUrl  string
+	project string
+}
+
+func (g *github) SetStatus(ctx context.Context, s Status) error {
+	return g.post(ctx, fmt.Sprintf("%s/repos/%s/statuses/%s", g.apiUrl, g.project, s.CommitId),
+		map[string]string{
+			"Authorization": "Bearer " + g.token,
+			"Accept":        "application/vnd.github+json",
+		},
+		map[string]string{
+			"state":       string(s.State),
+			"context":     s.Context,
+			"description": truncate(s.Description),
+		})
+}
+
+type gitlab struct {
+	*client
+	apiUrl  string
+	project string
+}
+
+func (g *gitlab) SetStatus(ctx context.Context, s Status) error {
+	// GitLab has its own status names
+	state := map[State]string{Pending: "running", Success: "success", Failure: "failed"}[s.State]
+	return g.post(ctx, fmt.Sprintf("%s/projects/%s/statuses/%s", g.apiUrl, url.PathEscape(g.project), s.CommitId),
+		map[string]string{
+			"PRIVATE-TOKEN": g.token,
+		},
+		map[string]string{
+			"state":       state,
+			"name":        s.Context,
+			"description": truncate(s.Description),
+		})
+}
+
+type gitea struct {
+	*client
+	apiUrl  string
+	project string
+}
+
+func (g *gitea) SetStatus(ctx context.Context, s Status) error {
+	return g.post(ctx, fmt.Sprintf("%s/repos/%s/statuses/%s", g.apiUrl, g.project, s.CommitId),
+		map[string]string{
+			"Authorization": "token " + g.token,
+		},
+		map[string]string{
+			"state":       string(s.State),
+			"context":     s.Context,
+			"description": truncate(s.Description),
+		})
+}
@@ -0,0 +1,104 @@
+package forge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nlewo/comin/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRemoteURL(t *testing.T) {
+	for remoteUrl, expected := range map[string][2]string{
+		"https://github.com/nlewo/comin.git":         {"github.com", "nlewo/comin"},
+		"https://gitlab.com/group/subgroup/project":  {"gitlab.com", "group/subgroup/project"},
+		"git@codeberg.org:owner/repo.git":            {"codeberg.org", "owner/repo"},
+		"ssh://git@git.example.com:2222/owner/repo/": {"git.example.com", "owner/repo"},
+		"https://token@github.com/nlewo/comin.git":   {"github.com", "nlewo/comin"},
+	} {
+		host, project, err := parseRemoteURL(remoteUrl)
+		assert.Nil(t, err, remoteUrl)
+		assert.Equal(t, expected, [2]string{host, project}, remoteUrl)
+	}
+	for _, remoteUrl := range []string{"/var/lib/repo", "https://github.com/comin", "file:///var/lib/repo"} {
+		_, _, err := parseRemoteURL(remoteUrl)
+		assert.NotNil(t, err, remoteUrl)
+	}
+}
+
+func TestNew(t *testing.T) {
+	f, err := New(types.Remote{URL: "https://github.com/nlewo/comin.git"})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://api.github.com", f.(*github).apiUrl)
+	f, err = New(types.Remote{URL: "https://gitlab.example.com/group/project.git"})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://gitlab.example.com/api/v4", f.(*gitlab).apiUrl)
+	f, err = New(types.Remote{URL: "https://git.example.com/owner/repo.git", CommitStatus: types.CommitStatus{Forge: "gitea"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://git.example.com/api/v1", f.(*gitea).apiUrl)
+	_, err = New(types.Remote{URL: "https://git.example.com/owner/repo.git"})
+	assert.NotNil(t, err)
+}
+
+type request struct {
+	path    string
+	headers http.Header
+	body    map[string]string
+}
+
+func fakeForge(t *testing.T) (*httptest.Server, chan request) {
+	requests := make(chan request, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		requests <- request{path: r.URL.EscapedPath(), headers: r.Header, body: body}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, requests
+}
+
+func TestSetStatus(t *testing.T) {
+	status := Status{CommitId: "abc", State: Pending, Context: "comin/host", Description: "building"}
+	for _, tc := range []struct {
+		forge    string
+		url      string
+		path     string
+		header   string
+		token    string
+		expected map[string]string
+	}{
+		{"github", "https://github.com/owner/repo.git", "/repos/owner/repo/statuses/abc", "Authorization", "Bearer secret",
+			map[string]string{"state": "pending", "context": "comin/host", "description": "building"}},
+		{"gitlab", "https://gitlab.com/group/repo.git", "/projects/group%2Frepo/statuses/abc", "Private-Token", "secret",
+			map[string]string{"state": "running", "name": "comin/host", "description": "building"}},
+		{"gitea", "https://codeberg.org/owner/repo.git", "/repos/owner/repo/statuses/abc", "Authorization", "token secret",
+			map[string]string{"state": "pending", "context": "comin/host", "description": "building"}},
+	} {
+		srv, requests := fakeForge(t)
+		f, err := New(types.Remote{
+			URL:          tc.url,
+			Auth:         types.Auth{AccessToken: "secret"},
+			CommitStatus: types.CommitStatus{Enable: true, Forge: tc.forge, ApiUrl: srv.URL},
+		})
+		assert.Nil(t, err)
+		assert.Nil(t, f.SetStatus(t.Context(), status), tc.forge)
+		req := <-requests
+		assert.Equal(t, tc.path, req.path, tc.forge)
+		assert.Equal(t, tc.token, req.headers.Get(tc.header), tc.forge)
+		assert.Equal(t, tc.expected, req.body, tc.forge)
+	}
+}
+
+func TestSetStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Bad credentials", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+	f, err := New(types.Remote{URL: "https://github.com/owner/repo.git", CommitStatus: types.CommitStatus{ApiUrl: srv.URL}})
+	assert.Nil(t, err)
+	err = f.SetStatus(t.Context(), Status{CommitId: "abc", State: Success})
+	assert.ErrorContains(t, err, "Bad credentials")
+}
@@ -0,0 +1,199 @@
+package forge
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nlewo/comin/internal/broker"
+	"github.com/nlewo/comin/internal/store"
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/sirupsen/logrus"
+)
+
+// retryPeriod is the period used to retry the statuses which could
+// not be published
+var retryPeriod = time.Minute
+
+// maxOutboxSize is the maximal number of statuses waiting to be
+// published. The oldest ones are dropped when it is reached.
+const maxOutboxSize = 100
+
+type queued struct {
+	remote string
+	status Status
+	seq    uint64
+}
+
+// Reporter publishes the statuses of the commits evaluated, built and
+// deployed by comin. The statuses are derived from the broker events
+// and are queued in an outbox, so that an unavailable forge never
+// blocks a deployment.
+type Reporter struct {
+	broker *broker.Broker
+	// The status context, containing the hostname
+	context string
+	// forges are the forges of the remotes, indexed by remote name
+	forges map[string]Forge
+
+	mu     sync.Mutex
+	outbox []queued
+	seq    uint64
+	wakeup chan struct{}
+}
+
+func NewReporter(broker *broker.Broker, hostname string, forges map[string]Forge) *Reporter {
+	return &Reporter{
+		broker:  broker,
+		context: "comin/" + hostname,
+		forges:  forges,
+		wakeup:  make(chan struct{}, 1),
+	}
+}
+
+func (r *Reporter) Start(ctx context.Context) {
+	events := r.broker.Subscribe()
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				r.broker.Unsubscribe(events)
+				return
+			case e := <-events:
+				if remote, status, ok := r.statusOf(e); ok {
+					r.enqueue(remote, status)
+				}
+			}
+		}
+	}()
+	go r.send(ctx)
+}
+
+// statusOf returns the commit status corresponding to an event.
+func (r *Reporter) statusOf(e *protobuf.Event) (remote string, status Status, ok bool) {
+	var g *protobuf.Generation
+	switch {
+	case e.GetEvalStartedType() != nil:
+		g = e.GetEvalStartedType().GetGeneration()
+		status = Status{State: Pending, Description: "The configuration is being evaluated"}
+	case e.GetEvalFinishedType() != nil:
+		g = e.GetEvalFinishedType().GetGeneration()
+		if g.GetEvalStatus() == store.EvalFailed.String() {
+			status = Status{State: Failure, Description: "The evaluation failed"}
+		} else {
+			status = Status{State: Pending, Description: "The configuration has been evaluated"}
+		}
+	case e.GetBuildStartedType() != nil:
+		g = e.GetBuildStartedType().GetGeneration()
+		status = Status{State: Pending, Description: "The configuration is being built"}
+	case e.GetBuildSkippedType() != nil:
+		g = e.GetBuildSkippedType().GetGeneration()
+		status = Status{State: Failure, Description: "The build has been skipped: " + g.GetBuildReason()}
+	case e.GetBuildFinishedType() != nil:
+		g = e.GetBuildFinishedType().GetGeneration()
+		if g.GetBuildStatus() == store.BuildFailed.String() {
+			status = Status{State: Failure, Description: "The build failed"}
+		} else {
+			status = Status{State: Success, Description: "The configuration has been built"}
+		}
+	case e.GetDeploymentStartedType() != nil:
+		d := e.GetDeploymentStartedType().GetDeployment()
+		g = d.GetGeneration()
+		status = Status{State: Pending, Description: "The configuration is being deployed with the " + d.GetOperation() + " operation"}
+	case e.GetDeploymentFinishedType() != nil:
+		d := e.GetDeploymentFinishedType().GetDeployment()
+		g = d.GetGeneration()
+		if d.GetStatus() == store.StatusToString(store.Failed) {
+			status = Status{State: Failure, Description: "The deployment failed: " + d.GetErrorMsg()}
+		} else {
+			status = Status{State: Success, Description: "The configuration has been deployed with the " + d.GetOperation() + " operation"}
+		}
+	default:
+		return "", Status{}, false
+	}
+	if g.GetSelectedCommitId() == "" {
+		return "", Status{}, false
+	}
+	if _, exists := r.forges[g.GetSelectedRemoteName()]; !exists {
+		return "", Status{}, false
+	}
+	status.CommitId = g.GetSelectedCommitId()
+	status.Context = r.context
+	return g.GetSelectedRemoteName(), status, true
+}
+
+// enqueue adds a status to the outbox. It replaces the status of the
+// same commit which has not been published yet.
+func (r *Reporter) enqueue(remote string, status Status) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	q := queued{remote: remote, status: status, seq: r.seq}
+	replaced := false
+	for i, o := range r.outbox {
+		if o.remote == remote && o.status.CommitId == status.CommitId {
+			r.outbox[i] = q
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		if len(r.outbox) >= maxOutboxSize {
+			logrus.Warningf("forge: the outbox is full, the status of the commit %s is dropped", r.outbox[0].status.CommitId)
+			r.outbox = r.outbox[1:]
+		}
+		r.outbox = append(r.outbox, q)
+	}
+	select {
+	case r.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// pending returns a copy of the outbox
+func (r *Reporter) pending() []queued {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]queued{}, r.outbox...)
+}
+
+// remove removes a published status from the outbox, unless it has
+// been replaced in the meantime.
+func (r *Reporter) remove(q queued) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, o := range r.outbox {
+		if o.seq == q.seq {
+			r.outbox = append(r.outbox[:i], r.outbox[i+1:]...)
+			return
+		}
+	}
+}
+
+func (r *Reporter) send(ctx context.Context) {
+	for {
+		failed := false
+		for _, q := range r.pending() {
+			ctx, cancel := context.WithTimeout(ctx, time.Minute)
+			err := r.forges[q.remote].SetStatus(ctx, q.status)
+			cancel()
+			if err != nil {
+				logrus.Errorf("forge: could not publish the status of the commit %s, retrying in %s: %s", q.status.CommitId, retryPeriod, err)
+				failed = true
+				continue
+			}
+			logrus.Debugf("forge: the status '%s' of the commit %s has been published", q.status.State, q.status.CommitId)
+			r.remove(q)
+		}
+		var retry <-chan time.Time
+		if failed {
+			retry = time.After(retryPeriod)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.wakeup:
+		case <-retry:
+		}
+	}
+}
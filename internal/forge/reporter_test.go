@@ -0,0 +1,97 @@
+package forge
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nlewo/comin/internal/broker"
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type forgeMock struct {
+	mu       sync.Mutex
+	down     bool
+	statuses []Status
+}
+
+func (f *forgeMock) SetStatus(ctx context.Context, s Status) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		return errors.New("the forge is down")
+	}
+	f.statuses = append(f.statuses, s)
+	return nil
+}
+
+func (f *forgeMock) setDown(down bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.down = down
+}
+
+func (f *forgeMock) published() []Status {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Status{}, f.statuses...)
+}
+
+func TestReporter(t *testing.T) {
+	retryPeriod = 100 * time.Millisecond
+	t.Cleanup(func() { retryPeriod = time.Minute })
+	bk := broker.New()
+	bk.Start()
+	f := &forgeMock{}
+	r := NewReporter(bk, "host", map[string]Forge{"origin": f})
+	r.Start(t.Context())
+
+	g := &protobuf.Generation{SelectedRemoteName: "origin", SelectedCommitId: "abc"}
+	bk.Publish(&protobuf.Event{Type: &protobuf.Event_EvalStartedType{EvalStartedType: &protobuf.Event_EvalStarted{Generation: g}}, CreatedAt: timestamppb.Now()})
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, []Status{{CommitId: "abc", State: Pending, Context: "comin/host", Description: "The configuration is being evaluated"}}, f.published())
+	}, 2*time.Second, 50*time.Millisecond)
+
+	// Statuses are retried while the forge is down
+	f.setDown(true)
+	d := &protobuf.Deployment{Generation: g, Operation: "switch", Status: "done"}
+	bk.Publish(&protobuf.Event{Type: &protobuf.Event_DeploymentFinishedType{DeploymentFinishedType: &protobuf.Event_DeploymentFinished{Deployment: d}}, CreatedAt: timestamppb.Now()})
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Len(c, r.pending(), 1)
+	}, 2*time.Second, 50*time.Millisecond)
+	time.Sleep(200 * time.Millisecond)
+	assert.Len(t, f.published(), 1)
+
+	f.setDown(false)
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		statuses := f.published()
+		if assert.Len(c, statuses, 2) {
+			assert.Equal(c, Success, statuses[1].State)
+		}
+	}, 2*time.Second, 50*time.Millisecond)
+	assert.Empty(t, r.pending())
+
+	// Events of remotes without forge are ignored
+	other := &protobuf.Generation{SelectedRemoteName: "local", SelectedCommitId: "def"}
+	bk.Publish(&protobuf.Event{Type: &protobuf.Event_EvalStartedType{EvalStartedType: &protobuf.Event_EvalStarted{Generation: other}}, CreatedAt: timestamppb.Now()})
+	time.Sleep(100 * time.Millisecond)
+	assert.Len(t, f.published(), 2)
+}
+
+func TestReporterOutbox(t *testing.T) {
+	r := NewReporter(nil, "host", map[string]Forge{"origin": &forgeMock{}})
+	r.enqueue("origin", Status{CommitId: "abc", State: Pending})
+	r.enqueue("origin", Status{CommitId: "abc", State: Failure})
+	pending := r.pending()
+	assert.Len(t, pending, 1)
+	assert.Equal(t, Failure, pending[0].status.State)
+
+	for i := range maxOutboxSize + 1 {
+		r.enqueue("origin", Status{CommitId: string(rune('a' + i))})
+	}
+	assert.Len(t, r.pending(), maxOutboxSize)
+}
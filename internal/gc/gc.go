@@ -0,0 +1,237 @@
+package gc
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nlewo/comin/internal/broker"
+	"github.com/nlewo/comin/internal/diskspace"
+	"github.com/nlewo/comin/internal/profile"
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	TriggerDeployment = "deployment"
+	TriggerSchedule   = "schedule"
+	// TriggerDiskSpace collects the garbage whatever the
+	// min_interval and min_free options
+	TriggerDiskSpace = "disk-space"
+)
+
+// profilesDir contains the Nix profiles whose old generations are
+// deleted according to the deleteOlderThan option
+const profilesDir = "/nix/var/nix/profiles"
+
+var linkRegexp = regexp.MustCompile(`-[0-9]+-link$`)
+
+// Collector runs nix-collect-garbage and optionally nix-store
+// --optimise. The garbage is collected without the
+// --delete-older-than option: the generations of the comin profile
+// and the GC roots maintained by comin, which are both subject to the
+// retention policy, are then never collected. Instead, when
+// deleteOlderThan is set, the generations older than this number of
+// days of the other profiles are deleted before the collection.
+type Collector struct {
+	broker          *broker.Broker
+	afterDeployment bool
+	minInterval     time.Duration
+	minFree         int64
+	maxFreed        int64
+	deleteOlderThan int
+	optimise        bool
+
+	// runFunc runs a command and returns its combined output
+	runFunc func(ctx context.Context, name string, args ...string) (string, error)
+	// freeSpaceFunc returns the available space of a filesystem in bytes
+	freeSpaceFunc func(path string) (int64, error)
+	// profilesFunc returns the profiles whose old generations can be
+	// deleted
+	profilesFunc func() ([]string, error)
+
+	// mu ensures a single collection runs at a time
+	mu        sync.Mutex
+	lastRunAt time.Time
+}
+
+func New(broker *broker.Broker, afterDeployment bool, minInterval time.Duration, minFree, maxFreed int64, deleteOlderThan int, optimise bool) *Collector {
+	return &Collector{
+		broker:          broker,
+		afterDeployment: afterDeployment,
+		minInterval:     minInterval,
+		minFree:         minFree,
+		maxFreed:        maxFreed,
+		deleteOlderThan: deleteOlderThan,
+		optimise:        optimise,
+		runFunc:         run,
+		freeSpaceFunc:   diskspace.FreeSpace,
+		profilesFunc:    func() ([]string, error) { return profiles(profilesDir, profile.CominProfileDir) },
+	}
+}
+
+// Start collects the garbage after each successful deployment, if
+// enabled.
+func (c *Collector) Start(ctx context.Context) {
+	if !c.afterDeployment {
+		return
+	}
+	events := c.broker.Subscribe()
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				c.broker.Unsubscribe(events)
+				return
+			case e := <-events:
+				if d := e.GetDeploymentFinishedType().GetDeployment(); d != nil && d.GetStatus() == "done" {
+					go c.Run(ctx, TriggerDeployment)
+				}
+			}
+		}
+	}()
+}
+
+// Run collects the garbage and publishes a GarbageCollected
+// event. It returns nil when the collection has been skipped because
+// another one is running, the previous deployment triggered one less
+// than minInterval ago, or there is enough free space. The free space
+// is not checked for the disk-space trigger.
+func (c *Collector) Run(ctx context.Context, trigger string) *protobuf.Event_GarbageCollected {
+	if !c.mu.TryLock() {
+		logrus.Infof("gc: skipping the garbage collection because another one is running")
+		return nil
+	}
+	defer c.mu.Unlock()
+
+	if trigger == TriggerDeployment && time.Since(c.lastRunAt) < c.minInterval {
+		logrus.Infof("gc: skipping the garbage collection because the previous one ran at %s", c.lastRunAt.Format(time.RFC3339))
+		return nil
+	}
+	if c.minFree > 0 && trigger != TriggerDiskSpace {
+		free, err := c.freeSpaceFunc(diskspace.StoreDir)
+		if err != nil {
+			logrus.Errorf("gc: failed to get the free space of %s: %s", diskspace.StoreDir, err)
+		} else if free >= c.minFree {
+			logrus.Infof("gc: skipping the garbage collection because %d bytes are available in %s", free, diskspace.StoreDir)
+			return nil
+		}
+	}
+	c.lastRunAt = time.Now()
+
+	result := &protobuf.Event_GarbageCollected{Trigger: trigger}
+	if c.deleteOlderThan > 0 {
+		if err := c.deleteOldGenerations(ctx); err != nil {
+			logrus.Errorf("gc: %s", err)
+		}
+	}
+	args := []string{}
+	if c.maxFreed > 0 {
+		args = append(args, "--max-freed", strconv.FormatInt(c.maxFreed, 10))
+	}
+	logrus.Infof("gc: running nix-collect-garbage (trigger: %s)", trigger)
+	start := time.Now()
+	output, err := c.runFunc(ctx, "nix-collect-garbage", args...)
+	result.DurationSeconds = time.Since(start).Seconds()
+	result.FreedBytes = parseFreedBytes(output)
+	if err != nil {
+		result.Error = fmt.Sprintf("nix-collect-garbage failed: %s", err)
+	} else if c.optimise {
+		logrus.Infof("gc: running nix-store --optimise")
+		start = time.Now()
+		output, err = c.runFunc(ctx, "nix-store", "--optimise")
+		result.OptimiseDurationSeconds = time.Since(start).Seconds()
+		result.OptimisedBytes = parseFreedBytes(output)
+		if err != nil {
+			result.Error = fmt.Sprintf("nix-store --optimise failed: %s", err)
+		}
+	}
+	if result.Error != "" {
+		logrus.Errorf("gc: %s", result.Error)
+	} else {
+		logrus.Infof("gc: %d bytes freed in %.1fs and %d bytes optimised in %.1fs",
+			result.FreedBytes, result.DurationSeconds, result.OptimisedBytes, result.OptimiseDurationSeconds)
+	}
+	c.broker.Publish(&protobuf.Event{
+		Type:      &protobuf.Event_GarbageCollectedType{GarbageCollectedType: result},
+		CreatedAt: timestamppb.Now(),
+	})
+	return result
+}
+
+// deleteOldGenerations deletes the generations older than
+// deleteOlderThan days of the profiles, excepting the comin
+// profile. The current generation of a profile is never deleted.
+func (c *Collector) deleteOldGenerations(ctx context.Context) error {
+	paths, err := c.profilesFunc()
+	if err != nil {
+		return fmt.Errorf("failed to list the profiles: %w", err)
+	}
+	age := fmt.Sprintf("%dd", c.deleteOlderThan)
+	var failed []string
+	for _, p := range paths {
+		logrus.Infof("gc: deleting the generations older than %s of the profile %s", age, p)
+		if output, err := c.runFunc(ctx, "nix-env", "--profile", p, "--delete-generations", age); err != nil {
+			logrus.Errorf("gc: failed to delete the generations of the profile %s: %s: %s", p, err, output)
+			failed = append(failed, p)
+		}
+	}
+	if len(failed) != 0 {
+		return fmt.Errorf("failed to delete the old generations of the profiles %v", failed)
+	}
+	return nil
+}
+
+// profiles returns the profiles found in dir and its subdirectories,
+// excepting the exclude profile. A profile is a symlink to its
+// current generation link, such as system -> system-42-link.
+func profiles(dir, exclude string) (paths []string, err error) {
+	err = filepath.WalkDir(dir, func(p string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if e.Type()&fs.ModeSymlink == 0 || linkRegexp.MatchString(e.Name()) || p == exclude {
+			return nil
+		}
+		paths = append(paths, p)
+		return nil
+	})
+	return
+}
+
+var freedRegexp = regexp.MustCompile(`([0-9.]+) (B|KiB|MiB|GiB|TiB) freed`)
+
+var units = map[string]float64{
+	"B":   1,
+	"KiB": 1 << 10,
+	"MiB": 1 << 20,
+	"GiB": 1 << 30,
+	"TiB": 1 << 40,
+}
+
+// parseFreedBytes parses outputs such as "42 store paths deleted,
+// 12.34 MiB freed" or "1.20 MiB freed by hard-linking 31 files"
+func parseFreedBytes(output string) int64 {
+	m := freedRegexp.FindAllStringSubmatch(output, -1)
+	if len(m) == 0 {
+		return 0
+	}
+	last := m[len(m)-1]
+	value, err := strconv.ParseFloat(last[1], 64)
+	if err != nil {
+		return 0
+	}
+	return int64(value * units[last[2]])
+}
+
+func run(ctx context.Context, name string, args ...string) (string, error) {
+	output, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	return string(output), err
+}
@@ -0,0 +1,92 @@
+package gc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nlewo/comin/internal/broker"
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFreedBytes(t *testing.T) {
+	assert.Equal(t, int64(0), parseFreedBytes("nothing to do"))
+	assert.Equal(t, int64(1536), parseFreedBytes("finding garbage collector roots...\n3 store paths deleted, 1.50 KiB freed\n"))
+	assert.Equal(t, int64(2*1<<20), parseFreedBytes("2.00 MiB freed by hard-linking 31 files\n"))
+}
+
+func TestProfiles(t *testing.T) {
+	tmp := t.TempDir()
+	assert.Nil(t, os.MkdirAll(tmp+"/per-user/root", 0o755))
+	assert.Nil(t, os.MkdirAll(tmp+"/system-profiles", 0o755))
+	for _, link := range []string{"system", "system-1-link", "per-user/root/profile", "per-user/root/profile-3-link", "system-profiles/comin", "system-profiles/comin-2-link"} {
+		assert.Nil(t, os.Symlink("target", filepath.Join(tmp, link)))
+	}
+	paths, err := profiles(tmp, tmp+"/system-profiles/comin")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{tmp + "/per-user/root/profile", tmp + "/system"}, paths)
+}
+
+func TestRun(t *testing.T) {
+	bk := broker.New()
+	bk.Start()
+	c := New(bk, true, time.Hour, 100, 1000, 30, true)
+	var commands []string
+	c.runFunc = func(ctx context.Context, name string, args ...string) (string, error) {
+		commands = append(commands, strings.Join(append([]string{name}, args...), " "))
+		if name == "nix-store" {
+			return "1.00 KiB freed by hard-linking 2 files", nil
+		}
+		return "2 store paths deleted, 2.00 KiB freed", nil
+	}
+	free := int64(50)
+	c.freeSpaceFunc = func(string) (int64, error) { return free, nil }
+	c.profilesFunc = func() ([]string, error) { return []string{"/profiles/system"}, nil }
+
+	result := c.Run(t.Context(), TriggerSchedule)
+	assert.Equal(t, []string{
+		"nix-env --profile /profiles/system --delete-generations 30d",
+		"nix-collect-garbage --max-freed 1000",
+		"nix-store --optimise",
+	}, commands)
+	assert.Equal(t, int64(2048), result.FreedBytes)
+	assert.Equal(t, int64(1024), result.OptimisedBytes)
+	assert.Equal(t, "", result.Error)
+
+	// A deployment doesn't trigger a collection before minInterval
+	assert.Nil(t, c.Run(t.Context(), TriggerDeployment))
+	// There is enough free space
+	free = 200
+	assert.Nil(t, c.Run(t.Context(), TriggerSchedule))
+}
+
+func TestStart(t *testing.T) {
+	bk := broker.New()
+	bk.Start()
+	c := New(bk, true, 0, 0, 0, 0, false)
+	c.runFunc = func(ctx context.Context, name string, args ...string) (string, error) {
+		return "", assert.AnError
+	}
+	events := bk.Subscribe()
+	c.Start(t.Context())
+	bk.Publish(&protobuf.Event{Type: &protobuf.Event_DeploymentFinishedType{DeploymentFinishedType: &protobuf.Event_DeploymentFinished{
+		Deployment: &protobuf.Deployment{Status: "done"},
+	}}})
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case e := <-events:
+			if gc := e.GetGarbageCollectedType(); gc != nil {
+				assert.Equal(t, TriggerDeployment, gc.Trigger)
+				assert.Contains(t, gc.Error, "nix-collect-garbage failed")
+				return
+			}
+		case <-timeout:
+			t.Fatal("no GarbageCollected event has been published")
+		}
+	}
+}
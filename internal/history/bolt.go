@@ -0,0 +1,517 @@
+package history
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+)
+
+// An item is stored in the bucket of its kind, keyed by its UUID. It
+// is also indexed in the index buckets of its kind, whose keys are
+// <value>\x00<time><uuid> with an empty value. The time is encoded in
+// big endian in order to iterate on the keys of an indexed value by
+// time.
+const (
+	indexTime   = "time"
+	indexStatus = "status"
+	indexCommit = "commit"
+	indexBranch = "branch"
+)
+
+var (
+	bucketDeployments = []byte("deployments")
+	bucketGenerations = []byte("generations")
+	indexes           = []string{indexTime, indexStatus, indexCommit, indexBranch}
+)
+
+// pruneInterval is the interval between two removals of the items
+// older than the maximal age
+const pruneInterval = time.Hour
+
+// writeBatchSize is the maximal number of puts written in a single
+// transaction
+const writeBatchSize = 256
+
+type put struct {
+	deployment *protobuf.Deployment
+	generation *protobuf.Generation
+}
+
+// Bolt is a Store backed by a bbolt database. It only mirrors the
+// deployments and generations of the state file. The puts are queued
+// and written by a goroutine, in order to not wait for the database
+// sync, and the reads wait for the queued puts to be written. The
+// items older than maxAge are removed at startup and then hourly.
+type Bolt struct {
+	db       *bolt.DB
+	filename string
+	// maxAge is the maximal age of the items. It is unbounded when 0.
+	maxAge time.Duration
+
+	queue chan put
+	done  chan struct{}
+	// closeMu prevents the queue from being closed while an item
+	// is queued
+	closeMu sync.RWMutex
+	closed  bool
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	// queued and written are the number of queued and written
+	// puts
+	queued  int
+	written int
+}
+
+// OpenBolt opens the database filename, creating it if it doesn't
+// exist.
+func OpenBolt(filename string, maxAge time.Duration) (*Bolt, error) {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(filename, 0644, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("history: cannot open the database %s: %w", filename, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, kind := range [][]byte{bucketDeployments, bucketGenerations} {
+			if _, err := tx.CreateBucketIfNotExists(kind); err != nil {
+				return err
+			}
+			for _, index := range indexes {
+				if _, err := tx.CreateBucketIfNotExists(indexBucket(kind, index)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("history: cannot initialize the database %s: %w", filename, err)
+	}
+	b := &Bolt{
+		db:       db,
+		filename: filename,
+		maxAge:   maxAge,
+		queue:    make(chan put, writeBatchSize),
+		done:     make(chan struct{}),
+	}
+	b.cond = sync.NewCond(&b.mu)
+	if err := b.prune(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	logrus.Infof("history: opened %s with %d items", filename, b.Len())
+	go b.write()
+	return b, nil
+}
+
+func indexBucket(kind []byte, index string) []byte {
+	return []byte(string(kind) + "_" + index)
+}
+
+// timeKey encodes t to be ordered as a byte slice
+func timeKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano())^(1<<63))
+	return key
+}
+
+// indexKeys returns the keys of an item in the index buckets. The
+// keys of a value are ordered by time and then by UUID.
+func indexKeys(uuid string, t time.Time, values map[string][]string) map[string][][]byte {
+	suffix := append(timeKey(t), uuid...)
+	keys := map[string][][]byte{
+		indexTime: {suffix},
+	}
+	for _, index := range []string{indexStatus, indexCommit, indexBranch} {
+		var seen []string
+		for _, v := range values[index] {
+			if v == "" || slices.Contains(seen, v) {
+				continue
+			}
+			seen = append(seen, v)
+			keys[index] = append(keys[index], slices.Concat([]byte(v), []byte{0}, suffix))
+		}
+	}
+	return keys
+}
+
+func deploymentIndexKeys(d *protobuf.Deployment) map[string][][]byte {
+	return indexKeys(d.Uuid, deploymentTime(d), map[string][]string{
+		indexStatus: {d.Status},
+		indexCommit: {d.Generation.GetSelectedCommitId(), d.Generation.GetMainCommitId()},
+		indexBranch: {d.Generation.GetSelectedBranchName()},
+	})
+}
+
+func generationIndexKeys(g *protobuf.Generation) map[string][][]byte {
+	return indexKeys(g.Uuid, generationTime(g), map[string][]string{
+		indexStatus: {g.EvalStatus, g.BuildStatus},
+		indexCommit: {g.SelectedCommitId, g.MainCommitId},
+		indexBranch: {g.SelectedBranchName},
+	})
+}
+
+func itemIndexKeys(kind []byte, m proto.Message) map[string][][]byte {
+	if bytes.Equal(kind, bucketDeployments) {
+		return deploymentIndexKeys(m.(*protobuf.Deployment))
+	}
+	return generationIndexKeys(m.(*protobuf.Generation))
+}
+
+func newItem(kind []byte) proto.Message {
+	if bytes.Equal(kind, bucketDeployments) {
+		return &protobuf.Deployment{}
+	}
+	return &protobuf.Generation{}
+}
+
+// getItem returns the item uuid of the bucket kind, or nil if it
+// doesn't exist
+func getItem(tx *bolt.Tx, kind []byte, uuid string) (proto.Message, error) {
+	value := tx.Bucket(kind).Get([]byte(uuid))
+	if value == nil {
+		return nil, nil
+	}
+	m := newItem(kind)
+	if err := proto.Unmarshal(value, m); err != nil {
+		return nil, fmt.Errorf("history: cannot decode the item %s: %w", uuid, err)
+	}
+	return m, nil
+}
+
+func deleteItem(tx *bolt.Tx, kind []byte, uuid string) error {
+	old, err := getItem(tx, kind, uuid)
+	if err != nil || old == nil {
+		return err
+	}
+	for index, keys := range itemIndexKeys(kind, old) {
+		for _, key := range keys {
+			if err := tx.Bucket(indexBucket(kind, index)).Delete(key); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Bucket(kind).Delete([]byte(uuid))
+}
+
+// putItem replaces the item and its index keys
+func putItem(tx *bolt.Tx, kind []byte, uuid string, m proto.Message) error {
+	if err := deleteItem(tx, kind, uuid); err != nil {
+		return err
+	}
+	value, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := tx.Bucket(kind).Put([]byte(uuid), value); err != nil {
+		return err
+	}
+	for index, keys := range itemIndexKeys(kind, m) {
+		for _, key := range keys {
+			if err := tx.Bucket(indexBucket(kind, index)).Put(key, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// write writes the queued puts, in batches
+func (b *Bolt) write() {
+	defer close(b.done)
+	lastPrune := time.Now()
+	for p := range b.queue {
+		batch := []put{p}
+	drain:
+		for len(batch) < writeBatchSize {
+			select {
+			case p, ok := <-b.queue:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, p)
+			default:
+				break drain
+			}
+		}
+		err := b.db.Update(func(tx *bolt.Tx) error {
+			for _, p := range batch {
+				var err error
+				if p.deployment != nil {
+					err = putItem(tx, bucketDeployments, p.deployment.Uuid, p.deployment)
+				} else {
+					err = putItem(tx, bucketGenerations, p.generation.Uuid, p.generation)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			logrus.Errorf("history: cannot write %d items to %s: %s", len(batch), b.filename, err)
+		}
+		if time.Since(lastPrune) > pruneInterval {
+			if err := b.prune(); err != nil {
+				logrus.Errorf("history: %s", err)
+			}
+			lastPrune = time.Now()
+		}
+		b.mu.Lock()
+		b.written += len(batch)
+		b.cond.Broadcast()
+		b.mu.Unlock()
+	}
+}
+
+// prune removes the items older than maxAge
+func (b *Bolt) prune() error {
+	if b.maxAge == 0 {
+		return nil
+	}
+	limit := timeKey(time.Now().Add(-b.maxAge))
+	var removed int
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		for _, kind := range [][]byte{bucketDeployments, bucketGenerations} {
+			var uuids []string
+			c := tx.Bucket(indexBucket(kind, indexTime)).Cursor()
+			for k, _ := c.First(); k != nil && bytes.Compare(k[:8], limit) < 0; k, _ = c.Next() {
+				uuids = append(uuids, string(k[8:]))
+			}
+			for _, uuid := range uuids {
+				if err := deleteItem(tx, kind, uuid); err != nil {
+					return err
+				}
+			}
+			removed += len(uuids)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("history: cannot remove the items older than %s from %s: %w", b.maxAge, b.filename, err)
+	}
+	if removed > 0 {
+		logrus.Infof("history: removed %d items older than %s", removed, b.maxAge)
+	}
+	return nil
+}
+
+func (b *Bolt) enqueue(p put) error {
+	b.closeMu.RLock()
+	defer b.closeMu.RUnlock()
+	if b.closed {
+		return fmt.Errorf("history: the database %s is closed", b.filename)
+	}
+	b.mu.Lock()
+	b.queued++
+	b.mu.Unlock()
+	b.queue <- p
+	return nil
+}
+
+// wait waits for the queued puts to be written
+func (b *Bolt) wait() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	queued := b.queued
+	for b.written < queued {
+		b.cond.Wait()
+	}
+}
+
+func (b *Bolt) DeploymentPut(d *protobuf.Deployment) error {
+	return b.enqueue(put{deployment: proto.CloneOf(d)})
+}
+
+func (b *Bolt) GenerationPut(g *protobuf.Generation) error {
+	return b.enqueue(put{generation: proto.CloneOf(g)})
+}
+
+func (b *Bolt) Deployment(uuid string) (d *protobuf.Deployment, err error) {
+	b.wait()
+	err = b.db.View(func(tx *bolt.Tx) error {
+		m, err := getItem(tx, bucketDeployments, uuid)
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			return fmt.Errorf("history: the deployment %s is %w", uuid, ErrNotFound)
+		}
+		d = m.(*protobuf.Deployment)
+		return nil
+	})
+	return
+}
+
+// scan calls yield with the suffixes of the keys of bucket having the
+// prefix, whose suffix is in [lower, upper), in the ascending or
+// descending order, until yield returns false. A nil bound doesn't
+// bound the range.
+func scan(bucket *bolt.Bucket, prefix, lower, upper []byte, descending bool, yield func(suffix []byte) bool) {
+	lo := slices.Concat(prefix, lower)
+	var hi []byte
+	if upper != nil {
+		hi = slices.Concat(prefix, upper)
+	} else if len(prefix) > 0 {
+		// The prefixes end with a 0 byte: the keys having the
+		// prefix are before the prefix ending with a 1 byte
+		hi = slices.Concat(prefix[:len(prefix)-1], []byte{1})
+	}
+	c := bucket.Cursor()
+	if !descending {
+		for k, _ := c.Seek(lo); k != nil && (hi == nil || bytes.Compare(k, hi) < 0); k, _ = c.Next() {
+			if !yield(k[len(prefix):]) {
+				return
+			}
+		}
+		return
+	}
+	var k []byte
+	if hi == nil {
+		k, _ = c.Last()
+	} else if k, _ = c.Seek(hi); k == nil {
+		k, _ = c.Last()
+	} else {
+		k, _ = c.Prev()
+	}
+	for ; k != nil && bytes.Compare(k, lo) >= 0; k, _ = c.Prev() {
+		if !yield(k[len(prefix):]) {
+			return
+		}
+	}
+}
+
+// candidates calls yield with the UUIDs of the items which can match
+// the query, in the query order, until yield returns false. They are
+// read from the most selective index of the query.
+func candidates(tx *bolt.Tx, kind []byte, q Query, yield func(uuid string) bool) {
+	var lower, upper []byte
+	if !q.Since.IsZero() {
+		lower = timeKey(q.Since)
+	}
+	if !q.Until.IsZero() {
+		upper = timeKey(q.Until)
+	}
+	if q.After != nil {
+		after := append(timeKey(q.After.Time), q.After.Uuid...)
+		if q.OldestFirst {
+			// The smallest key greater than the cursor
+			after = append(after, 0)
+			if lower == nil || bytes.Compare(after, lower) > 0 {
+				lower = after
+			}
+		} else if upper == nil || bytes.Compare(after, upper) < 0 {
+			upper = after
+		}
+	}
+	descending := !q.OldestFirst
+	yieldSuffix := func(suffix []byte) bool {
+		return yield(string(suffix[8:]))
+	}
+	switch {
+	case q.CommitId != "":
+		// The commit ID is a prefix: the keys of the matching
+		// commits are merged
+		bucket := tx.Bucket(indexBucket(kind, indexCommit))
+		var commits [][]byte
+		c := bucket.Cursor()
+		for k, _ := c.Seek([]byte(q.CommitId)); k != nil && bytes.HasPrefix(k, []byte(q.CommitId)); k, _ = c.Next() {
+			commit := k[:bytes.IndexByte(k, 0)+1]
+			if len(commits) == 0 || !bytes.Equal(commits[len(commits)-1], commit) {
+				commits = append(commits, bytes.Clone(commit))
+			}
+		}
+		var suffixes [][]byte
+		for _, commit := range commits {
+			scan(bucket, commit, lower, upper, false, func(suffix []byte) bool {
+				suffixes = append(suffixes, bytes.Clone(suffix))
+				return true
+			})
+		}
+		slices.SortFunc(suffixes, bytes.Compare)
+		suffixes = slices.CompactFunc(suffixes, bytes.Equal)
+		if descending {
+			slices.Reverse(suffixes)
+		}
+		for _, suffix := range suffixes {
+			if !yieldSuffix(suffix) {
+				return
+			}
+		}
+	case q.Status != "":
+		scan(tx.Bucket(indexBucket(kind, indexStatus)), append([]byte(q.Status), 0), lower, upper, descending, yieldSuffix)
+	case q.Branch != "":
+		scan(tx.Bucket(indexBucket(kind, indexBranch)), append([]byte(q.Branch), 0), lower, upper, descending, yieldSuffix)
+	default:
+		scan(tx.Bucket(indexBucket(kind, indexTime)), nil, lower, upper, descending, yieldSuffix)
+	}
+}
+
+// query returns the items of the bucket kind for which match is true,
+// in the query order and up to the query limit
+func query[T proto.Message](b *Bolt, kind []byte, q Query, match func(T) bool) (result []T, err error) {
+	b.wait()
+	err = b.db.View(func(tx *bolt.Tx) error {
+		var err error
+		candidates(tx, kind, q, func(uuid string) bool {
+			var m proto.Message
+			if m, err = getItem(tx, kind, uuid); err != nil {
+				return false
+			}
+			if m != nil && match(m.(T)) {
+				result = append(result, m.(T))
+			}
+			return q.Limit <= 0 || len(result) < q.Limit
+		})
+		return err
+	})
+	return
+}
+
+func (b *Bolt) Deployments(q Query) ([]*protobuf.Deployment, error) {
+	return query(b, bucketDeployments, q, q.matchDeployment)
+}
+
+func (b *Bolt) Generations(q Query) ([]*protobuf.Generation, error) {
+	return query(b, bucketGenerations, q, q.matchGenerationStatus)
+}
+
+func (b *Bolt) Len() (n int) {
+	b.wait()
+	_ = b.db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(bucketDeployments).Stats().KeyN + tx.Bucket(bucketGenerations).Stats().KeyN
+		return nil
+	})
+	return
+}
+
+// Close writes the queued puts and closes the database
+func (b *Bolt) Close() error {
+	b.closeMu.Lock()
+	if b.closed {
+		b.closeMu.Unlock()
+		return nil
+	}
+	b.closed = true
+	close(b.queue)
+	b.closeMu.Unlock()
+	<-b.done
+	if err := b.db.Close(); err != nil && !errors.Is(err, bolt.ErrDatabaseNotOpen) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package history
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func deployment(uuid, status, commitId string, createdAt time.Time) *protobuf.Deployment {
+	return &protobuf.Deployment{
+		Uuid:      uuid,
+		Status:    status,
+		CreatedAt: timestamppb.New(createdAt),
+		Generation: &protobuf.Generation{
+			Uuid:               "g-" + uuid,
+			SelectedCommitId:   commitId,
+			SelectedBranchName: "main",
+		},
+	}
+}
+
+func uuids(deployments []*protobuf.Deployment) (result []string) {
+	for _, d := range deployments {
+		result = append(result, d.Uuid)
+	}
+	return
+}
+
+func TestBoltQuery(t *testing.T) {
+	now := time.Now()
+	l, err := OpenBolt(t.TempDir()+"/history.db", 0)
+	assert.Nil(t, err)
+	assert.Nil(t, l.DeploymentPut(deployment("d1", "done", "aaaa", now.Add(-3*time.Hour))))
+	assert.Nil(t, l.DeploymentPut(deployment("d2", "failed", "bbbb", now.Add(-2*time.Hour))))
+	assert.Nil(t, l.DeploymentPut(deployment("d3", "done", "cccc", now.Add(-1*time.Hour))))
+	// An update replaces the previous version
+	assert.Nil(t, l.DeploymentPut(deployment("d2", "done", "bbbb", now.Add(-2*time.Hour))))
+
+	ds, _ := l.Deployments(Query{})
+	assert.Equal(t, []string{"d3", "d2", "d1"}, uuids(ds))
+	ds, _ = l.Deployments(Query{Status: "failed"})
+	assert.Empty(t, ds)
+	ds, _ = l.Deployments(Query{CommitId: "bb"})
+	assert.Equal(t, []string{"d2"}, uuids(ds))
+	ds, _ = l.Deployments(Query{Since: now.Add(-150 * time.Minute), Until: now.Add(-30 * time.Minute)})
+	assert.Equal(t, []string{"d3", "d2"}, uuids(ds))
+	ds, _ = l.Deployments(Query{Limit: 1})
+	assert.Equal(t, []string{"d3"}, uuids(ds))
+	ds, _ = l.Deployments(Query{Branch: "testing"})
+	assert.Empty(t, ds)
+	ds, _ = l.Deployments(Query{Operation: "boot"})
+	assert.Empty(t, ds)
+	ds, _ = l.Deployments(Query{OldestFirst: true, After: &Cursor{Time: now.Add(-3 * time.Hour), Uuid: "d1"}, Limit: 1})
+	assert.Equal(t, []string{"d2"}, uuids(ds))
+	ds, _ = l.Deployments(Query{After: &Cursor{Time: now.Add(-3 * time.Hour), Uuid: "d1"}})
+	assert.Empty(t, ds)
+	d, err := l.Deployment("d2")
+	assert.Nil(t, err)
+	assert.Equal(t, "done", d.Status)
+	_, err = l.Deployment("d4")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// The returned deployments are copies
+	ds, _ = l.Deployments(Query{Limit: 1})
+	ds[0].Status = "modified"
+	ds, _ = l.Deployments(Query{Limit: 1})
+	assert.Equal(t, "done", ds[0].Status)
+}
+
+func TestBoltReopen(t *testing.T) {
+	now := time.Now()
+	filename := t.TempDir() + "/history.db"
+	l, err := OpenBolt(filename, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, l.DeploymentPut(deployment("d1", "running", "aaaa", now)))
+	assert.Nil(t, l.DeploymentPut(deployment("d1", "done", "aaaa", now)))
+	assert.Nil(t, l.GenerationPut(&protobuf.Generation{Uuid: "g1", EvalStatus: "evaluated", EvalStartedAt: timestamppb.New(now)}))
+	// The queued puts are written on close
+	assert.Nil(t, l.Close())
+	assert.NotNil(t, l.DeploymentPut(deployment("d2", "done", "aaaa", now)))
+
+	l, err = OpenBolt(filename, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, l.Len())
+	ds, _ := l.Deployments(Query{})
+	assert.Equal(t, []string{"d1"}, uuids(ds))
+	assert.Equal(t, "done", ds[0].Status)
+	// The index keys of the previous version are removed
+	ds, _ = l.Deployments(Query{Status: "running"})
+	assert.Empty(t, ds)
+	gs, _ := l.Generations(Query{Status: "evaluated"})
+	assert.Len(t, gs, 1)
+	assert.Nil(t, l.Close())
+}
+
+func TestBoltMaxAge(t *testing.T) {
+	now := time.Now()
+	filename := t.TempDir() + "/history.db"
+	l, err := OpenBolt(filename, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, l.DeploymentPut(deployment("d1", "done", "aaaa", now.Add(-48*time.Hour))))
+	assert.Nil(t, l.DeploymentPut(deployment("d2", "done", "bbbb", now)))
+	assert.Nil(t, l.Close())
+
+	l, err = OpenBolt(filename, 24*time.Hour)
+	assert.Nil(t, err)
+	ds, _ := l.Deployments(Query{})
+	assert.Equal(t, []string{"d2"}, uuids(ds))
+	ds, _ = l.Deployments(Query{CommitId: "aa"})
+	assert.Empty(t, ds)
+	assert.Equal(t, 1, l.Len())
+	assert.Nil(t, l.Close())
+}
+
+func TestBoltCommitPrefix(t *testing.T) {
+	now := time.Now()
+	l, err := OpenBolt(t.TempDir()+"/history.db", 0)
+	assert.Nil(t, err)
+	defer l.Close() // nolint: errcheck
+	assert.Nil(t, l.DeploymentPut(deployment("d1", "done", "abcd", now.Add(-3*time.Hour))))
+	assert.Nil(t, l.DeploymentPut(deployment("d2", "done", "abef", now.Add(-2*time.Hour))))
+	assert.Nil(t, l.DeploymentPut(deployment("d3", "done", "abcd", now.Add(-1*time.Hour))))
+	assert.Nil(t, l.DeploymentPut(deployment("d4", "done", "bbbb", now)))
+	ds, _ := l.Deployments(Query{CommitId: "ab"})
+	assert.Equal(t, []string{"d3", "d2", "d1"}, uuids(ds))
+	ds, _ = l.Deployments(Query{CommitId: "abc", OldestFirst: true})
+	assert.Equal(t, []string{"d1", "d3"}, uuids(ds))
+	ds, _ = l.Deployments(Query{CommitId: "ab", Status: "done", Until: now.Add(-90 * time.Minute)})
+	assert.Equal(t, []string{"d2", "d1"}, uuids(ds))
+}
+
+func TestBoltPages(t *testing.T) {
+	now := time.Now()
+	l, err := OpenBolt(t.TempDir()+"/history.db", 0)
+	assert.Nil(t, err)
+	defer l.Close() // nolint: errcheck
+	var all []*protobuf.Deployment
+	for i := range 9 {
+		status := "done"
+		if i%3 == 0 {
+			status = "failed"
+		}
+		// Deployments created at the same time are ordered by UUID
+		d := deployment(fmt.Sprintf("d%d", 8-i), status, fmt.Sprintf("c%d", i%2), now.Add(time.Duration(i/2)*time.Minute))
+		all = append(all, d)
+		assert.Nil(t, l.DeploymentPut(d))
+	}
+	queries := []Query{
+		{},
+		{OldestFirst: true},
+		{Status: "done"},
+		{Status: "failed", OldestFirst: true},
+		{CommitId: "c1"},
+		{CommitId: "c", OldestFirst: true},
+		{Branch: "main", Since: now.Add(time.Minute)},
+		{Until: now.Add(3 * time.Minute), OldestFirst: true},
+	}
+	for _, q := range queries {
+		expected := FilterDeployments(q, all)
+		assert.NotEmpty(t, expected)
+		// The pages of the history and of the deployments list
+		// are the same
+		var fromHistory, fromList []*protobuf.Deployment
+		for _, pages := range []*[]*protobuf.Deployment{&fromHistory, &fromList} {
+			pq := q
+			pq.Limit = 2
+			for range 10 {
+				var page []*protobuf.Deployment
+				if pages == &fromHistory {
+					page, err = l.Deployments(pq)
+					assert.Nil(t, err)
+				} else {
+					page = FilterDeployments(pq, all)
+				}
+				*pages = append(*pages, page...)
+				if len(page) < pq.Limit {
+					break
+				}
+				c := DeploymentCursor(page[len(page)-1])
+				pq.After = &c
+			}
+		}
+		assert.Equal(t, uuids(expected), uuids(fromHistory), "%+v", q)
+		assert.Equal(t, uuids(expected), uuids(fromList), "%+v", q)
+	}
+}
+
+func TestCursor(t *testing.T) {
+	c := Cursor{Time: time.Unix(0, 1234567890), Uuid: "6f0c1d4e-2b4e"}
+	parsed, err := ParseCursor(c.String())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, c.compare(parsed))
+	for _, s := range []string{"", "12", "a:uuid", "12:"} {
+		_, err = ParseCursor(s)
+		assert.NotNil(t, err, s)
+	}
+}
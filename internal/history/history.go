@@ -0,0 +1,179 @@
+// This package records the history of the deployments and
+// generations. Contrary to the comin state file, which only keeps the
+// deployments and generations required by the retention policy, the
+// history is unbounded or bounded by an age.
+//
+// The history is a mirror of the state file: the store puts the
+// deployments and generations into the history when they change, and
+// the state file remains the primary store, rewritten on every change
+// and used by the retention policy. The Store interface is only a
+// backend for this mirror.
+package history
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nlewo/comin/pkg/protobuf"
+)
+
+// Query filters the deployments and generations of the history. The
+// zero value of a field doesn't filter.
+type Query struct {
+	Since time.Time
+	Until time.Time
+	// Status is the deployment status, or the evaluation or build
+	// status of a generation
+	Status string
+	// CommitId is a prefix of the selected or main commit ID
+	CommitId string
+	// Branch is the selected branch name
+	Branch string
+	// Operation is the deployment operation
+	Operation string
+	// OldestFirst sorts the items from the oldest one
+	OldestFirst bool
+	// After only returns the items after this cursor in the query
+	// order. It is the cursor of the last item of the previous
+	// page.
+	After *Cursor
+	// Limit is the maximal number of returned items
+	Limit int
+}
+
+var ErrNotFound = errors.New("not found")
+
+// Cursor is the position of an item in the order of the items, which
+// are sorted by time and then by UUID.
+type Cursor struct {
+	Time time.Time
+	Uuid string
+}
+
+// DeploymentCursor returns the cursor of a deployment
+func DeploymentCursor(d *protobuf.Deployment) Cursor {
+	return Cursor{Time: deploymentTime(d), Uuid: d.Uuid}
+}
+
+// String encodes the cursor as <unix-nanoseconds>:<uuid>
+func (c Cursor) String() string {
+	return fmt.Sprintf("%d:%s", c.Time.UnixNano(), c.Uuid)
+}
+
+// ParseCursor decodes a cursor encoded by Cursor.String
+func ParseCursor(s string) (Cursor, error) {
+	nanos, uuid, ok := strings.Cut(s, ":")
+	if !ok || uuid == "" {
+		return Cursor{}, fmt.Errorf("the cursor '%s' must be <unix-nanoseconds>:<uuid>", s)
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("the cursor '%s' must be <unix-nanoseconds>:<uuid>", s)
+	}
+	return Cursor{Time: time.Unix(0, n), Uuid: uuid}, nil
+}
+
+func (c Cursor) compare(o Cursor) int {
+	if n := c.Time.Compare(o.Time); n != 0 {
+		return n
+	}
+	return strings.Compare(c.Uuid, o.Uuid)
+}
+
+// Store is a backend storing the history. The deployments and
+// generations are identified by their UUID: putting a deployment or a
+// generation replaces the previous version with the same UUID.
+type Store interface {
+	DeploymentPut(d *protobuf.Deployment) error
+	GenerationPut(g *protobuf.Generation) error
+	// Deployment returns the deployment uuid or ErrNotFound
+	Deployment(uuid string) (*protobuf.Deployment, error)
+	// Deployments returns the deployments matching the query, newest
+	// first by default
+	Deployments(q Query) ([]*protobuf.Deployment, error)
+	// Generations returns the generations matching the query, newest
+	// first by default
+	Generations(q Query) ([]*protobuf.Generation, error)
+	// Len returns the number of deployments and generations
+	Len() int
+	Close() error
+}
+
+func deploymentTime(d *protobuf.Deployment) time.Time {
+	if d.CreatedAt != nil {
+		return d.CreatedAt.AsTime()
+	}
+	return d.StartedAt.AsTime()
+}
+
+func generationTime(g *protobuf.Generation) time.Time {
+	if g.EvalStartedAt != nil {
+		return g.EvalStartedAt.AsTime()
+	}
+	return g.BuildStartedAt.AsTime()
+}
+
+func (q Query) matchTime(t time.Time) bool {
+	return (q.Since.IsZero() || !t.Before(q.Since)) && (q.Until.IsZero() || t.Before(q.Until))
+}
+
+func (q Query) matchGeneration(g *protobuf.Generation) bool {
+	if q.CommitId != "" && !strings.HasPrefix(g.GetSelectedCommitId(), q.CommitId) && !strings.HasPrefix(g.GetMainCommitId(), q.CommitId) {
+		return false
+	}
+	if q.Branch != "" && g.GetSelectedBranchName() != q.Branch {
+		return false
+	}
+	return true
+}
+
+func (q Query) matchDeployment(d *protobuf.Deployment) bool {
+	if !q.matchTime(deploymentTime(d)) || !q.matchGeneration(d.Generation) {
+		return false
+	}
+	if q.Status != "" && d.Status != q.Status {
+		return false
+	}
+	return q.Operation == "" || d.Operation == q.Operation
+}
+
+func (q Query) matchGenerationStatus(g *protobuf.Generation) bool {
+	if !q.matchTime(generationTime(g)) || !q.matchGeneration(g) {
+		return false
+	}
+	return q.Status == "" || g.EvalStatus == q.Status || g.BuildStatus == q.Status
+}
+
+// FilterDeployments returns the deployments matching the query, newest
+// first by default
+func FilterDeployments(q Query, deployments []*protobuf.Deployment) (result []*protobuf.Deployment) {
+	for _, d := range deployments {
+		if q.matchDeployment(d) {
+			result = append(result, d)
+		}
+	}
+	slices.SortFunc(result, func(a, b *protobuf.Deployment) int {
+		return DeploymentCursor(b).compare(DeploymentCursor(a))
+	})
+	if q.OldestFirst {
+		slices.Reverse(result)
+	}
+	if q.After != nil {
+		i := slices.IndexFunc(result, func(d *protobuf.Deployment) bool {
+			n := DeploymentCursor(d).compare(*q.After)
+			return (q.OldestFirst && n > 0) || (!q.OldestFirst && n < 0)
+		})
+		if i < 0 {
+			return nil
+		}
+		result = result[i:]
+	}
+	if q.Limit > 0 && len(result) > q.Limit {
+		result = result[:q.Limit]
+	}
+	return result
+}
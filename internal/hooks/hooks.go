@@ -0,0 +1,169 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os/exec"
+	"slices"
+	"time"
+
+	"github.com/nlewo/comin/internal/types"
+	pb "github.com/nlewo/comin/pkg/protobuf"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The lifecycle stages at which hooks can be run
+const (
+	PostFetch  = "post-fetch"
+	PostEval   = "post-eval"
+	PostBuild  = "post-build"
+	PreDeploy  = "pre-deploy"
+	PostDeploy = "post-deploy"
+	PostReboot = "post-reboot"
+)
+
+var Stages = []string{PostFetch, PostEval, PostBuild, PreDeploy, PostDeploy, PostReboot}
+
+// The failure policies of the hooks
+const (
+	// OnFailureContinue runs the next hooks of the stage. A failed
+	// pre-deploy hook still vetoes the deployment once the next
+	// pre-deploy hooks have been run.
+	OnFailureContinue = "continue"
+	// OnFailureAbort doesn't run the next hooks of the stage. A
+	// failed pre-deploy hook vetoes the deployment while a failed
+	// post-deploy hook doesn't change the finished deployment.
+	OnFailureAbort = "abort"
+	// OnFailureVeto records the deployment as failed. It is only
+	// available for the pre-deploy hooks.
+	OnFailureVeto = "veto"
+	// OnFailurePostpone retries the deployment after the retry
+	// period. It is only available for the pre-deploy hooks.
+	OnFailurePostpone = "postpone"
+)
+
+// maxOutputLength is the length of the end of the hook output stored
+// in the hook result
+const maxOutputLength = 4096
+
+// Hook is a command run at a stage of the comin lifecycle. It receives
+// the context of the stage as environment variables and as JSON on
+// its stdin.
+type Hook struct {
+	Name             string
+	Stage            string
+	Command          string
+	Args             []string
+	Timeout          time.Duration
+	Environment      map[string]string
+	WorkingDirectory string
+	OnFailure        string
+	RetryPeriod      time.Duration
+}
+
+// New returns the hook of a hook configuration
+func New(cfg types.Hook) (Hook, error) {
+	if cfg.Name == "" || cfg.Command == "" {
+		return Hook{}, fmt.Errorf("hooks: a hook requires a name and a command")
+	}
+	if !slices.Contains(Stages, cfg.Stage) {
+		return Hook{}, fmt.Errorf("hooks: the stage of the hook '%s' is '%s' while it must be one of %v", cfg.Name, cfg.Stage, Stages)
+	}
+	policies := []string{OnFailureContinue, OnFailureAbort}
+	if cfg.Stage == PreDeploy {
+		policies = append(policies, OnFailureVeto, OnFailurePostpone)
+	}
+	if !slices.Contains(policies, cfg.OnFailure) {
+		return Hook{}, fmt.Errorf("hooks: the on_failure of the %s hook '%s' is '%s' while it must be one of %v", cfg.Stage, cfg.Name, cfg.OnFailure, policies)
+	}
+	if cfg.Timeout < 0 || cfg.RetryPeriod < 0 {
+		return Hook{}, fmt.Errorf("hooks: the timeout and retry_period of the hook '%s' must be positive", cfg.Name)
+	}
+	return Hook{
+		Name:             cfg.Name,
+		Stage:            cfg.Stage,
+		Command:          cfg.Command,
+		Args:             cfg.Args,
+		Timeout:          time.Duration(cfg.Timeout) * time.Second,
+		Environment:      cfg.Environment,
+		WorkingDirectory: cfg.WorkingDirectory,
+		OnFailure:        cfg.OnFailure,
+		RetryPeriod:      time.Duration(cfg.RetryPeriod) * time.Second,
+	}, nil
+}
+
+// OfStage returns the hooks of a stage, in their configuration order
+func OfStage(hooks []Hook, stage string) (res []Hook) {
+	for _, h := range hooks {
+		if h.Stage == stage {
+			res = append(res, h)
+		}
+	}
+	return
+}
+
+// Run runs a hook with the environment env and the input on its
+// stdin. The exit code of the result is -1 if the hook could not be
+// started or has been killed.
+func Run(ctx context.Context, hook Hook, env []string, input []byte) *pb.HookResult {
+	result := &pb.HookResult{
+		Name:      hook.Name,
+		Stage:     hook.Stage,
+		StartedAt: timestamppb.New(time.Now().UTC()),
+	}
+	if hook.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, hook.Timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, hook.Command, hook.Args...)
+	cmd.Env = append(slices.Clone(env), "COMIN_STAGE="+hook.Stage)
+	for _, k := range slices.Sorted(maps.Keys(hook.Environment)) {
+		cmd.Env = append(cmd.Env, k+"="+hook.Environment[k])
+	}
+	cmd.Dir = hook.WorkingDirectory
+	cmd.Stdin = bytes.NewReader(input)
+	// Do not wait for children still holding the output once the
+	// hook has been killed
+	cmd.WaitDelay = 10 * time.Second
+	output, err := cmd.CombinedOutput()
+	result.EndedAt = timestamppb.New(time.Now().UTC())
+	if len(output) > maxOutputLength {
+		output = output[len(output)-maxOutputLength:]
+	}
+	result.Output = string(output)
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr) && exitErr.ExitCode() > 0:
+		result.ExitCode = int32(exitErr.ExitCode())
+	default:
+		result.ExitCode = -1
+		result.Output += err.Error()
+	}
+	return result
+}
+
+// RunAll runs the hooks in order. When a hook fails, the next hooks
+// are run only if its failure policy is "continue". It returns the
+// results of the hooks which have been run and the failed hook which
+// stopped the run, if any.
+func RunAll(ctx context.Context, hooks []Hook, env []string, input []byte) (results []*pb.HookResult, failed *Hook) {
+	for i, hook := range hooks {
+		logrus.Infof("hooks: running the %s hook %s", hook.Stage, hook.Name)
+		result := Run(ctx, hook, env, input)
+		results = append(results, result)
+		if result.ExitCode == 0 {
+			continue
+		}
+		logrus.Infof("hooks: the %s hook %s exited with %d: %s", hook.Stage, hook.Name, result.ExitCode, result.Output)
+		if hook.OnFailure != OnFailureContinue {
+			return results, &hooks[i]
+		}
+	}
+	return results, nil
+}
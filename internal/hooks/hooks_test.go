@@ -0,0 +1,78 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nlewo/comin/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeHook(t *testing.T, script string) string {
+	path := filepath.Join(t.TempDir(), "hook")
+	assert.Nil(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755))
+	return path
+}
+
+func TestRun(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "input")
+	dir := t.TempDir()
+	hook := Hook{
+		Name:             "check",
+		Stage:            PostBuild,
+		Command:          writeHook(t, "cat > "+input+"\necho \"$1 $COMIN_STAGE $FOO $(pwd)\"\nexit 3\n"),
+		Args:             []string{"arg"},
+		Environment:      map[string]string{"FOO": "bar"},
+		WorkingDirectory: dir,
+	}
+	result := Run(t.Context(), hook, []string{"COMIN_GIT_SHA=commit-1"}, []byte(`{"uuid":"uuid"}`))
+	assert.Equal(t, "check", result.Name)
+	assert.Equal(t, PostBuild, result.Stage)
+	assert.Equal(t, int32(3), result.ExitCode)
+	assert.Equal(t, "arg post-build bar "+dir+"\n", result.Output)
+	content, err := os.ReadFile(input)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"uuid":"uuid"}`, string(content))
+
+	hook.Command = writeHook(t, "exec sleep 10\n")
+	hook.Timeout = 100 * time.Millisecond
+	result = Run(t.Context(), hook, nil, nil)
+	assert.Equal(t, int32(-1), result.ExitCode)
+
+	hook.Command = filepath.Join(dir, "missing")
+	result = Run(t.Context(), hook, nil, nil)
+	assert.Equal(t, int32(-1), result.ExitCode)
+}
+
+func TestRunAll(t *testing.T) {
+	hooks := []Hook{
+		{Name: "ok", Command: writeHook(t, "exit 0\n"), OnFailure: OnFailureAbort},
+		{Name: "ignored", Command: writeHook(t, "exit 1\n"), OnFailure: OnFailureContinue},
+		{Name: "ko", Command: writeHook(t, "exit 1\n"), OnFailure: OnFailureAbort},
+		{Name: "never", Command: writeHook(t, "exit 0\n"), OnFailure: OnFailureAbort},
+	}
+	results, failed := RunAll(t.Context(), hooks, nil, nil)
+	assert.Len(t, results, 3)
+	assert.Equal(t, "ko", failed.Name)
+
+	results, failed = RunAll(t.Context(), hooks[:2], nil, nil)
+	assert.Len(t, results, 2)
+	assert.Nil(t, failed)
+}
+
+func TestNew(t *testing.T) {
+	h, err := New(types.Hook{Name: "h", Stage: PreDeploy, Command: "/bin/true", Timeout: 10, OnFailure: OnFailureVeto})
+	assert.Nil(t, err)
+	assert.Equal(t, 10*time.Second, h.Timeout)
+
+	_, err = New(types.Hook{Name: "h", Stage: PostBuild, Command: "/bin/true", OnFailure: OnFailureVeto})
+	assert.ErrorContains(t, err, "veto")
+	_, err = New(types.Hook{Name: "h", Stage: "pre-lunch", Command: "/bin/true", OnFailure: OnFailureAbort})
+	assert.ErrorContains(t, err, "pre-lunch")
+	_, err = New(types.Hook{Name: "h", Stage: PostBuild, OnFailure: OnFailureAbort})
+	assert.ErrorContains(t, err, "command")
+
+	assert.Len(t, OfStage([]Hook{{Stage: PostBuild}, {Stage: PostEval}, {Stage: PostBuild}}, PostBuild), 2)
+}
@@ -0,0 +1,180 @@
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/nlewo/comin/internal/broker"
+	"github.com/nlewo/comin/internal/store"
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// job is the run of the hooks of a stage
+type job struct {
+	stage          string
+	env            []string
+	input          []byte
+	generationUuid string
+}
+
+// Runner runs the post-fetch, post-eval, post-build and post-reboot
+// hooks. These hooks are run in the background, one stage after the
+// other, and their results are recorded in the store. The pre-deploy
+// and post-deploy hooks are run by the deployer.
+type Runner struct {
+	broker   *broker.Broker
+	store    *store.Store
+	hostname string
+	hooks    []Hook
+
+	mu    sync.Mutex
+	queue []job
+	// queuedCh wakes up the worker when a job is queued
+	queuedCh chan struct{}
+
+	lastCommitId string
+}
+
+func NewRunner(broker *broker.Broker, store *store.Store, hostname string, hooks []Hook) *Runner {
+	return &Runner{
+		broker:   broker,
+		store:    store,
+		hostname: hostname,
+		hooks:    hooks,
+		queuedCh: make(chan struct{}, 1),
+	}
+}
+
+// Start runs the post-reboot hooks if the machine has rebooted since
+// the previous comin start, and then the hooks of the events
+// published by the broker. Reboots are not detected when the bootId
+// is empty.
+func (r *Runner) Start(ctx context.Context, bootId string) {
+	events := r.broker.Subscribe()
+	if bootId != "" && r.store.BootIdUpdate(bootId) {
+		logrus.Infof("hooks: the machine has rebooted since the previous comin start")
+		r.enqueue(job{
+			stage: PostReboot,
+			env:   append(os.Environ(), "COMIN_HOSTNAME="+r.hostname),
+		})
+	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				r.broker.Unsubscribe(events)
+				return
+			case e := <-events:
+				if j, ok := r.jobOf(e); ok {
+					r.enqueue(j)
+				}
+			}
+		}
+	}()
+	go r.work(ctx)
+}
+
+func (r *Runner) enqueue(j job) {
+	if len(OfStage(r.hooks, j.stage)) == 0 {
+		return
+	}
+	r.mu.Lock()
+	r.queue = append(r.queue, j)
+	r.mu.Unlock()
+	select {
+	case r.queuedCh <- struct{}{}:
+	default:
+	}
+}
+
+func (r *Runner) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.queuedCh:
+		}
+		for {
+			r.mu.Lock()
+			if len(r.queue) == 0 {
+				r.mu.Unlock()
+				break
+			}
+			j := r.queue[0]
+			r.queue = r.queue[1:]
+			r.mu.Unlock()
+			r.run(ctx, j)
+		}
+	}
+}
+
+func (r *Runner) run(ctx context.Context, j job) {
+	results, _ := RunAll(ctx, OfStage(r.hooks, j.stage), j.env, j.input)
+	if j.generationUuid == "" {
+		r.store.HookResultsAdd(results)
+		return
+	}
+	if err := r.store.GenerationHookResults(j.generationUuid, results); err != nil {
+		logrus.Errorf("hooks: could not record the results of the %s hooks: %s", j.stage, err)
+	}
+}
+
+// jobOf returns the job of an event, if hooks have to be run for this
+// event. The post-fetch hooks are only run when a fetch selects a new
+// commit.
+func (r *Runner) jobOf(e *protobuf.Event) (j job, ok bool) {
+	switch {
+	case e.GetFetched() != nil:
+		rs := e.GetFetched().GetRepositoryStatus()
+		if rs.GetSelectedCommitId() == "" || rs.GetSelectedCommitId() == r.lastCommitId {
+			return j, false
+		}
+		r.lastCommitId = rs.GetSelectedCommitId()
+		input, err := protojson.Marshal(rs)
+		if err != nil {
+			return j, false
+		}
+		return job{
+			stage: PostFetch,
+			env: append(os.Environ(),
+				"COMIN_GIT_SHA="+rs.GetSelectedCommitId(),
+				fmt.Sprintf("COMIN_GIT_REF=%s/%s", rs.GetSelectedRemoteName(), rs.GetSelectedBranchName()),
+				"COMIN_GIT_MSG="+strings.Trim(rs.GetSelectedCommitMsg(), "\n"),
+				"COMIN_HOSTNAME="+r.hostname,
+			),
+			input: input,
+		}, true
+	case e.GetEvalFinishedType() != nil:
+		return generationJob(PostEval, e.GetEvalFinishedType().GetGeneration())
+	case e.GetBuildFinishedType() != nil:
+		return generationJob(PostBuild, e.GetBuildFinishedType().GetGeneration())
+	}
+	return j, false
+}
+
+func generationJob(stage string, g *protobuf.Generation) (j job, ok bool) {
+	input, err := protojson.Marshal(g)
+	if err != nil {
+		return j, false
+	}
+	return job{
+		stage: stage,
+		env: append(os.Environ(),
+			"COMIN_GIT_SHA="+g.GetSelectedCommitId(),
+			fmt.Sprintf("COMIN_GIT_REF=%s/%s", g.GetSelectedRemoteName(), g.GetSelectedBranchName()),
+			"COMIN_GIT_MSG="+strings.Trim(g.GetSelectedCommitMsg(), "\n"),
+			"COMIN_HOSTNAME="+g.GetHostname(),
+			"COMIN_GENERATION="+g.GetUuid(),
+			"COMIN_EVAL_STATUS="+g.GetEvalStatus(),
+			"COMIN_BUILD_STATUS="+g.GetBuildStatus(),
+			"COMIN_OUT_PATH="+g.GetOutPath(),
+		),
+		input:          input,
+		generationUuid: g.GetUuid(),
+	}, true
+}
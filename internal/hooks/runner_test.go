@@ -0,0 +1,53 @@
+package hooks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nlewo/comin/internal/broker"
+	"github.com/nlewo/comin/internal/store"
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRunner(t *testing.T) {
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
+	assert.Nil(t, err)
+	g := s.NewGeneration("host", "repo", "", "attr", &protobuf.RepositoryStatus{SelectedCommitId: "commit-1"})
+	// The machine was running with another boot ID
+	s.BootIdUpdate("boot-1")
+
+	hooks := []Hook{
+		{Name: "build", Stage: PostBuild, Command: writeHook(t, "echo $COMIN_GENERATION $COMIN_BUILD_STATUS\n"), OnFailure: OnFailureContinue},
+		{Name: "fetch", Stage: PostFetch, Command: writeHook(t, "echo $COMIN_GIT_SHA\n"), OnFailure: OnFailureContinue},
+		{Name: "reboot", Stage: PostReboot, Command: writeHook(t, "echo $COMIN_HOSTNAME\n"), OnFailure: OnFailureContinue},
+	}
+	r := NewRunner(bk, s, "host", hooks)
+	r.Start(t.Context(), "boot-2")
+
+	built := &protobuf.Generation{Uuid: g.Uuid, BuildStatus: "built"}
+	bk.Publish(&protobuf.Event{Type: &protobuf.Event_BuildFinishedType{BuildFinishedType: &protobuf.Event_BuildFinished{Generation: built}}, CreatedAt: timestamppb.Now()})
+	fetched := &protobuf.Event{Type: &protobuf.Event_Fetched_{Fetched: &protobuf.Event_Fetched{RepositoryStatus: &protobuf.RepositoryStatus{SelectedCommitId: "commit-1"}}}}
+	// The post-fetch hooks are only run for a new commit
+	bk.Publish(fetched)
+	bk.Publish(fetched)
+
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		generation, err := s.GenerationGet(g.Uuid)
+		assert.Nil(c, err)
+		if assert.Len(c, generation.Hooks, 1) {
+			assert.Equal(c, g.Uuid+" built\n", generation.Hooks[0].Output)
+		}
+		results := s.GetState().HookResults
+		if assert.Len(c, results, 2) {
+			assert.Equal(c, PostReboot, results[0].Stage)
+			assert.Equal(c, "host\n", results[0].Output)
+			assert.Equal(c, PostFetch, results[1].Stage)
+			assert.Equal(c, "commit-1\n", results[1].Output)
+		}
+	}, 5*time.Second, 100*time.Millisecond)
+}
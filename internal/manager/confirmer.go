@@ -71,6 +71,9 @@ func NewConfirmer(broker *broker.Broker, mode Mode, duration time.Duration, reas
 type Command struct {
 	action string
 	uuid   string
+	// heldReason forces a manual confirmation of the submitted
+	// generation when it is not empty
+	heldReason string
 }
 
 func (c *Confirmer) status() *protobuf.Confirmer {
@@ -85,6 +88,16 @@ func (c *Confirmer) Submit(generationUuid string) {
 	}
 }
 
+// SubmitHeld submits a generation which requires a manual
+// confirmation whatever the confirmer mode, because of reason.
+func (c *Confirmer) SubmitHeld(generationUuid, reason string) {
+	c.command <- Command{
+		action:     "submit",
+		uuid:       generationUuid,
+		heldReason: reason,
+	}
+}
+
 func (c *Confirmer) Confirm(generationUuid string) {
 	c.command <- Command{
 		action: "confirm",
@@ -114,9 +127,27 @@ func (c *Confirmer) start() {
 			case "submit":
 				notified = false
 				c.state.Submitted = command.uuid
-				switch Mode(c.state.Mode) {
+				c.state.HeldReason = command.heldReason
+				mode := Mode(c.state.Mode)
+				if command.heldReason != "" {
+					mode = Manual
+					// A previous autoconfirm timer must not
+					// confirm this generation
+					if c.timer != nil {
+						c.timer.Stop()
+					}
+					c.state.AutoconfirmStarted = wrapperspb.Bool(false)
+				}
+				if mode != Without {
+					e := &protobuf.Event_ConfirmationSubmitted{Mode: map[Mode]string{Manual: "manual", Auto: "auto"}[mode], Uuid: command.uuid, For: c.reason, HeldReason: command.heldReason}
+					c.broker.Publish(&protobuf.Event{Type: &protobuf.Event_ConfirmationSubmittedType{ConfirmationSubmittedType: e}, CreatedAt: timestamppb.New(time.Now().UTC())})
+				}
+				switch mode {
 				case Manual:
 					logrus.Infof("confirmer: generation %s has been submitted", command.uuid)
+					if command.heldReason != "" {
+						logrus.Infof("confirmer: generation %s requires a manual confirmation because %s", command.uuid, command.heldReason)
+					}
 				case Without:
 					logrus.Infof("confirmer: generation %s has been submitted and confirmed", command.uuid)
 					c.state.Confirmed = command.uuid
@@ -156,6 +187,7 @@ func (c *Confirmer) start() {
 			c.confirmed <- c.state.Confirmed
 			c.state.Confirmed = ""
 			c.state.Submitted = ""
+			c.state.HeldReason = ""
 			c.state.AutoconfirmStarted = wrapperspb.Bool(false)
 		}
 	}
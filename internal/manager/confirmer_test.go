@@ -171,3 +171,29 @@ func TestConfirmerConfirmBeforeSubmit(t *testing.T) {
 		assert.True(ct, expectedUuid.Load())
 	}, 4*time.Second, 100*time.Millisecond)
 }
+
+func TestConfirmerSubmitHeld(t *testing.T) {
+	bk := broker.New()
+	bk.Start()
+	c := NewConfirmer(bk, Without, 0, "")
+	var confirmed atomic.Value
+	go func() {
+		for uuid := range c.confirmed {
+			confirmed.Store(uuid)
+		}
+	}()
+	c.Start()
+
+	c.SubmitHeld("uuid1", "a critical unit would restart")
+	assert.EventuallyWithT(t, func(ct *assert.CollectT) {
+		assert.Equal(ct, "uuid1", c.status().Submitted)
+		assert.Equal(ct, "a critical unit would restart", c.status().HeldReason)
+	}, 1*time.Second, 100*time.Millisecond)
+	assert.Nil(t, confirmed.Load())
+
+	c.Confirm("uuid1")
+	assert.EventuallyWithT(t, func(ct *assert.CollectT) {
+		assert.Equal(ct, "uuid1", confirmed.Load())
+		assert.Equal(ct, "", c.status().HeldReason)
+	}, 1*time.Second, 100*time.Millisecond)
+}
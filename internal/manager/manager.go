@@ -8,7 +8,9 @@ package manager
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/nlewo/comin/internal/broker"
@@ -16,7 +18,10 @@ import (
 	"github.com/nlewo/comin/internal/deployer"
 	"github.com/nlewo/comin/internal/executor"
 	"github.com/nlewo/comin/internal/fetcher"
+	"github.com/nlewo/comin/internal/history"
 	"github.com/nlewo/comin/internal/prometheus"
+	"github.com/nlewo/comin/internal/reboot"
+	"github.com/nlewo/comin/internal/rollout"
 	"github.com/nlewo/comin/internal/scheduler"
 	"github.com/nlewo/comin/internal/store"
 	"github.com/nlewo/comin/pkg/protobuf"
@@ -37,7 +42,9 @@ type Manager struct {
 	stateRequestCh chan struct{}
 	stateResultCh  chan *protobuf.State
 
-	needToReboot bool
+	// rebootReasons are the reasons why a reboot is required. The
+	// machine needs to be rebooted when it is not empty.
+	rebootReasons []string
 
 	prometheus      prometheus.Prometheus
 	storage         *store.Store
@@ -50,6 +57,15 @@ type Manager struct {
 	DeployConfirmer *Confirmer
 
 	configurationOperations ConfigurationOperations
+	// criticalUnits are the patterns of the units whose stop or
+	// restart requires a manual confirmation of the deployment
+	criticalUnits []string
+	// rebooter automatically reboots the machine when it is not
+	// nil
+	rebooter *reboot.Rebooter
+	// rollout holds the generations of the main branches until the
+	// earlier rollout waves have deployed them, when it is not nil
+	rollout *rollout.Rollout
 
 	isSuspended bool
 
@@ -69,6 +85,9 @@ func New(s *store.Store,
 	deployConfirmer *Confirmer,
 	broker *broker.Broker,
 	configurationOperations ConfigurationOperations,
+	criticalUnits []string,
+	rebooter *reboot.Rebooter,
+	rollout *rollout.Rollout,
 ) *Manager {
 
 	m := &Manager{
@@ -88,6 +107,9 @@ func New(s *store.Store,
 		DeployConfirmer:         deployConfirmer,
 		broker:                  broker,
 		configurationOperations: configurationOperations,
+		criticalUnits:           criticalUnits,
+		rebooter:                rebooter,
+		rollout:                 rollout,
 	}
 	return m
 }
@@ -98,8 +120,17 @@ func (m *Manager) GetState() *protobuf.State {
 }
 
 func (m *Manager) toState() *protobuf.State {
+	var rebootState *protobuf.Reboot
+	if m.rebooter != nil {
+		rebootState = m.rebooter.State()
+	}
+	var rolloutState *protobuf.Rollout
+	if m.rollout != nil {
+		rolloutState = m.rollout.State()
+	}
 	return &protobuf.State{
-		NeedToReboot:    wrapperspb.Bool(m.needToReboot),
+		NeedToReboot:    wrapperspb.Bool(len(m.rebootReasons) > 0),
+		RebootReasons:   m.rebootReasons,
 		IsSuspended:     wrapperspb.Bool(m.isSuspended),
 		Builder:         m.Builder.State(),
 		Deployer:        m.deployer.State(),
@@ -107,9 +138,29 @@ func (m *Manager) toState() *protobuf.State {
 		Store:           m.storage.GetState(),
 		BuildConfirmer:  m.BuildConfirmer.status(),
 		DeployConfirmer: m.DeployConfirmer.status(),
+		Reboot:          rebootState,
+		Rollout:         rolloutState,
 	}
 }
 
+// GenerationLogs writes the eval or build logs of a generation to
+// w. If follow is true, it returns at the end of the evaluation or
+// build.
+func (m *Manager) GenerationLogs(ctx context.Context, generationUuid, kind string, follow bool, w io.Writer) error {
+	return m.storage.GenerationLogsRead(ctx, generationUuid, kind, follow, w)
+}
+
+// ListDeployments returns the deployments matching the query, from
+// the history when it is enabled
+func (m *Manager) ListDeployments(q history.Query, bootEntries bool) ([]*protobuf.Deployment, error) {
+	return m.storage.ListDeployments(q, bootEntries)
+}
+
+// GetDeployment returns the deployment uuid
+func (m *Manager) GetDeployment(uuid string) (*protobuf.Deployment, error) {
+	return m.storage.DeploymentFind(uuid)
+}
+
 func (m *Manager) DeploymentLatestSubmit(operation string) error {
 	latest := m.storage.GetDeploymentLastest()
 	if latest == nil {
@@ -128,6 +179,26 @@ func (m *Manager) DeploymentLatestSubmit(operation string) error {
 	return nil
 }
 
+// DeploymentWindowOverride deploys the generation waiting for a
+// deployment window now.
+func (m *Manager) DeploymentWindowOverride() error {
+	return m.deployer.OverrideWindow()
+}
+
+// InhibitorOverride accepts the switch inhibitor changes of a
+// generation.
+func (m *Manager) InhibitorOverride(generationUuid string, keys []string, comment string) error {
+	return m.deployer.OverrideInhibitors(generationUuid, keys, comment)
+}
+
+// RebootCancel cancels the planned automatic reboot.
+func (m *Manager) RebootCancel() error {
+	if m.rebooter == nil {
+		return fmt.Errorf("the automatic reboots are not enabled")
+	}
+	return m.rebooter.Cancel()
+}
+
 func (m *Manager) Suspend() error {
 	if m.isSuspended {
 		return fmt.Errorf("the manager is already suspended")
@@ -182,6 +253,10 @@ func (m *Manager) FetchAndBuild(ctx context.Context) {
 				}
 				if generation.MachineId != "" && m.machineId != generation.MachineId {
 					logrus.Infof("manager: the comin.machineId %s is not the host machine-id %s", generation.MachineId, m.machineId)
+					reason := fmt.Sprintf("%s: the expected machine-id is %s while the host machine-id is %s", builder.BuildReasonMachineIdMismatch, generation.MachineId, m.machineId)
+					if err := m.Builder.SkipBuild(generationUUID, reason); err != nil {
+						logrus.Error(err)
+					}
 				} else {
 					logrus.Infof("manager: the build of the generation %s is submitted", generation.Uuid)
 					m.BuildConfirmer.Submit(generationUUID)
@@ -199,10 +274,28 @@ func (m *Manager) FetchAndBuild(ctx context.Context) {
 					logrus.Infof("manager: a generation is available for deployment with commit %s", generation.SelectedCommitId)
 					operation := m.getOperationFromConfigurationOperations(generation.SelectedRemoteName, generation.SelectedBranchName)
 					if !m.deployer.IsAlreadyDeployed(&generation, operation) {
-						m.DeployConfirmer.Submit(generationUUID)
+						if reason := criticalUnitsHeldReason(generation.UnitChanges, m.criticalUnits); reason != "" {
+							m.DeployConfirmer.SubmitHeld(generationUUID, reason)
+						} else {
+							m.DeployConfirmer.Submit(generationUUID)
+						}
 					}
 				}
 			case generationUUID := <-m.DeployConfirmer.confirmed:
+				generation, err := m.storage.GenerationGet(generationUUID)
+				if err != nil {
+					logrus.Error(err)
+					continue
+				}
+				// Commits of testing branches are not rolled out in waves
+				if m.rollout != nil && !generation.SelectedBranchIsTesting.GetValue() {
+					m.rollout.Submit(generationUUID, generation.SelectedCommitId)
+					continue
+				}
+				operation := m.getOperationFromConfigurationOperations(generation.SelectedRemoteName, generation.SelectedBranchName)
+				reason := fmt.Sprintf("The generation %s needs to be deployed", generationUUID)
+				m.deployer.Submit(&generation, operation, false, reason)
+			case generationUUID := <-m.rolloutReady():
 				generation, err := m.storage.GenerationGet(generationUUID)
 				if err != nil {
 					logrus.Error(err)
@@ -216,6 +309,16 @@ func (m *Manager) FetchAndBuild(ctx context.Context) {
 	}()
 }
 
+// rolloutReady returns the channel of the generations ready to be
+// deployed by the rollout. It is nil, and thus never ready, when the
+// rollout is not enabled.
+func (m *Manager) rolloutReady() chan string {
+	if m.rollout == nil {
+		return nil
+	}
+	return m.rollout.Ready
+}
+
 // ConfigurationOperations is a map describing the operation associated
 // to each remote/branch. It is a map looking such as:
 // { origin: { main: switch, testing: test }, local { main: switch }
@@ -240,7 +343,16 @@ func (m *Manager) Run(ctx context.Context) {
 	logrus.Infof("manager: starting with machineId=%s", m.machineId)
 	lastDpl := m.deployer.State().Deployment
 	if lastDpl != nil {
-		m.needToReboot = m.executor.NeedToReboot(lastDpl.Generation.OutPath, lastDpl.Operation)
+		m.rebootReasons = m.executor.NeedToReboot(lastDpl.Generation.OutPath, lastDpl.Operation)
+	}
+	if m.rebooter != nil {
+		m.rebooter.Start(ctx)
+		if len(m.rebootReasons) > 0 {
+			m.rebooter.Request(lastDpl.Uuid, m.rebootReasons)
+		}
+	}
+	if m.rollout != nil {
+		m.rollout.Start(ctx)
 	}
 
 	m.FetchAndBuild(ctx)
@@ -251,10 +363,33 @@ func (m *Manager) Run(ctx context.Context) {
 		case <-m.stateRequestCh:
 			m.stateResultCh <- m.toState()
 		case dpl := <-m.deployer.DeploymentDoneCh:
-			m.needToReboot = m.executor.NeedToReboot(dpl.Generation.OutPath, dpl.Operation)
-			if m.needToReboot {
-				e := &protobuf.Event_RebootRequired{Deployment: dpl}
+			m.rebootReasons = m.executor.NeedToReboot(dpl.Generation.OutPath, dpl.Operation)
+			if len(m.rebootReasons) > 0 {
+				logrus.Infof("manager: a reboot is required: %s", strings.Join(m.rebootReasons, ", "))
+				if err := m.storage.DeploymentRebootReasons(dpl.Uuid, m.rebootReasons); err != nil {
+					logrus.Errorf("manager: could not record the reboot reasons of the deployment %s: %s", dpl.Uuid, err)
+				}
+				e := &protobuf.Event_RebootRequired{Deployment: dpl, Reasons: m.rebootReasons}
 				m.broker.Publish(&protobuf.Event{Type: &protobuf.Event_RebootRequired_{RebootRequired: e}, CreatedAt: timestamppb.New(time.Now().UTC())})
+				if m.rebooter != nil {
+					m.rebooter.Request(dpl.Uuid, m.rebootReasons)
+				}
+			} else if m.rebooter != nil {
+				m.rebooter.Withdraw(dpl.Uuid)
+			}
+			if m.rollout != nil && !dpl.Generation.SelectedBranchIsTesting.GetValue() {
+				succeeded := dpl.Status == store.StatusToString(store.Done)
+				report := func() {
+					if err := m.rollout.Report(ctx, dpl.Generation.SelectedCommitId, succeeded); err != nil {
+						logrus.Errorf("manager: could not report the deployment %s to the rollout: %s", dpl.Uuid, err)
+					}
+				}
+				// The report has to be published before comin exits
+				if dpl.RestartComin.GetValue() {
+					report()
+				} else {
+					go report()
+				}
 			}
 			if dpl.RestartComin.GetValue() {
 				// TODO: stop contexts
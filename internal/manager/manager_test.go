@@ -3,6 +3,7 @@ package manager
 import (
 	"context"
 	"fmt"
+	"io"
 	"testing"
 	"time"
 
@@ -30,22 +31,24 @@ var mkDeployerMock = func(t *testing.T) *deployer.Deployer {
 	bk := broker.New()
 	bk.Start()
 
-	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1)
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
 	assert.Nil(t, err)
-	return deployer.New(s, deployFunc, nil, "")
+	return deployer.New(s, deployFunc, nil, "", nil, nil, nil)
 }
 
 type ExecutorMock struct {
 	evalOk    chan bool
 	buildOk   chan bool
 	machineId string
+	// dryActivateErr makes the unit changes prediction fail
+	dryActivateErr error
 }
 
 func (n ExecutorMock) ReadMachineId() (string, error) {
 	return "", nil
 }
-func (n ExecutorMock) NeedToReboot(_, _ string) bool {
-	return false
+func (n ExecutorMock) NeedToReboot(_, _ string) []string {
+	return nil
 }
 func (n ExecutorMock) IsStorePathExist(storePath string) bool {
 	return false
@@ -53,7 +56,7 @@ func (n ExecutorMock) IsStorePathExist(storePath string) bool {
 func (n ExecutorMock) Deploy(ctx context.Context, outPath, operation string, profilePaths []string) (needToRestartComin bool, profilePath string, err error) {
 	return false, "", nil
 }
-func (n ExecutorMock) Eval(ctx context.Context, repositoryPath, repositorySubdir, commitId, systemAttr, hostname string, submodules bool) (drvPath string, outPath string, machineId string, err error) {
+func (n ExecutorMock) Eval(ctx context.Context, repositoryPath, repositorySubdir, commitId, systemAttr, hostname string, submodules bool, logs io.Writer) (drvPath string, outPath string, machineId string, err error) {
 	ok := <-n.evalOk
 	if ok {
 		return "drv-path", "out-path", n.machineId, nil
@@ -61,22 +64,35 @@ func (n ExecutorMock) Eval(ctx context.Context, repositoryPath, repositorySubdir
 		return "", "", n.machineId, fmt.Errorf("An error occured")
 	}
 }
-func (n ExecutorMock) Build(ctx context.Context, drvPath string) (err error) {
+func (n ExecutorMock) Build(ctx context.Context, drvPath, outPath string, logs io.Writer, progress executor.ProgressFunc) (builtBy string, err error) {
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return "", ctx.Err()
 	case ok := <-n.buildOk:
 		if ok {
-			return nil
+			return executor.BuiltByLocal, nil
 		} else {
-			return fmt.Errorf("An error occured")
+			return "", fmt.Errorf("An error occured")
 		}
 	}
 }
+func (n ExecutorMock) Substitute(ctx context.Context, outPath string, logs io.Writer, progress executor.ProgressFunc) error {
+	return fmt.Errorf("not available")
+}
+func (n ExecutorMock) ClosureDiff(ctx context.Context, outPath string) (executor.ClosureDiff, error) {
+	return executor.ClosureDiff{}, nil
+}
+func (n ExecutorMock) DryActivate(ctx context.Context, outPath string) (executor.UnitChanges, error) {
+	if n.dryActivateErr != nil {
+		return executor.UnitChanges{}, n.dryActivateErr
+	}
+	return executor.UnitChanges{Restart: []string{"sshd.service"}}, nil
+}
 func NewExecutorMock(machineId string) ExecutorMock {
 	return ExecutorMock{
-		evalOk:  make(chan bool, 1),
-		buildOk: make(chan bool, 1),
+		evalOk:    make(chan bool, 1),
+		buildOk:   make(chan bool, 1),
+		machineId: machineId,
 	}
 }
 
@@ -88,19 +104,19 @@ func TestBuild(t *testing.T) {
 	bk.Start()
 	f := fetcher.NewFetcher(r, bk)
 	f.Start(t.Context())
-	s, _ := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1)
+	s, _ := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
 	eMock := NewExecutorMock("")
-	b := builder.New(s, eMock, "repoPath", "", "", "my-machine", false, 2*time.Second, 2*time.Second)
+	b := builder.New(s, eMock, "repoPath", "", "", "my-machine", false, 2*time.Second, 2*time.Second, 0, nil, nil, nil)
 	var deployFunc = func(context.Context, string, string, []string) (bool, string, error) {
 		return false, "profile-path", nil
 	}
-	d := deployer.New(s, deployFunc, nil, "")
+	d := deployer.New(s, deployFunc, nil, "", nil, nil, nil)
 	e, _ := executor.NewNixOSFlake()
 	bc := NewConfirmer(bk, Without, 0, "")
 	bc.Start()
 	dc := NewConfirmer(bk, Without, 0, "")
 	dc.Start()
-	m := New(s, prometheus.New(), scheduler.New(), f, b, d, "", "", e, bc, dc, bk, emptyConfigurationOperations)
+	m := New(s, prometheus.New(), scheduler.New(), f, b, d, "", "", e, bc, dc, bk, emptyConfigurationOperations, nil, nil, nil)
 	go m.Run(t.Context())
 	assert.False(t, m.Fetcher.GetState().IsFetching.GetValue())
 	assert.False(t, m.Builder.State().IsEvaluating.GetValue())
@@ -200,21 +216,21 @@ func TestDeploy(t *testing.T) {
 	f := fetcher.NewFetcher(r, bk)
 	f.Start(t.Context())
 
-	s, _ := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1)
+	s, _ := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
 	eMock := NewExecutorMock("")
 	eMock.evalOk <- true
 	eMock.buildOk <- true
-	b := builder.New(s, eMock, "repoPath", "", "", "my-machine", false, 2*time.Second, 2*time.Second)
+	b := builder.New(s, eMock, "repoPath", "", "", "my-machine", false, 2*time.Second, 2*time.Second, 0, nil, nil, nil)
 	var deployFunc = func(context.Context, string, string, []string) (bool, string, error) {
 		return false, "profile-path", nil
 	}
-	d := deployer.New(s, deployFunc, nil, "")
+	d := deployer.New(s, deployFunc, nil, "", nil, nil, nil)
 	e, _ := executor.NewNixOSFlake()
 	bc := NewConfirmer(bk, Without, 0, "")
 	bc.Start()
 	dc := NewConfirmer(bk, Without, 0, "")
 	dc.Start()
-	m := New(s, prometheus.New(), scheduler.New(), f, b, d, "", "", e, bc, dc, bk, emptyConfigurationOperations)
+	m := New(s, prometheus.New(), scheduler.New(), f, b, d, "", "", e, bc, dc, bk, emptyConfigurationOperations, nil, nil, nil)
 	go m.Run(t.Context())
 	assert.False(t, m.Fetcher.GetState().IsFetching.GetValue())
 	assert.False(t, m.Builder.State().IsEvaluating.GetValue())
@@ -226,6 +242,78 @@ func TestDeploy(t *testing.T) {
 
 }
 
+func TestCriticalUnitsHoldDeployment(t *testing.T) {
+	r := utils.NewRepositoryMock()
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+	f := fetcher.NewFetcher(r, bk)
+	f.Start(t.Context())
+
+	s, _ := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
+	eMock := NewExecutorMock("")
+	eMock.evalOk <- true
+	eMock.buildOk <- true
+	b := builder.New(s, eMock, "repoPath", "", "", "my-machine", false, 2*time.Second, 2*time.Second, 0, nil, nil, nil)
+	d := mkDeployerMock(t)
+	e, _ := executor.NewNixOSFlake()
+	bc := NewConfirmer(bk, Without, 0, "")
+	bc.Start()
+	dc := NewConfirmer(bk, Without, 0, "")
+	dc.Start()
+	// The executor mock predicts a restart of sshd.service
+	m := New(s, prometheus.New(), scheduler.New(), f, b, d, "", "", e, bc, dc, bk, emptyConfigurationOperations, []string{"sshd.service"}, nil, nil)
+	go m.Run(t.Context())
+
+	f.TriggerFetch([]string{"remote"})
+	r.RsCh <- &protobuf.RepositoryStatus{
+		SelectedCommitId: "id-1",
+	}
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		status := dc.status()
+		assert.Equal(c, m.Builder.GenerationUuid, status.Submitted)
+		assert.Equal(c, "", status.Confirmed)
+		assert.Contains(c, status.HeldReason, "sshd.service")
+	}, 5*time.Second, 100*time.Millisecond)
+	assert.Nil(t, m.deployer.GenerationToDeploy)
+}
+
+func TestCriticalUnitsPredictionUnavailable(t *testing.T) {
+	r := utils.NewRepositoryMock()
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+	f := fetcher.NewFetcher(r, bk)
+	f.Start(t.Context())
+
+	s, _ := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
+	eMock := NewExecutorMock("")
+	eMock.dryActivateErr = fmt.Errorf("dry-activate failed")
+	eMock.evalOk <- true
+	eMock.buildOk <- true
+	b := builder.New(s, eMock, "repoPath", "", "", "my-machine", false, 2*time.Second, 2*time.Second, 0, nil, nil, nil)
+	d := mkDeployerMock(t)
+	e, _ := executor.NewNixOSFlake()
+	bc := NewConfirmer(bk, Without, 0, "")
+	bc.Start()
+	dc := NewConfirmer(bk, Without, 0, "")
+	dc.Start()
+	m := New(s, prometheus.New(), scheduler.New(), f, b, d, "", "", e, bc, dc, bk, emptyConfigurationOperations, []string{"sshd.service"}, nil, nil)
+	go m.Run(t.Context())
+
+	f.TriggerFetch([]string{"remote"})
+	r.RsCh <- &protobuf.RepositoryStatus{
+		SelectedCommitId: "id-1",
+	}
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		status := dc.status()
+		assert.Equal(c, m.Builder.GenerationUuid, status.Submitted)
+		assert.Equal(c, "", status.Confirmed)
+		assert.Contains(c, status.HeldReason, "unit change prediction is unavailable")
+	}, 5*time.Second, 100*time.Millisecond)
+	assert.Nil(t, m.deployer.GenerationToDeploy)
+}
+
 func TestIncorrectMachineId(t *testing.T) {
 	logrus.SetLevel(logrus.DebugLevel)
 	r := utils.NewRepositoryMock()
@@ -235,16 +323,17 @@ func TestIncorrectMachineId(t *testing.T) {
 	f := fetcher.NewFetcher(r, bk)
 	f.Start(t.Context())
 
-	s, _ := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1)
+	s, _ := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
 	eMock := NewExecutorMock("invalid-machine-id")
-	b := builder.New(s, eMock, "repoPath", "", "", "my-machine", false, 2*time.Second, 2*time.Second)
+	eMock.evalOk <- true
+	b := builder.New(s, eMock, "repoPath", "", "", "my-machine", false, 2*time.Second, 2*time.Second, 0, nil, nil, nil)
 	d := mkDeployerMock(t)
 	e, _ := executor.NewNixOSFlake()
 	bc := NewConfirmer(bk, Without, 0, "")
 	bc.Start()
 	dc := NewConfirmer(bk, Without, 0, "")
 	dc.Start()
-	m := New(s, prometheus.New(), scheduler.New(), f, b, d, "the-test-machine-id", "", e, bc, dc, bk, emptyConfigurationOperations)
+	m := New(s, prometheus.New(), scheduler.New(), f, b, d, "the-test-machine-id", "", e, bc, dc, bk, emptyConfigurationOperations, nil, nil, nil)
 	go m.Run(t.Context())
 
 	f.TriggerFetch([]string{"remote"})
@@ -255,6 +344,13 @@ func TestIncorrectMachineId(t *testing.T) {
 	assert.EventuallyWithT(t, func(c *assert.CollectT) {
 		assert.False(t, m.GetState().Builder.IsBuilding.GetValue())
 	}, 5*time.Second, 100*time.Millisecond)
+
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		g := m.GetState().Builder.Generation
+		assert.NotNil(c, g)
+		assert.Equal(c, store.BuildSkipped.String(), g.GetBuildStatus())
+		assert.Contains(c, g.GetBuildReason(), builder.BuildReasonMachineIdMismatch)
+	}, 5*time.Second, 100*time.Millisecond)
 }
 
 func TestCorrectMachineId(t *testing.T) {
@@ -266,17 +362,17 @@ func TestCorrectMachineId(t *testing.T) {
 	f := fetcher.NewFetcher(r, bk)
 	f.Start(t.Context())
 
-	s, _ := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1)
+	s, _ := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
 	eMock := NewExecutorMock("the-test-machine-id")
 	eMock.evalOk <- true
-	b := builder.New(s, eMock, "repoPath", "", "", "my-machine", false, 2*time.Second, 2*time.Second)
+	b := builder.New(s, eMock, "repoPath", "", "", "my-machine", false, 2*time.Second, 2*time.Second, 0, nil, nil, nil)
 	d := mkDeployerMock(t)
 	e, _ := executor.NewNixOSFlake()
 	bc := NewConfirmer(bk, Without, 0, "")
 	bc.Start()
 	dc := NewConfirmer(bk, Without, 0, "")
 	dc.Start()
-	m := New(s, prometheus.New(), scheduler.New(), f, b, d, "the-test-machine-id", "", e, bc, dc, bk, emptyConfigurationOperations)
+	m := New(s, prometheus.New(), scheduler.New(), f, b, d, "the-test-machine-id", "", e, bc, dc, bk, emptyConfigurationOperations, nil, nil, nil)
 	go m.Run(t.Context())
 
 	f.TriggerFetch([]string{"remote"})
@@ -298,8 +394,8 @@ func TestManagerWithDarwinConfiguration(t *testing.T) {
 	bk.Start()
 	f := fetcher.NewFetcher(r, bk)
 
-	s, _ := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1)
-	b := builder.New(s, eMock, "repoPath", "", "", "my-machine", false, 2*time.Second, 2*time.Second)
+	s, _ := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
+	b := builder.New(s, eMock, "repoPath", "", "", "my-machine", false, 2*time.Second, 2*time.Second, 0, nil, nil, nil)
 	d := mkDeployerMock(t)
 
 	// Test with Darwin configuration
@@ -308,7 +404,7 @@ func TestManagerWithDarwinConfiguration(t *testing.T) {
 	bc.Start()
 	dc := NewConfirmer(bk, Without, 0, "")
 	dc.Start()
-	m := New(s, prometheus.New(), scheduler.New(), f, b, d, "darwin-machine-id", "", e, bc, dc, bk, emptyConfigurationOperations)
+	m := New(s, prometheus.New(), scheduler.New(), f, b, d, "darwin-machine-id", "", e, bc, dc, bk, emptyConfigurationOperations, nil, nil, nil)
 
 	// Verify the manager was created with the correct configuration attribute
 	assert.Equal(t, "darwin-machine-id", m.machineId)
@@ -0,0 +1,52 @@
+package manager
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/sirupsen/logrus"
+)
+
+// criticalUnitsHeldReason returns the reason why the deployment of a
+// generation has to be held because of its unit changes, or an empty
+// string. When critical units are configured, a generation whose unit
+// changes have not been predicted is held since they could touch one
+// of them.
+func criticalUnitsHeldReason(changes *protobuf.UnitChanges, patterns []string) string {
+	if len(patterns) == 0 {
+		return ""
+	}
+	if changes == nil {
+		return "the unit change prediction is unavailable"
+	}
+	if units := criticalUnitChanges(changes, patterns); len(units) > 0 {
+		return fmt.Sprintf("the critical units %s would be stopped or restarted", strings.Join(units, ", "))
+	}
+	return ""
+}
+
+// criticalUnitChanges returns the units which would be stopped or
+// restarted and match one of the critical unit patterns. Patterns are
+// shell patterns such as "sshd.service" or "network-*.service".
+func criticalUnitChanges(changes *protobuf.UnitChanges, patterns []string) (units []string) {
+	if changes == nil {
+		return
+	}
+	for _, u := range slices.Concat(changes.Stop, changes.Restart) {
+		for _, p := range patterns {
+			matched, err := path.Match(p, u)
+			if err != nil {
+				logrus.Errorf("manager: invalid critical unit pattern '%s': %s", p, err)
+				continue
+			}
+			if matched && !slices.Contains(units, u) {
+				units = append(units, u)
+				break
+			}
+		}
+	}
+	return
+}
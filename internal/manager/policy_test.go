@@ -0,0 +1,31 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCriticalUnitChanges(t *testing.T) {
+	changes := &protobuf.UnitChanges{
+		Stop:    []string{"network-addresses-eth0.service"},
+		Restart: []string{"sshd.service", "nginx.service"},
+		Reload:  []string{"dbus.service"},
+		Start:   []string{"new.service"},
+	}
+	patterns := []string{"sshd.service", "network-*.service", "dbus.service", "new.service"}
+	assert.Equal(t, []string{"network-addresses-eth0.service", "sshd.service"}, criticalUnitChanges(changes, patterns))
+	assert.Nil(t, criticalUnitChanges(changes, nil))
+	assert.Nil(t, criticalUnitChanges(nil, patterns))
+}
+
+func TestCriticalUnitsHeldReason(t *testing.T) {
+	changes := &protobuf.UnitChanges{Restart: []string{"sshd.service"}}
+	assert.Equal(t, "the critical units sshd.service would be stopped or restarted", criticalUnitsHeldReason(changes, []string{"sshd.service"}))
+	assert.Equal(t, "", criticalUnitsHeldReason(changes, []string{"nginx.service"}))
+	assert.Equal(t, "", criticalUnitsHeldReason(nil, nil))
+	// The units changed by a generation whose prediction failed
+	// are unknown
+	assert.Equal(t, "the unit change prediction is unavailable", criticalUnitsHeldReason(nil, []string{"nginx.service"}))
+}
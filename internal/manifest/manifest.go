@@ -0,0 +1,209 @@
+// Package manifest reads the manifests published by a CI. A manifest
+// maps hostnames to the toplevel store path of their configuration
+// for a commit. It allows comin to deploy a configuration without
+// evaluating it.
+//
+// A manifest is a JSON file such as
+//
+//	{
+//	  "commit_id": "f0a4d1...",
+//	  "hosts": {
+//	    "machine": {
+//	      "out_path": "/nix/store/...-nixos-system-machine",
+//	      "machine_id": "..."
+//	    }
+//	  }
+//	}
+//
+// It has to be signed by a detached armored GPG signature stored next
+// to it, with the .asc extension. When the manifest is fetched from an
+// URL, its commit_id has to be the deployed commit ID. When it is read
+// from the repository, its commit_id has to be the parent of the
+// deployed commit since the manifest is committed on top of the
+// commit it describes.
+package manifest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/sirupsen/logrus"
+)
+
+// CommitIdPlaceholder is replaced by the commit ID in manifest URLs.
+const CommitIdPlaceholder = "{commit_id}"
+
+type Host struct {
+	OutPath   string `json:"out_path"`
+	MachineId string `json:"machine_id"`
+}
+
+type Manifest struct {
+	CommitId string          `json:"commit_id"`
+	Hosts    map[string]Host `json:"hosts"`
+}
+
+// Source is the location of manifests: either a file in the
+// repository or an HTTP URL.
+type Source struct {
+	// path is the manifest file path relative to the repository
+	// root directory
+	path string
+	// url is the manifest URL which can contain the commit ID
+	// placeholder
+	url     string
+	keyring openpgp.EntityList
+}
+
+func New(path, url string, publicKeyPaths []string) (*Source, error) {
+	if (path == "") == (url == "") {
+		return nil, fmt.Errorf("manifest: exactly one of the manifest path or URL has to be set")
+	}
+	if len(publicKeyPaths) == 0 {
+		return nil, fmt.Errorf("manifest: at least one GPG public key is required to verify manifests")
+	}
+	var keyring openpgp.EntityList
+	for _, p := range publicKeyPaths {
+		f, err := os.Open(p)
+		if err != nil {
+			return nil, fmt.Errorf("manifest: failed to open the GPG public key file %s: %w", p, err)
+		}
+		entities, err := openpgp.ReadArmoredKeyRing(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("manifest: failed to read the GPG public key %s: %w", p, err)
+		}
+		keyring = append(keyring, entities...)
+	}
+	logrus.Infof("manifest: creating a manifest source with path=%s url=%s", path, url)
+	return &Source{
+		path:    path,
+		url:     url,
+		keyring: keyring,
+	}, nil
+}
+
+// String returns the location of manifests. It is recorded as the
+// evaluation source of generations.
+func (s *Source) String() string {
+	if s.path != "" {
+		return "manifest " + s.path
+	}
+	return "manifest " + s.url
+}
+
+// Eval reads the manifest of commitId and returns the out path and
+// the expected machine-id of hostname. It has the signature of
+// executor.EvalFunc in order to replace the evaluation of the
+// configuration.
+func (s *Source) Eval(ctx context.Context, repositoryPath, repositorySubdir, commitId, systemAttr, hostname string, submodules bool, logs io.Writer) (drvPath string, outPath string, machineId string, err error) {
+	_, _ = fmt.Fprintf(logs, "comin: reading the %s for the commit %s\n", s, commitId)
+	m, signedBy, err := s.Get(ctx, repositoryPath, commitId)
+	if err != nil {
+		return
+	}
+	_, _ = fmt.Fprintf(logs, "comin: the manifest is signed by %s\n", signedBy)
+	host, ok := m.Hosts[hostname]
+	if !ok || host.OutPath == "" {
+		err = fmt.Errorf("manifest: the manifest of the commit %s does not contain the host %s", commitId, hostname)
+		return
+	}
+	_, _ = fmt.Fprintf(logs, "comin: the out path of %s is %s\n", hostname, host.OutPath)
+	return "", host.OutPath, host.MachineId, nil
+}
+
+// Get fetches the manifest of commitId, verifies its signature and
+// returns it with the name of its signer.
+func (s *Source) Get(ctx context.Context, repositoryPath, commitId string) (m Manifest, signedBy string, err error) {
+	var data, signature []byte
+	// The commit ID the manifest has to describe
+	expected := commitId
+	if s.path != "" {
+		data, signature, expected, err = readFromRepository(repositoryPath, commitId, s.path)
+	} else {
+		url := strings.ReplaceAll(s.url, CommitIdPlaceholder, commitId)
+		data, signature, err = readFromUrl(ctx, url)
+	}
+	if err != nil {
+		return
+	}
+	signer, err := openpgp.CheckArmoredDetachedSignature(s.keyring, bytes.NewReader(data), bytes.NewReader(signature), nil)
+	if err != nil {
+		return m, "", fmt.Errorf("manifest: the signature of the manifest is not valid: %w", err)
+	}
+	signedBy = signer.PrimaryIdentity().Name
+	if err = json.Unmarshal(data, &m); err != nil {
+		return m, "", fmt.Errorf("manifest: failed to parse the manifest: %w", err)
+	}
+	// This prevents the replay of an older signed manifest, which
+	// would downgrade the machine.
+	if m.CommitId != expected {
+		return m, "", fmt.Errorf("manifest: the manifest is for the commit %s while the commit %s is expected", m.CommitId, expected)
+	}
+	return
+}
+
+// readFromRepository reads the manifest from the commit commitId and
+// returns it with the ID of the first parent of this commit. A
+// manifest can not contain the ID of its own commit: it is committed
+// by the CI on top of the commit it describes.
+func readFromRepository(repositoryPath, commitId, path string) (data, signature []byte, parentId string, err error) {
+	r, err := git.PlainOpen(repositoryPath)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("manifest: failed to open the repository %s: %w", repositoryPath, err)
+	}
+	commit, err := r.CommitObject(plumbing.NewHash(commitId))
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("manifest: failed to get the commit %s: %w", commitId, err)
+	}
+	if commit.NumParents() == 0 {
+		return nil, nil, "", fmt.Errorf("manifest: the commit %s containing the manifest has no parent", commitId)
+	}
+	parentId = commit.ParentHashes[0].String()
+	read := func(p string) ([]byte, error) {
+		f, err := commit.File(p)
+		if err != nil {
+			return nil, fmt.Errorf("manifest: failed to get the file %s from the commit %s: %w", p, commitId, err)
+		}
+		content, err := f.Contents()
+		return []byte(content), err
+	}
+	if data, err = read(path); err != nil {
+		return
+	}
+	signature, err = read(path + ".asc")
+	return
+}
+
+func readFromUrl(ctx context.Context, url string) (data, signature []byte, err error) {
+	if data, err = httpGet(ctx, url); err != nil {
+		return
+	}
+	signature, err = httpGet(ctx, url+".asc")
+	return
+}
+
+func httpGet(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("manifest: failed to get %s: %w", url, err)
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("manifest: failed to get %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,164 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+)
+
+// The keys of the repository package tests are reused
+const (
+	privateKeyPath = "../repository/test.private"
+	publicKeyPath  = "../repository/test.public"
+)
+
+func signManifest(t *testing.T, m Manifest) (data, signature []byte) {
+	f, err := os.Open(privateKeyPath)
+	assert.Nil(t, err)
+	defer f.Close() // nolint: errcheck
+	entities, err := openpgp.ReadArmoredKeyRing(f)
+	assert.Nil(t, err)
+	data, err = json.Marshal(m)
+	assert.Nil(t, err)
+	var sig bytes.Buffer
+	err = openpgp.ArmoredDetachSign(&sig, entities[0], bytes.NewReader(data), nil)
+	assert.Nil(t, err)
+	return data, sig.Bytes()
+}
+
+func serveManifest(t *testing.T, data, signature []byte) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/commit-1/manifest.json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(data)
+	})
+	mux.HandleFunc("/commit-1/manifest.json.asc", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(signature)
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestNew(t *testing.T) {
+	_, err := New("", "", []string{publicKeyPath})
+	assert.ErrorContains(t, err, "exactly one")
+	_, err = New("manifest.json", "http://localhost", []string{publicKeyPath})
+	assert.ErrorContains(t, err, "exactly one")
+	_, err = New("manifest.json", "", nil)
+	assert.ErrorContains(t, err, "public key")
+	s, err := New("manifest.json", "", []string{publicKeyPath})
+	assert.Nil(t, err)
+	assert.Equal(t, "manifest manifest.json", s.String())
+}
+
+func TestEvalFromUrl(t *testing.T) {
+	m := Manifest{
+		CommitId: "commit-1",
+		Hosts: map[string]Host{
+			"machine": {OutPath: "/nix/store/aaa-nixos-system-machine", MachineId: "machine-id"},
+		},
+	}
+	data, signature := signManifest(t, m)
+	ts := serveManifest(t, data, signature)
+
+	s, err := New("", ts.URL+"/"+CommitIdPlaceholder+"/manifest.json", []string{publicKeyPath})
+	assert.Nil(t, err)
+	var logs bytes.Buffer
+	drvPath, outPath, machineId, err := s.Eval(t.Context(), "", "", "commit-1", "", "machine", false, &logs)
+	assert.Nil(t, err)
+	assert.Equal(t, "", drvPath)
+	assert.Equal(t, "/nix/store/aaa-nixos-system-machine", outPath)
+	assert.Equal(t, "machine-id", machineId)
+	assert.Contains(t, logs.String(), "the manifest is signed by")
+
+	_, _, _, err = s.Eval(t.Context(), "", "", "commit-1", "", "unknown", false, io.Discard)
+	assert.ErrorContains(t, err, "does not contain the host unknown")
+
+	_, _, _, err = s.Eval(t.Context(), "", "", "commit-2", "", "machine", false, io.Discard)
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestGetInvalid(t *testing.T) {
+	m := Manifest{CommitId: "commit-1"}
+	data, signature := signManifest(t, m)
+
+	// The manifest has been modified after being signed
+	tampered := bytes.Replace(data, []byte("commit-1"), []byte("commit-2"), 1)
+	ts := serveManifest(t, tampered, signature)
+	s, err := New("", ts.URL+"/commit-1/manifest.json", []string{publicKeyPath})
+	assert.Nil(t, err)
+	_, _, err = s.Get(t.Context(), "", "commit-1")
+	assert.ErrorContains(t, err, "signature of the manifest is not valid")
+
+	// The manifest of another commit is served
+	ts = serveManifest(t, data, signature)
+	s, err = New("", ts.URL+"/commit-1/manifest.json", []string{publicKeyPath})
+	assert.Nil(t, err)
+	_, _, err = s.Get(t.Context(), "", "commit-3")
+	assert.ErrorContains(t, err, "while the commit commit-3 is expected")
+}
+
+func TestGetFromRepository(t *testing.T) {
+	dir := t.TempDir()
+	r, err := git.PlainInit(dir, false)
+	assert.Nil(t, err)
+	w, err := r.Worktree()
+	assert.Nil(t, err)
+	commit := func(m Manifest) string {
+		data, signature := signManifest(t, m)
+		assert.Nil(t, os.MkdirAll(filepath.Join(dir, "ci"), 0755))
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, "ci/manifest.json"), data, 0644))
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, "ci/manifest.json.asc"), signature, 0644))
+		_, err = w.Add("ci")
+		assert.Nil(t, err)
+		hash, err := w.Commit("manifest", &git.CommitOptions{
+			Author: &object.Signature{Name: "John Doe", Email: "john@doe.org", When: time.Unix(0, 0)},
+		})
+		assert.Nil(t, err)
+		return hash.String()
+	}
+	// The manifest is committed on top of the commit it describes
+	parent := commit(Manifest{CommitId: "unknown"})
+	child := commit(Manifest{
+		CommitId: parent,
+		Hosts: map[string]Host{
+			"machine": {OutPath: "/nix/store/aaa-nixos-system-machine"},
+		},
+	})
+
+	s, err := New("ci/manifest.json", "", []string{publicKeyPath})
+	assert.Nil(t, err)
+	m, signedBy, err := s.Get(t.Context(), dir, child)
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", signedBy)
+	assert.Equal(t, parent, m.CommitId)
+	assert.Equal(t, "/nix/store/aaa-nixos-system-machine", m.Hosts["machine"].OutPath)
+
+	_, _, err = s.Get(t.Context(), dir, "0000000000000000000000000000000000000000")
+	assert.NotNil(t, err)
+
+	_, _, err = s.Get(t.Context(), dir, parent)
+	assert.ErrorContains(t, err, "has no parent")
+
+	// The signed manifest of the child is replayed in a later
+	// commit to downgrade the machine
+	replayed := commit(Manifest{
+		CommitId: parent,
+		Hosts: map[string]Host{
+			"machine": {OutPath: "/nix/store/aaa-nixos-system-machine"},
+		},
+	})
+	_, _, err = s.Get(t.Context(), dir, replayed)
+	assert.ErrorContains(t, err, "while the commit "+child+" is expected")
+}
@@ -0,0 +1,345 @@
+// Package notifier sends notifications of the comin lifecycle events,
+// such as failed builds or finished deployments, to sinks such as
+// webhooks, chat services or emails. Notifications are stored in a
+// persisted outbox until they are sent, so that they are not lost on
+// transient failures or restarts.
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nlewo/comin/internal/broker"
+	"github.com/nlewo/comin/internal/store"
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	EventEvalFailed          = "eval_failed"
+	EventBuildFailed         = "build_failed"
+	EventDeploymentSucceeded = "deployment_succeeded"
+	EventDeploymentFailed    = "deployment_failed"
+	EventRebootRequired      = "reboot_required"
+	EventConfirmationPending = "confirmation_pending"
+)
+
+// Events are the events which can be notified
+var Events = []string{
+	EventEvalFailed,
+	EventBuildFailed,
+	EventDeploymentSucceeded,
+	EventDeploymentFailed,
+	EventRebootRequired,
+	EventConfirmationPending,
+}
+
+// retryPeriod is the delay before the first retry of a notification.
+// It is doubled at each attempt, up to maxRetryPeriod.
+var retryPeriod = 30 * time.Second
+
+const (
+	maxRetryPeriod = time.Hour
+	// maxAttempts is the number of attempts after which a
+	// notification is dropped
+	maxAttempts = 20
+	// maxOutboxSize is the maximal number of notifications waiting
+	// to be sent. The oldest ones are dropped when it is reached.
+	maxOutboxSize = 1000
+)
+
+// Notification is the data of the sink templates.
+type Notification struct {
+	Event          string    `json:"event"`
+	Hostname       string    `json:"hostname"`
+	Summary        string    `json:"summary"`
+	GenerationUuid string    `json:"generation_uuid,omitempty"`
+	RemoteName     string    `json:"remote_name,omitempty"`
+	BranchName     string    `json:"branch_name,omitempty"`
+	CommitId       string    `json:"commit_id,omitempty"`
+	Operation      string    `json:"operation,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	Reasons        []string  `json:"reasons,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type entry struct {
+	Id            string       `json:"id"`
+	Sink          string       `json:"sink"`
+	Notification  Notification `json:"notification"`
+	Attempts      int          `json:"attempts"`
+	NextAttemptAt time.Time    `json:"next_attempt_at"`
+}
+
+type Notifier struct {
+	broker     *broker.Broker
+	hostname   string
+	sinks      map[string]*Sink
+	outboxPath string
+
+	mu     sync.Mutex
+	outbox []entry
+	wakeup chan struct{}
+}
+
+// New returns a notifier loading the notifications which were not
+// sent from the outbox file.
+func New(broker *broker.Broker, hostname string, sinks []*Sink, outboxPath string) (*Notifier, error) {
+	n := &Notifier{
+		broker:     broker,
+		hostname:   hostname,
+		sinks:      make(map[string]*Sink),
+		outboxPath: outboxPath,
+		wakeup:     make(chan struct{}, 1),
+	}
+	for _, s := range sinks {
+		n.sinks[s.Name] = s
+	}
+	content, err := os.ReadFile(outboxPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return n, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("notifier: failed to read the outbox: %w", err)
+	}
+	if err := json.Unmarshal(content, &n.outbox); err != nil {
+		return nil, fmt.Errorf("notifier: failed to parse the outbox %s: %w", outboxPath, err)
+	}
+	if len(n.outbox) > 0 {
+		logrus.Infof("notifier: %d notifications are waiting to be sent", len(n.outbox))
+	}
+	return n, nil
+}
+
+func (n *Notifier) Start(ctx context.Context) {
+	events := n.broker.Subscribe()
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				n.broker.Unsubscribe(events)
+				return
+			case e := <-events:
+				if notification, ok := n.notificationOf(e); ok {
+					n.enqueue(notification)
+				}
+			}
+		}
+	}()
+	go n.send(ctx)
+}
+
+// notificationOf returns the notification of an event, if this event
+// can be notified.
+func (n *Notifier) notificationOf(e *protobuf.Event) (notification Notification, ok bool) {
+	var g *protobuf.Generation
+	switch {
+	case e.GetEvalFinishedType() != nil:
+		g = e.GetEvalFinishedType().GetGeneration()
+		if g.GetEvalStatus() != store.EvalFailed.String() {
+			return notification, false
+		}
+		notification.Event = EventEvalFailed
+		notification.Summary = fmt.Sprintf("The evaluation of the commit %s failed", g.GetSelectedCommitId())
+		notification.Error = g.GetEvalErr()
+	case e.GetBuildFinishedType() != nil:
+		g = e.GetBuildFinishedType().GetGeneration()
+		if g.GetBuildStatus() != store.BuildFailed.String() {
+			return notification, false
+		}
+		notification.Event = EventBuildFailed
+		notification.Summary = fmt.Sprintf("The build of the commit %s failed", g.GetSelectedCommitId())
+		notification.Error = g.GetBuildErr()
+	case e.GetDeploymentFinishedType() != nil:
+		d := e.GetDeploymentFinishedType().GetDeployment()
+		g = d.GetGeneration()
+		notification.Operation = d.GetOperation()
+		if d.GetStatus() == store.StatusToString(store.Failed) {
+			notification.Event = EventDeploymentFailed
+			notification.Summary = fmt.Sprintf("The deployment of the commit %s failed", g.GetSelectedCommitId())
+			notification.Error = d.GetErrorMsg()
+		} else {
+			notification.Event = EventDeploymentSucceeded
+			notification.Summary = fmt.Sprintf("The commit %s has been deployed with the %s operation", g.GetSelectedCommitId(), d.GetOperation())
+		}
+	case e.GetRebootRequired() != nil:
+		g = e.GetRebootRequired().GetDeployment().GetGeneration()
+		notification.Event = EventRebootRequired
+		notification.Reasons = e.GetRebootRequired().GetReasons()
+		notification.Summary = "A reboot is required"
+		if len(notification.Reasons) > 0 {
+			notification.Summary += " because " + strings.Join(notification.Reasons, ", ")
+		}
+	case e.GetConfirmationSubmittedType() != nil:
+		c := e.GetConfirmationSubmittedType()
+		notification.Event = EventConfirmationPending
+		notification.GenerationUuid = c.GetUuid()
+		notification.Summary = fmt.Sprintf("The %s of the generation %s waits for a confirmation", c.GetFor(), c.GetUuid())
+		if c.GetHeldReason() != "" {
+			notification.Summary += " because " + c.GetHeldReason()
+		}
+	default:
+		return notification, false
+	}
+	if g != nil {
+		notification.GenerationUuid = g.GetUuid()
+		notification.RemoteName = g.GetSelectedRemoteName()
+		notification.BranchName = g.GetSelectedBranchName()
+		notification.CommitId = g.GetSelectedCommitId()
+	}
+	notification.Hostname = n.hostname
+	notification.CreatedAt = e.GetCreatedAt().AsTime()
+	return notification, true
+}
+
+// enqueue adds the notification to the outbox for each sink accepting
+// its event.
+func (n *Notifier) enqueue(notification Notification) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	now := time.Now()
+	for name, s := range n.sinks {
+		if !s.accepts(notification.Event) {
+			continue
+		}
+		if len(n.outbox) >= maxOutboxSize {
+			logrus.Warningf("notifier: the outbox is full, the %s notification for the sink %s is dropped", n.outbox[0].Notification.Event, n.outbox[0].Sink)
+			n.outbox = n.outbox[1:]
+		}
+		n.outbox = append(n.outbox, entry{
+			Id:            fmt.Sprintf("%d-%s", now.UnixNano(), name),
+			Sink:          name,
+			Notification:  notification,
+			NextAttemptAt: now,
+		})
+	}
+	n.persist()
+	select {
+	case n.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// persist writes the outbox to its file. It must be called with the
+// lock held.
+func (n *Notifier) persist() {
+	content, err := json.Marshal(n.outbox)
+	if err != nil {
+		logrus.Errorf("notifier: failed to marshal the outbox: %s", err)
+		return
+	}
+	tmp := n.outboxPath + ".tmp"
+	if err := os.WriteFile(tmp, content, 0600); err != nil {
+		logrus.Errorf("notifier: failed to write the outbox: %s", err)
+		return
+	}
+	if err := os.Rename(tmp, n.outboxPath); err != nil {
+		logrus.Errorf("notifier: failed to write the outbox: %s", err)
+	}
+}
+
+// due returns the entries to send now and the time of the next
+// attempt of the other ones.
+func (n *Notifier) due(now time.Time) (entries []entry, next time.Time) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, e := range n.outbox {
+		if !e.NextAttemptAt.After(now) {
+			entries = append(entries, e)
+		} else if next.IsZero() || e.NextAttemptAt.Before(next) {
+			next = e.NextAttemptAt
+		}
+	}
+	return
+}
+
+// done updates the outbox with the result of an attempt.
+func (n *Notifier) done(e entry, err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	i := -1
+	for j, o := range n.outbox {
+		if o.Id == e.Id {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		return
+	}
+	if err == nil {
+		n.outbox = append(n.outbox[:i], n.outbox[i+1:]...)
+		n.persist()
+		return
+	}
+	e.Attempts++
+	if e.Attempts >= maxAttempts {
+		logrus.Errorf("notifier: the %s notification for the sink %s is dropped after %d attempts: %s", e.Notification.Event, e.Sink, e.Attempts, err)
+		n.outbox = append(n.outbox[:i], n.outbox[i+1:]...)
+		n.persist()
+		return
+	}
+	delay := retryPeriod << (e.Attempts - 1)
+	if delay > maxRetryPeriod || delay <= 0 {
+		delay = maxRetryPeriod
+	}
+	e.NextAttemptAt = time.Now().Add(delay)
+	logrus.Errorf("notifier: failed to send the %s notification to the sink %s, retrying in %s: %s", e.Notification.Event, e.Sink, delay, err)
+	n.outbox[i] = e
+	n.persist()
+}
+
+func (n *Notifier) send(ctx context.Context) {
+	for {
+		entries, next := n.due(time.Now())
+		for _, e := range entries {
+			s, ok := n.sinks[e.Sink]
+			if !ok {
+				logrus.Warningf("notifier: the sink %s of the %s notification no longer exists", e.Sink, e.Notification.Event)
+				n.done(e, nil)
+				continue
+			}
+			message, err := s.render(e.Notification)
+			if err != nil {
+				// A template failure is not transient
+				logrus.Error(err)
+				n.done(e, nil)
+				continue
+			}
+			ctx, cancel := context.WithTimeout(ctx, time.Minute)
+			err = s.sender.send(ctx, e.Id, e.Notification, message)
+			cancel()
+			if err == nil {
+				logrus.Debugf("notifier: the %s notification has been sent to the sink %s", e.Notification.Event, e.Sink)
+			}
+			n.done(e, err)
+		}
+		if len(entries) > 0 {
+			// Entries may have been rescheduled
+			continue
+		}
+		var retry <-chan time.Time
+		if !next.IsZero() {
+			retry = time.After(time.Until(next))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-n.wakeup:
+		case <-retry:
+		}
+	}
+}
+
+// OutboxPath returns the path of the outbox file in the state
+// directory.
+func OutboxPath(stateDir string) string {
+	return filepath.Join(stateDir, "notifier-outbox.json")
+}
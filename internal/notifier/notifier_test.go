@@ -0,0 +1,137 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nlewo/comin/internal/broker"
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type senderMock struct {
+	mu       sync.Mutex
+	down     bool
+	messages []string
+}
+
+func (s *senderMock) send(ctx context.Context, id string, n Notification, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down {
+		return errors.New("the sink is down")
+	}
+	s.messages = append(s.messages, message)
+	return nil
+}
+
+func (s *senderMock) setDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+}
+
+func (s *senderMock) sent() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.messages...)
+}
+
+func mkSink(t *testing.T, name string, events []string) (*Sink, *senderMock) {
+	tmpl, err := ParseTemplate("")
+	assert.Nil(t, err)
+	s := &senderMock{}
+	return &Sink{Name: name, Events: events, template: tmpl, sender: s}, s
+}
+
+func deploymentFinished(commitId, status string) *protobuf.Event {
+	d := &protobuf.Deployment{
+		Generation: &protobuf.Generation{Uuid: "uuid", SelectedCommitId: commitId},
+		Operation:  "switch",
+		Status:     status,
+		ErrorMsg:   "boom",
+	}
+	return &protobuf.Event{Type: &protobuf.Event_DeploymentFinishedType{DeploymentFinishedType: &protobuf.Event_DeploymentFinished{Deployment: d}}, CreatedAt: timestamppb.Now()}
+}
+
+func TestNotificationOf(t *testing.T) {
+	n := &Notifier{hostname: "host"}
+	notification, ok := n.notificationOf(deploymentFinished("abc", "failed"))
+	assert.True(t, ok)
+	assert.Equal(t, EventDeploymentFailed, notification.Event)
+	assert.Equal(t, "host", notification.Hostname)
+	assert.Equal(t, "abc", notification.CommitId)
+	assert.Equal(t, "boom", notification.Error)
+
+	notification, ok = n.notificationOf(&protobuf.Event{Type: &protobuf.Event_ConfirmationSubmittedType{
+		ConfirmationSubmittedType: &protobuf.Event_ConfirmationSubmitted{Uuid: "uuid", For: "deploy", HeldReason: "sshd.service would be restarted"}}})
+	assert.True(t, ok)
+	assert.Equal(t, "The deploy of the generation uuid waits for a confirmation because sshd.service would be restarted", notification.Summary)
+
+	g := &protobuf.Generation{BuildStatus: "built"}
+	_, ok = n.notificationOf(&protobuf.Event{Type: &protobuf.Event_BuildFinishedType{BuildFinishedType: &protobuf.Event_BuildFinished{Generation: g}}})
+	assert.False(t, ok)
+	_, ok = n.notificationOf(&protobuf.Event{Type: &protobuf.Event_Suspend_{Suspend: &protobuf.Event_Suspend{}}})
+	assert.False(t, ok)
+}
+
+func TestNotifier(t *testing.T) {
+	retryPeriod = 100 * time.Millisecond
+	t.Cleanup(func() { retryPeriod = 30 * time.Second })
+	outbox := filepath.Join(t.TempDir(), "outbox.json")
+	bk := broker.New()
+	bk.Start()
+	all, allSender := mkSink(t, "all", nil)
+	failures, failuresSender := mkSink(t, "failures", []string{EventDeploymentFailed})
+	n, err := New(bk, "host", []*Sink{all, failures}, outbox)
+	assert.Nil(t, err)
+	n.Start(t.Context())
+
+	bk.Publish(deploymentFinished("abc", "done"))
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, []string{"[host] The commit abc has been deployed with the switch operation"}, allSender.sent())
+	}, 2*time.Second, 50*time.Millisecond)
+	assert.Empty(t, failuresSender.sent())
+
+	// Notifications are retried while the sink is down
+	failuresSender.setDown(true)
+	bk.Publish(deploymentFinished("def", "failed"))
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Len(c, allSender.sent(), 2)
+		entries, _ := n.due(time.Now().Add(time.Hour))
+		if assert.Len(c, entries, 1) {
+			assert.Greater(c, entries[0].Attempts, 0)
+		}
+	}, 2*time.Second, 50*time.Millisecond)
+	failuresSender.setDown(false)
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, []string{"[host] The deployment of the commit def failed"}, failuresSender.sent())
+	}, 2*time.Second, 50*time.Millisecond)
+	entries, _ := n.due(time.Now().Add(time.Hour))
+	assert.Empty(t, entries)
+}
+
+func TestNotifierOutboxPersistence(t *testing.T) {
+	outbox := filepath.Join(t.TempDir(), "outbox.json")
+	sink, _ := mkSink(t, "all", nil)
+	n, err := New(nil, "host", []*Sink{sink}, outbox)
+	assert.Nil(t, err)
+	notification, _ := n.notificationOf(deploymentFinished("abc", "failed"))
+	n.enqueue(notification)
+
+	// The notification is sent by the next comin instance
+	sink, sender := mkSink(t, "all", nil)
+	bk := broker.New()
+	bk.Start()
+	n, err = New(bk, "host", []*Sink{sink}, outbox)
+	assert.Nil(t, err)
+	n.Start(t.Context())
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, []string{"[host] The deployment of the commit abc failed"}, sender.sent())
+	}, 2*time.Second, 50*time.Millisecond)
+}
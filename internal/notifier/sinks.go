@@ -0,0 +1,280 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/nlewo/comin/internal/types"
+)
+
+// SinkTypes are the supported sink types
+var SinkTypes = []string{"webhook", "slack", "matrix", "ntfy", "smtp"}
+
+// DefaultTemplate is the template of the messages of the sinks
+// without template
+const DefaultTemplate = "[{{.Hostname}}] {{.Summary}}"
+
+// Sink is a destination of the notifications.
+type Sink struct {
+	Name string
+	// Events are the events notified to this sink. All events are
+	// notified when it is empty.
+	Events   []string
+	template *template.Template
+	sender   sender
+}
+
+type sender interface {
+	// send sends a notification. The id is the same for all the
+	// attempts of a notification.
+	send(ctx context.Context, id string, n Notification, message string) error
+}
+
+// ParseTemplate parses a sink template whose data is a Notification.
+func ParseTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultTemplate
+	}
+	return template.New("notification").Parse(text)
+}
+
+func NewSink(cfg types.NotifierSink) (*Sink, error) {
+	tmpl, err := ParseTemplate(cfg.Template)
+	if err != nil {
+		return nil, fmt.Errorf("notifier: the template of the sink %s is invalid: %w", cfg.Name, err)
+	}
+	for _, e := range cfg.Events {
+		if !slices.Contains(Events, e) {
+			return nil, fmt.Errorf("notifier: the event '%s' of the sink %s is not one of '%s'", e, cfg.Name, Events)
+		}
+	}
+	timeout := 30 * time.Second
+	client := &http.Client{Timeout: timeout}
+	var s sender
+	switch cfg.Type {
+	case "webhook":
+		s = &webhook{client: client, url: cfg.Url, token: cfg.Token}
+	case "slack":
+		s = &slack{client: client, url: cfg.Url}
+	case "matrix":
+		s = &matrix{client: client, url: strings.TrimSuffix(cfg.Url, "/"), room: cfg.Room, token: cfg.Token}
+	case "ntfy":
+		s = &ntfy{client: client, url: cfg.Url, token: cfg.Token}
+	case "smtp":
+		s = &mail{
+			addr:     net.JoinHostPort(cfg.Smtp.Host, strconv.Itoa(cfg.Smtp.Port)),
+			host:     cfg.Smtp.Host,
+			username: cfg.Smtp.Username,
+			password: cfg.Smtp.Password,
+			from:     cfg.Smtp.From,
+			to:       cfg.Smtp.To,
+			timeout:  timeout,
+		}
+	default:
+		return nil, fmt.Errorf("notifier: the type '%s' of the sink %s is not one of '%s'", cfg.Type, cfg.Name, SinkTypes)
+	}
+	return &Sink{Name: cfg.Name, Events: cfg.Events, template: tmpl, sender: s}, nil
+}
+
+func (s *Sink) accepts(event string) bool {
+	return len(s.Events) == 0 || slices.Contains(s.Events, event)
+}
+
+func (s *Sink) render(n Notification) (string, error) {
+	var b strings.Builder
+	if err := s.template.Execute(&b, n); err != nil {
+		return "", fmt.Errorf("notifier: failed to render the template of the sink %s: %w", s.Name, err)
+	}
+	return b.String(), nil
+}
+
+func do(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notifier: failed to send the notification to %s: %w", req.URL.Redacted(), err)
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notifier: failed to send the notification to %s: %s: %s", req.URL.Redacted(), resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func postJSON(ctx context.Context, client *http.Client, method, url, token string, body any) error {
+	content, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return do(client, req)
+}
+
+// webhook posts the notification as JSON with the rendered message
+type webhook struct {
+	client *http.Client
+	url    string
+	token  string
+}
+
+func (w *webhook) send(ctx context.Context, id string, n Notification, message string) error {
+	payload := struct {
+		Notification
+		Message string `json:"message"`
+	}{n, message}
+	return postJSON(ctx, w.client, http.MethodPost, w.url, w.token, payload)
+}
+
+// slack posts the message to a Slack incoming webhook. Mattermost
+// and Discord (with the /slack URL suffix) incoming webhooks are
+// compatible.
+type slack struct {
+	client *http.Client
+	url    string
+}
+
+func (s *slack) send(ctx context.Context, id string, n Notification, message string) error {
+	return postJSON(ctx, s.client, http.MethodPost, s.url, "", map[string]string{"text": message})
+}
+
+// matrix sends the message to a room of a Matrix homeserver
+type matrix struct {
+	client *http.Client
+	url    string
+	room   string
+	token  string
+}
+
+func (m *matrix) send(ctx context.Context, id string, n Notification, message string) error {
+	// The transaction ID makes retries idempotent
+	u := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/comin-%s", m.url, url.PathEscape(m.room), id)
+	return postJSON(ctx, m.client, http.MethodPut, u, m.token, map[string]string{"msgtype": "m.text", "body": message})
+}
+
+// ntfy publishes the message to a ntfy topic URL
+type ntfy struct {
+	client *http.Client
+	url    string
+	token  string
+}
+
+func (s *ntfy) send(ctx context.Context, id string, n Notification, message string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, strings.NewReader(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", "comin on "+n.Hostname)
+	req.Header.Set("Tags", n.Event)
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	return do(s.client, req)
+}
+
+// mail sends the message by email. The first line of the message is
+// the subject.
+type mail struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+	to       []string
+	// timeout is the maximal duration of the SMTP session
+	timeout time.Duration
+}
+
+func (m *mail) message(message string, date time.Time) []byte {
+	subject, _, _ := strings.Cut(message, "\n")
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", m.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(m.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(message, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
+
+func (m *mail) send(ctx context.Context, id string, n Notification, message string) error {
+	if err := m.deliver(ctx, m.message(message, n.CreatedAt)); err != nil {
+		return fmt.Errorf("notifier: failed to send the email with %s: %w", m.addr, err)
+	}
+	return nil
+}
+
+// deliver delivers msg as smtp.SendMail does. Since the sinks are
+// notified one after the other, the SMTP session is bound to ctx and
+// has a deadline: a hung SMTP server must not block the other sinks.
+func (m *mail) deliver(ctx context.Context, msg []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close() // nolint: errcheck
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	// The session is interrupted when ctx is canceled
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		return err
+	}
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+	if m.username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(m.from); err != nil {
+		return err
+	}
+	for _, to := range m.to {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
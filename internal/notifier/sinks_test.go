@@ -0,0 +1,172 @@
+package notifier
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nlewo/comin/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type request struct {
+	method  string
+	path    string
+	headers http.Header
+	body    string
+}
+
+func fakeServer(t *testing.T, status int) (*httptest.Server, chan request) {
+	requests := make(chan request, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{method: r.Method, path: r.URL.EscapedPath(), headers: r.Header, body: string(body)}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, requests
+}
+
+func TestSinks(t *testing.T) {
+	n := Notification{Event: EventBuildFailed, Hostname: "host", Summary: "The build failed", CommitId: "abc"}
+	for _, tc := range []struct {
+		cfg    types.NotifierSink
+		method string
+		path   string
+		body   string
+		header [2]string
+	}{
+		{types.NotifierSink{Type: "webhook", Token: "secret"}, "POST", "/",
+			`{"event":"build_failed","hostname":"host","summary":"The build failed","commit_id":"abc","created_at":"0001-01-01T00:00:00Z","message":"[host] The build failed"}`,
+			[2]string{"Authorization", "Bearer secret"}},
+		{types.NotifierSink{Type: "slack", Template: "{{.Summary}} on {{.Hostname}}"}, "POST", "/",
+			`{"text":"The build failed on host"}`,
+			[2]string{"Content-Type", "application/json"}},
+		{types.NotifierSink{Type: "matrix", Room: "!room:example.com", Token: "secret"}, "PUT", "/_matrix/client/v3/rooms/%21room:example.com/send/m.room.message/comin-1",
+			`{"body":"[host] The build failed","msgtype":"m.text"}`,
+			[2]string{"Authorization", "Bearer secret"}},
+		{types.NotifierSink{Type: "ntfy"}, "POST", "/",
+			`[host] The build failed`,
+			[2]string{"Title", "comin on host"}},
+	} {
+		srv, requests := fakeServer(t, http.StatusOK)
+		tc.cfg.Name = tc.cfg.Type
+		tc.cfg.Url = srv.URL
+		s, err := NewSink(tc.cfg)
+		assert.Nil(t, err)
+		message, err := s.render(n)
+		assert.Nil(t, err)
+		assert.Nil(t, s.sender.send(t.Context(), "1", n, message), tc.cfg.Type)
+		req := <-requests
+		assert.Equal(t, tc.method, req.method, tc.cfg.Type)
+		assert.Equal(t, tc.path, req.path, tc.cfg.Type)
+		assert.Equal(t, tc.body, req.body, tc.cfg.Type)
+		assert.Equal(t, tc.header[1], req.headers.Get(tc.header[0]), tc.cfg.Type)
+	}
+}
+
+func TestSinkError(t *testing.T) {
+	srv, _ := fakeServer(t, http.StatusServiceUnavailable)
+	s, err := NewSink(types.NotifierSink{Name: "hook", Type: "webhook", Url: srv.URL})
+	assert.Nil(t, err)
+	assert.ErrorContains(t, s.sender.send(t.Context(), "1", Notification{}, ""), "503")
+}
+
+func TestNewSink(t *testing.T) {
+	_, err := NewSink(types.NotifierSink{Name: "s", Type: "pigeon"})
+	assert.ErrorContains(t, err, "pigeon")
+	_, err = NewSink(types.NotifierSink{Name: "s", Type: "slack", Events: []string{"lunch"}})
+	assert.ErrorContains(t, err, "lunch")
+	_, err = NewSink(types.NotifierSink{Name: "s", Type: "slack", Template: "{{.Summary"})
+	assert.ErrorContains(t, err, "template")
+
+	s, err := NewSink(types.NotifierSink{Name: "s", Type: "slack", Events: []string{EventBuildFailed}})
+	assert.Nil(t, err)
+	assert.True(t, s.accepts(EventBuildFailed))
+	assert.False(t, s.accepts(EventDeploymentSucceeded))
+}
+
+func TestMailMessage(t *testing.T) {
+	m := &mail{from: "comin@example.com", to: []string{"a@example.com", "b@example.com"}}
+	date := time.Date(2025, time.January, 6, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, "From: comin@example.com\r\n"+
+		"To: a@example.com, b@example.com\r\n"+
+		"Subject: [host] The build failed\r\n"+
+		"Date: Mon, 06 Jan 2025 12:00:00 +0000\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n\r\n"+
+		"[host] The build failed\r\nerror: boom\r\n",
+		string(m.message("[host] The build failed\nerror: boom", date)))
+}
+
+// fakeSmtpServer accepts a single SMTP session. When hung is true, it
+// never answers. The received message is sent to the returned channel.
+func fakeSmtpServer(t *testing.T, hung bool) (string, chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close() // nolint: errcheck
+		if hung {
+			_, _ = io.Copy(io.Discard, conn)
+			return
+		}
+		r := bufio.NewReader(conn)
+		write := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+		write("220 localhost ESMTP")
+		var data strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"):
+				write("250 localhost")
+			case cmd == "DATA":
+				write("354 go ahead")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				messages <- data.String()
+				write("250 ok")
+			case cmd == "QUIT":
+				write("221 bye")
+				return
+			default:
+				write("250 ok")
+			}
+		}
+	}()
+	return l.Addr().String(), messages
+}
+
+func TestMailSend(t *testing.T) {
+	addr, messages := fakeSmtpServer(t, false)
+	m := &mail{addr: addr, host: "127.0.0.1", from: "comin@example.com", to: []string{"a@example.com"}, timeout: 5 * time.Second}
+	err := m.send(t.Context(), "id", Notification{}, "[host] The build failed\nerror: boom")
+	assert.Nil(t, err)
+	assert.Contains(t, <-messages, "Subject: [host] The build failed\r\n")
+}
+
+func TestMailSendHungServer(t *testing.T) {
+	addr, _ := fakeSmtpServer(t, true)
+	m := &mail{addr: addr, host: "127.0.0.1", from: "comin@example.com", to: []string{"a@example.com"}, timeout: 200 * time.Millisecond}
+	start := time.Now()
+	err := m.send(t.Context(), "id", Notification{}, "message")
+	assert.NotNil(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
@@ -12,9 +12,10 @@ import (
 )
 
 const (
-	systemProfiles  = "/nix/var/nix/profiles/system-profiles"
-	profileName     = "comin"
-	cominProfileDir = systemProfiles + "/" + profileName
+	systemProfiles = "/nix/var/nix/profiles/system-profiles"
+	profileName    = "comin"
+	// CominProfileDir is the profile of the systems deployed by comin
+	CominProfileDir = systemProfiles + "/" + profileName
 )
 
 func RemoveProfiles(bootEntryOutPaths []string) {
@@ -88,9 +89,9 @@ func SetSystemProfile(operation string, outPath string, dryRun bool) (profilePat
 		if err != nil && !os.IsExist(err) {
 			return profilePath, fmt.Errorf("nix: failed to create the profile directory: %s", systemProfiles)
 		}
-		cmdStr := fmt.Sprintf("nix-env --profile %s --set %s", cominProfileDir, outPath)
+		cmdStr := fmt.Sprintf("nix-env --profile %s --set %s", CominProfileDir, outPath)
 		logrus.Infof("nix: running '%s'", cmdStr)
-		cmd := exec.Command("nix-env", "--profile", cominProfileDir, "--set", outPath)
+		cmd := exec.Command("nix-env", "--profile", CominProfileDir, "--set", outPath)
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 		if dryRun {
@@ -101,9 +102,9 @@ func SetSystemProfile(operation string, outPath string, dryRun bool) (profilePat
 				return profilePath, fmt.Errorf("nix: command '%s' fails with %s", cmdStr, err)
 			}
 			logrus.Infof("nix: command '%s' succeeded", cmdStr)
-			dst, err := os.Readlink(cominProfileDir)
+			dst, err := os.Readlink(CominProfileDir)
 			if err != nil {
-				return profilePath, fmt.Errorf("nix: failed to os.Readlink(%s)", cominProfileDir)
+				return profilePath, fmt.Errorf("nix: failed to os.Readlink(%s)", CominProfileDir)
 			}
 			profilePath = path.Join(systemProfiles, dst)
 			logrus.Infof("nix: the profile %s has been created", profilePath)
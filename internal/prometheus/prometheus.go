@@ -2,8 +2,11 @@ package prometheus
 
 import (
 	"net/http"
+	"strings"
 
 	brokerPkg "github.com/nlewo/comin/internal/broker"
+	"github.com/nlewo/comin/internal/builder"
+	"github.com/nlewo/comin/internal/store"
 	"github.com/nlewo/comin/pkg/protobuf"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -20,6 +23,13 @@ type Prometheus struct {
 	lastEvalFailed       prometheus.Gauge
 	lastBuildFailed      prometheus.Gauge
 	lastDeploymentFailed prometheus.Gauge
+	machineIdMismatch    prometheus.Gauge
+
+	gcFreedBytes *prometheus.CounterVec
+	gcDuration   *prometheus.GaugeVec
+	lastGcFailed prometheus.Gauge
+
+	insufficientDiskSpace prometheus.Gauge
 }
 
 func New() Prometheus {
@@ -61,6 +71,26 @@ func New() Prometheus {
 		Name: "comin_last_deployment_failed",
 		Help: "Whether the last deployment failed (1) or not (0).",
 	})
+	machineIdMismatch := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "comin_machine_id_mismatch",
+		Help: "Whether the last evaluated generation has been skipped because its machine-id is not the host one (1) or not (0).",
+	})
+	gcFreedBytes := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "comin_gc_freed_bytes_total",
+		Help: "Bytes freed in the Nix store per operation (collect or optimise).",
+	}, []string{"operation"})
+	gcDuration := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "comin_gc_duration_seconds",
+		Help: "Duration of the last Nix store operation (collect or optimise).",
+	}, []string{"operation"})
+	lastGcFailed := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "comin_last_gc_failed",
+		Help: "Whether the last Nix store garbage collection failed (1) or not (0).",
+	})
+	insufficientDiskSpace := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "comin_insufficient_disk_space",
+		Help: "Whether the last build or deployment has been refused because of insufficient disk space (1) or not (0).",
+	})
 	promReg.MustRegister(buildInfo)
 	promReg.MustRegister(deploymentInfo)
 	promReg.MustRegister(fetchCounter)
@@ -70,6 +100,11 @@ func New() Prometheus {
 	promReg.MustRegister(lastEvalFailed)
 	promReg.MustRegister(lastBuildFailed)
 	promReg.MustRegister(lastDeploymentFailed)
+	promReg.MustRegister(machineIdMismatch)
+	promReg.MustRegister(gcFreedBytes)
+	promReg.MustRegister(gcDuration)
+	promReg.MustRegister(lastGcFailed)
+	promReg.MustRegister(insufficientDiskSpace)
 	return Prometheus{
 		promRegistry:         promReg,
 		buildInfo:            buildInfo,
@@ -81,6 +116,12 @@ func New() Prometheus {
 		lastEvalFailed:       lastEvalFailed,
 		lastBuildFailed:      lastBuildFailed,
 		lastDeploymentFailed: lastDeploymentFailed,
+		machineIdMismatch:    machineIdMismatch,
+		gcFreedBytes:         gcFreedBytes,
+		gcDuration:           gcDuration,
+		lastGcFailed:         lastGcFailed,
+
+		insufficientDiskSpace: insufficientDiskSpace,
 	}
 }
 
@@ -99,15 +140,29 @@ func Subscribe(broker *brokerPkg.Broker, metrics *Prometheus) {
 					m.GetEvalFinishedType().GetGeneration().GetEvalStatus() == "failed",
 				))
 
+			case m.GetBuildStartedType() != nil:
+				metrics.machineIdMismatch.Set(boolToFloat64(false))
+
+			case m.GetBuildSkippedType() != nil:
+				metrics.machineIdMismatch.Set(boolToFloat64(
+					strings.HasPrefix(m.GetBuildSkippedType().GetGeneration().GetBuildReason(), builder.BuildReasonMachineIdMismatch),
+				))
+
 			case m.GetBuildFinishedType() != nil:
 				metrics.lastBuildFailed.Set(boolToFloat64(
 					m.GetBuildFinishedType().GetGeneration().GetBuildStatus() == "failed",
 				))
+				metrics.insufficientDiskSpace.Set(boolToFloat64(
+					m.GetBuildFinishedType().GetGeneration().GetBuildErrCause() == store.ErrInsufficientDiskSpace.Error(),
+				))
 
 			case m.GetDeploymentFinishedType() != nil:
 				d := m.GetDeploymentFinishedType().GetDeployment()
 				metrics.lastDeploymentFailed.Set(boolToFloat64(d.GetStatus() == "failed"))
 				metrics.SetDeploymentInfo(d.GetGeneration().GetMainCommitId(), d.GetStatus())
+				metrics.insufficientDiskSpace.Set(boolToFloat64(
+					strings.HasPrefix(d.GetErrorMsg(), store.ErrInsufficientDiskSpace.Error()),
+				))
 
 			case m.GetSuspend() != nil:
 				metrics.isSuspended.Set(boolToFloat64(true))
@@ -117,6 +172,9 @@ func Subscribe(broker *brokerPkg.Broker, metrics *Prometheus) {
 
 			case m.GetRebootRequired() != nil:
 				metrics.needToReboot.Set(boolToFloat64(true))
+
+			case m.GetGarbageCollectedType() != nil:
+				updateGarbageCollected(m.GetGarbageCollectedType(), metrics)
 			}
 		}
 	})()
@@ -136,6 +194,16 @@ func updateFetched(fetched *protobuf.Event_Fetched, metrics *Prometheus) {
 	}
 }
 
+func updateGarbageCollected(gc *protobuf.Event_GarbageCollected, metrics *Prometheus) {
+	metrics.lastGcFailed.Set(boolToFloat64(gc.GetError() != ""))
+	metrics.gcFreedBytes.With(prometheus.Labels{"operation": "collect"}).Add(float64(gc.GetFreedBytes()))
+	metrics.gcDuration.With(prometheus.Labels{"operation": "collect"}).Set(gc.GetDurationSeconds())
+	if gc.GetOptimiseDurationSeconds() != 0 {
+		metrics.gcFreedBytes.With(prometheus.Labels{"operation": "optimise"}).Add(float64(gc.GetOptimisedBytes()))
+		metrics.gcDuration.With(prometheus.Labels{"operation": "optimise"}).Set(gc.GetOptimiseDurationSeconds())
+	}
+}
+
 func (m Prometheus) Handler() http.Handler {
 	return promhttp.HandlerFor(
 		m.promRegistry,
@@ -0,0 +1,296 @@
+// Package reboot automatically reboots the machine when a deployment
+// requires it. Reboots are only triggered inside maintenance windows
+// and are postponed while inhibitors, such as logged-in users or a
+// lock file, are active.
+package reboot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nlewo/comin/internal/broker"
+	"github.com/nlewo/comin/internal/store"
+	"github.com/nlewo/comin/internal/window"
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// retryPeriod is the period used to recheck the inhibitors of a
+// postponed reboot
+var retryPeriod = time.Minute
+
+type Inhibitors struct {
+	// LoggedInUsers postpones the reboot while users are logged in
+	LoggedInUsers bool
+	// LockFile postpones the reboot while this file exists
+	LockFile string
+}
+
+type Rebooter struct {
+	state       *protobuf.Reboot
+	windows     window.Windows
+	randomDelay time.Duration
+	inhibitors  Inhibitors
+
+	// rebootFunc reboots the machine
+	rebootFunc func(ctx context.Context) error
+	// loggedInUsersFunc returns the users having a session
+	loggedInUsersFunc func(ctx context.Context) ([]string, error)
+
+	request    chan request
+	cancel     chan chan error
+	statusReq  chan struct{}
+	statusResp chan *protobuf.Reboot
+
+	store  *store.Store
+	broker *broker.Broker
+}
+
+func New(store *store.Store, broker *broker.Broker, windows window.Windows, randomDelay time.Duration, inhibitors Inhibitors) *Rebooter {
+	specs := make([]string, len(windows))
+	for i, w := range windows {
+		specs[i] = w.String()
+	}
+	return &Rebooter{
+		state: &protobuf.Reboot{
+			Windows: specs,
+		},
+		windows:           windows,
+		randomDelay:       randomDelay,
+		inhibitors:        inhibitors,
+		rebootFunc:        reboot,
+		loggedInUsersFunc: loggedInUsers,
+		request:           make(chan request),
+		cancel:            make(chan chan error),
+		statusReq:         make(chan struct{}),
+		statusResp:        make(chan *protobuf.Reboot),
+		store:             store,
+		broker:            broker,
+	}
+}
+
+type request struct {
+	deploymentUuid string
+	reasons        []string
+}
+
+// Request plans a reboot required by the deployment deploymentUuid
+// for reasons in the next maintenance window. If a reboot is already
+// planned, only its reasons are updated. Nothing is planned if the
+// reboot required by this deployment has been cancelled.
+func (r *Rebooter) Request(deploymentUuid string, reasons []string) {
+	r.request <- request{deploymentUuid: deploymentUuid, reasons: reasons}
+}
+
+// Withdraw withdraws the planned reboot because the deployment
+// deploymentUuid, which is more recent than the one requiring it, does
+// not require a reboot anymore. This is for instance the case of a
+// rollback to the booted system.
+func (r *Rebooter) Withdraw(deploymentUuid string) {
+	r.request <- request{deploymentUuid: deploymentUuid}
+}
+
+// Cancel cancels the planned reboot. The cancellation is persisted
+// and the next deployment requiring a reboot plans a new one.
+func (r *Rebooter) Cancel() error {
+	errCh := make(chan error)
+	r.cancel <- errCh
+	return <-errCh
+}
+
+func (r *Rebooter) State() *protobuf.Reboot {
+	r.statusReq <- struct{}{}
+	return <-r.statusResp
+}
+
+func (r *Rebooter) Start(ctx context.Context) {
+	go r.start(ctx)
+}
+
+// plan returns the time of the next reboot. A random delay is added
+// to the opening of the next window to stagger the reboots of a
+// fleet. It is reduced when the delayed reboot would be outside of
+// the window.
+func (r *Rebooter) plan(now time.Time) time.Time {
+	at := r.windows.Next(now)
+	if r.randomDelay <= 0 {
+		return at
+	}
+	delay := rand.N(r.randomDelay)
+	for delay > time.Second && !r.windows.Contains(at.Add(delay)) {
+		delay /= 2
+	}
+	if !r.windows.Contains(at.Add(delay)) {
+		return at
+	}
+	return at.Add(delay)
+}
+
+// inhibitedBy returns why the reboot has to be postponed, or an empty
+// string.
+func (r *Rebooter) inhibitedBy(ctx context.Context) string {
+	if r.inhibitors.LockFile != "" {
+		if _, err := os.Stat(r.inhibitors.LockFile); err == nil {
+			return fmt.Sprintf("the lock file %s exists", r.inhibitors.LockFile)
+		}
+	}
+	if r.inhibitors.LoggedInUsers {
+		users, err := r.loggedInUsersFunc(ctx)
+		if err != nil {
+			logrus.Errorf("reboot: could not get the logged-in users: %s", err)
+			return "the logged-in users are unknown"
+		}
+		if len(users) > 0 {
+			return fmt.Sprintf("the users %s are logged in", strings.Join(users, ", "))
+		}
+	}
+	return ""
+}
+
+func (r *Rebooter) start(ctx context.Context) {
+	logrus.Infof("reboot: starting with the maintenance windows '%s'", r.windows)
+	var timer *time.Timer
+	var timerC <-chan time.Time
+	schedule := func(at time.Time) {
+		if timer != nil {
+			timer.Stop()
+		}
+		timer = time.NewTimer(time.Until(at))
+		timerC = timer.C
+		r.state.PlannedAt = timestamppb.New(at.UTC())
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.statusReq:
+			state := proto.CloneOf(r.state)
+			state.LastReboot = r.store.LastReboot()
+			r.statusResp <- state
+		case req := <-r.request:
+			reasons := req.reasons
+			r.state.Reasons = reasons
+			r.state.DeploymentUuid = req.deploymentUuid
+			if len(reasons) == 0 {
+				r.state.Cancelled = wrapperspb.Bool(false)
+				if r.state.PlannedAt == nil {
+					continue
+				}
+				logrus.Infof("reboot: the reboot planned at %s is withdrawn since the deployment %s does not require it", r.state.PlannedAt.AsTime().Local(), req.deploymentUuid)
+				timer.Stop()
+				timerC = nil
+				r.state.PlannedAt = nil
+				r.state.InhibitedBy = ""
+				r.state.Error = ""
+				e := &protobuf.Event_RebootCancelled{}
+				r.broker.Publish(&protobuf.Event{Type: &protobuf.Event_RebootCancelledType{RebootCancelledType: e}, CreatedAt: timestamppb.New(time.Now().UTC())})
+				continue
+			}
+			if r.store.IsRebootCancelled(req.deploymentUuid) {
+				logrus.Infof("reboot: the reboot required by the deployment %s has been cancelled", req.deploymentUuid)
+				r.state.Cancelled = wrapperspb.Bool(true)
+				continue
+			}
+			r.state.Cancelled = wrapperspb.Bool(false)
+			if r.state.PlannedAt != nil {
+				logrus.Infof("reboot: the reboot planned at %s is now required because %s", r.state.PlannedAt.AsTime().Local(), strings.Join(reasons, ", "))
+				continue
+			}
+			schedule(r.plan(time.Now()))
+			logrus.Infof("reboot: a reboot is planned at %s because %s", r.state.PlannedAt.AsTime().Local(), strings.Join(reasons, ", "))
+			e := &protobuf.Event_RebootPlanned{Reboot: proto.CloneOf(r.state)}
+			r.broker.Publish(&protobuf.Event{Type: &protobuf.Event_RebootPlannedType{RebootPlannedType: e}, CreatedAt: timestamppb.New(time.Now().UTC())})
+		case errCh := <-r.cancel:
+			if r.state.PlannedAt == nil {
+				errCh <- fmt.Errorf("reboot: no reboot is planned")
+				continue
+			}
+			logrus.Infof("reboot: the reboot planned at %s has been cancelled", r.state.PlannedAt.AsTime().Local())
+			timer.Stop()
+			timerC = nil
+			r.state.PlannedAt = nil
+			r.state.InhibitedBy = ""
+			r.state.Error = ""
+			r.state.Cancelled = wrapperspb.Bool(true)
+			r.store.RebootCancelled(r.state.DeploymentUuid)
+			e := &protobuf.Event_RebootCancelled{}
+			r.broker.Publish(&protobuf.Event{Type: &protobuf.Event_RebootCancelledType{RebootCancelledType: e}, CreatedAt: timestamppb.New(time.Now().UTC())})
+			errCh <- nil
+		case <-timerC:
+			timerC = nil
+			now := time.Now()
+			if !r.windows.Contains(now) {
+				schedule(r.plan(now))
+				logrus.Infof("reboot: the maintenance window is closed, the reboot is postponed to %s", r.state.PlannedAt.AsTime().Local())
+				continue
+			}
+			if inhibitedBy := r.inhibitedBy(ctx); inhibitedBy != "" {
+				if r.state.InhibitedBy != inhibitedBy {
+					logrus.Infof("reboot: the reboot is postponed because %s", inhibitedBy)
+				}
+				r.state.InhibitedBy = inhibitedBy
+				schedule(now.Add(retryPeriod))
+				continue
+			}
+			r.state.InhibitedBy = ""
+			logrus.Infof("reboot: rebooting the machine because %s", strings.Join(r.state.Reasons, ", "))
+			r.store.RebootRecorded(r.state.Reasons)
+			if err := r.rebootFunc(ctx); err != nil {
+				r.state.Error = err.Error()
+				schedule(now.Add(retryPeriod))
+				logrus.Errorf("reboot: failed to reboot the machine, retrying at %s: %s", r.state.PlannedAt.AsTime().Local(), err)
+				continue
+			}
+			r.state.Error = ""
+			r.state.PlannedAt = nil
+		}
+	}
+}
+
+func reboot(ctx context.Context) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "systemctl", "reboot")
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("command systemctl reboot fails with %s: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// loggedInUsers returns the users having a login session, such as a
+// SSH or a TTY session.
+func loggedInUsers(ctx context.Context) (users []string, err error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "loginctl", "list-sessions", "--no-legend")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		return nil, fmt.Errorf("command loginctl list-sessions fails with %s: %s", err, stderr.String())
+	}
+	return parseSessions(stdout.String()), nil
+}
+
+// parseSessions parses the output of loginctl list-sessions
+// --no-legend whose lines look like "3 1000 alice seat0 tty2".
+func parseSessions(output string) (users []string) {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		if !slices.Contains(users, fields[2]) {
+			users = append(users, fields[2])
+		}
+	}
+	return
+}
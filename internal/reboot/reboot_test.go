@@ -0,0 +1,208 @@
+package reboot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nlewo/comin/internal/broker"
+	"github.com/nlewo/comin/internal/store"
+	"github.com/nlewo/comin/internal/window"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRebooter(t *testing.T, specs []string, inhibitors Inhibitors) (*Rebooter, *store.Store, *atomic.Int64) {
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+	s, err := store.New(bk, tmp+"/state.json", tmp+"/gcroots", 1, 1, 1, 0)
+	assert.Nil(t, err)
+	windows, err := window.Parse(specs)
+	assert.Nil(t, err)
+	r := New(s, bk, windows, 0, inhibitors)
+	rebooted := &atomic.Int64{}
+	r.rebootFunc = func(ctx context.Context) error {
+		rebooted.Add(1)
+		return nil
+	}
+	r.loggedInUsersFunc = func(ctx context.Context) ([]string, error) {
+		return nil, nil
+	}
+	return r, s, rebooted
+}
+
+func TestRebooterReboot(t *testing.T) {
+	r, s, rebooted := newRebooter(t, nil, Inhibitors{})
+	r.Start(t.Context())
+	r.Request("dpl-1", []string{"the kernel has changed"})
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, int64(1), rebooted.Load())
+	}, 2*time.Second, 50*time.Millisecond)
+	assert.Equal(t, []string{"the kernel has changed"}, s.LastReboot().Reasons)
+	state := r.State()
+	assert.Nil(t, state.PlannedAt)
+	assert.Equal(t, []string{"the kernel has changed"}, state.LastReboot.Reasons)
+}
+
+func TestRebooterInhibitors(t *testing.T) {
+	retryPeriod = 100 * time.Millisecond
+	t.Cleanup(func() { retryPeriod = time.Minute })
+	lockFile := filepath.Join(t.TempDir(), "reboot.lock")
+	assert.Nil(t, os.WriteFile(lockFile, []byte{}, 0644))
+
+	r, _, rebooted := newRebooter(t, nil, Inhibitors{LockFile: lockFile, LoggedInUsers: true})
+	users := &atomic.Bool{}
+	users.Store(true)
+	r.loggedInUsersFunc = func(ctx context.Context) ([]string, error) {
+		if users.Load() {
+			return []string{"alice"}, nil
+		}
+		return nil, nil
+	}
+	r.Start(t.Context())
+	r.Request("dpl-1", []string{"the kernel has changed"})
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Contains(c, r.State().InhibitedBy, "lock file")
+	}, 2*time.Second, 50*time.Millisecond)
+
+	assert.Nil(t, os.Remove(lockFile))
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, "the users alice are logged in", r.State().InhibitedBy)
+	}, 2*time.Second, 50*time.Millisecond)
+	assert.Equal(t, int64(0), rebooted.Load())
+
+	users.Store(false)
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, int64(1), rebooted.Load())
+	}, 2*time.Second, 50*time.Millisecond)
+	assert.Equal(t, "", r.State().InhibitedBy)
+}
+
+func TestRebooterCancel(t *testing.T) {
+	// This window is not open during the test
+	opening := time.Now().Add(2 * time.Hour)
+	specs := []string{opening.Format("15:04") + "-" + opening.Add(time.Minute).Format("15:04")}
+	r, s, rebooted := newRebooter(t, specs, Inhibitors{})
+	r.Start(t.Context())
+
+	assert.NotNil(t, r.Cancel())
+
+	r.Request("dpl-1", []string{"the kernel has changed"})
+	state := r.State()
+	assert.NotNil(t, state.PlannedAt)
+	assert.True(t, state.PlannedAt.AsTime().After(time.Now()))
+	assert.Equal(t, specs, state.Windows)
+
+	assert.Nil(t, r.Cancel())
+	state = r.State()
+	assert.Nil(t, state.PlannedAt)
+	assert.True(t, state.Cancelled.GetValue())
+	assert.Equal(t, int64(0), rebooted.Load())
+	assert.True(t, s.IsRebootCancelled("dpl-1"))
+
+	// The cancellation survives a restart
+	r = New(s, r.broker, r.windows, 0, Inhibitors{})
+	r.Start(t.Context())
+	r.Request("dpl-1", []string{"the kernel has changed"})
+	state = r.State()
+	assert.Nil(t, state.PlannedAt)
+	assert.True(t, state.Cancelled.GetValue())
+
+	r.Request("dpl-2", []string{"the kernel has changed"})
+	state = r.State()
+	assert.NotNil(t, state.PlannedAt)
+	assert.False(t, state.Cancelled.GetValue())
+	assert.Equal(t, "dpl-2", state.DeploymentUuid)
+}
+
+func TestRebooterWithdraw(t *testing.T) {
+	retryPeriod = 100 * time.Millisecond
+	t.Cleanup(func() { retryPeriod = time.Minute })
+	lockFile := filepath.Join(t.TempDir(), "reboot.lock")
+	assert.Nil(t, os.WriteFile(lockFile, []byte{}, 0644))
+	r, _, rebooted := newRebooter(t, nil, Inhibitors{LockFile: lockFile})
+	r.Start(t.Context())
+
+	// Nothing is planned
+	r.Withdraw("dpl-1")
+	assert.Nil(t, r.State().PlannedAt)
+
+	r.Request("dpl-2", []string{"the kernel has changed"})
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Contains(c, r.State().InhibitedBy, "lock file")
+	}, 2*time.Second, 50*time.Millisecond)
+
+	// A rollback to the booted system doesn't require a reboot
+	r.Withdraw("dpl-3")
+	state := r.State()
+	assert.Nil(t, state.PlannedAt)
+	assert.Empty(t, state.Reasons)
+	assert.Equal(t, "", state.InhibitedBy)
+	assert.False(t, state.Cancelled.GetValue())
+	assert.Equal(t, "dpl-3", state.DeploymentUuid)
+
+	assert.Nil(t, os.Remove(lockFile))
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, int64(0), rebooted.Load())
+
+	// A later deployment requiring a reboot plans a new one
+	r.Request("dpl-4", []string{"the kernel has changed"})
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, int64(1), rebooted.Load())
+	}, 2*time.Second, 50*time.Millisecond)
+}
+
+func TestRebooterRetry(t *testing.T) {
+	retryPeriod = 100 * time.Millisecond
+	t.Cleanup(func() { retryPeriod = time.Minute })
+	r, _, rebooted := newRebooter(t, nil, Inhibitors{})
+	failing := &atomic.Bool{}
+	failing.Store(true)
+	r.rebootFunc = func(ctx context.Context) error {
+		if failing.Load() {
+			return fmt.Errorf("systemctl reboot failed")
+		}
+		rebooted.Add(1)
+		return nil
+	}
+	r.Start(t.Context())
+	r.Request("dpl-1", []string{"the kernel has changed"})
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		state := r.State()
+		assert.Equal(c, "systemctl reboot failed", state.Error)
+		assert.NotNil(c, state.PlannedAt)
+	}, 2*time.Second, 50*time.Millisecond)
+
+	failing.Store(false)
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, int64(1), rebooted.Load())
+	}, 2*time.Second, 50*time.Millisecond)
+	state := r.State()
+	assert.Nil(t, state.PlannedAt)
+	assert.Equal(t, "", state.Error)
+}
+
+func TestPlanRandomDelay(t *testing.T) {
+	windows, err := window.Parse([]string{"02:00-03:00"})
+	assert.Nil(t, err)
+	r := New(nil, nil, windows, 10*time.Hour, Inhibitors{})
+	now := time.Date(2025, time.January, 6, 12, 0, 0, 0, time.Local)
+	for range 100 {
+		at := r.plan(now)
+		assert.True(t, windows.Contains(at), at)
+		assert.False(t, at.Before(time.Date(2025, time.January, 7, 2, 0, 0, 0, time.Local)))
+	}
+}
+
+func TestParseSessions(t *testing.T) {
+	output := `      3 1000 alice seat0 tty2
+      5 1001 bob   -     pts/0
+      7 1000 alice -     pts/1
+`
+	assert.Equal(t, []string{"alice", "bob"}, parseSessions(output))
+	assert.Empty(t, parseSessions(""))
+}
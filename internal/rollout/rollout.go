@@ -0,0 +1,272 @@
+// Package rollout coordinates the deployments of a fleet in waves. A
+// host deploys a commit only once all earlier waves have reported a
+// successful deployment of this commit, so a bad commit only hits the
+// first waves.
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// WaveOf returns the wave of a hostname among waves waves.
+func WaveOf(hostname string, waves int) int {
+	if waves <= 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(hostname))
+	return int(h.Sum32() % uint32(waves))
+}
+
+type submission struct {
+	generationUuid string
+	commitId       string
+}
+
+// fetched is the result of the fetch of the reports of a commit
+type fetched struct {
+	commitId string
+	reports  []Report
+	hosts    []Report
+	err      error
+}
+
+type Rollout struct {
+	hostname    string
+	wave        int
+	source      Source
+	checkPeriod time.Duration
+
+	state *protobuf.Rollout
+
+	submit     chan submission
+	fetched    chan fetched
+	statusReq  chan struct{}
+	statusResp chan *protobuf.Rollout
+
+	// Ready receives the UUID of the generations which can be
+	// deployed
+	Ready chan string
+}
+
+func New(hostname string, wave int, source Source, checkPeriod time.Duration) *Rollout {
+	return &Rollout{
+		hostname:    hostname,
+		wave:        wave,
+		source:      source,
+		checkPeriod: checkPeriod,
+		state: &protobuf.Rollout{
+			Wave:   int32(wave),
+			Source: source.String(),
+		},
+		submit:     make(chan submission),
+		fetched:    make(chan fetched),
+		statusReq:  make(chan struct{}),
+		statusResp: make(chan *protobuf.Rollout),
+		Ready:      make(chan string),
+	}
+}
+
+// Submit submits a generation which is sent to the Ready channel once
+// the earlier waves have successfully deployed its commit. It
+// replaces the generation previously submitted.
+func (r *Rollout) Submit(generationUuid, commitId string) {
+	r.submit <- submission{generationUuid: generationUuid, commitId: commitId}
+}
+
+func (r *Rollout) State() *protobuf.Rollout {
+	r.statusReq <- struct{}{}
+	return <-r.statusResp
+}
+
+// Report publishes the deployment result of a commit on this host.
+func (r *Rollout) Report(ctx context.Context, commitId string, succeeded bool) error {
+	status := StatusSucceeded
+	if !succeeded {
+		status = StatusFailed
+	}
+	return r.publish(ctx, commitId, status)
+}
+
+// register publishes the wave of this host
+func (r *Rollout) register(ctx context.Context) error {
+	return r.publish(ctx, HostsCommitId, StatusRegistered)
+}
+
+func (r *Rollout) publish(ctx context.Context, commitId, status string) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	return r.source.Publish(ctx, Report{
+		Hostname:   r.hostname,
+		Wave:       r.wave,
+		CommitId:   commitId,
+		Status:     status,
+		ReportedAt: time.Now().UTC(),
+	})
+}
+
+// fetch gets the reports of a commit and the registered hosts, and
+// sends them to the fetched channel.
+func (r *Rollout) fetch(ctx context.Context, commitId string) {
+	f := fetched{commitId: commitId}
+	fetchCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	f.reports, f.err = r.source.Reports(fetchCtx, commitId)
+	if f.err == nil {
+		f.hosts, f.err = r.source.Reports(fetchCtx, HostsCommitId)
+	}
+	cancel()
+	select {
+	case r.fetched <- f:
+	case <-ctx.Done():
+	}
+}
+
+// populatedWaves returns the waves having at least one host. A host
+// is in the wave of its last registration.
+func populatedWaves(reports, hosts []Report) map[int]bool {
+	last := make(map[string]Report)
+	for _, h := range hosts {
+		if l, ok := last[h.Hostname]; !ok || !h.ReportedAt.Before(l.ReportedAt) {
+			last[h.Hostname] = h
+		}
+	}
+	populated := make(map[int]bool)
+	for _, h := range last {
+		populated[h.Wave] = true
+	}
+	for _, r := range reports {
+		populated[r.Wave] = true
+	}
+	return populated
+}
+
+// waitingReason returns why a commit can not be deployed by the
+// wave, or an empty string if all earlier waves having hosts have
+// successfully deployed the commit.
+func waitingReason(reports, hosts []Report, wave int) string {
+	populated := populatedWaves(reports, hosts)
+	succeeded := make(map[int]bool)
+	var failed []string
+	for _, r := range reports {
+		if r.Wave >= wave {
+			continue
+		}
+		switch r.Status {
+		case StatusSucceeded:
+			succeeded[r.Wave] = true
+		case StatusFailed:
+			failed = append(failed, fmt.Sprintf("%s (wave %d)", r.Hostname, r.Wave))
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Sprintf("the deployment failed on %s", strings.Join(failed, ", "))
+	}
+	var waiting []string
+	for w := range wave {
+		if populated[w] && !succeeded[w] {
+			waiting = append(waiting, fmt.Sprint(w))
+		}
+	}
+	if len(waiting) > 0 {
+		return fmt.Sprintf("waiting for the waves %s", strings.Join(waiting, ", "))
+	}
+	return ""
+}
+
+func (r *Rollout) Start(ctx context.Context) {
+	go func() {
+		if err := r.register(ctx); err != nil {
+			logrus.Errorf("rollout: failed to register the wave %d of the host %s: %s", r.wave, r.hostname, err)
+		}
+	}()
+	go r.start(ctx)
+}
+
+func (r *Rollout) start(ctx context.Context) {
+	logrus.Infof("rollout: starting in the wave %d with the %s", r.wave, r.source)
+	var pending *submission
+	var timer *time.Timer
+	var timerC <-chan time.Time
+	// readyCh is only set when a generation is ready, in order to
+	// keep serving Submit while the generation is not consumed
+	var readyCh chan string
+	var readyUuid string
+	// cancelFetch cancels the running fetch of reports, whose
+	// result is no longer expected
+	cancelFetch := func() {}
+	release := func() {
+		logrus.Infof("rollout: the commit %s can be deployed by the wave %d", pending.commitId, r.wave)
+		readyCh = r.Ready
+		readyUuid = pending.generationUuid
+		pending = nil
+		r.state.WaitingReason = ""
+		r.state.GenerationUuid = ""
+		r.state.CommitId = ""
+	}
+	check := func() {
+		timerC = nil
+		if r.wave == 0 {
+			release()
+			return
+		}
+		var fetchCtx context.Context
+		fetchCtx, cancelFetch = context.WithCancel(ctx)
+		go r.fetch(fetchCtx, pending.commitId)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			cancelFetch()
+			return
+		case <-r.statusReq:
+			r.statusResp <- proto.CloneOf(r.state)
+		case s := <-r.submit:
+			if timer != nil {
+				timer.Stop()
+			}
+			cancelFetch()
+			readyCh = nil
+			pending = &s
+			r.state.GenerationUuid = s.generationUuid
+			r.state.CommitId = s.commitId
+			r.state.WaitingReason = "checking the reports of the earlier waves"
+			check()
+		case f := <-r.fetched:
+			if pending == nil || f.commitId != pending.commitId {
+				continue
+			}
+			r.state.CheckedAt = timestamppb.New(time.Now().UTC())
+			previous := r.state.WaitingReason
+			if f.err != nil {
+				logrus.Errorf("rollout: failed to get the reports of the commit %s: %s", f.commitId, f.err)
+				r.state.WaitingReason = "the reports of the earlier waves are unavailable"
+			} else {
+				r.state.WaitingReason = waitingReason(f.reports, f.hosts, r.wave)
+			}
+			if r.state.WaitingReason == "" {
+				release()
+				continue
+			}
+			if r.state.WaitingReason != previous {
+				logrus.Infof("rollout: the deployment of the commit %s is postponed: %s", f.commitId, r.state.WaitingReason)
+			}
+			timer = time.NewTimer(r.checkPeriod)
+			timerC = timer.C
+		case <-timerC:
+			check()
+		case readyCh <- readyUuid:
+			readyCh = nil
+		}
+	}
+}
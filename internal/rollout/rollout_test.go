@@ -0,0 +1,170 @@
+package rollout
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaveOf(t *testing.T) {
+	assert.Equal(t, 0, WaveOf("host", 0))
+	assert.Equal(t, 0, WaveOf("host", 1))
+	assert.Equal(t, WaveOf("host", 3), WaveOf("host", 3))
+	assert.Less(t, WaveOf("host", 3), 3)
+}
+
+func TestWaitingReason(t *testing.T) {
+	reports := []Report{
+		{Hostname: "a", Wave: 0, Status: StatusSucceeded},
+		{Hostname: "b", Wave: 2, Status: StatusFailed},
+	}
+	hosts := []Report{
+		{Hostname: "a", Wave: 0, Status: StatusRegistered},
+		{Hostname: "c", Wave: 1, Status: StatusRegistered},
+	}
+	assert.Equal(t, "", waitingReason(nil, hosts, 0))
+	assert.Equal(t, "", waitingReason(reports, hosts, 1))
+	assert.Equal(t, "waiting for the waves 1", waitingReason(reports, hosts, 2))
+	assert.Equal(t, "the deployment failed on b (wave 2)", waitingReason(reports, hosts, 3))
+	assert.Equal(t, "waiting for the waves 0", waitingReason(nil, hosts, 1))
+	// The waves without hosts are skipped
+	assert.Equal(t, "", waitingReason(nil, nil, 1))
+	assert.Equal(t, "waiting for the waves 1", waitingReason(nil, hosts[1:], 3))
+}
+
+func TestPopulatedWaves(t *testing.T) {
+	now := time.Now()
+	hosts := []Report{
+		{Hostname: "a", Wave: 2, ReportedAt: now},
+		{Hostname: "a", Wave: 0, ReportedAt: now.Add(-time.Hour)},
+		{Hostname: "b", Wave: 1, ReportedAt: now},
+	}
+	reports := []Report{{Hostname: "c", Wave: 3}}
+	assert.Equal(t, map[int]bool{1: true, 2: true, 3: true}, populatedWaves(reports, hosts))
+}
+
+func TestDirectorySource(t *testing.T) {
+	s := NewDirectorySource(t.TempDir())
+	reports, err := s.Reports(t.Context(), "id-1")
+	assert.Nil(t, err)
+	assert.Empty(t, reports)
+
+	assert.Nil(t, s.Publish(t.Context(), Report{Hostname: "a", CommitId: "id-1", Status: StatusFailed}))
+	assert.Nil(t, s.Publish(t.Context(), Report{Hostname: "a", CommitId: "id-1", Status: StatusSucceeded}))
+	assert.Nil(t, s.Publish(t.Context(), Report{Hostname: "b", Wave: 1, CommitId: "id-1", Status: StatusSucceeded}))
+	reports, err = s.Reports(t.Context(), "id-1")
+	assert.Nil(t, err)
+	assert.Len(t, reports, 2)
+	assert.Equal(t, "a", reports[0].Hostname)
+	assert.Equal(t, StatusSucceeded, reports[0].Status)
+}
+
+func TestURLSource(t *testing.T) {
+	var mu sync.Mutex
+	reports := map[string][]Report{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		commitId := r.URL.Path[len("/reports/"):]
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(reports[commitId])
+		case http.MethodPost:
+			var report Report
+			_ = json.NewDecoder(r.Body).Decode(&report)
+			reports[commitId] = append(reports[commitId], report)
+		}
+	}))
+	defer srv.Close()
+
+	s := NewURLSource(srv.URL + "/reports/{commit_id}")
+	assert.Nil(t, s.Publish(t.Context(), Report{Hostname: "a", CommitId: "id-1", Status: StatusSucceeded}))
+	got, err := s.Reports(t.Context(), "id-1")
+	assert.Nil(t, err)
+	assert.Equal(t, []Report{{Hostname: "a", CommitId: "id-1", Status: StatusSucceeded}}, got)
+	got, err = s.Reports(t.Context(), "id-2")
+	assert.Nil(t, err)
+	assert.Empty(t, got)
+}
+
+func TestRollout(t *testing.T) {
+	source := NewDirectorySource(t.TempDir())
+	canary := New("canary", 0, source, time.Hour)
+	canary.Start(t.Context())
+	// The wave 0 is only waited for once the canary is registered
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		hosts, err := source.Reports(t.Context(), HostsCommitId)
+		assert.Nil(c, err)
+		assert.Len(c, hosts, 1)
+	}, 2*time.Second, 50*time.Millisecond)
+	r := New("host", 1, source, 100*time.Millisecond)
+	r.Start(t.Context())
+
+	r.Submit("uuid-1", "id-1")
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		state := r.State()
+		assert.Equal(c, "uuid-1", state.GenerationUuid)
+		assert.Equal(c, "waiting for the waves 0", state.WaitingReason)
+	}, 2*time.Second, 50*time.Millisecond)
+
+	canary.Submit("uuid-0", "id-1")
+	assert.Equal(t, "uuid-0", <-canary.Ready)
+	assert.Nil(t, canary.Report(t.Context(), "id-1", true))
+	select {
+	case uuid := <-r.Ready:
+		assert.Equal(t, "uuid-1", uuid)
+	case <-time.After(2 * time.Second):
+		t.Fatal("the generation has not been released")
+	}
+	state := r.State()
+	assert.Equal(t, "", state.GenerationUuid)
+	assert.Equal(t, "", state.WaitingReason)
+
+	assert.Nil(t, canary.Report(t.Context(), "id-2", false))
+	r.Submit("uuid-2", "id-2")
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Contains(c, r.State().WaitingReason, "failed on canary")
+	}, 2*time.Second, 50*time.Millisecond)
+}
+
+// blockingSource is a source whose reports are only returned once
+// unblocked
+type blockingSource struct {
+	*DirectorySource
+	unblock chan struct{}
+}
+
+func (s *blockingSource) Reports(ctx context.Context, commitId string) ([]Report, error) {
+	select {
+	case <-s.unblock:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return s.DirectorySource.Reports(ctx, commitId)
+}
+
+func TestRolloutSlowSource(t *testing.T) {
+	source := &blockingSource{DirectorySource: NewDirectorySource(t.TempDir()), unblock: make(chan struct{})}
+	r := New("host", 1, source, time.Hour)
+	r.Start(t.Context())
+	r.Submit("uuid-1", "id-1")
+
+	// The state is served while the reports are fetched
+	state := r.State()
+	assert.Equal(t, "uuid-1", state.GenerationUuid)
+	assert.Equal(t, "checking the reports of the earlier waves", state.WaitingReason)
+
+	close(source.unblock)
+	select {
+	case uuid := <-r.Ready:
+		assert.Equal(t, "uuid-1", uuid)
+	case <-time.After(2 * time.Second):
+		t.Fatal("the generation has not been released")
+	}
+}
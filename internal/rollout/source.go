@@ -0,0 +1,188 @@
+package rollout
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	// StatusRegistered is the status of the reports registering
+	// the wave of a host
+	StatusRegistered = "registered"
+)
+
+// HostsCommitId is the commit ID under which hosts register their
+// wave, in order to know which waves have hosts.
+const HostsCommitId = "hosts"
+
+// CommitIdPlaceholder is replaced by the commit ID in rollout URLs.
+const CommitIdPlaceholder = "{commit_id}"
+
+// Report is the deployment result of a commit on a host.
+type Report struct {
+	Hostname   string    `json:"hostname"`
+	Wave       int       `json:"wave"`
+	CommitId   string    `json:"commit_id"`
+	Status     string    `json:"status"`
+	ReportedAt time.Time `json:"reported_at"`
+}
+
+// Source is where hosts publish and read the deployment reports of
+// commits.
+type Source interface {
+	// Reports returns the reports of all hosts for a commit
+	Reports(ctx context.Context, commitId string) ([]Report, error)
+	// Publish publishes the report of a host
+	Publish(ctx context.Context, report Report) error
+	String() string
+}
+
+// DirectorySource stores reports in a directory shared by hosts,
+// such as a network file system. The report of a host is stored in
+// the file <directory>/<commit_id>/<hostname>.json.
+type DirectorySource struct {
+	directory string
+}
+
+func NewDirectorySource(directory string) *DirectorySource {
+	return &DirectorySource{directory: directory}
+}
+
+func (s *DirectorySource) String() string {
+	return "directory " + s.directory
+}
+
+func (s *DirectorySource) Reports(ctx context.Context, commitId string) (reports []Report, err error) {
+	entries, err := os.ReadDir(filepath.Join(s.directory, commitId))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("rollout: failed to read the reports of the commit %s: %w", commitId, err)
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(s.directory, commitId, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("rollout: failed to read the report %s: %w", e.Name(), err)
+		}
+		var r Report
+		if err := json.Unmarshal(content, &r); err != nil {
+			return nil, fmt.Errorf("rollout: failed to parse the report %s: %w", e.Name(), err)
+		}
+		reports = append(reports, r)
+	}
+	return
+}
+
+func (s *DirectorySource) Publish(ctx context.Context, report Report) error {
+	dir := filepath.Join(s.directory, report.CommitId)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("rollout: failed to create the directory %s: %w", dir, err)
+	}
+	content, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	// The report is renamed to not expose partially written
+	// reports to other hosts
+	tmp, err := os.CreateTemp(dir, ".report-*")
+	if err != nil {
+		return fmt.Errorf("rollout: failed to create the report: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // nolint: errcheck
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("rollout: failed to write the report: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("rollout: failed to write the report: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("rollout: failed to write the report: %w", err)
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, report.Hostname+".json"))
+}
+
+// URLSource reads the reports of a commit with a GET request
+// returning a JSON list of reports, and publishes a report with a
+// POST request of the JSON report. The URL can contain the commit ID
+// placeholder.
+type URLSource struct {
+	url    string
+	client *http.Client
+}
+
+func NewURLSource(url string) *URLSource {
+	return &URLSource{
+		url:    url,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *URLSource) String() string {
+	return "url " + s.url
+}
+
+func (s *URLSource) urlOf(commitId string) string {
+	return strings.ReplaceAll(s.url, CommitIdPlaceholder, commitId)
+}
+
+func (s *URLSource) Reports(ctx context.Context, commitId string) (reports []Report, err error) {
+	url := s.urlOf(commitId)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("rollout: failed to get %s: %w", url, err)
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rollout: failed to get %s: %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reports); err != nil {
+		return nil, fmt.Errorf("rollout: failed to parse the reports from %s: %w", url, err)
+	}
+	return
+}
+
+func (s *URLSource) Publish(ctx context.Context, report Report) error {
+	url := s.urlOf(report.CommitId)
+	content, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("rollout: failed to post to %s: %w", url, err)
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("rollout: failed to post to %s: %s", url, resp.Status)
+	}
+	return nil
+}
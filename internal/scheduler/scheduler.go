@@ -1,11 +1,13 @@
 package scheduler
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/go-co-op/gocron/v2"
 	"github.com/nlewo/comin/internal/fetcher"
+	"github.com/nlewo/comin/internal/gc"
 	"github.com/nlewo/comin/internal/types"
 	"github.com/sirupsen/logrus"
 )
@@ -44,3 +46,20 @@ func (s Scheduler) FetchRemotes(fetcher *fetcher.Fetcher, remotes []types.Remote
 		}
 	}
 }
+
+func (s Scheduler) CollectGarbage(ctx context.Context, collector *gc.Collector, period int) {
+	logrus.Infof("scheduler: starting the garbage collection job with period %ds", period)
+	_, _ = s.s.NewJob(
+		gocron.DurationJob(
+			time.Duration(period)*time.Second,
+		),
+		gocron.NewTask(
+			func() {
+				logrus.Debugf("scheduler: running the garbage collection task")
+				collector.Run(ctx, gc.TriggerSchedule)
+			},
+		),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+		gocron.WithName("collect-garbage"),
+	)
+}
@@ -2,12 +2,14 @@ package server
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net"
 	"os"
 	"time"
 
 	"github.com/nlewo/comin/internal/broker"
+	"github.com/nlewo/comin/internal/history"
 	"github.com/nlewo/comin/internal/manager"
 	"github.com/nlewo/comin/pkg/protobuf"
 	"github.com/sirupsen/logrus"
@@ -49,6 +51,30 @@ func (s *cominServer) Events(_ *emptypb.Empty, stream grpc.ServerStreamingServer
 	}
 }
 
+// chunkWriter sends each write as a chunk on a logs stream.
+type chunkWriter struct {
+	stream grpc.ServerStreamingServer[protobuf.GenerationLogsChunk]
+}
+
+func (w chunkWriter) Write(p []byte) (int, error) {
+	// The buffer can be reused by the caller after the write
+	data := make([]byte, len(p))
+	copy(data, p)
+	if err := w.stream.Send(&protobuf.GenerationLogsChunk{Data: data}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *cominServer) GenerationLogs(req *protobuf.GenerationLogsRequest, stream grpc.ServerStreamingServer[protobuf.GenerationLogsChunk]) error {
+	logrus.Infof("server: start to stream %s logs of the generation %s", req.Kind, req.GenerationUuid)
+	err := s.manager.GenerationLogs(stream.Context(), req.GenerationUuid, req.Kind, req.Follow.GetValue(), chunkWriter{stream: stream})
+	if err != nil {
+		return status.New(codes.Aborted, err.Error()).Err()
+	}
+	return nil
+}
+
 func (s *cominServer) GetState(ctx context.Context, empty *emptypb.Empty) (*protobuf.State, error) {
 	return s.manager.GetState(), nil
 }
@@ -89,6 +115,102 @@ func (s *cominServer) Resume(ctx context.Context, empty *emptypb.Empty) (*emptyp
 	return nil, err
 }
 
+func (s *cominServer) DeploymentWindowOverride(ctx context.Context, empty *emptypb.Empty) (*emptypb.Empty, error) {
+	err := s.manager.DeploymentWindowOverride()
+	if err != nil {
+		st := status.New(codes.Aborted, err.Error())
+		err = st.Err()
+	}
+	return nil, err
+}
+
+func (s *cominServer) InhibitorOverride(ctx context.Context, req *protobuf.InhibitorOverrideRequest) (*emptypb.Empty, error) {
+	err := s.manager.InhibitorOverride(req.GenerationUuid, req.Keys, req.Comment)
+	if err != nil {
+		st := status.New(codes.Aborted, err.Error())
+		err = st.Err()
+	}
+	return nil, err
+}
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+func (s *cominServer) ListDeployments(ctx context.Context, req *protobuf.ListDeploymentsRequest) (*protobuf.ListDeploymentsResponse, error) {
+	q := history.Query{
+		Status:    req.Status,
+		Operation: req.Operation,
+		Branch:    req.Branch,
+		CommitId:  req.CommitId,
+		Limit:     int(req.PageSize),
+	}
+	if req.Since != nil {
+		q.Since = req.Since.AsTime()
+	}
+	if req.Until != nil {
+		q.Until = req.Until.AsTime()
+	}
+	// The boot entries are listed newest first and the other
+	// deployments oldest first by default
+	switch req.Order {
+	case "":
+		q.OldestFirst = !req.BootEntries
+	case "newest-first":
+	case "oldest-first":
+		q.OldestFirst = true
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "the order '%s' must be newest-first or oldest-first", req.Order)
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultPageSize
+	} else if q.Limit > maxPageSize {
+		q.Limit = maxPageSize
+	}
+	// The page token is the position of the last deployment of the
+	// previous page
+	if req.PageToken != "" {
+		cursor, err := history.ParseCursor(req.PageToken)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page token '%s'", req.PageToken)
+		}
+		q.After = &cursor
+	}
+	pageSize := q.Limit
+	// One more deployment is queried to know if there is a next page
+	q.Limit++
+	dpls, err := s.manager.ListDeployments(q, req.BootEntries)
+	if err != nil {
+		return nil, status.New(codes.Aborted, err.Error()).Err()
+	}
+	resp := &protobuf.ListDeploymentsResponse{Deployments: dpls}
+	if len(dpls) > pageSize {
+		resp.Deployments = dpls[:pageSize]
+		resp.NextPageToken = history.DeploymentCursor(dpls[pageSize-1]).String()
+	}
+	return resp, nil
+}
+
+func (s *cominServer) GetDeployment(ctx context.Context, req *protobuf.GetDeploymentRequest) (*protobuf.Deployment, error) {
+	d, err := s.manager.GetDeployment(req.Uuid)
+	if errors.Is(err, history.ErrNotFound) {
+		return nil, status.New(codes.NotFound, err.Error()).Err()
+	} else if err != nil {
+		return nil, status.New(codes.Aborted, err.Error()).Err()
+	}
+	return d, nil
+}
+
+func (s *cominServer) RebootCancel(ctx context.Context, empty *emptypb.Empty) (*emptypb.Empty, error) {
+	err := s.manager.RebootCancel()
+	if err != nil {
+		st := status.New(codes.Aborted, err.Error())
+		err = st.Err()
+	}
+	return nil, err
+}
+
 func (s *cominServer) Confirm(ctx context.Context, req *protobuf.ConfirmRequest) (*emptypb.Empty, error) {
 	switch req.For {
 	case "build":
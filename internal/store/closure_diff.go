@@ -0,0 +1,83 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/nlewo/comin/pkg/protobuf"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GenerationClosureDiff records the package changes between the
+// running system and the out path of a generation.
+func (s *Store) GenerationClosureDiff(uuid string, diff *protobuf.ClosureDiff) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, err := s.generationGet(uuid)
+	if err != nil {
+		return err
+	}
+	g.ClosureDiff = diff
+	e := &protobuf.Event_ClosureDiffComputed{Generation: g}
+	s.broker.Publish(&protobuf.Event{Type: &protobuf.Event_ClosureDiffComputedType{ClosureDiffComputedType: e}, CreatedAt: timestamppb.New(time.Now().UTC())})
+	s.historyRecord()
+	return nil
+}
+
+// ClosureDiffSummary returns a human readable summary of a closure
+// diff, such as "3 added, 1 removed, 12 upgraded, closure +12.3 MiB".
+func ClosureDiffSummary(d *protobuf.ClosureDiff) string {
+	if d == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d added, %d removed, %d upgraded, closure %s",
+		len(d.Added), len(d.Removed), len(d.Upgraded), SizeDelta(d.ClosureSizeDelta))
+}
+
+// ClosureDiffLines returns a line per package change of a closure
+// diff, such as "hello: 2.11 → 2.12 (+12 KiB)".
+func ClosureDiffLines(d *protobuf.ClosureDiff) (lines []string) {
+	if d == nil {
+		return
+	}
+	line := func(prefix string, c *protobuf.PackageChange) string {
+		versions := c.NewVersion
+		switch {
+		case c.OldVersion != "" && c.NewVersion != "":
+			versions = c.OldVersion + " → " + c.NewVersion
+		case c.OldVersion != "":
+			versions = c.OldVersion
+		}
+		return fmt.Sprintf("%s %s: %s (%s)", prefix, c.Name, versions, SizeDelta(c.SizeDelta))
+	}
+	for _, c := range d.Added {
+		lines = append(lines, line("+", c))
+	}
+	for _, c := range d.Removed {
+		lines = append(lines, line("-", c))
+	}
+	for _, c := range d.Upgraded {
+		lines = append(lines, line("~", c))
+	}
+	return
+}
+
+// SizeDelta returns a human readable size difference, such as
+// "+12 MiB" or "-1.2 GiB".
+func SizeDelta(delta int64) string {
+	if delta < 0 {
+		return "-" + humanize.IBytes(uint64(-delta))
+	}
+	return "+" + humanize.IBytes(uint64(delta))
+}
+
+func closureDiffShow(d *protobuf.ClosureDiff, padding string) {
+	if d == nil {
+		return
+	}
+	fmt.Printf("%s  Changes: %s\n", padding, ClosureDiffSummary(d))
+	for _, l := range ClosureDiffLines(d) {
+		fmt.Printf("%s    %s\n", padding, l)
+	}
+}
@@ -0,0 +1,24 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClosureDiffSummary(t *testing.T) {
+	d := &protobuf.ClosureDiff{
+		Added:            []*protobuf.PackageChange{{Name: "hello", NewVersion: "2.12", SizeDelta: 2048}},
+		Removed:          []*protobuf.PackageChange{{Name: "nano", OldVersion: "7.2", SizeDelta: -1024}},
+		Upgraded:         []*protobuf.PackageChange{{Name: "firefox", OldVersion: "120.0", NewVersion: "121.0"}},
+		ClosureSizeDelta: -3 * 1024 * 1024,
+	}
+	assert.Equal(t, "1 added, 1 removed, 1 upgraded, closure -3.0 MiB", ClosureDiffSummary(d))
+	assert.Equal(t, []string{
+		"+ hello: 2.12 (+2.0 KiB)",
+		"- nano: 7.2 (-1.0 KiB)",
+		"~ firefox: 120.0 → 121.0 (+0 B)",
+	}, ClosureDiffLines(d))
+	assert.Equal(t, "", ClosureDiffSummary(nil))
+}
@@ -7,12 +7,14 @@ import (
 	"os"
 	"path"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/nlewo/comin/internal/types"
 	"github.com/nlewo/comin/pkg/protobuf"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 )
@@ -107,6 +109,7 @@ func (s *Store) updateDataDeployments(bootedStorepath, currentStorepath string,
 	logDiff("any deployments", s.persisted.DeploymentsAny, anyUuids)
 	s.persisted.DeploymentsAny = anyUuids
 
+	s.logsGC()
 	s.Commit()
 }
 
@@ -127,13 +130,21 @@ func loadInhibitors(filepath string) map[string]string {
 }
 
 func (s *Store) NewDeployment(g *protobuf.Generation, operationSubmitted, reason, bootedStorepath, currentStorepath string) *protobuf.Deployment {
+	d := CandidateDeployment(g, operationSubmitted, reason, currentStorepath, nil)
+	s.DeploymentAdd(d, bootedStorepath, currentStorepath)
+	return d
+}
+
+// CandidateDeployment returns the deployment of a generation without
+// adding it to the store. It allows to run the pre-deployment hooks
+// before registering the deployment.
+func CandidateDeployment(g *protobuf.Generation, operationSubmitted, reason, currentStorepath string, override *protobuf.InhibitorOverride) *protobuf.Deployment {
 	currentInhibitors := loadInhibitors(path.Join(currentStorepath, "switch-inhibitors"))
 	newInhibitors := loadInhibitors(path.Join(g.OutPath, "switch-inhibitors"))
-	operation, operationreason := computeOperation(operationSubmitted, currentInhibitors, newInhibitors)
+	changes := InhibitorChanges(currentInhibitors, newInhibitors, override)
+	operation, operationreason := computeOperation(operationSubmitted, changes)
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	d := &protobuf.Deployment{
+	return &protobuf.Deployment{
 		Uuid:               uuid.New().String(),
 		Generation:         g,
 		OperationSubmitted: operationSubmitted,
@@ -144,11 +155,17 @@ func (s *Store) NewDeployment(g *protobuf.Generation, operationSubmitted, reason
 		CreatedAt:          timestamppb.New(time.Now().UTC()),
 		CurrentInhibitors:  currentInhibitors,
 		NewInhibitors:      newInhibitors,
+		InhibitorChanges:   changes,
+		InhibitorOverride:  override,
 	}
+}
+
+// DeploymentAdd adds a candidate deployment to the store.
+func (s *Store) DeploymentAdd(d *protobuf.Deployment, bootedStorepath, currentStorepath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.updateDataDeployments(bootedStorepath, currentStorepath, d)
 	s.Commit()
-	return d
-
 }
 
 func (s *Store) deploymentGet(uuid string) (g *protobuf.Deployment, err error) {
@@ -237,6 +254,20 @@ func (s *Store) DeploymentFinished(uuid string, deploymentErr error, cominNeedRe
 	return nil
 }
 
+// DeploymentRebootReasons records the reasons why a reboot is
+// required to take a deployment into account.
+func (s *Store) DeploymentRebootReasons(uuid string, reasons []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, err := s.deploymentGet(uuid)
+	if err != nil {
+		return err
+	}
+	d.RebootReasons = reasons
+	s.Commit()
+	return nil
+}
+
 func isBootEntry(d *protobuf.Deployment) bool {
 	return d.Operation == "boot" || d.Operation == "switch"
 }
@@ -405,17 +436,97 @@ func compareSwitchInhibitors(current map[string]string, new map[string]string) (
 	return
 }
 
-func computeOperation(operation string, currentInhibitors map[string]string, newInhibitors map[string]string) (computedOperation string, reason string) {
+// InhibitorChanges returns the switch inhibitors which differ
+// between the current and the new system, sorted by key. The changes
+// accepted by the override are marked as overridden.
+func InhibitorChanges(currentInhibitors map[string]string, newInhibitors map[string]string, override *protobuf.InhibitorOverride) (changes []*protobuf.InhibitorChange) {
 	diff := compareSwitchInhibitors(currentInhibitors, newInhibitors)
+	for _, k := range slices.Sorted(maps.Keys(diff)) {
+		changes = append(changes, &protobuf.InhibitorChange{
+			Key:        k,
+			OldValue:   diff[k].old,
+			NewValue:   diff[k].new,
+			Overridden: override != nil && (len(override.Keys) == 0 || slices.Contains(override.Keys, k)),
+		})
+	}
+	return
+}
+
+// InhibitorOverrideSet records the switch inhibitor override of a
+// generation, replacing any previous override of this generation.
+func (s *Store) InhibitorOverrideSet(override *protobuf.InhibitorOverride) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.generationGet(override.GenerationUuid); err != nil {
+		return err
+	}
+	s.persisted.InhibitorOverrides = slices.DeleteFunc(s.persisted.InhibitorOverrides, func(o *protobuf.InhibitorOverride) bool {
+		return o.GenerationUuid == override.GenerationUuid
+	})
+	s.persisted.InhibitorOverrides = append(s.persisted.InhibitorOverrides, proto.CloneOf(override))
+	s.Commit()
+	return nil
+}
+
+// InhibitorOverrideGet returns the switch inhibitor override of a
+// generation, or nil if it has not been overridden.
+func (s *Store) InhibitorOverrideGet(generationUuid string) *protobuf.InhibitorOverride {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, o := range s.persisted.InhibitorOverrides {
+		if o.GenerationUuid == generationUuid {
+			return proto.CloneOf(o)
+		}
+	}
+	return nil
+}
+
+// inhibitorOverridesGC removes the overrides of the generations which
+// are no longer in the store. This is not thread safe.
+func (s *Store) inhibitorOverridesGC() {
+	s.persisted.InhibitorOverrides = slices.DeleteFunc(s.persisted.InhibitorOverrides, func(o *protobuf.InhibitorOverride) bool {
+		if _, err := s.generationGet(o.GenerationUuid); err != nil {
+			logrus.Infof("store: the switch inhibitor override of the generation %s has been removed", o.GenerationUuid)
+			return true
+		}
+		return false
+	})
+}
+
+func computeOperation(operation string, changes []*protobuf.InhibitorChange) (computedOperation string, reason string) {
+	var keys, overridden []string
+	for _, c := range changes {
+		if c.Overridden {
+			overridden = append(overridden, c.Key)
+		} else {
+			keys = append(keys, c.Key)
+		}
+	}
 	switch operation {
 	case types.OperationTest:
-		if len(diff) != 0 {
-			return types.OperationNull, "The test operation is not possible because of different switch inhibitors"
+		if len(keys) != 0 {
+			return types.OperationNull, fmt.Sprintf("The test operation is not possible because of different switch inhibitors: %s", strings.Join(keys, ", "))
 		}
 	case types.OperationSwitch:
-		if len(diff) != 0 {
-			return types.OperationBoot, "Using the boot operation instead of switch operation because of diffrent switch inhibitors"
+		if len(keys) != 0 {
+			return types.OperationBoot, fmt.Sprintf("Using the boot operation instead of switch operation because of different switch inhibitors: %s", strings.Join(keys, ", "))
 		}
 	}
+	if len(overridden) != 0 && operation != types.OperationBoot {
+		return operation, fmt.Sprintf("The different switch inhibitors %s have been overridden by the operator", strings.Join(overridden, ", "))
+	}
 	return operation, ""
 }
+
+// InhibitorChangeLines returns a human readable description of switch
+// inhibitor changes, such as "systemd: 255 -> 256 (overridden)".
+func InhibitorChangeLines(changes []*protobuf.InhibitorChange) (lines []string) {
+	for _, c := range changes {
+		line := fmt.Sprintf("%s: %s -> %s", c.Key, c.OldValue, c.NewValue)
+		if c.Overridden {
+			line += " (overridden)"
+		}
+		lines = append(lines, line)
+	}
+	return
+}
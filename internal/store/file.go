@@ -0,0 +1,154 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/sirupsen/logrus"
+)
+
+// BackupCount is the number of previous versions of the store file
+// kept, as filename.1 (the most recent) to filename.<BackupCount>
+const BackupCount = 3
+
+// writeFile atomically replaces filename by content: content is
+// written to a temporary file which is synced and then renamed to
+// filename. If rotate is true, the previous version of filename is
+// kept as filename.1 and the older ones are rotated.
+func writeFile(filename string, content []byte, rotate bool) error {
+	dir := filepath.Dir(filename)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filename)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // nolint: errcheck
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if rotate {
+		rotateBackups(filename)
+	}
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+func backupPath(filename string, i int) string {
+	return fmt.Sprintf("%s.%d", filename, i)
+}
+
+// rotateBackups shifts the backups of filename and hard links
+// filename to filename.1. Since filename is not moved, it is always
+// present, even if comin stops before the end of the write.
+func rotateBackups(filename string) {
+	if _, err := os.Stat(filename); err != nil {
+		return
+	}
+	for i := BackupCount - 1; i >= 1; i-- {
+		if err := os.Rename(backupPath(filename, i), backupPath(filename, i+1)); err != nil && !os.IsNotExist(err) {
+			logrus.Errorf("store: cannot rotate the backup %s: %s", backupPath(filename, i), err)
+		}
+	}
+	_ = os.Remove(backupPath(filename, 1))
+	if err := os.Link(filename, backupPath(filename, 1)); err != nil {
+		logrus.Errorf("store: cannot backup %s: %s", filename, err)
+	}
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close() // nolint: errcheck
+	return d.Sync()
+}
+
+// Backups returns the backups of the store file filename which
+// exist: the previous versions, from the most recent one, the backups
+// done before the migrations and the quarantined files.
+func Backups(filename string) (backups []string) {
+	for i := 1; i <= BackupCount; i++ {
+		if _, err := os.Stat(backupPath(filename, i)); err == nil {
+			backups = append(backups, backupPath(filename, i))
+		}
+	}
+	for _, pattern := range []string{".v*.bak", ".corrupted-*"} {
+		matches, _ := filepath.Glob(filename + pattern)
+		sort.Sort(sort.Reverse(sort.StringSlice(matches)))
+		backups = append(backups, matches...)
+	}
+	return
+}
+
+// Verify decodes the store file filename and checks the consistency
+// of its deployment lists. It returns the decoded store and the
+// schema version of the file.
+func Verify(filename string) (*protobuf.Store, int32, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, 0, err
+	}
+	data, version, err := decode(content)
+	if err != nil {
+		return nil, version, err
+	}
+	uuids := make(map[string]bool)
+	for _, d := range data.Deployments {
+		if d.Generation == nil {
+			return data, version, fmt.Errorf("%w: the deployment %s has no generation", ErrCorrupted, d.Uuid)
+		}
+		uuids[d.Uuid] = true
+	}
+	lists := map[string][]string{
+		"deployments_boot_entry": data.DeploymentsBootEntry,
+		"deployments_successful": data.DeploymentsSuccessful,
+		"deployments_any":        data.DeploymentsAny,
+	}
+	for _, name := range []string{"deployments_boot_entry", "deployments_successful", "deployments_any"} {
+		for _, uuid := range lists[name] {
+			if !uuids[uuid] {
+				return data, version, fmt.Errorf("%w: the deployment %s of %s doesn't exist", ErrCorrupted, uuid, name)
+			}
+		}
+	}
+	for _, uuid := range []string{data.DeploymentSwitched, data.DeploymentBooted} {
+		if uuid != "" && !uuids[uuid] {
+			return data, version, fmt.Errorf("%w: the deployment %s doesn't exist", ErrCorrupted, uuid)
+		}
+	}
+	return data, version, nil
+}
+
+// Restore replaces the store file filename by the backup once it has
+// been verified. The replaced store file is kept as filename.1.
+func Restore(filename, backup string) error {
+	if _, _, err := Verify(backup); err != nil {
+		return fmt.Errorf("the backup %s is not valid: %w", backup, err)
+	}
+	content, err := os.ReadFile(backup)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filename, content, true); err != nil {
+		return err
+	}
+	logrus.Infof("store: the store file %s has been restored from %s", filename, backup)
+	return nil
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/nlewo/comin/internal/broker"
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteFileBackups(t *testing.T) {
+	filename := t.TempDir() + "/state.json"
+	for i := range BackupCount + 2 {
+		assert.Nil(t, writeFile(filename, []byte(fmt.Sprintf("%d", i)), true))
+	}
+	content, _ := os.ReadFile(filename)
+	assert.Equal(t, "4", string(content))
+	assert.Equal(t, []string{filename + ".1", filename + ".2", filename + ".3"}, Backups(filename))
+	content, _ = os.ReadFile(filename + ".1")
+	assert.Equal(t, "3", string(content))
+	content, _ = os.ReadFile(filename + ".3")
+	assert.Equal(t, "1", string(content))
+
+	// Without rotation, the backups are not modified
+	assert.Nil(t, writeFile(filename, []byte("5"), false))
+	content, _ = os.ReadFile(filename)
+	assert.Equal(t, "5", string(content))
+	content, _ = os.ReadFile(filename + ".1")
+	assert.Equal(t, "3", string(content))
+}
+
+func TestStoreBackupsPerLoad(t *testing.T) {
+	tmp := t.TempDir()
+	filename := tmp + "/state.json"
+	bk := broker.New()
+	bk.Start()
+	s, _ := New(bk, filename, tmp+"/gcroots", 2, 2, 5, 0)
+	assert.Nil(t, s.Load())
+	s.DeployerUpdate(&protobuf.DeployerState{SuspendReason: "0-a"})
+	s.DeployerUpdate(&protobuf.DeployerState{SuspendReason: "0-b"})
+	// The store file didn't exist when it has been loaded
+	assert.Empty(t, Backups(filename))
+
+	for i := 1; i <= BackupCount+1; i++ {
+		assert.Nil(t, s.Load())
+		s.DeployerUpdate(&protobuf.DeployerState{SuspendReason: fmt.Sprintf("%d-a", i)})
+		s.DeployerUpdate(&protobuf.DeployerState{SuspendReason: fmt.Sprintf("%d-b", i)})
+	}
+	// The backups are the store files of the last loads
+	assert.Equal(t, []string{filename + ".1", filename + ".2", filename + ".3"}, Backups(filename))
+	for i, expected := range []string{"3-b", "2-b", "1-b"} {
+		data, _, err := Verify(backupPath(filename, i+1))
+		assert.Nil(t, err)
+		assert.Equal(t, expected, data.Deployer.SuspendReason)
+	}
+}
+
+func TestVerifyAndRestore(t *testing.T) {
+	tmp := t.TempDir()
+	filename := tmp + "/state.json"
+	bk := broker.New()
+	bk.Start()
+	s, _ := New(bk, filename, tmp+"/gcroots", 2, 2, 5, 0)
+	for i := range 3 {
+		d := s.NewDeployment(&protobuf.Generation{Uuid: fmt.Sprintf("g%d", i), OutPath: fmt.Sprintf("/nix/store/%d", i)}, "switch", "", "", "")
+		assert.Nil(t, s.DeploymentStarted(d.Uuid, "", ""))
+		assert.Nil(t, s.DeploymentFinished(d.Uuid, nil, false, "", "", ""))
+	}
+	// The store file is backed up at the first write after a load
+	assert.Nil(t, s.Load())
+	d := s.NewDeployment(&protobuf.Generation{Uuid: "g3", OutPath: "/nix/store/3"}, "switch", "", "", "")
+	assert.Nil(t, s.DeploymentStarted(d.Uuid, "", ""))
+	data, version, err := Verify(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, SchemaVersion, version)
+	assert.NotEmpty(t, data.Deployments)
+
+	// A truncated store file
+	content, _ := os.ReadFile(filename)
+	assert.Nil(t, os.WriteFile(filename, content[:len(content)/2], 0644))
+	_, _, err = Verify(filename)
+	assert.ErrorIs(t, err, ErrCorrupted)
+	assert.NotNil(t, Restore(filename+".1", filename))
+
+	assert.Nil(t, Restore(filename, filename+".1"))
+	_, _, err = Verify(filename)
+	assert.Nil(t, err)
+	// The truncated store file is kept
+	truncated, _ := os.ReadFile(filename + ".1")
+	assert.Equal(t, content[:len(content)/2], truncated)
+}
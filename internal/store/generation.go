@@ -1,8 +1,10 @@
 package store
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 	"time"
@@ -15,6 +17,39 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// ErrTimeout is the error cause recorded when an evaluation or a
+// build is cancelled because it has reached its timeout.
+var ErrTimeout = errors.New("timeout")
+
+// ErrMaxSilentTime is the error cause recorded when a build is
+// cancelled because it didn't produce any output during too long.
+var ErrMaxSilentTime = errors.New("max-silent-time")
+
+// ErrCacheDeadline is the error cause recorded when the closure of a
+// generation has not been available in binary caches before the
+// substitution deadline.
+var ErrCacheDeadline = errors.New("cache-deadline")
+
+// ErrInsufficientDiskSpace is the error cause recorded when a build
+// or a deployment is refused because the free disk space is too low.
+var ErrInsufficientDiskSpace = errors.New("insufficient-disk-space")
+
+// errCause returns the cause of an error when it has been triggered
+// by comin itself and an empty string otherwise.
+func errCause(err error) string {
+	switch {
+	case errors.Is(err, ErrTimeout):
+		return ErrTimeout.Error()
+	case errors.Is(err, ErrMaxSilentTime):
+		return ErrMaxSilentTime.Error()
+	case errors.Is(err, ErrCacheDeadline):
+		return ErrCacheDeadline.Error()
+	case errors.Is(err, ErrInsufficientDiskSpace):
+		return ErrInsufficientDiskSpace.Error()
+	}
+	return ""
+}
+
 type EvalStatus int64
 
 const (
@@ -60,6 +95,12 @@ const (
 	Building
 	Built
 	BuildFailed
+	// The build has not been submitted, for instance because the
+	// machine-id of the generation is not the one of the host.
+	BuildSkipped
+	// The closure of the generation is not yet available in
+	// binary caches, it is periodically rechecked.
+	BuildWaitingForCache
 )
 
 func (s BuildStatus) String() string {
@@ -72,6 +113,10 @@ func (s BuildStatus) String() string {
 		return "built"
 	case BuildFailed:
 		return "failed"
+	case BuildSkipped:
+		return "skipped"
+	case BuildWaitingForCache:
+		return "waiting for cache"
 	}
 	return "unknown"
 }
@@ -86,6 +131,10 @@ func StringToBuildStatus(statusStr string) BuildStatus {
 		return Built
 	case "failed":
 		return BuildFailed
+	case "skipped":
+		return BuildSkipped
+	case "waiting for cache":
+		return BuildWaitingForCache
 	default:
 		return BuildInit
 	}
@@ -115,6 +164,7 @@ func (s *Store) NewGeneration(hostname, repositoryPath, repositoryDir, systemAtt
 
 func GenerationShow(g *protobuf.Generation) {
 	padding := "    "
+	defer hookResultsShow(g.Hooks, padding)
 	fmt.Printf("%sGeneration UUID %s\n", padding, g.Uuid)
 	fmt.Printf("%sCommit ID %s from %s/%s\n", padding, g.SelectedCommitId, g.SelectedRemoteName, g.SelectedBranchName)
 	fmt.Printf("%sCommit message: %s\n", padding, strings.Trim(g.SelectedCommitMsg, "\n"))
@@ -130,32 +180,68 @@ func GenerationShow(g *protobuf.Generation) {
 	switch g.EvalStatus {
 	case Evaluated.String():
 		fmt.Printf("%sEvaluation succeeded %s\n", padding, humanize.Time(g.EvalEndedAt.AsTime()))
-		fmt.Printf("%s  DrvPath: %s\n", padding, g.DrvPath)
+		if g.EvalSource != "" {
+			fmt.Printf("%s  Source: %s\n", padding, g.EvalSource)
+		} else {
+			fmt.Printf("%s  DrvPath: %s\n", padding, g.DrvPath)
+		}
 	case EvalFailed.String():
 		fmt.Printf("%sEvaluation failed %s\n", padding, humanize.Time(g.EvalEndedAt.AsTime()))
+		if g.EvalErrCause != "" {
+			fmt.Printf("%s  Cause: %s\n", padding, g.EvalErrCause)
+		}
 	}
 	if g.BuildStatus == BuildInit.String() {
 		fmt.Printf("%sNo build started\n", padding)
 		return
 	}
+	if g.BuildStatus == BuildSkipped.String() {
+		fmt.Printf("%sBuild skipped %s\n", padding, humanize.Time(g.BuildEndedAt.AsTime()))
+		fmt.Printf("%s  Reason: %s\n", padding, g.BuildReason)
+		return
+	}
 	if g.BuildStatus == Building.String() {
 		fmt.Printf("%sBuild started %s\n", padding, humanize.Time(g.BuildStartedAt.AsTime()))
 		return
 	}
+	if g.BuildStatus == BuildWaitingForCache.String() {
+		fmt.Printf("%sWaiting for the binary cache since %s\n", padding, humanize.Time(g.BuildStartedAt.AsTime()))
+		return
+	}
 	switch g.BuildStatus {
 	case Built.String():
 		fmt.Printf("%sBuilt %s\n", padding, humanize.Time(g.BuildEndedAt.AsTime()))
 		fmt.Printf("%s  Outpath:  %s\n", padding, g.OutPath)
+		if g.BuiltBy != "" {
+			fmt.Printf("%s  Built by: %s\n", padding, g.BuiltBy)
+		}
+		closureDiffShow(g.ClosureDiff, padding)
+		unitChangesShow(g.UnitChanges, padding)
 	case BuildFailed.String():
 		fmt.Printf("%sBuild failed %s\n", padding, humanize.Time(g.BuildEndedAt.AsTime()))
+		if g.BuildErrCause != "" {
+			fmt.Printf("%s  Cause: %s\n", padding, g.BuildErrCause)
+		}
 	}
 }
 
-// generationsGC garbage collects unwanted generations. This is not thread safe.
+// generationsGC garbage collects unwanted generations. The last
+// evaluated and built generations are kept, as well as the last
+// builtCapacity built generations. This is not thread safe.
 func (s *Store) generationsGC() {
+	built := make([]*protobuf.Generation, 0)
+	for _, g := range s.persisted.Generations {
+		if g.BuildStatus == Built.String() {
+			built = append(built, g)
+		}
+	}
+	slices.SortStableFunc(built, func(a, b *protobuf.Generation) int {
+		return b.BuildEndedAt.AsTime().Compare(a.BuildEndedAt.AsTime())
+	})
+	built = built[:min(len(built), s.builtCapacity)]
 	alive := make([]*protobuf.Generation, 0)
 	for _, g := range s.persisted.Generations {
-		if g == s.lastEvalStarted || g == s.lastEvalFinished || g == s.lastBuildStarted || g == s.lastBuildFinished {
+		if g == s.lastEvalStarted || g == s.lastEvalFinished || g == s.lastBuildStarted || g == s.lastBuildFinished || slices.Contains(built, g) {
 			alive = append(alive, g)
 		}
 	}
@@ -166,9 +252,48 @@ func (s *Store) generationsGC() {
 		}
 	}
 	s.persisted.Generations = alive
+	s.logsGC()
+	s.inhibitorOverridesGC()
+	s.gcRootsGC()
 }
 
-func (s *Store) GenerationEvalStarted(uuid string) error {
+// generationGcRoot returns the path of the GC root of a generation
+func (s *Store) generationGcRoot(uuid string) string {
+	return filepath.Join(s.gcRootsDir, "generation-"+uuid)
+}
+
+// gcRootsGC removes the GC roots of the generations which are no
+// longer in the store. This is not thread safe.
+func (s *Store) gcRootsGC() {
+	entries, err := os.ReadDir(s.gcRootsDir)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			logrus.Errorf("store: cannot read the GC roots directory %s: %s", s.gcRootsDir, err)
+		}
+		return
+	}
+	alive := make([]string, 0)
+	for _, g := range s.persisted.Generations {
+		alive = append(alive, filepath.Base(s.generationGcRoot(g.Uuid)))
+	}
+	for _, e := range entries {
+		// last-built-generation is the GC root used by previous
+		// comin versions
+		if e.Name() != "last-built-generation" && (!strings.HasPrefix(e.Name(), "generation-") || slices.Contains(alive, e.Name())) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.gcRootsDir, e.Name())); err != nil {
+			logrus.Errorf("store: cannot remove the GC root %s: %s", e.Name(), err)
+		} else {
+			logrus.Infof("store: GC root %s removed", e.Name())
+		}
+	}
+}
+
+// GenerationEvalStarted marks a generation as evaluating. evalSource
+// is empty when the configuration is evaluated, otherwise it is the
+// source of the out path, such as a manifest.
+func (s *Store) GenerationEvalStarted(uuid, evalSource string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	g, err := s.generationGet(uuid)
@@ -177,11 +302,13 @@ func (s *Store) GenerationEvalStarted(uuid string) error {
 	}
 	g.EvalStartedAt = timestamppb.New(time.Now().UTC())
 	g.EvalStatus = Evaluating.String()
+	g.EvalSource = evalSource
 
 	s.lastEvalStarted = g
 	s.generationsGC()
 	e := &protobuf.Event_EvalStarted{Generation: g}
 	s.broker.Publish(&protobuf.Event{Type: &protobuf.Event_EvalStartedType{EvalStartedType: e}, CreatedAt: timestamppb.New(time.Now().UTC())})
+	s.historyRecord()
 	return nil
 }
 
@@ -194,6 +321,7 @@ func (s *Store) GenerationEvalFinished(uuid string, drvPath, outPath, machineId
 	}
 	if evalErr != nil {
 		g.EvalErr = evalErr.Error()
+		g.EvalErrCause = errCause(evalErr)
 		g.EvalStatus = EvalFailed.String()
 	} else {
 		g.EvalStatus = Evaluated.String()
@@ -206,6 +334,7 @@ func (s *Store) GenerationEvalFinished(uuid string, drvPath, outPath, machineId
 	s.generationsGC()
 	e := &protobuf.Event_EvalFinished{Generation: g}
 	s.broker.Publish(&protobuf.Event{Type: &protobuf.Event_EvalFinishedType{EvalFinishedType: e}, CreatedAt: timestamppb.New(time.Now().UTC())})
+	s.historyRecord()
 	return nil
 }
 
@@ -223,10 +352,11 @@ func (s *Store) GenerationBuildStart(uuid, reason string) error {
 	s.generationsGC()
 	e := &protobuf.Event_BuildStarted{Generation: g}
 	s.broker.Publish(&protobuf.Event{Type: &protobuf.Event_BuildStartedType{BuildStartedType: e}, CreatedAt: timestamppb.New(time.Now().UTC())})
+	s.historyRecord()
 	return nil
 }
 
-func (s *Store) GenerationBuildFinished(uuid string, buildErr error) error {
+func (s *Store) GenerationBuildFinished(uuid, builtBy string, buildErr error) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	g, err := s.generationGet(uuid)
@@ -236,26 +366,68 @@ func (s *Store) GenerationBuildFinished(uuid string, buildErr error) error {
 	g.BuildEndedAt = timestamppb.New(time.Now().UTC())
 	if buildErr == nil {
 		g.BuildStatus = Built.String()
-		// We create a gcroots for the last built generation
-		// in order to avoid the Nix garbage collector to
-		// remove this store path which could be used later by
-		// the deployment step.
-		if _, err := os.Lstat(s.generationGcRoot); err == nil {
-			if err := os.Remove(s.generationGcRoot); err != nil {
+		g.BuiltBy = builtBy
+		// We create a gcroots for the built generation in
+		// order to avoid the Nix garbage collector to remove
+		// this store path which could be used later by the
+		// deployment step. It is removed with the generation.
+		gcRoot := s.generationGcRoot(g.Uuid)
+		if _, err := os.Lstat(gcRoot); err == nil {
+			if err := os.Remove(gcRoot); err != nil {
 				logrus.Error(err)
 			}
 		}
-		if err := os.Symlink(g.OutPath, s.generationGcRoot); err != nil {
+		if err := os.Symlink(g.OutPath, gcRoot); err != nil {
 			logrus.Errorf("Could not create the gcroot symlink for the generation %s: %s", g.Uuid, err)
+		} else {
+			g.GcRoot = gcRoot
 		}
 	} else {
 		g.BuildStatus = BuildFailed.String()
 		g.BuildErr = buildErr.Error()
+		g.BuildErrCause = errCause(buildErr)
 	}
 	s.lastBuildFinished = g
 	s.generationsGC()
 	e := &protobuf.Event_BuildFinished{Generation: g}
 	s.broker.Publish(&protobuf.Event{Type: &protobuf.Event_BuildFinishedType{BuildFinishedType: e}, CreatedAt: timestamppb.New(time.Now().UTC())})
+	s.historyRecord()
+	return nil
+}
+
+// GenerationBuildWaitingForCache marks a generation as waiting for
+// its closure to be available in binary caches.
+func (s *Store) GenerationBuildWaitingForCache(uuid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, err := s.generationGet(uuid)
+	if err != nil {
+		return err
+	}
+	g.BuildStatus = BuildWaitingForCache.String()
+	e := &protobuf.Event_BuildWaitingForCache{Generation: g}
+	s.broker.Publish(&protobuf.Event{Type: &protobuf.Event_BuildWaitingForCacheType{BuildWaitingForCacheType: e}, CreatedAt: timestamppb.New(time.Now().UTC())})
+	s.historyRecord()
+	return nil
+}
+
+// GenerationBuildSkipped marks a generation as not being built
+// because of the provided reason.
+func (s *Store) GenerationBuildSkipped(uuid, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, err := s.generationGet(uuid)
+	if err != nil {
+		return err
+	}
+	g.BuildEndedAt = timestamppb.New(time.Now().UTC())
+	g.BuildStatus = BuildSkipped.String()
+	g.BuildReason = reason
+	s.lastBuildFinished = g
+	s.generationsGC()
+	e := &protobuf.Event_BuildSkipped{Generation: g}
+	s.broker.Publish(&protobuf.Event{Type: &protobuf.Event_BuildSkippedType{BuildSkippedType: e}, CreatedAt: timestamppb.New(time.Now().UTC())})
+	s.historyRecord()
 	return nil
 }
 
@@ -283,3 +455,10 @@ func (s *Store) generationGet(uuid string) (g *protobuf.Generation, err error) {
 func GenerationHasToBeBuilt(g *protobuf.Generation) bool {
 	return g.EvalStatus == Evaluated.String() && g.BuildStatus != Built.String()
 }
+
+// GenerationBuildProgress publishes the progress of the build of a
+// generation. The progress is not persisted.
+func (s *Store) GenerationBuildProgress(uuid string, progress *protobuf.BuildProgress) {
+	e := &protobuf.Event_BuildProgress{GenerationUuid: uuid, Progress: progress}
+	s.broker.Publish(&protobuf.Event{Type: &protobuf.Event_BuildProgressType{BuildProgressType: e}, CreatedAt: timestamppb.New(time.Now().UTC())})
+}
@@ -0,0 +1,109 @@
+package store
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/nlewo/comin/internal/history"
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+)
+
+// SetHistory records the updates of the deployments and generations
+// into the history h, which mirrors the state: the state file is still
+// written on every change. The deployments and generations of the
+// state are recorded immediately, which initializes an empty history.
+// The history is not updated when h is nil.
+func (s *Store) SetHistory(h history.Store) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = h
+	s.recorded = make(map[string]proto.Message)
+	s.historyRecord()
+}
+
+// History returns the history, or nil if it is not enabled
+func (s *Store) History() history.Store {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.history
+}
+
+// historyRecord puts into the history the deployments and generations
+// of the state which have changed since they have been recorded. It
+// is called by Commit and by the mutators which don't commit. This is
+// not thread safe.
+func (s *Store) historyRecord() {
+	if s.history == nil {
+		return
+	}
+	recorded := make(map[string]proto.Message, len(s.recorded))
+	for _, g := range s.persisted.Generations {
+		r, ok := s.recorded[g.Uuid]
+		if !ok || !proto.Equal(r, g) {
+			if err := s.history.GenerationPut(g); err != nil {
+				logrus.Errorf("store: cannot record the generation %s in the history: %s", g.Uuid, err)
+				continue
+			}
+			r = proto.CloneOf(g)
+		}
+		recorded[g.Uuid] = r
+	}
+	for _, d := range s.persisted.Deployments {
+		// The dummy deployments of the booted and switched
+		// systems, which have no generation UUID, are not recorded
+		if d.Generation.GetUuid() == "" {
+			continue
+		}
+		r, ok := s.recorded[d.Uuid]
+		if !ok || !proto.Equal(r, d) {
+			if err := s.history.DeploymentPut(d); err != nil {
+				logrus.Errorf("store: cannot record the deployment %s in the history: %s", d.Uuid, err)
+				continue
+			}
+			r = proto.CloneOf(d)
+		}
+		recorded[d.Uuid] = r
+	}
+	s.recorded = recorded
+}
+
+// ListDeployments is thread safe and returns copies of the
+// deployments matching the query. They are read from the history when
+// it is enabled and from the state otherwise. When bootEntries is
+// true, only the switched, booted and boot entry deployments of the
+// state are listed.
+func (s *Store) ListDeployments(q history.Query, bootEntries bool) ([]*protobuf.Deployment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.history != nil && !bootEntries {
+		return s.history.Deployments(q)
+	}
+	dpls := make([]*protobuf.Deployment, 0, len(s.persisted.Deployments))
+	for _, d := range s.persisted.Deployments {
+		if bootEntries && d.Uuid != s.persisted.DeploymentSwitched && d.Uuid != s.persisted.DeploymentBooted && !slices.Contains(s.persisted.DeploymentsBootEntry, d.Uuid) {
+			continue
+		}
+		dpls = append(dpls, d)
+	}
+	result := history.FilterDeployments(q, dpls)
+	for i, d := range result {
+		result[i] = proto.CloneOf(d)
+	}
+	return result, nil
+}
+
+// DeploymentFind is thread safe and returns a copy of the deployment
+// uuid. It is searched in the state and then in the history.
+func (s *Store) DeploymentFind(uuid string) (*protobuf.Deployment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d, err := s.deploymentGet(uuid); err == nil {
+		return proto.CloneOf(d), nil
+	}
+	if s.history != nil {
+		return s.history.Deployment(uuid)
+	}
+	return nil, fmt.Errorf("store: the deployment %s is %w", uuid, history.ErrNotFound)
+}
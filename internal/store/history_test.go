@@ -0,0 +1,110 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/nlewo/comin/internal/broker"
+	"github.com/nlewo/comin/internal/history"
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory(t *testing.T) {
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+	s, _ := New(bk, tmp+"/store.json", tmp+"/gcroots", 1, 1, 1, 0)
+	h, err := history.OpenBolt(tmp+"/history/history.db", 0)
+	assert.Nil(t, err)
+	defer h.Close() // nolint: errcheck
+	s.SetHistory(h)
+
+	var uuids []string
+	for i := range 3 {
+		d := s.NewDeployment(&protobuf.Generation{Uuid: "g", OutPath: "/nix/store/" + string(rune('a'+i))}, "switch", "", "", "")
+		assert.Nil(t, s.DeploymentStarted(d.Uuid, "", ""))
+		assert.Nil(t, s.DeploymentFinished(d.Uuid, nil, false, "", "", ""))
+		uuids = append(uuids, d.Uuid)
+	}
+	// The state file only keeps the deployments required by the
+	// retention policy while the history keeps all of them
+	ds, err := h.Deployments(history.Query{})
+	assert.Nil(t, err)
+	assert.Len(t, ds, 3)
+	for _, d := range ds {
+		assert.Contains(t, uuids, d.Uuid)
+		assert.Equal(t, "done", d.Status)
+	}
+	assert.Less(t, len(s.DeploymentList()), 3)
+
+	// The deployments are listed from the history, except the boot
+	// entries which are listed from the state
+	ds, err = s.ListDeployments(history.Query{OldestFirst: true}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, uuids[0], ds[0].Uuid)
+	assert.Len(t, ds, 3)
+	ds, err = s.ListDeployments(history.Query{}, true)
+	assert.Nil(t, err)
+	assert.Less(t, len(ds), 3)
+	d, err := s.DeploymentFind(uuids[0])
+	assert.Nil(t, err)
+	assert.Equal(t, uuids[0], d.Uuid)
+	_, err = s.DeploymentFind("unknown")
+	assert.ErrorIs(t, err, history.ErrNotFound)
+}
+
+func TestHistoryRecord(t *testing.T) {
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+	s, _ := New(bk, tmp+"/store.json", tmp+"/gcroots", 1, 1, 1, 0)
+	d := s.NewDeployment(&protobuf.Generation{Uuid: "g0", OutPath: "/nix/store/a"}, "switch", "", "", "")
+
+	// The deployments of the state are recorded when the history
+	// is set
+	h, err := history.OpenBolt(tmp+"/history/history.db", 0)
+	assert.Nil(t, err)
+	defer h.Close() // nolint: errcheck
+	s.SetHistory(h)
+	_, err = h.Deployment(d.Uuid)
+	assert.Nil(t, err)
+
+	// The mutators which don't commit are recorded
+	g := s.NewGeneration("machine", "", "", "", &protobuf.RepositoryStatus{SelectedCommitId: "aaaa"})
+	assert.Nil(t, s.GenerationEvalStarted(g.Uuid, ""))
+	gs, err := h.Generations(history.Query{Status: Evaluating.String()})
+	assert.Nil(t, err)
+	assert.Len(t, gs, 1)
+	assert.Nil(t, s.GenerationBuildStart(g.Uuid, ""))
+	gs, err = h.Generations(history.Query{Status: Building.String()})
+	assert.Nil(t, err)
+	assert.Len(t, gs, 1)
+
+	assert.Nil(t, s.DeploymentRebootReasons(d.Uuid, []string{"the kernel has changed"}))
+	recorded, err := h.Deployment(d.Uuid)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"the kernel has changed"}, recorded.RebootReasons)
+}
+
+func TestListDeploymentsWithoutHistory(t *testing.T) {
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+	s, _ := New(bk, tmp+"/store.json", tmp+"/gcroots", 2, 2, 5, 0)
+	for _, operation := range []string{"switch", "boot", "switch"} {
+		d := s.NewDeployment(&protobuf.Generation{Uuid: "g"}, operation, "", "", "")
+		d.Operation = operation
+		assert.Nil(t, s.DeploymentStarted(d.Uuid, "", ""))
+		assert.Nil(t, s.DeploymentFinished(d.Uuid, nil, false, "", "", ""))
+	}
+	ds, err := s.ListDeployments(history.Query{Operation: "switch"}, false)
+	assert.Nil(t, err)
+	assert.Len(t, ds, 2)
+	// The returned deployments are copies
+	ds[0].Status = "modified"
+	d, err := s.DeploymentFind(ds[0].Uuid)
+	assert.Nil(t, err)
+	assert.Equal(t, "done", d.Status)
+	_, err = s.DeploymentFind("unknown")
+	assert.ErrorIs(t, err, history.ErrNotFound)
+}
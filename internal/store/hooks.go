@@ -0,0 +1,99 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nlewo/comin/pkg/protobuf"
+)
+
+// hookResultsCapacity is the number of post-fetch and post-reboot
+// hook results kept in the store
+const hookResultsCapacity = 50
+
+// GenerationHookResults records the results of the post-eval and
+// post-build hooks of a generation.
+func (s *Store) GenerationHookResults(uuid string, results []*protobuf.HookResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, err := s.generationGet(uuid)
+	if err != nil {
+		return err
+	}
+	g.Hooks = append(g.Hooks, results...)
+	s.Commit()
+	return nil
+}
+
+// DeploymentHookResults records the results of the post-deploy hooks
+// of a deployment.
+func (s *Store) DeploymentHookResults(uuid string, results []*protobuf.HookResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, err := s.deploymentGet(uuid)
+	if err != nil {
+		return err
+	}
+	d.Hooks = append(d.Hooks, results...)
+	s.Commit()
+	return nil
+}
+
+// HookResultsAdd records the results of the post-fetch and
+// post-reboot hooks. Only the last results are kept.
+func (s *Store) HookResultsAdd(results []*protobuf.HookResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.persisted.HookResults = append(s.persisted.HookResults, results...)
+	if len(s.persisted.HookResults) > hookResultsCapacity {
+		s.persisted.HookResults = s.persisted.HookResults[len(s.persisted.HookResults)-hookResultsCapacity:]
+	}
+	s.Commit()
+}
+
+// BootIdUpdate records the boot ID of the machine and returns true if
+// it differs from the previously recorded one, meaning the machine has
+// rebooted since the previous comin start.
+func (s *Store) BootIdUpdate(bootId string) (rebooted bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rebooted = s.persisted.BootId != "" && s.persisted.BootId != bootId
+	s.persisted.BootId = bootId
+	s.Commit()
+	return
+}
+
+// BootId returns the boot ID of the running machine
+func BootId() (string, error) {
+	content, err := os.ReadFile("/proc/sys/kernel/random/boot_id")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// HookResultLines returns a human readable summary of hook results,
+// with the end of their output
+func HookResultLines(results []*protobuf.HookResult) (lines []string) {
+	for _, r := range results {
+		lines = append(lines, fmt.Sprintf("%s hook %s exited with %d", r.Stage, r.Name, r.ExitCode))
+		for _, line := range strings.Split(strings.TrimRight(r.Output, "\n"), "\n") {
+			if line != "" {
+				lines = append(lines, "  "+line)
+			}
+		}
+	}
+	return
+}
+
+func hookResultsShow(results []*protobuf.HookResult, padding string) {
+	lines := HookResultLines(results)
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Printf("%sHooks:\n", padding)
+	for _, l := range lines {
+		fmt.Printf("%s  %s\n", padding, l)
+	}
+}
@@ -0,0 +1,180 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	uuidPkg "github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	LogsEval  = "eval"
+	LogsBuild = "build"
+)
+
+// logsFollowPeriod is the period used to poll a log file which is
+// still being written.
+var logsFollowPeriod = 500 * time.Millisecond
+
+// logsStartTimeout is the maximal duration to wait for the start of
+// the evaluation or the build of a generation when following its logs.
+var logsStartTimeout = time.Minute
+
+func (s *Store) generationLogsPath(uuid, kind string) string {
+	return filepath.Join(s.logsDir, uuid, kind+".log")
+}
+
+// GenerationLogsCreate creates the file storing the logs of kind
+// (eval or build) of a generation. The file is truncated if it
+// already exists.
+func (s *Store) GenerationLogsCreate(uuid, kind string) (*os.File, error) {
+	if kind != LogsEval && kind != LogsBuild {
+		return nil, fmt.Errorf("store: the logs kind is '%s' while it must be one of [%s, %s]", kind, LogsEval, LogsBuild)
+	}
+	p := s.generationLogsPath(uuid, kind)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(p)
+}
+
+// generationLogsRunning returns true when the logs of kind of a
+// generation could still be written. pending is true when the
+// evaluation or the build has not started yet.
+func (s *Store) generationLogsRunning(uuid, kind string) (running, pending bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, err := s.generationGet(uuid)
+	if err != nil {
+		return false, false
+	}
+	switch kind {
+	case LogsEval:
+		pending = g.EvalStatus == EvalInit.String()
+		return pending || g.EvalStatus == Evaluating.String(), pending
+	case LogsBuild:
+		if g.EvalStatus == EvalFailed.String() {
+			return false, false
+		}
+		pending = g.BuildStatus == BuildInit.String()
+		return pending || g.BuildStatus == Building.String() || g.BuildStatus == BuildWaitingForCache.String(), pending
+	}
+	return false, false
+}
+
+// checkGenerationLogs checks the generation uuid and the kind of a
+// logs request. Since they are provided by the API and used to build
+// the logs file path, the kind has to be known, the uuid has to be
+// valid and the generation has to exist.
+func (s *Store) checkGenerationLogs(uuid, kind string) error {
+	if kind != LogsEval && kind != LogsBuild {
+		return fmt.Errorf("store: the logs kind is '%s' while it must be one of [%s, %s]", kind, LogsEval, LogsBuild)
+	}
+	if _, err := uuidPkg.Parse(uuid); err != nil {
+		return fmt.Errorf("store: the generation uuid '%s' is invalid: %w", uuid, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.generationGet(uuid); err == nil {
+		return nil
+	}
+	for _, d := range s.persisted.Deployments {
+		if d.Generation != nil && d.Generation.Uuid == uuid {
+			return nil
+		}
+	}
+	return fmt.Errorf("store: no %s logs for the generation %s since it doesn't exist", kind, uuid)
+}
+
+// GenerationLogsRead writes the logs of kind of a generation to w. If
+// follow is true, it waits for new logs until the evaluation or the
+// build of the generation is finished or until ctx is cancelled. It
+// doesn't wait more than logsStartTimeout for the evaluation or the
+// build to start.
+func (s *Store) GenerationLogsRead(ctx context.Context, uuid, kind string, follow bool, w io.Writer) error {
+	if err := s.checkGenerationLogs(uuid, kind); err != nil {
+		return err
+	}
+	p := s.generationLogsPath(uuid, kind)
+	var f *os.File
+	var err error
+	startDeadline := time.Now().Add(logsStartTimeout)
+	for {
+		f, err = os.Open(p)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, os.ErrNotExist) || !follow {
+			return fmt.Errorf("store: no %s logs for the generation %s", kind, uuid)
+		}
+		running, pending := s.generationLogsRunning(uuid, kind)
+		if !running || (pending && time.Now().After(startDeadline)) {
+			return fmt.Errorf("store: no %s logs for the generation %s", kind, uuid)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(logsFollowPeriod):
+		}
+	}
+	defer f.Close() // nolint: errcheck
+
+	for {
+		if _, err := io.Copy(w, f); err != nil {
+			return err
+		}
+		if !follow {
+			return nil
+		}
+		// Logs written between the previous copy and the end
+		// of the generation step are copied by a last copy.
+		if running, _ := s.generationLogsRunning(uuid, kind); !running {
+			_, err := io.Copy(w, f)
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(logsFollowPeriod):
+		}
+	}
+}
+
+// logsGC removes the logs of generations which are no longer in the
+// store, either as a generation or as a deployment generation. This
+// is not thread safe.
+func (s *Store) logsGC() {
+	entries, err := os.ReadDir(s.logsDir)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			logrus.Errorf("store: cannot read the logs directory %s: %s", s.logsDir, err)
+		}
+		return
+	}
+	alive := make([]string, 0)
+	for _, g := range s.persisted.Generations {
+		alive = append(alive, g.Uuid)
+	}
+	for _, d := range s.persisted.Deployments {
+		if d.Generation != nil {
+			alive = append(alive, d.Generation.Uuid)
+		}
+	}
+	for _, e := range entries {
+		if slices.Contains(alive, e.Name()) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(s.logsDir, e.Name())); err != nil {
+			logrus.Errorf("store: cannot remove the logs of the generation %s: %s", e.Name(), err)
+		} else {
+			logrus.Infof("store: logs of the generation %s removed", e.Name())
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nlewo/comin/pkg/protobuf"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// ErrCorrupted is returned when the store file can not be decoded
+var ErrCorrupted = errors.New("the store file is corrupted")
+
+// ErrNewerSchema is returned when the store file has been written by
+// a newer comin version
+var ErrNewerSchema = errors.New("the store file has been written by a newer comin version")
+
+// migration migrates the decoded JSON store file from a schema version
+// to the next one
+type migration func(data map[string]any) error
+
+// migrations are the store file migrations ordered by schema
+// version: migrations[i] migrates a store file from the schema version
+// i+1 to the schema version i+2. A migration has to be appended to this
+// list each time the schema of the store file changes in a non
+// backward compatible way. The store files written before the schema
+// versioning have the version 0 and the same schema as the version 1.
+var migrations = []migration{}
+
+// SchemaVersion is the schema version of the store files written by
+// this comin version
+var SchemaVersion = int32(len(migrations)) + 1
+
+// decode decodes the content of a store file, after having migrated
+// it to the current schema version. It also returns the schema version
+// of the content.
+func decode(content []byte) (*protobuf.Store, int32, error) {
+	var data map[string]any
+	if err := json.Unmarshal(content, &data); err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", ErrCorrupted, err)
+	}
+	version := int32(0)
+	if v, ok := data["schema_version"]; ok {
+		f, ok := v.(float64)
+		if !ok {
+			return nil, 0, fmt.Errorf("%w: the schema_version is not a number", ErrCorrupted)
+		}
+		version = int32(f)
+	} else if v, ok := data["schemaVersion"].(float64); ok {
+		version = int32(v)
+	}
+	if version > SchemaVersion {
+		return nil, version, fmt.Errorf("%w: the schema version is %d while comin supports up to %d", ErrNewerSchema, version, SchemaVersion)
+	}
+	if version < SchemaVersion {
+		for v := max(version, 1); v < SchemaVersion; v++ {
+			logrus.Debugf("store: migrating the store file from the schema version %d to %d", v, v+1)
+			if err := migrations[v-1](data); err != nil {
+				return nil, version, fmt.Errorf("%w: the migration from the schema version %d failed: %w", ErrCorrupted, v, err)
+			}
+		}
+		delete(data, "schemaVersion")
+		data["schema_version"] = SchemaVersion
+		migrated, err := json.Marshal(data)
+		if err != nil {
+			return nil, version, err
+		}
+		content = migrated
+	}
+	// The unknown fields are discarded to be able to load a store
+	// file written by a newer comin version with the same schema
+	// version, for instance after a rollback
+	var store protobuf.Store
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(content, &store); err != nil {
+		return nil, version, fmt.Errorf("%w: %w", ErrCorrupted, err)
+	}
+	return &store, version, nil
+}
+
+// Quarantine moves a corrupted store file aside, to
+// filename.corrupted-<date>, in order to start with an empty store. It
+// returns the path of the quarantined file.
+func (s *Store) Quarantine() (string, error) {
+	quarantined := fmt.Sprintf("%s.corrupted-%s", s.filename, time.Now().UTC().Format("20060102T150405Z"))
+	if err := os.Rename(s.filename, quarantined); err != nil {
+		return "", err
+	}
+	logrus.Errorf("store: the store file %s has been quarantined to %s", s.filename, quarantined)
+	return quarantined, nil
+}
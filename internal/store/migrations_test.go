@@ -0,0 +1,105 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nlewo/comin/internal/broker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrations(t *testing.T) {
+	assert.Empty(t, migrations)
+	assert.Equal(t, int32(1), SchemaVersion)
+
+	tmp := t.TempDir()
+	filename := tmp + "/state.json"
+	bk := broker.New()
+	bk.Start()
+	// A store file written before the schema versioning
+	v0 := `{"deployments": [{"uuid": "d1", "generation": {"uuid": "g1"}}]}`
+	assert.Nil(t, os.WriteFile(filename, []byte(v0), 0644))
+
+	s, _ := New(bk, filename, tmp+"/gcroots", 2, 2, 5, 0)
+	assert.Nil(t, s.Load())
+	_, err := s.GetDeployment("d1")
+	assert.Nil(t, err)
+	backup, err := os.ReadFile(filename + ".v0.bak")
+	assert.Nil(t, err)
+	assert.Equal(t, v0, string(backup))
+
+	// The store file has been written with the current schema
+	// version
+	content, err := os.ReadFile(filename)
+	assert.Nil(t, err)
+	var data map[string]any
+	assert.Nil(t, json.Unmarshal(content, &data))
+	assert.Equal(t, float64(SchemaVersion), data["schema_version"])
+	s, _ = New(bk, filename, tmp+"/gcroots", 2, 2, 5, 0)
+	assert.Nil(t, s.Load())
+}
+
+func TestDecodeMigrations(t *testing.T) {
+	previousMigrations, previousSchemaVersion := migrations, SchemaVersion
+	t.Cleanup(func() {
+		migrations, SchemaVersion = previousMigrations, previousSchemaVersion
+	})
+	migrations = []migration{
+		func(data map[string]any) error {
+			data["deployment_switched"] = data["switched"]
+			delete(data, "switched")
+			return nil
+		},
+		func(data map[string]any) error {
+			if _, ok := data["deployment_switched"]; !ok {
+				return fmt.Errorf("the deployment_switched field is missing")
+			}
+			return nil
+		},
+	}
+	SchemaVersion = int32(len(migrations)) + 1
+
+	for _, content := range []string{`{"switched": "d1"}`, `{"schema_version": 1, "switched": "d1"}`} {
+		data, version, err := decode([]byte(content))
+		assert.Nil(t, err)
+		assert.LessOrEqual(t, version, int32(1))
+		assert.Equal(t, "d1", data.DeploymentSwitched)
+		assert.Equal(t, int32(3), data.SchemaVersion)
+	}
+	// Only the migrations from the schema version of the file are run
+	data, version, err := decode([]byte(`{"schema_version": 2, "deployment_switched": "d1"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), version)
+	assert.Equal(t, "d1", data.DeploymentSwitched)
+
+	_, _, err = decode([]byte(`{"schema_version": 2}`))
+	assert.ErrorIs(t, err, ErrCorrupted)
+}
+
+func TestLoadErrors(t *testing.T) {
+	tmp := t.TempDir()
+	filename := tmp + "/state.json"
+	bk := broker.New()
+	bk.Start()
+	s, _ := New(bk, filename, tmp+"/gcroots", 2, 2, 5, 0)
+
+	assert.Nil(t, os.WriteFile(filename, []byte(`{"schema_version": 1000}`), 0644))
+	assert.ErrorIs(t, s.Load(), ErrNewerSchema)
+
+	assert.Nil(t, os.WriteFile(filename, []byte(`{"deployments": [`), 0644))
+	assert.ErrorIs(t, s.Load(), ErrCorrupted)
+	// The unknown fields, written by a newer comin version, are
+	// ignored
+	assert.Nil(t, os.WriteFile(filename, []byte(`{"schema_version": 1, "unknown": 1}`), 0644))
+	assert.Nil(t, s.Load())
+	assert.Nil(t, os.WriteFile(filename, []byte(`{"deployments": [`), 0644))
+
+	quarantined, err := s.Quarantine()
+	assert.Nil(t, err)
+	matches, _ := filepath.Glob(filename + ".corrupted-*")
+	assert.Equal(t, []string{quarantined}, matches)
+	assert.Nil(t, s.Load())
+}
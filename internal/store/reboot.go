@@ -0,0 +1,49 @@
+package store
+
+import (
+	"time"
+
+	"github.com/nlewo/comin/pkg/protobuf"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// RebootRecorded records the reasons of a reboot triggered by comin.
+// It is committed immediately since the machine is going to reboot.
+func (s *Store) RebootRecorded(reasons []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.persisted.LastReboot = &protobuf.RebootRecord{
+		RebootedAt: timestamppb.New(time.Now().UTC()),
+		Reasons:    reasons,
+	}
+	s.Commit()
+}
+
+// LastReboot returns the last reboot triggered by comin, or nil.
+func (s *Store) LastReboot() *protobuf.RebootRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.persisted.LastReboot == nil {
+		return nil
+	}
+	return proto.CloneOf(s.persisted.LastReboot)
+}
+
+// RebootCancelled records that the reboot required by the deployment
+// deploymentUuid has been cancelled, in order to not plan it again
+// when comin restarts.
+func (s *Store) RebootCancelled(deploymentUuid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.persisted.RebootCancelledDeploymentUuid = deploymentUuid
+	s.Commit()
+}
+
+// IsRebootCancelled returns true if the reboot required by the
+// deployment deploymentUuid has been cancelled.
+func (s *Store) IsRebootCancelled(deploymentUuid string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return deploymentUuid != "" && s.persisted.RebootCancelledDeploymentUuid == deploymentUuid
+}
@@ -4,25 +4,30 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sync"
 
 	"github.com/google/uuid"
 	"github.com/nlewo/comin/internal/broker"
+	"github.com/nlewo/comin/internal/history"
 	"github.com/nlewo/comin/internal/types"
 	"github.com/nlewo/comin/internal/utils"
 	"github.com/nlewo/comin/pkg/protobuf"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 )
 
 type Store struct {
 	persisted          *protobuf.Store
 	mu                 sync.Mutex
 	filename           string
-	generationGcRoot   string
+	gcRootsDir         string
+	logsDir            string
 	bootEntryCapacity  int
 	successfulCapacity int
 	anyCapacity        int
+	builtCapacity      int
 
 	lastEvalStarted   *protobuf.Generation
 	lastEvalFinished  *protobuf.Generation
@@ -30,9 +35,20 @@ type Store struct {
 	lastBuildFinished *protobuf.Generation
 
 	broker *broker.Broker
+
+	history history.Store
+
+	// recorded are the versions of the deployments and
+	// generations of the state last recorded in the history
+	recorded map[string]proto.Message
+
+	// rotated is true once the store file read by Load has been
+	// backed up, so that the backups are the store files of the
+	// last loads instead of the last writes
+	rotated bool
 }
 
-func New(broker *broker.Broker, filename, gcRootsDir string, bootEntryCapacity, successfulCapacity, anyCapacity int) (*Store, error) {
+func New(broker *broker.Broker, filename, gcRootsDir string, bootEntryCapacity, successfulCapacity, anyCapacity, builtCapacity int) (*Store, error) {
 	if bootEntryCapacity < 1 {
 		return nil, fmt.Errorf("store: bootEntryCapacity cannot be < 1")
 	}
@@ -42,6 +58,9 @@ func New(broker *broker.Broker, filename, gcRootsDir string, bootEntryCapacity,
 	if anyCapacity < 1 {
 		return nil, fmt.Errorf("store: anyCapacity cannot be < 1")
 	}
+	if builtCapacity < 0 {
+		return nil, fmt.Errorf("store: builtCapacity cannot be < 0")
+	}
 
 	data := &protobuf.Store{
 		Deployments:                  make([]*protobuf.Deployment, 0),
@@ -50,20 +69,24 @@ func New(broker *broker.Broker, filename, gcRootsDir string, bootEntryCapacity,
 		DeploymentBootEntryCapacity:  int32(bootEntryCapacity),
 		DeploymentSuccessfulCapacity: int32(successfulCapacity),
 		DeploymentAnyCapacity:        int32(anyCapacity),
+		GenerationBuiltCapacity:      int32(builtCapacity),
+		SchemaVersion:                SchemaVersion,
 	}
 	st := Store{
 		filename:           filename,
-		generationGcRoot:   gcRootsDir + "/last-built-generation",
+		gcRootsDir:         gcRootsDir,
+		logsDir:            filepath.Join(filepath.Dir(filename), "logs"),
 		bootEntryCapacity:  bootEntryCapacity,
 		successfulCapacity: successfulCapacity,
 		anyCapacity:        anyCapacity,
+		builtCapacity:      builtCapacity,
 		persisted:          data,
 		broker:             broker,
 	}
 	if err := os.MkdirAll(gcRootsDir, os.ModeDir); err != nil {
 		return nil, err
 	}
-	logrus.Infof("store: init with generationGcRoot=%s deploymentBootEntryCapacity=%d deploymentSuccessfulCapacity=%d deploymentAnyCapacity=%d", st.generationGcRoot, bootEntryCapacity, successfulCapacity, anyCapacity)
+	logrus.Infof("store: init with gcRootsDir=%s logsDir=%s deploymentBootEntryCapacity=%d deploymentSuccessfulCapacity=%d deploymentAnyCapacity=%d generationBuiltCapacity=%d", st.gcRootsDir, st.logsDir, bootEntryCapacity, successfulCapacity, anyCapacity, builtCapacity)
 
 	return &st, nil
 }
@@ -95,7 +118,9 @@ func (s *Store) DeployerUpdate(state *protobuf.DeployerState) {
 }
 
 func (s *Store) Load() (err error) {
-	var data protobuf.Store
+	s.rotated = false
+	data := &protobuf.Store{}
+	var migrated bool
 	content, err := os.ReadFile(s.filename)
 	if errors.Is(err, os.ErrNotExist) {
 		logrus.Infof("store: the store file %s doesn't exist and will be initialized", s.filename)
@@ -103,20 +128,32 @@ func (s *Store) Load() (err error) {
 	} else if err != nil {
 		return
 	} else {
-		unmarshaler := protojson.UnmarshalOptions{}
-		err = unmarshaler.Unmarshal(content, &data)
+		var version int32
+		data, version, err = decode(content)
 		if err != nil {
 			return
 		}
+		if version != SchemaVersion {
+			// The store file is backed up before being
+			// overwritten with the current schema version
+			backup := fmt.Sprintf("%s.v%d.bak", s.filename, version)
+			if err = os.WriteFile(backup, content, 0644); err != nil {
+				return fmt.Errorf("store: cannot backup the store file to %s: %w", backup, err)
+			}
+			logrus.Infof("store: the store file %s has been migrated from the schema version %d to %d and backed up to %s", s.filename, version, SchemaVersion, backup)
+			migrated = true
+		}
 	}
 	if s.persisted != nil {
-		s.compareAndLogCapacities(&data, s.persisted)
+		s.compareAndLogCapacities(data, s.persisted)
 	}
 	// We update stored capacities with the ones provided by the comin configuration
 	data.DeploymentAnyCapacity = s.persisted.DeploymentAnyCapacity
 	data.DeploymentBootEntryCapacity = s.persisted.DeploymentBootEntryCapacity
 	data.DeploymentSuccessfulCapacity = s.persisted.DeploymentSuccessfulCapacity
-	s.persisted = &data
+	data.GenerationBuiltCapacity = s.persisted.GenerationBuiltCapacity
+	data.SchemaVersion = SchemaVersion
+	s.persisted = data
 
 	logrus.Infof("store: loaded %d deployments from %s", len(s.persisted.Deployments), s.filename)
 	if s.persisted.Deployer == nil {
@@ -156,6 +193,10 @@ func (s *Store) Load() (err error) {
 	}
 
 	s.updateDataDeployments(booted, current, nil)
+	s.gcRootsGC()
+	if migrated {
+		s.Commit()
+	}
 
 	return
 }
@@ -171,10 +212,13 @@ func (s *Store) Commit() {
 		logrus.Errorf("store: cannot marshal store.data: %s", err)
 		return
 	}
-	err = os.WriteFile(s.filename, buf, 0644)
+	err = writeFile(s.filename, buf, !s.rotated)
 	if err != nil {
 		logrus.Errorf("store: cannot write store.data to %s: %s", s.filename, err)
+		return
 	}
+	s.rotated = true
+	s.historyRecord()
 }
 
 func (s *Store) compareAndLogCapacities(old, new *protobuf.Store) {
@@ -187,4 +231,7 @@ func (s *Store) compareAndLogCapacities(old, new *protobuf.Store) {
 	if old.DeploymentAnyCapacity != new.DeploymentAnyCapacity {
 		logrus.Infof("store: deploymentAnyCapacity changed from %d to %d", old.DeploymentAnyCapacity, new.DeploymentAnyCapacity)
 	}
+	if old.GenerationBuiltCapacity != new.GenerationBuiltCapacity {
+		logrus.Infof("store: generationBuiltCapacity changed from %d to %d", old.GenerationBuiltCapacity, new.GenerationBuiltCapacity)
+	}
 }
@@ -1,11 +1,14 @@
 package store
 
 import (
+	"bytes"
 	"fmt"
+	"os"
 	"slices"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/nlewo/comin/internal/broker"
 	"github.com/nlewo/comin/pkg/protobuf"
 	"github.com/stretchr/testify/assert"
@@ -184,16 +187,16 @@ func TestDeploymentCommitAndLoad(t *testing.T) {
 	filename := tmp + "/state.json"
 	bk := broker.New()
 	bk.Start()
-	s, _ := New(bk, filename, tmp+"/gcroots", 2, 2, 5)
+	s, _ := New(bk, filename, tmp+"/gcroots", 2, 2, 5, 0)
 	s.Commit()
 
-	s1, _ := New(bk, filename, tmp+"/gcroots", 2, 2, 5)
+	s1, _ := New(bk, filename, tmp+"/gcroots", 2, 2, 5, 0)
 	err := s1.Load()
 	assert.Nil(t, err)
 	assert.Equal(t, 0, len(s.persisted.Deployments))
 
 	s.NewDeployment(&protobuf.Generation{}, "", "", "", "")
-	s1, _ = New(bk, filename, tmp+"/gcroots", 2, 2, 5)
+	s1, _ = New(bk, filename, tmp+"/gcroots", 2, 2, 5, 0)
 	err = s1.Load()
 	assert.Nil(t, err)
 	assert.Equal(t, 2, len(s.persisted.Deployments))
@@ -203,7 +206,7 @@ func TestLastDeployment(t *testing.T) {
 	tmp := t.TempDir()
 	bk := broker.New()
 	bk.Start()
-	s, _ := New(bk, "state.json", tmp+"/gcroots", 2, 2, 5)
+	s, _ := New(bk, tmp+"/state.json", tmp+"/gcroots", 2, 2, 5, 0)
 	ok, _ := s.LastDeployment()
 	assert.False(t, ok)
 	s.NewDeployment(&protobuf.Generation{Uuid: "1"}, "", "", "", "")
@@ -217,10 +220,118 @@ func TestNewGeneration(t *testing.T) {
 	tmp := t.TempDir()
 	bk := broker.New()
 	bk.Start()
-	s, _ := New(bk, tmp+"/filename", tmp+"/gcroots", 2, 2, 5)
+	s, _ := New(bk, tmp+"/filename", tmp+"/gcroots", 2, 2, 5, 0)
 	s.NewGeneration("hostname", "repositoryPath", "repositoryDir", "systemAttr", &protobuf.RepositoryStatus{})
 }
 
+func TestGenerationLogsGC(t *testing.T) {
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+	s, _ := New(bk, tmp+"/state.json", tmp+"/gcroots", 2, 2, 5, 0)
+
+	g1 := s.NewGeneration("hostname", "repositoryPath", "repositoryDir", "systemAttr", &protobuf.RepositoryStatus{})
+	_ = s.GenerationEvalStarted(g1.Uuid, "")
+	f, err := s.GenerationLogsCreate(g1.Uuid, LogsEval)
+	assert.Nil(t, err)
+	_, _ = f.WriteString("evaluating")
+	_ = f.Close()
+	_ = s.GenerationEvalFinished(g1.Uuid, "", "", "", nil)
+
+	var buf bytes.Buffer
+	err = s.GenerationLogsRead(t.Context(), g1.Uuid, LogsEval, true, &buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "evaluating", buf.String())
+
+	// The first generation is removed from the store and its logs are then removed
+	g2 := s.NewGeneration("hostname", "repositoryPath", "repositoryDir", "systemAttr", &protobuf.RepositoryStatus{})
+	_ = s.GenerationEvalStarted(g2.Uuid, "")
+	_ = s.GenerationEvalFinished(g2.Uuid, "", "", "", nil)
+	_, err = os.Stat(tmp + "/logs/" + g1.Uuid)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	err = s.GenerationLogsRead(t.Context(), g1.Uuid, LogsEval, false, &buf)
+	assert.ErrorContains(t, err, "no eval logs")
+}
+
+func TestGenerationLogsRequests(t *testing.T) {
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+	s, _ := New(bk, tmp+"/state.json", tmp+"/gcroots", 2, 2, 5, 0)
+	assert.Nil(t, os.WriteFile(tmp+"/secret.log", []byte("secret"), 0644))
+
+	var buf bytes.Buffer
+	// The uuid and the kind come from the API and can't be used to
+	// read other files
+	err := s.GenerationLogsRead(t.Context(), "../..", "secret", false, &buf)
+	assert.ErrorContains(t, err, "logs kind")
+	err = s.GenerationLogsRead(t.Context(), "..", LogsEval, false, &buf)
+	assert.ErrorContains(t, err, "is invalid")
+	err = s.GenerationLogsRead(t.Context(), uuid.New().String(), LogsEval, true, &buf)
+	assert.ErrorContains(t, err, "doesn't exist")
+	assert.Empty(t, buf.String())
+
+	// Following the logs of a generation which doesn't start stops
+	// after logsStartTimeout
+	previous := logsStartTimeout
+	logsStartTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { logsStartTimeout = previous })
+	g := s.NewGeneration("hostname", "repositoryPath", "repositoryDir", "systemAttr", &protobuf.RepositoryStatus{})
+	err = s.GenerationLogsRead(t.Context(), g.Uuid, LogsEval, true, &buf)
+	assert.ErrorContains(t, err, "no eval logs")
+}
+
+func TestGenerationBuiltRetention(t *testing.T) {
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+	s, _ := New(bk, tmp+"/state.json", tmp+"/gcroots", 2, 2, 5, 2)
+	// The GC root of previous comin versions is removed
+	assert.Nil(t, os.Symlink(tmp, tmp+"/gcroots/last-built-generation"))
+
+	build := func() string {
+		g := s.NewGeneration("hostname", "repositoryPath", "repositoryDir", "systemAttr", &protobuf.RepositoryStatus{})
+		_ = s.GenerationEvalStarted(g.Uuid, "")
+		_ = s.GenerationEvalFinished(g.Uuid, "", tmp, "", nil)
+		_ = s.GenerationBuildStart(g.Uuid, "")
+		_ = s.GenerationBuildFinished(g.Uuid, "local", nil)
+		return g.Uuid
+	}
+	g1, g2 := build(), build()
+	_, err := os.Lstat(s.generationGcRoot(g1))
+	assert.Nil(t, err)
+	assert.Nil(t, s.InhibitorOverrideSet(&protobuf.InhibitorOverride{GenerationUuid: g1}))
+	assert.Nil(t, s.InhibitorOverrideSet(&protobuf.InhibitorOverride{GenerationUuid: g2, Comment: "first"}))
+	assert.Nil(t, s.InhibitorOverrideSet(&protobuf.InhibitorOverride{GenerationUuid: g2, Comment: "second"}))
+	assert.ErrorContains(t, s.InhibitorOverrideSet(&protobuf.InhibitorOverride{GenerationUuid: "unknown"}), "no generation")
+	assert.Len(t, s.persisted.InhibitorOverrides, 2)
+
+	// A generation evaluated but not built doesn't evict built generations
+	g3 := s.NewGeneration("hostname", "repositoryPath", "repositoryDir", "systemAttr", &protobuf.RepositoryStatus{})
+	_ = s.GenerationEvalStarted(g3.Uuid, "")
+	_, err = s.GenerationGet(g1)
+	assert.Nil(t, err)
+
+	g4 := build()
+	_, err = s.GenerationGet(g1)
+	assert.ErrorContains(t, err, "no generation")
+	_, err = os.Lstat(s.generationGcRoot(g1))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	// The override of the removed generation is pruned
+	assert.Nil(t, s.InhibitorOverrideGet(g1))
+	assert.Equal(t, "second", s.InhibitorOverrideGet(g2).Comment)
+	for _, g := range []string{g2, g4} {
+		generation, err := s.GenerationGet(g)
+		assert.Nil(t, err)
+		assert.Equal(t, s.generationGcRoot(g), generation.GcRoot)
+		target, err := os.Readlink(generation.GcRoot)
+		assert.Nil(t, err)
+		assert.Equal(t, tmp, target)
+	}
+	_, err = os.Lstat(tmp + "/gcroots/last-built-generation")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
 func TestCompareSwitchInhibitors(t *testing.T) {
 	oldInhibitors := map[string]string{
 		"inhibitor1": "old-value-1",
@@ -248,3 +359,47 @@ func TestCompareSwitchInhibitors(t *testing.T) {
 	diff = compareSwitchInhibitors(map[string]string{}, map[string]string{})
 	assert.Len(t, diff, 0)
 }
+
+func TestComputeOperation(t *testing.T) {
+	current := map[string]string{"systemd": "255", "kernel": "6.6", "common": "common"}
+	new := map[string]string{"systemd": "256", "kernel": "6.12", "common": "common"}
+
+	changes := InhibitorChanges(current, new, nil)
+	assert.Equal(t, []string{"kernel: 6.6 -> 6.12", "systemd: 255 -> 256"}, InhibitorChangeLines(changes))
+	operation, reason := computeOperation("switch", changes)
+	assert.Equal(t, "boot", operation)
+	assert.Contains(t, reason, "kernel, systemd")
+	operation, _ = computeOperation("test", changes)
+	assert.Equal(t, "null", operation)
+
+	// Only the systemd change is accepted by the operator
+	changes = InhibitorChanges(current, new, &protobuf.InhibitorOverride{Keys: []string{"systemd"}})
+	assert.Equal(t, []string{"kernel: 6.6 -> 6.12", "systemd: 255 -> 256 (overridden)"}, InhibitorChangeLines(changes))
+	operation, _ = computeOperation("switch", changes)
+	assert.Equal(t, "boot", operation)
+
+	changes = InhibitorChanges(current, new, &protobuf.InhibitorOverride{})
+	operation, reason = computeOperation("switch", changes)
+	assert.Equal(t, "switch", operation)
+	assert.Equal(t, "The different switch inhibitors kernel, systemd have been overridden by the operator", reason)
+}
+
+func TestDeploymentRebootReasons(t *testing.T) {
+	tmp := t.TempDir()
+	bk := broker.New()
+	bk.Start()
+	filename := tmp + "/state.json"
+	s, _ := New(bk, filename, tmp+"/gcroots", 2, 2, 5, 0)
+	d := s.NewDeployment(&protobuf.Generation{Uuid: "1"}, "", "", "", "")
+	err := s.DeploymentRebootReasons(d.Uuid, []string{"the kernel has changed"})
+	assert.Nil(t, err)
+	err = s.DeploymentRebootReasons("unknown", []string{"the kernel has changed"})
+	assert.NotNil(t, err)
+
+	s1, _ := New(bk, filename, tmp+"/gcroots", 2, 2, 5, 0)
+	err = s1.Load()
+	assert.Nil(t, err)
+	loaded, err := s1.GetDeployment(d.Uuid)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"the kernel has changed"}, loaded.RebootReasons)
+}
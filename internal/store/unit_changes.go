@@ -0,0 +1,59 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nlewo/comin/pkg/protobuf"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GenerationUnitChanges records the systemd units which would be
+// changed by the activation of the out path of a generation.
+func (s *Store) GenerationUnitChanges(uuid string, changes *protobuf.UnitChanges) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, err := s.generationGet(uuid)
+	if err != nil {
+		return err
+	}
+	g.UnitChanges = changes
+	e := &protobuf.Event_UnitChangesPredicted{Generation: g}
+	s.broker.Publish(&protobuf.Event{Type: &protobuf.Event_UnitChangesPredictedType{UnitChangesPredictedType: e}, CreatedAt: timestamppb.New(time.Now().UTC())})
+	s.historyRecord()
+	return nil
+}
+
+// UnitChangesLines returns a line per kind of unit change, such as
+// "restart: sshd.service, nginx.service".
+func UnitChangesLines(c *protobuf.UnitChanges) (lines []string) {
+	if c == nil {
+		return
+	}
+	for _, k := range []struct {
+		action string
+		units  []string
+	}{
+		{"stop", c.Stop},
+		{"restart", c.Restart},
+		{"reload", c.Reload},
+		{"start", c.Start},
+	} {
+		if len(k.units) > 0 {
+			lines = append(lines, fmt.Sprintf("%s: %s", k.action, strings.Join(k.units, ", ")))
+		}
+	}
+	return
+}
+
+func unitChangesShow(c *protobuf.UnitChanges, padding string) {
+	lines := UnitChangesLines(c)
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Printf("%s  Units:\n", padding)
+	for _, l := range lines {
+		fmt.Printf("%s    %s\n", padding, l)
+	}
+}
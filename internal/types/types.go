@@ -13,6 +13,21 @@ type Remote struct {
 	Timeout  int      `yaml:"timeout"`
 	// The period to poll the remote in second
 	Poller Poller `yaml:"poller"`
+
+	CommitStatus CommitStatus `yaml:"commit_status"`
+}
+
+// CommitStatus configures the statuses published to the forge of a
+// remote for the commits deployed by comin. The remote access token
+// is used to authenticate to the forge API.
+type CommitStatus struct {
+	Enable bool `yaml:"enable"`
+	// Forge is "github", "gitlab" or "gitea". It is guessed from
+	// the remote URL when empty.
+	Forge string `yaml:"forge"`
+	// ApiUrl is the URL of the forge API. It is derived from the
+	// remote URL when empty.
+	ApiUrl string `yaml:"api_url"`
 }
 
 type Poller struct {
@@ -40,6 +55,12 @@ type Branch struct {
 	// TODO: use it
 	Protected bool   `yaml:"protected"`
 	Operation string `yaml:"operation"`
+	// Windows are the deployment windows of the switch and test
+	// operations, such as "Mon-Fri 02:00-05:00"
+	Windows []string `yaml:"windows"`
+	// OutsideWindow is either "wait" to postpone the deployment to
+	// the next window or "boot" to deploy with the boot operation
+	OutsideWindow string `yaml:"outside_window"`
 }
 
 type Branches struct {
@@ -61,10 +82,205 @@ type Confirmer struct {
 	AutoDuration int    `yaml:"auto_duration"`
 }
 
+type DeployConfirmer struct {
+	Confirmer `yaml:",inline"`
+	// CriticalUnits are patterns of systemd units whose stop or
+	// restart requires a manual confirmation
+	CriticalUnits []string `yaml:"critical_units"`
+}
+
 type Retention struct {
 	DeploymentBootEntryCapacity  int `yaml:"deployment_boot_entry_capacity"`
 	DeploymentSuccessfulCapacity int `yaml:"deployment_successful_capacity"`
 	DeploymentAnyCapacity        int `yaml:"deployment_any_capacity"`
+	// The number of built generations to keep, with their GC roots
+	GenerationBuiltCapacity int `yaml:"generation_built_capacity"`
+}
+
+type Builder struct {
+	// The evaluation timeout in seconds
+	EvalTimeout int `yaml:"eval_timeout"`
+	// The build timeout in seconds
+	BuildTimeout int `yaml:"build_timeout"`
+	// A build is cancelled when it doesn't produce any output
+	// during this duration in seconds. It is disabled when 0.
+	MaxSilentTime int `yaml:"max_silent_time"`
+	// Builders is a list of Nix machine specifications used to
+	// build configurations remotely
+	Builders []string `yaml:"builders"`
+	// BuildHost is a Nix store URI from which the closure of
+	// configurations is copied
+	BuildHost string `yaml:"build_host"`
+	// SubstituteOnly only substitutes the closure of
+	// configurations from binary caches instead of building them
+	SubstituteOnly SubstituteOnly `yaml:"substitute_only"`
+	// Manifest replaces the evaluation of configurations by a
+	// manifest published by a CI
+	Manifest Manifest `yaml:"manifest"`
+}
+
+type Manifest struct {
+	// The manifest file path relative to the repository root
+	Path string `yaml:"path"`
+	// The manifest URL which can contain the {commit_id} placeholder
+	Url string `yaml:"url"`
+	// The GPG public keys used to verify the manifest signature
+	GpgPublicKeyPaths []string `yaml:"gpg_public_key_paths"`
+}
+
+type SubstituteOnly struct {
+	Enable bool `yaml:"enable"`
+	// The period in seconds used to recheck the availability of
+	// the closure in binary caches
+	RecheckPeriod int `yaml:"recheck_period"`
+	// The maximal duration in seconds to wait for the closure to
+	// be available in binary caches
+	Deadline int `yaml:"deadline"`
+	// Build the configuration locally once the deadline is reached
+	Fallback bool `yaml:"fallback"`
+}
+
+type Reboot struct {
+	Enable bool `yaml:"enable"`
+	// Windows are the maintenance windows in which the machine
+	// can be rebooted, such as "Mon-Fri 02:00-04:00" or
+	// "0 3 * * 1 2h". The machine can be rebooted at any time
+	// when it is empty.
+	Windows []string `yaml:"windows"`
+	// The maximal random delay in seconds added to the opening of
+	// a maintenance window to stagger the reboots of a fleet
+	RandomDelay int              `yaml:"random_delay"`
+	Inhibitors  RebootInhibitors `yaml:"inhibitors"`
+}
+
+type RebootInhibitors struct {
+	// Postpone the reboot while users are logged in
+	LoggedInUsers bool `yaml:"logged_in_users"`
+	// Postpone the reboot while this file exists
+	LockFile string `yaml:"lock_file"`
+}
+
+type Rollout struct {
+	Enable bool `yaml:"enable"`
+	// Wave is the rollout wave of this host. When it is nil, the
+	// wave is derived from a hash of the hostname.
+	Wave *int `yaml:"wave"`
+	// Waves is the number of waves used to derive the wave from
+	// the hostname
+	Waves int `yaml:"waves"`
+	// Directory is a directory shared by the hosts of the fleet
+	// to publish their deployment reports
+	Directory string `yaml:"directory"`
+	// Url is an HTTP endpoint used to get (GET) and publish (POST)
+	// the deployment reports. The "{commit_id}" placeholder is
+	// replaced by the commit ID.
+	Url string `yaml:"url"`
+	// The period in seconds used to recheck the reports of the
+	// earlier waves
+	CheckPeriod int `yaml:"check_period"`
+}
+
+type Notifier struct {
+	Sinks []NotifierSink `yaml:"sinks"`
+}
+
+type NotifierSink struct {
+	Name string `yaml:"name"`
+	// Type is "webhook", "slack", "matrix", "ntfy" or "smtp"
+	Type string `yaml:"type"`
+	// Events are the events sent to the sink, such as
+	// "deployment_failed". All events are sent when it is empty.
+	Events []string `yaml:"events"`
+	// Template is a Go template of the message
+	Template string `yaml:"template"`
+	// Url is the URL of the webhook, the Matrix homeserver or the
+	// ntfy topic
+	Url string `yaml:"url"`
+	// TokenPath is the path of a file containing the bearer token
+	// of the webhook, the Matrix access token or the ntfy token
+	TokenPath string `yaml:"token_path"`
+	Token     string `yaml:"-"`
+	// Room is the Matrix room ID
+	Room string     `yaml:"room"`
+	Smtp SmtpServer `yaml:"smtp"`
+}
+
+type SmtpServer struct {
+	Host         string `yaml:"host"`
+	Port         int    `yaml:"port"`
+	Username     string `yaml:"username"`
+	PasswordPath string `yaml:"password_path"`
+	Password     string `yaml:"-"`
+	From         string `yaml:"from"`
+	// To are the recipients
+	To []string `yaml:"to"`
+}
+
+// Gc configures the Nix store garbage collection. The profiles and
+// the GC roots maintained by comin are never collected.
+type Gc struct {
+	Enable bool `yaml:"enable"`
+	// Collect the garbage after each successful deployment
+	AfterDeployment bool `yaml:"after_deployment"`
+	// The period in seconds of the scheduled garbage collections.
+	// It is disabled when 0.
+	Period int `yaml:"period"`
+	// The minimal duration in seconds between two garbage
+	// collections triggered by deployments
+	MinInterval int `yaml:"min_interval"`
+	// Only collect the garbage when the free space of the Nix
+	// store is lower than this number of bytes
+	MinFree int64 `yaml:"min_free"`
+	// Stop the garbage collection once this number of bytes has
+	// been freed
+	MaxFreed int64 `yaml:"max_freed"`
+	// Delete the generations older than this number of days of the
+	// profiles, excepting the comin profile, before collecting the
+	// garbage. It is disabled when 0.
+	DeleteOlderThan int `yaml:"delete_older_than"`
+	// Run nix-store --optimise after the garbage collection
+	Optimise bool `yaml:"optimise"`
+}
+
+// DiskSpace configures the free space checks done before building
+// and deploying
+type DiskSpace struct {
+	Enable bool `yaml:"enable"`
+	// The minimal free space in bytes of the Nix store filesystem
+	MinStoreFree int64 `yaml:"min_store_free"`
+	// The minimal free space in bytes of the /boot filesystem
+	MinBootFree int64 `yaml:"min_boot_free"`
+	// Collect the Nix store garbage when its free space is too low
+	CollectGarbage bool `yaml:"collect_garbage"`
+}
+
+// History configures the history of the deployments and generations.
+// Contrary to the state file, it is not bounded by the retention
+// policy.
+type History struct {
+	Enable bool `yaml:"enable"`
+	// The maximal age in seconds of the deployments and generations
+	// kept in the history. It is unbounded when 0.
+	MaxAge int `yaml:"max_age"`
+}
+
+// Hook is a command run at a stage of the comin lifecycle
+type Hook struct {
+	Name string `yaml:"name"`
+	// Stage is one of "post-fetch", "post-eval", "post-build",
+	// "pre-deploy", "post-deploy" or "post-reboot"
+	Stage   string   `yaml:"stage"`
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args"`
+	// The timeout of the command in seconds
+	Timeout          int               `yaml:"timeout"`
+	Environment      map[string]string `yaml:"environment"`
+	WorkingDirectory string            `yaml:"working_directory"`
+	// OnFailure is "continue" or "abort", and also "veto" or
+	// "postpone" for the pre-deploy hooks
+	OnFailure string `yaml:"on_failure"`
+	// The period in seconds to retry a postponed deployment
+	RetryPeriod int `yaml:"retry_period"`
 }
 
 type Configuration struct {
@@ -72,17 +288,32 @@ type Configuration struct {
 	StateDir      string `yaml:"state_dir"`
 	StateFilepath string `yaml:"state_filepath"`
 	// RepositoryType describes type of the repository. It can currently only be "flake"
-	RepositoryType        string     `yaml:"repository_type"`
-	RepositorySubdir      string     `yaml:"repository_subdir"`
-	Submodules            bool       `yaml:"submodules"`
-	SystemAttr            string     `yaml:"system_attr"`
-	Remotes               []Remote   `yaml:"remotes"`
-	ApiServer             HttpServer `yaml:"api_server"`
-	Grpc                  Grpc       `yaml:"grpc"`
-	Exporter              HttpServer `yaml:"exporter"`
-	GpgPublicKeyPaths     []string   `yaml:"gpg_public_key_paths"`
-	PostDeploymentCommand string     `yaml:"post_deployment_command"`
-	BuildConfirmer        Confirmer  `yaml:"build_confirmer"`
-	DeployConfirmer       Confirmer  `yaml:"deploy_confirmer"`
-	Retention             Retention  `yaml:"retention"`
+	RepositoryType        string          `yaml:"repository_type"`
+	RepositorySubdir      string          `yaml:"repository_subdir"`
+	Submodules            bool            `yaml:"submodules"`
+	SystemAttr            string          `yaml:"system_attr"`
+	Remotes               []Remote        `yaml:"remotes"`
+	ApiServer             HttpServer      `yaml:"api_server"`
+	Grpc                  Grpc            `yaml:"grpc"`
+	Exporter              HttpServer      `yaml:"exporter"`
+	GpgPublicKeyPaths     []string        `yaml:"gpg_public_key_paths"`
+	PostDeploymentCommand string          `yaml:"post_deployment_command"`
+	BuildConfirmer        Confirmer       `yaml:"build_confirmer"`
+	DeployConfirmer       DeployConfirmer `yaml:"deploy_confirmer"`
+	Retention             Retention       `yaml:"retention"`
+	Builder               Builder         `yaml:"builder"`
+	Reboot                Reboot          `yaml:"reboot"`
+	Rollout               Rollout         `yaml:"rollout"`
+	Notifier              Notifier        `yaml:"notifier"`
+
+	Hooks []Hook `yaml:"hooks"`
+	Gc    Gc     `yaml:"gc"`
+
+	DiskSpace DiskSpace `yaml:"disk_space"`
+	// StateOnCorruption is "refuse" to refuse to start when the
+	// state file can't be loaded, or "quarantine" to move it aside
+	// and start with an empty state
+	StateOnCorruption string `yaml:"state_on_corruption"`
+
+	History History `yaml:"history"`
 }
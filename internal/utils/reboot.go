@@ -1,48 +1,118 @@
 package utils
 
 import (
+	"bytes"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 )
 
-// NeedToReboot return true when the current deployed kernel is not
-// the booted kernel. Note we should implement something smarter such
-// as described in
-// https://discourse.nixos.org/t/nixos-needsreboot-determine-if-you-need-to-reboot-your-nixos-machine/40790
+var (
+	bootedSystemPath  = "/run/booted-system"
+	currentSystemPath = "/run/current-system"
+	// systemProfilePath is the system booted at the next reboot
+	systemProfilePath = "/nix/var/nix/profiles/system"
+)
 
+// NeedToRebootLinux returns the reasons why the machine needs to be
+// rebooted to fully take the deployment of outPath into account. As
+// nixos-needsreboot does, the booted system is compared to the
+// system which would be booted at next reboot: the kernel, the
+// initrd, the kernel modules, the kernel parameters, systemd and the
+// firmware.
+//
 // switch-to-configuration test: it updates the /run/current-system link
 // switch-to-configuration boot: it doesn't update the /run/current-system link
 // switch-to-configuration switch: it updates the /run/current-system link
-
-// Be careful since the reboot information could not be correct with
-// the sequence: boot -> test. This could lead to error because if the
-// user reboot, it rollbacks to the boot deployment. For now, we
-// accept this tradeoff since this is a corner case.
-func NeedToRebootLinux(outPath, operation string) (reboot bool) {
-	if operation == "boot" {
-		currentSystem, err := os.Readlink("/run/current-system")
+//
+// Since the test operation doesn't update the system profile, the
+// system booted at next reboot is the one of the system profile and
+// not outPath. With the sequence boot -> test, the reboot is then
+// still required by the boot deployment while the tested kernel is
+// ignored since a reboot would not activate it.
+func NeedToRebootLinux(outPath, operation string) (reasons []string) {
+	booted, err := filepath.EvalSymlinks(bootedSystemPath)
+	if err != nil {
+		logrus.Infof("nix: could not know if a reboot is required since it failed to read the symlink %s: %s", bootedSystemPath, err)
+		return
+	}
+	next := outPath
+	if operation == "test" {
+		next, err = filepath.EvalSymlinks(systemProfilePath)
 		if err != nil {
-			logrus.Infof("nix: could not know if a reboot is required since it failed to read the symlink /run/current-system: %s", err)
+			logrus.Infof("nix: could not know if a reboot is required since it failed to read the symlink %s: %s", systemProfilePath, err)
 			return
 		}
-		if outPath != currentSystem {
-			return true
-		}
-	} else {
-		current, err := os.Readlink("/run/current-system/kernel")
+	}
+	if operation == "boot" {
+		current, err := filepath.EvalSymlinks(currentSystemPath)
 		if err != nil {
-			logrus.Infof("nix: could not know if a reboot is required since it failed to read the symlink /run/current-system/kernel: %s", err)
+			logrus.Infof("nix: could not know if a reboot is required since it failed to read the symlink %s: %s", currentSystemPath, err)
 			return
 		}
-		booted, err := os.Readlink("/run/booted-system/kernel")
-		if err != nil {
-			logrus.Infof("nix: could not know if a reboot is required since it failed to read the symlink /run/booted-system/kernel: %s", err)
-			return
+		if outPath != current {
+			reasons = append(reasons, "the configuration is activated at next boot")
 		}
-		if current != booted {
-			reboot = true
+	}
+	return append(reasons, rebootReasons(booted, next)...)
+}
+
+// rebootReasons compares the components of the booted system with
+// the ones of the next system.
+func rebootReasons(booted, next string) (reasons []string) {
+	if booted == next {
+		return
+	}
+	links := []struct {
+		name   string
+		reason string
+	}{
+		{"kernel", "the kernel has changed"},
+		{"initrd", "the initrd has changed"},
+		{"kernel-modules", "the kernel modules have changed"},
+		{"firmware", "the firmware has changed"},
+	}
+	for _, l := range links {
+		if readlink(booted, l.name) != readlink(next, l.name) {
+			reasons = append(reasons, l.reason)
 		}
 	}
+	// kernel-params is a file containing the kernel command line
+	bootedParams, _ := os.ReadFile(filepath.Join(booted, "kernel-params"))
+	nextParams, _ := os.ReadFile(filepath.Join(next, "kernel-params"))
+	if !bytes.Equal(bytes.TrimSpace(bootedParams), bytes.TrimSpace(nextParams)) {
+		reasons = append(reasons, "the kernel parameters have changed")
+	}
+	bootedSystemd := systemdVersion(readlink(booted, "systemd"))
+	nextSystemd := systemdVersion(readlink(next, "systemd"))
+	if bootedSystemd != nextSystemd {
+		reasons = append(reasons, fmt.Sprintf("systemd has changed from %s to %s", bootedSystemd, nextSystemd))
+	}
 	return
 }
+
+// readlink returns the target of the name link of a system, or an
+// empty string if it doesn't exist.
+func readlink(system, name string) string {
+	target, err := os.Readlink(filepath.Join(system, name))
+	if err != nil {
+		return ""
+	}
+	return target
+}
+
+// systemdVersion returns the version of a systemd store path such as
+// /nix/store/<hash>-systemd-256.8.
+func systemdVersion(storePath string) string {
+	if storePath == "" {
+		return "none"
+	}
+	_, name, _ := strings.Cut(filepath.Base(storePath), "-")
+	if version, ok := strings.CutPrefix(name, "systemd-"); ok {
+		return version
+	}
+	return name
+}
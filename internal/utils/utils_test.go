@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -55,31 +57,72 @@ func TestReadMachineId(t *testing.T) {
 	}
 }
 
-func TestNeedToReboot(t *testing.T) {
-	tests := []struct {
-		name             string
-		systemAttr       string
-		expectedBehavior string
-	}{
-		{
-			name:             "Linux reboot check",
-			systemAttr:       "nixosConfigurations",
-			expectedBehavior: "should call needToRebootLinux",
-		},
-		{
-			name:             "Darwin reboot check",
-			systemAttr:       "darwinConfigurations",
-			expectedBehavior: "should call needToRebootDarwin",
-		},
+func makeSystem(t *testing.T, dir, name string, links map[string]string, kernelParams string) string {
+	system := filepath.Join(dir, name)
+	assert.Nil(t, os.MkdirAll(system, 0755))
+	for link, target := range links {
+		assert.Nil(t, os.Symlink(target, filepath.Join(system, link)))
 	}
+	assert.Nil(t, os.WriteFile(filepath.Join(system, "kernel-params"), []byte(kernelParams), 0644))
+	return system
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Test that the function doesn't panic and follows the right code path
-			result := NeedToRebootLinux("", "")
-			t.Logf("NeedToReboot with %s returned: %v", tt.systemAttr, result)
-			// The function should return a boolean without panicking
-			assert.IsType(t, false, result)
-		})
+func TestNeedToReboot(t *testing.T) {
+	dir := t.TempDir()
+	links := map[string]string{
+		"kernel":         "/nix/store/aaa-linux-6.6/bzImage",
+		"initrd":         "/nix/store/aaa-initrd/initrd",
+		"kernel-modules": "/nix/store/aaa-kernel-modules",
+		"firmware":       "/nix/store/aaa-firmware",
+		"systemd":        "/nix/store/aaa-systemd-256.7",
 	}
+	booted := makeSystem(t, dir, "booted", links, "quiet")
+	same := makeSystem(t, dir, "same", links, "quiet\n")
+
+	links["kernel"] = "/nix/store/bbb-linux-6.12/bzImage"
+	links["systemd"] = "/nix/store/bbb-systemd-256.8"
+	upgraded := makeSystem(t, dir, "upgraded", links, "quiet loglevel=4")
+
+	links["systemd"] = "/nix/store/ccc-systemd-256.8"
+	profile := makeSystem(t, dir, "profile", map[string]string{"systemd": links["systemd"]}, "")
+
+	bootedSystemPath = booted
+	currentSystemPath = booted
+	systemProfilePath = booted
+	t.Cleanup(func() {
+		bootedSystemPath = "/run/booted-system"
+		currentSystemPath = "/run/current-system"
+		systemProfilePath = "/nix/var/nix/profiles/system"
+	})
+
+	assert.Empty(t, NeedToRebootLinux(booted, "switch"))
+	assert.Empty(t, NeedToRebootLinux(same, "switch"))
+	assert.Equal(t, []string{
+		"the kernel has changed",
+		"the kernel parameters have changed",
+		"systemd has changed from 256.7 to 256.8",
+	}, NeedToRebootLinux(upgraded, "switch"))
+
+	// The boot operation doesn't activate the configuration
+	assert.Equal(t, []string{"the configuration is activated at next boot"}, NeedToRebootLinux(same, "boot"))
+
+	// A tested configuration is not booted at next reboot
+	assert.Empty(t, NeedToRebootLinux(upgraded, "test"))
+
+	// With the sequence boot -> test, the boot deployment still
+	// requires a reboot
+	systemProfilePath = profile
+	currentSystemPath = upgraded
+	assert.Equal(t, []string{
+		"the kernel has changed",
+		"the initrd has changed",
+		"the kernel modules have changed",
+		"the firmware has changed",
+		"the kernel parameters have changed",
+		"systemd has changed from 256.7 to 256.8",
+	}, NeedToRebootLinux(upgraded, "test"))
+
+	// Without booted system, the reboot requirement is unknown
+	bootedSystemPath = filepath.Join(dir, "missing")
+	assert.Empty(t, NeedToRebootLinux(upgraded, "switch"))
 }
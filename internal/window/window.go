@@ -0,0 +1,241 @@
+// Package window parses time windows such as maintenance windows. A
+// window is either
+//
+//   - a weekday and time range such as "Mon-Fri 02:00-04:00",
+//     "Sat,Sun 22:00-06:00" or "03:00-05:00" for every day. A range
+//     whose end is before its start ends the next day.
+//   - a cron expression followed by a duration, such as
+//     "0 3 * * 1 2h". The window opens at each activation of the
+//     cron expression and lasts the duration.
+//
+// Times are in the local time zone.
+package window
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+type Window interface {
+	// Contains returns true if t is in the window
+	Contains(t time.Time) bool
+	// Next returns the next time, after or equal to t, where the
+	// window is open
+	Next(t time.Time) time.Time
+	String() string
+}
+
+// Windows is a list of windows. An empty list is always open.
+type Windows []Window
+
+// Parse parses a list of windows.
+func Parse(specs []string) (Windows, error) {
+	windows := make(Windows, 0, len(specs))
+	for _, s := range specs {
+		w, err := ParseWindow(s)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+// ParseWindow parses a window specification.
+func ParseWindow(spec string) (Window, error) {
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 1:
+		return parseTimeRange(spec, allDays(), fields[0])
+	case 2:
+		days, err := parseDays(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("window: invalid window '%s': %w", spec, err)
+		}
+		return parseTimeRange(spec, days, fields[1])
+	case 6:
+		schedule, err := cron.ParseStandard(strings.Join(fields[:5], " "))
+		if err != nil {
+			return nil, fmt.Errorf("window: invalid cron expression in the window '%s': %w", spec, err)
+		}
+		duration, err := time.ParseDuration(fields[5])
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("window: invalid duration in the window '%s'", spec)
+		}
+		return &cronWindow{spec: spec, schedule: schedule, duration: duration}, nil
+	}
+	return nil, fmt.Errorf("window: invalid window '%s'", spec)
+}
+
+func (ws Windows) Contains(t time.Time) bool {
+	if len(ws) == 0 {
+		return true
+	}
+	for _, w := range ws {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ws Windows) Next(t time.Time) time.Time {
+	if len(ws) == 0 {
+		return t
+	}
+	var next time.Time
+	for _, w := range ws {
+		if n := w.Next(t); next.IsZero() || n.Before(next) {
+			next = n
+		}
+	}
+	return next
+}
+
+func (ws Windows) String() string {
+	specs := make([]string, len(ws))
+	for i, w := range ws {
+		specs[i] = w.String()
+	}
+	return strings.Join(specs, ", ")
+}
+
+type cronWindow struct {
+	spec     string
+	schedule cron.Schedule
+	duration time.Duration
+}
+
+func (w *cronWindow) Contains(t time.Time) bool {
+	// The last activation before t is the first one after
+	// t-duration
+	return !w.schedule.Next(t.Add(-w.duration)).After(t)
+}
+
+func (w *cronWindow) Next(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+	return w.schedule.Next(t)
+}
+
+func (w *cronWindow) String() string {
+	return w.spec
+}
+
+type timeRange struct {
+	spec string
+	days []time.Weekday
+	// start and duration are durations since midnight
+	start    time.Duration
+	duration time.Duration
+}
+
+func parseTimeRange(spec string, days []time.Weekday, s string) (*timeRange, error) {
+	start, end, ok := strings.Cut(s, "-")
+	if !ok {
+		return nil, fmt.Errorf("window: invalid time range in the window '%s'", spec)
+	}
+	startTime, err := parseClock(start)
+	if err != nil {
+		return nil, fmt.Errorf("window: invalid window '%s': %w", spec, err)
+	}
+	endTime, err := parseClock(end)
+	if err != nil {
+		return nil, fmt.Errorf("window: invalid window '%s': %w", spec, err)
+	}
+	duration := endTime - startTime
+	if duration <= 0 {
+		duration += 24 * time.Hour
+	}
+	return &timeRange{spec: spec, days: days, start: startTime, duration: duration}, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time '%s', it must be formatted as HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+func allDays() []time.Weekday {
+	return []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+}
+
+// parseDays parses a comma separated list of days or day ranges,
+// such as "Mon-Fri,Sun".
+func parseDays(s string) (days []time.Weekday, err error) {
+	for _, item := range strings.Split(s, ",") {
+		first, last, isRange := strings.Cut(item, "-")
+		from, ok := weekdays[strings.ToLower(first)]
+		if !ok {
+			return nil, fmt.Errorf("invalid day '%s'", first)
+		}
+		to := from
+		if isRange {
+			if to, ok = weekdays[strings.ToLower(last)]; !ok {
+				return nil, fmt.Errorf("invalid day '%s'", last)
+			}
+		}
+		for d := from; ; d = (d + 1) % 7 {
+			if !slices.Contains(days, d) {
+				days = append(days, d)
+			}
+			if d == to {
+				break
+			}
+		}
+	}
+	return
+}
+
+// openingAt returns the opening of the window on the day of t shifted
+// by offset days.
+func (w *timeRange) openingAt(t time.Time, offset int) time.Time {
+	midnight := time.Date(t.Year(), t.Month(), t.Day()+offset, 0, 0, 0, 0, t.Location())
+	return midnight.Add(w.start)
+}
+
+func (w *timeRange) Contains(t time.Time) bool {
+	// A window opened the previous day can still be open
+	for _, offset := range []int{-1, 0} {
+		opening := w.openingAt(t, offset)
+		if slices.Contains(w.days, opening.Weekday()) && !opening.After(t) && t.Before(opening.Add(w.duration)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *timeRange) Next(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+	for offset := 0; offset <= 7; offset++ {
+		opening := w.openingAt(t, offset)
+		if slices.Contains(w.days, opening.Weekday()) && opening.After(t) {
+			return opening
+		}
+	}
+	// Not reachable since a time range has at least one day
+	return t
+}
+
+func (w *timeRange) String() string {
+	return w.spec
+}
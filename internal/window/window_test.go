@@ -0,0 +1,83 @@
+package window
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 2025-01-06 is a Monday
+func date(day, hour, minute int) time.Time {
+	return time.Date(2025, time.January, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParseWindow(t *testing.T) {
+	for _, spec := range []string{"02:00-04:00", "Mon-Fri 02:00-04:00", "sat,sun 22:00-06:00", "0 3 * * 1 2h"} {
+		w, err := ParseWindow(spec)
+		assert.Nil(t, err, spec)
+		assert.Equal(t, spec, w.String())
+	}
+	for _, spec := range []string{"", "02:00", "25:00-26:00", "Foo 02:00-04:00", "0 3 * * 1", "0 3 * * 1 foo", "0 3 * * 1 -1h", "a b c"} {
+		_, err := ParseWindow(spec)
+		assert.NotNil(t, err, spec)
+	}
+}
+
+func TestTimeRange(t *testing.T) {
+	w, err := ParseWindow("Mon-Fri 02:00-04:00")
+	assert.Nil(t, err)
+	assert.True(t, w.Contains(date(6, 2, 0)))
+	assert.True(t, w.Contains(date(6, 3, 59)))
+	assert.False(t, w.Contains(date(6, 4, 0)))
+	assert.False(t, w.Contains(date(6, 1, 59)))
+	// Saturday
+	assert.False(t, w.Contains(date(11, 3, 0)))
+
+	assert.Equal(t, date(6, 3, 0), w.Next(date(6, 3, 0)))
+	assert.Equal(t, date(7, 2, 0), w.Next(date(6, 5, 0)))
+	// From Friday evening to Monday
+	assert.Equal(t, date(13, 2, 0), w.Next(date(10, 5, 0)))
+}
+
+func TestTimeRangeOverMidnight(t *testing.T) {
+	w, err := ParseWindow("Sat,Sun 22:00-06:00")
+	assert.Nil(t, err)
+	// Saturday night
+	assert.True(t, w.Contains(date(11, 23, 0)))
+	// Sunday morning, opened on Saturday
+	assert.True(t, w.Contains(date(12, 5, 0)))
+	// Monday morning, opened on Sunday
+	assert.True(t, w.Contains(date(13, 5, 0)))
+	// Saturday morning, opened on Friday which is not in the window
+	assert.False(t, w.Contains(date(11, 5, 0)))
+	assert.Equal(t, date(11, 22, 0), w.Next(date(6, 12, 0)))
+}
+
+func TestCronWindow(t *testing.T) {
+	w, err := ParseWindow("30 3 * * 1 2h")
+	assert.Nil(t, err)
+	assert.True(t, w.Contains(date(6, 3, 30)))
+	assert.True(t, w.Contains(date(6, 5, 29)))
+	assert.False(t, w.Contains(date(6, 5, 30)))
+	assert.False(t, w.Contains(date(7, 4, 0)))
+	assert.Equal(t, date(13, 3, 30), w.Next(date(6, 6, 0)))
+	assert.Equal(t, date(6, 4, 0), w.Next(date(6, 4, 0)))
+}
+
+func TestWindows(t *testing.T) {
+	ws, err := Parse(nil)
+	assert.Nil(t, err)
+	assert.True(t, ws.Contains(date(6, 12, 0)))
+	assert.Equal(t, date(6, 12, 0), ws.Next(date(6, 12, 0)))
+
+	ws, err = Parse([]string{"Sat 02:00-04:00", "Wed 02:00-04:00"})
+	assert.Nil(t, err)
+	assert.False(t, ws.Contains(date(6, 3, 0)))
+	assert.True(t, ws.Contains(date(8, 3, 0)))
+	assert.Equal(t, date(8, 2, 0), ws.Next(date(6, 3, 0)))
+	assert.Equal(t, "Sat 02:00-04:00, Wed 02:00-04:00", ws.String())
+
+	_, err = Parse([]string{"Sat 02:00-04:00", "invalid"})
+	assert.NotNil(t, err)
+}
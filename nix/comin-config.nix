@@ -25,6 +25,15 @@ rec {
     build_confirmer = cfg.services.comin.buildConfirmer;
     deploy_confirmer = cfg.services.comin.deployConfirmer;
     retention = cfg.services.comin.retention;
+    builder = cfg.services.comin.builder;
+    reboot = cfg.services.comin.reboot;
+    rollout = cfg.services.comin.rollout;
+    notifier = cfg.services.comin.notifier;
+    hooks = cfg.services.comin.hooks;
+    gc = cfg.services.comin.gc;
+    disk_space = cfg.services.comin.disk_space;
+    state_on_corruption = cfg.services.comin.stateOnCorruption;
+    history = cfg.services.comin.history;
   }
   // (lib.optionalAttrs (cfg.services.comin.postDeploymentCommand != null) {
     post_deployment_command = cfg.services.comin.postDeploymentCommand;
@@ -192,6 +192,31 @@ in
                             default = "switch";
                             description = "The switch-to-configuration operation to do on this branch.";
                           };
+                          windows = mkOption {
+                            type = listOf str;
+                            default = [ ];
+                            example = [ "Mon-Fri 02:00-05:00" ];
+                            description = ''
+                              The deployment windows of the switch and test
+                              operations, in the local time zone. A window is
+                              either days and a time range or a cron expression
+                              followed by a duration. When empty, the
+                              configuration can be deployed at any time.
+                            '';
+                          };
+                          outside_window = mkOption {
+                            type = enum [
+                              "wait"
+                              "boot"
+                            ];
+                            default = "wait";
+                            description = ''
+                              Outside of the deployment windows, "wait" postpones
+                              the deployment to the opening of the next window
+                              while "boot" deploys a switch operation with the
+                              boot operation.
+                            '';
+                          };
                         };
                       };
                     };
@@ -215,6 +240,31 @@ in
                             default = "test";
                             description = "The switch-to-configuration operation to do on this branch.";
                           };
+                          windows = mkOption {
+                            type = listOf str;
+                            default = [ ];
+                            example = [ "Mon-Fri 02:00-05:00" ];
+                            description = ''
+                              The deployment windows of the switch and test
+                              operations, in the local time zone. A window is
+                              either days and a time range or a cron expression
+                              followed by a duration. When empty, the
+                              configuration can be deployed at any time.
+                            '';
+                          };
+                          outside_window = mkOption {
+                            type = enum [
+                              "wait"
+                              "boot"
+                            ];
+                            default = "wait";
+                            description = ''
+                              Outside of the deployment windows, "wait" postpones
+                              the deployment to the opening of the next window
+                              while "boot" deploys a switch operation with the
+                              boot operation.
+                            '';
+                          };
                         };
                       };
                     };
@@ -236,6 +286,36 @@ in
                   };
                 };
               };
+              commit_status = mkOption {
+                default = { };
+                description = "The options of the commit statuses published to the forge of the remote.";
+                type = submodule {
+                  options = {
+                    enable = mkEnableOption "the publication of the evaluation, build and deployment statuses of the commits to the forge of the remote, with the access token of the remote";
+                    forge = mkOption {
+                      type = nullOr (enum [
+                        "github"
+                        "gitlab"
+                        "gitea"
+                      ]);
+                      default = null;
+                      description = ''
+                        The forge of the remote. Forgejo uses the "gitea"
+                        forge. When null, it is guessed from the remote URL.
+                      '';
+                    };
+                    api_url = mkOption {
+                      type = nullOr str;
+                      default = null;
+                      example = "https://github.example.com/api/v3";
+                      description = ''
+                        The URL of the forge API. When null, it is derived
+                        from the remote URL.
+                      '';
+                    };
+                  };
+                };
+              };
             };
           });
         };
@@ -278,6 +358,106 @@ in
             pkgs.writers.writeBash "post" "echo $COMIN_GIT_SHA";
           '';
         };
+        stateOnCorruption = mkOption {
+          type = enum [
+            "refuse"
+            "quarantine"
+          ];
+          default = "refuse";
+          description = ''
+            What to do when the comin state file can not be loaded
+            because it is corrupted: `refuse` to start, or
+            `quarantine` the file by moving it aside and start with an
+            empty state. The state file is backed up before each
+            migration of its schema.
+          '';
+        };
+        hooks = mkOption {
+          description = ''
+            Commands executed at the stages of the comin lifecycle. The
+            hooks of a stage are executed in their order of this list.
+            They receive the context of the stage as environment
+            variables, such as `COMIN_GIT_SHA`, `COMIN_GENERATION` or
+            `COMIN_STAGE`, and as JSON on their stdin. Their exit code
+            and the end of their output are stored by comin.
+          '';
+          default = [ ];
+          type = listOf (submodule {
+            options = {
+              name = mkOption {
+                type = str;
+                description = "The name of the hook.";
+              };
+              stage = mkOption {
+                type = enum [
+                  "post-fetch"
+                  "post-eval"
+                  "post-build"
+                  "pre-deploy"
+                  "post-deploy"
+                  "post-reboot"
+                ];
+                description = ''
+                  The stage of the hook. The post-fetch hooks are executed
+                  when a fetch selects a new commit and the post-reboot
+                  hooks when comin starts after a reboot of the machine.
+                '';
+              };
+              command = mkOption {
+                type = str;
+                example = lib.literalExpression ''"''${pkgs.curl}/bin/curl"'';
+                description = "The path of the command of the hook.";
+              };
+              args = mkOption {
+                type = listOf str;
+                default = [ ];
+                description = "The arguments of the command.";
+              };
+              timeout = mkOption {
+                type = int;
+                default = 300;
+                description = "The timeout of the hook in seconds.";
+              };
+              environment = mkOption {
+                type = attrsOf str;
+                default = { };
+                description = "Additional environment variables of the hook.";
+              };
+              working_directory = mkOption {
+                type = nullOr str;
+                default = null;
+                description = "The working directory of the hook.";
+              };
+              on_failure = mkOption {
+                type = nullOr (enum [
+                  "continue"
+                  "abort"
+                  "veto"
+                  "postpone"
+                ]);
+                default = null;
+                description = ''
+                  When the hook fails, "continue" executes the next hooks
+                  of the stage while "abort" doesn't. For the pre-deploy
+                  hooks, "veto" records the deployment as failed and
+                  "postpone" retries the deployment after the retry
+                  period. A failed pre-deploy hook always prevents the
+                  deployment: "abort" and "continue" veto it, "continue"
+                  once the next pre-deploy hooks have been executed. For
+                  the post-deploy hooks, "abort" only skips the next
+                  post-deploy hooks: the deployment is already finished.
+                  It defaults to "veto" for the pre-deploy hooks and to
+                  "continue" for the other ones.
+                '';
+              };
+              retry_period = mkOption {
+                type = int;
+                default = 300;
+                description = "The period in seconds to retry a deployment postponed by a pre-deploy hook.";
+              };
+            };
+          });
+        };
         buildConfirmer = mkOption {
           description = "The confirmer options for the build.";
           default = { };
@@ -336,7 +516,22 @@ in
                   duration, the action is automatically confirmed.
                 '';
               };
-
+              critical_units = mkOption {
+                type = listOf str;
+                default = [ ];
+                example = [
+                  "sshd.service"
+                  "network-*.service"
+                ];
+                description = ''
+                  Patterns of systemd units. When the activation of a
+                  built generation would stop or restart one of these
+                  units (as predicted by switch-to-configuration
+                  dry-activate), its deployment requires a manual
+                  confirmation, whatever the confirmer mode. This is
+                  also the case when the prediction is unavailable.
+                '';
+              };
             };
           };
         };
@@ -348,6 +543,138 @@ in
             description = "The notification title.";
           };
         };
+        builder = mkOption {
+          description = "The evaluation and build options.";
+          default = { };
+          type = submodule {
+            options = {
+              eval_timeout = mkOption {
+                type = int;
+                default = 1800;
+                description = ''
+                  The evaluation timeout in seconds.
+                '';
+              };
+              build_timeout = mkOption {
+                type = int;
+                default = 1800;
+                description = ''
+                  The build timeout in seconds.
+                '';
+              };
+              max_silent_time = mkOption {
+                type = int;
+                default = 0;
+                description = ''
+                  A build is cancelled when it does not produce any log
+                  output during this duration in seconds. 0 disables it.
+                '';
+              };
+              builders = mkOption {
+                type = listOf str;
+                default = [ ];
+                example = [ "ssh-ng://builder aarch64-linux" ];
+                description = ''
+                  A list of Nix remote builders (in the nix.buildMachines
+                  format) used to build the configuration. The
+                  configuration is built locally when none of them is
+                  available or if the remote build fails.
+                '';
+              };
+              build_host = mkOption {
+                type = nullOr str;
+                default = null;
+                example = "ssh-ng://build-host";
+                description = ''
+                  A Nix store URI from which the prebuilt closure of the
+                  configuration is copied with nix copy. If the closure
+                  can not be copied, the configuration is built on the
+                  builders or locally.
+                '';
+              };
+              substitute_only = mkOption {
+                description = ''
+                  Only substitute the closure of the configuration from
+                  the binary caches instead of building it. This is
+                  useful when a CI pushes configurations to a binary
+                  cache.
+                '';
+                default = { };
+                type = submodule {
+                  options = {
+                    enable = mkEnableOption "the substitute-only mode";
+                    recheck_period = mkOption {
+                      type = int;
+                      default = 60;
+                      description = ''
+                        The period in seconds used to recheck if the
+                        closure is available in the binary caches.
+                      '';
+                    };
+                    deadline = mkOption {
+                      type = int;
+                      default = 3600;
+                      description = ''
+                        The maximal duration in seconds to wait for the
+                        closure to be available in the binary caches.
+                      '';
+                    };
+                    fallback = mkOption {
+                      type = bool;
+                      default = false;
+                      description = ''
+                        Build the configuration locally once the deadline
+                        is reached. Otherwise, the build fails.
+                      '';
+                    };
+                  };
+                };
+              };
+              manifest = mkOption {
+                description = ''
+                  Deploy the out paths of a manifest published by a CI
+                  instead of evaluating the configuration.
+                '';
+                default = { };
+                type = submodule {
+                  options = {
+                    path = mkOption {
+                      type = nullOr str;
+                      default = null;
+                      example = "ci/manifest.json";
+                      description = ''
+                        The path, relative to the repository root, of a
+                        manifest mapping hostnames to the out path of their
+                        configuration. When set, the configuration is not
+                        evaluated and its out path is substituted from the
+                        binary caches.
+                      '';
+                    };
+                    url = mkOption {
+                      type = nullOr str;
+                      default = null;
+                      example = "https://ci.example.com/{commit_id}/manifest.json";
+                      description = ''
+                        The URL of a manifest mapping hostnames to the out
+                        path of their configuration. The {commit_id}
+                        placeholder is replaced by the commit ID to deploy.
+                      '';
+                    };
+                    gpg_public_key_paths = mkOption {
+                      type = listOf str;
+                      default = [ ];
+                      description = ''
+                        A list of GPG public key file paths used to verify
+                        the detached signature of the manifest (the manifest
+                        file with the .asc extension).
+                      '';
+                    };
+                  };
+                };
+              };
+            };
+          };
+        };
         retention = mkOption {
           description = "The deployments and profiles retention policyes.";
           default = { };
@@ -378,6 +705,392 @@ in
                   regardless of status (including failed deployments).
                 '';
               };
+              generation_built_capacity = mkOption {
+                type = int;
+                default = 3;
+                description = ''
+                  Number of built generations to keep, even if they have not
+                  been deployed. A GC root is created for each of them in
+                  order to prevent the Nix garbage collector from removing
+                  their closure.
+                '';
+              };
+            };
+          };
+        };
+        reboot = mkOption {
+          description = "The automatic reboot options.";
+          default = { };
+          type = submodule {
+            options = {
+              enable = mkEnableOption "the automatic reboot of the machine when a deployment requires it";
+              windows = mkOption {
+                type = listOf str;
+                default = [ ];
+                example = [
+                  "Mon-Fri 02:00-04:00"
+                  "Sat,Sun 22:00-06:00"
+                  "0 3 1 * * 2h"
+                ];
+                description = ''
+                  The maintenance windows in which the machine can be
+                  rebooted, in the local time zone. A window is either
+                  days and a time range or a cron expression followed by
+                  a duration. When empty, the machine can be rebooted at
+                  any time.
+                '';
+              };
+              random_delay = mkOption {
+                type = int;
+                default = 0;
+                description = ''
+                  The maximal random delay in seconds added to the
+                  opening of a maintenance window to stagger the reboots
+                  of a fleet.
+                '';
+              };
+              inhibitors = {
+                logged_in_users = mkOption {
+                  type = bool;
+                  default = false;
+                  description = ''
+                    Postpone the reboot while users are logged in.
+                  '';
+                };
+                lock_file = mkOption {
+                  type = nullOr str;
+                  default = null;
+                  example = "/run/comin/reboot.lock";
+                  description = ''
+                    Postpone the reboot while this file exists.
+                  '';
+                };
+              };
+            };
+          };
+        };
+        notifier = mkOption {
+          description = "The options of the notifications of the comin events.";
+          default = { };
+          type = submodule {
+            options = {
+              sinks = mkOption {
+                description = "The sinks receiving the notifications.";
+                default = [ ];
+                type = listOf (submodule {
+                  options = {
+                    name = mkOption {
+                      type = str;
+                      description = "The unique name of the sink.";
+                    };
+                    type = mkOption {
+                      type = enum [
+                        "webhook"
+                        "slack"
+                        "matrix"
+                        "ntfy"
+                        "smtp"
+                      ];
+                      description = ''
+                        The type of the sink. A "webhook" sink receives the
+                        notification as JSON with the rendered message, a
+                        "slack" sink receives the message on a Slack
+                        compatible incoming webhook, a "matrix" sink sends it
+                        to a Matrix room, a "ntfy" sink publishes it to a ntfy
+                        topic and a "smtp" sink sends it by email.
+                      '';
+                    };
+                    events = mkOption {
+                      type = listOf (enum [
+                        "eval_failed"
+                        "build_failed"
+                        "deployment_succeeded"
+                        "deployment_failed"
+                        "reboot_required"
+                        "confirmation_pending"
+                      ]);
+                      default = [ ];
+                      example = [ "deployment_failed" ];
+                      description = ''
+                        The events sent to the sink. All events are sent when
+                        empty.
+                      '';
+                    };
+                    template = mkOption {
+                      type = str;
+                      default = "";
+                      example = "{{.Hostname}}: {{.Summary}}";
+                      description = ''
+                        The Go template of the message. Its data has the
+                        fields Event, Hostname, Summary, GenerationUuid,
+                        RemoteName, BranchName, CommitId, Operation, Error,
+                        Reasons and CreatedAt. When empty, the template is
+                        "[{{.Hostname}}] {{.Summary}}".
+                      '';
+                    };
+                    url = mkOption {
+                      type = nullOr str;
+                      default = null;
+                      example = "https://ntfy.sh/my-fleet";
+                      description = ''
+                        The URL of the webhook, of the Matrix homeserver or
+                        of the ntfy topic.
+                      '';
+                    };
+                    token_path = mkOption {
+                      type = nullOr str;
+                      default = null;
+                      description = ''
+                        The path of a file containing the bearer token of the
+                        webhook, the Matrix access token or the ntfy token.
+                      '';
+                    };
+                    room = mkOption {
+                      type = nullOr str;
+                      default = null;
+                      example = "!room:example.com";
+                      description = "The Matrix room ID.";
+                    };
+                    smtp = {
+                      host = mkOption {
+                        type = nullOr str;
+                        default = null;
+                        description = "The SMTP server host.";
+                      };
+                      port = mkOption {
+                        type = int;
+                        default = 25;
+                        description = "The SMTP server port.";
+                      };
+                      username = mkOption {
+                        type = nullOr str;
+                        default = null;
+                        description = "The SMTP username. No authentication is used when null.";
+                      };
+                      password_path = mkOption {
+                        type = nullOr str;
+                        default = null;
+                        description = "The path of a file containing the SMTP password.";
+                      };
+                      from = mkOption {
+                        type = nullOr str;
+                        default = null;
+                        example = "comin@example.com";
+                        description = "The sender of the emails.";
+                      };
+                      to = mkOption {
+                        type = listOf str;
+                        default = [ ];
+                        example = [ "ops@example.com" ];
+                        description = "The recipients of the emails.";
+                      };
+                    };
+                  };
+                });
+              };
+            };
+          };
+        };
+        rollout = mkOption {
+          description = "The options of the rollout waves coordinating the deployments of a fleet.";
+          default = { };
+          type = submodule {
+            options = {
+              enable = mkEnableOption "the rollout waves: a commit of a main branch is deployed once the earlier waves have successfully deployed it";
+              wave = mkOption {
+                type = nullOr int;
+                default = null;
+                example = 0;
+                description = ''
+                  The rollout wave of this machine. The wave 0 deploys
+                  new commits immediately. When null, the wave is
+                  derived from a hash of the hostname.
+                '';
+              };
+              waves = mkOption {
+                type = int;
+                default = 2;
+                description = ''
+                  The number of waves used to derive the wave of the
+                  machine from its hostname.
+                '';
+              };
+              directory = mkOption {
+                type = nullOr str;
+                default = null;
+                example = "/mnt/fleet/comin-rollout";
+                description = ''
+                  A directory shared by the machines of the fleet, such
+                  as a network file system, where the deployment reports
+                  are published.
+                '';
+              };
+              url = mkOption {
+                type = nullOr str;
+                default = null;
+                example = "https://rollout.example.com/reports/{commit_id}";
+                description = ''
+                  An HTTP endpoint returning the deployment reports of a
+                  commit on GET requests and receiving the report of the
+                  machine on POST requests. The "{commit_id}" placeholder
+                  is replaced by the commit ID.
+                '';
+              };
+              check_period = mkOption {
+                type = int;
+                default = 60;
+                description = ''
+                  The period in seconds used to recheck the deployment
+                  reports of the earlier waves.
+                '';
+              };
+            };
+          };
+        };
+        gc = mkOption {
+          description = "The Nix store garbage collection options.";
+          default = { };
+          type = submodule {
+            options = {
+              enable = mkEnableOption ''
+                the Nix store garbage collection. The garbage is collected
+                without removing the old generations of the profiles: the
+                profiles and GC roots kept by the retention policy are never
+                collected
+              '';
+              after_deployment = mkOption {
+                type = bool;
+                default = true;
+                description = ''
+                  Collect the garbage after each successful deployment.
+                '';
+              };
+              period = mkOption {
+                type = int;
+                default = 0;
+                example = 86400;
+                description = ''
+                  The period in seconds of the scheduled garbage
+                  collections. They are disabled when 0.
+                '';
+              };
+              min_interval = mkOption {
+                type = int;
+                default = 3600;
+                description = ''
+                  The minimal duration in seconds between two garbage
+                  collections triggered by deployments.
+                '';
+              };
+              min_free = mkOption {
+                type = int;
+                default = 0;
+                example = 10 * 1024 * 1024 * 1024;
+                description = ''
+                  Only collect the garbage when the available space of the
+                  Nix store is lower than this number of bytes. The garbage
+                  is always collected when 0.
+                '';
+              };
+              max_freed = mkOption {
+                type = int;
+                default = 0;
+                example = 20 * 1024 * 1024 * 1024;
+                description = ''
+                  Stop the garbage collection once this number of bytes
+                  has been freed (the nix-collect-garbage --max-freed
+                  option). It is unlimited when 0.
+                '';
+              };
+              delete_older_than = mkOption {
+                type = int;
+                default = 0;
+                example = 30;
+                description = ''
+                  Delete the generations older than this number of days
+                  of the Nix profiles before collecting the garbage. The
+                  profile maintained by comin is never affected. It is
+                  disabled when 0.
+                '';
+              };
+              optimise = mkOption {
+                type = bool;
+                default = false;
+                description = ''
+                  Run nix-store --optimise after the garbage collection to
+                  hard-link identical files.
+                '';
+              };
+            };
+          };
+        };
+        disk_space = mkOption {
+          description = "The free disk space checks done before building and deploying.";
+          default = { };
+          type = submodule {
+            options = {
+              enable = mkEnableOption ''
+                the free disk space checks. A build is refused when the Nix
+                store free space is too low and a switch or boot deployment
+                is refused when the Nix store or /boot free space is too low
+              '';
+              min_store_free = mkOption {
+                type = int;
+                default = 5 * 1024 * 1024 * 1024;
+                description = ''
+                  The minimal free space in bytes of the Nix store
+                  filesystem. A build also requires the unpacked size of
+                  the store paths to fetch, estimated by nix build
+                  --dry-run.
+                '';
+              };
+              min_boot_free = mkOption {
+                type = int;
+                default = 100 * 1024 * 1024;
+                description = ''
+                  The minimal free space in bytes of the /boot filesystem.
+                  At least the size of the kernel and the initrd of the new
+                  system is required. The check is skipped when they are
+                  the kernel and the initrd of the current or booted
+                  system.
+                '';
+              };
+              collect_garbage = mkOption {
+                type = bool;
+                default = true;
+                description = ''
+                  Collect the Nix store garbage before refusing a build or
+                  a deployment because of the Nix store free space. The
+                  gc.max_freed and gc.optimise options are used.
+                '';
+              };
+            };
+          };
+        };
+        history = mkOption {
+          description = ''
+            The history of the deployments and generations. Contrary to
+            the state file, it is not bounded by the retention options.
+            It mirrors the state file, which remains the primary store.
+          '';
+          default = { };
+          type = submodule {
+            options = {
+              enable = mkEnableOption ''
+                the history of the deployments and generations, stored in
+                /var/lib/comin/history. It is initialized from the state
+                file
+              '';
+              max_age = mkOption {
+                type = int;
+                default = 0;
+                example = 90 * 24 * 3600;
+                description = ''
+                  The maximal age in seconds of the deployments and
+                  generations kept in the history. The history is
+                  unbounded when 0.
+                '';
+              };
             };
           };
         };
@@ -39,7 +39,7 @@ buildGoModule rec {
       ../main.go
     ];
   };
-  vendorHash = "sha256-M+0YUoMRnObCSUqnygPNiv1sKl3YB9Cb4nzK39zWwBg=";
+  vendorHash = "sha256-rorgS4DBfVXkrYFXHCHaurLE7vrQmGcw7ORW3+yu1LM=";
   ldflags = [
     "-X github.com/nlewo/comin/cmd.version=${version}"
   ];
@@ -11,6 +11,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 type Client struct {
@@ -91,13 +92,79 @@ func (c Client) Resume() error {
 	_, err := c.cominClient.Resume(context.Background(), &emptypb.Empty{})
 	return err
 }
+func (c Client) DeploymentWindowOverride() error {
+	_, err := c.cominClient.DeploymentWindowOverride(context.Background(), &emptypb.Empty{})
+	return err
+}
+func (c Client) InhibitorOverride(generationUuid string, keys []string, comment string) error {
+	_, err := c.cominClient.InhibitorOverride(context.Background(), &protobuf.InhibitorOverrideRequest{
+		GenerationUuid: generationUuid,
+		Keys:           keys,
+		Comment:        comment,
+	})
+	return err
+}
+func (c Client) RebootCancel() error {
+	_, err := c.cominClient.RebootCancel(context.Background(), &emptypb.Empty{})
+	return err
+}
 func (c Client) DeploymentLatestSubmit(operation string) error {
 	_, err := c.cominClient.DeploymentLatestSubmit(context.Background(), &protobuf.Operation{OperationSubmitted: operation})
 	return err
 }
 
+// GenerationLogs writes the eval or build logs of a generation to
+// w. If follow is true, it returns at the end of the evaluation or
+// build.
+func (c Client) GenerationLogs(ctx context.Context, generationUUID, kind string, follow bool, w io.Writer) error {
+	stream, err := c.cominClient.GenerationLogs(ctx, &protobuf.GenerationLogsRequest{
+		GenerationUuid: generationUUID,
+		Kind:           kind,
+		Follow:         wrapperspb.Bool(follow),
+	})
+	if err != nil {
+		return err
+	}
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(chunk.Data); err != nil {
+			return err
+		}
+	}
+}
+
 func (c Client) Confirm(generationUUID, for_ string) error {
 	_, err := c.cominClient.Confirm(context.Background(), &protobuf.ConfirmRequest{
 		GenerationUuid: generationUUID, For: for_})
 	return err
 }
+
+// ListDeployments returns the deployments matching the request. The
+// pages are fetched until limit deployments have been returned, or
+// until the last page when limit is 0.
+func (c Client) ListDeployments(req *protobuf.ListDeploymentsRequest, limit int) (dpls []*protobuf.Deployment, err error) {
+	for {
+		if limit > 0 {
+			req.PageSize = int32(min(limit-len(dpls), 1000))
+		}
+		resp, err := c.cominClient.ListDeployments(context.Background(), req)
+		if err != nil {
+			return nil, err
+		}
+		dpls = append(dpls, resp.Deployments...)
+		if resp.NextPageToken == "" || (limit > 0 && len(dpls) >= limit) {
+			return dpls, nil
+		}
+		req.PageToken = resp.NextPageToken
+	}
+}
+
+func (c Client) GetDeployment(uuid string) (*protobuf.Deployment, error) {
+	return c.cominClient.GetDeployment(context.Background(), &protobuf.GetDeploymentRequest{Uuid: uuid})
+}
@@ -27,6 +27,339 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// ListDeploymentsRequest filters the deployments. The deployments are
+// read from the history when it is enabled, otherwise from the
+// state. The empty fields don't filter.
+type ListDeploymentsRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Status    string                 `protobuf:"bytes,1,opt,name=status" json:"status,omitempty"`
+	Operation string                 `protobuf:"bytes,2,opt,name=operation" json:"operation,omitempty"`
+	// branch is the selected branch name
+	Branch string `protobuf:"bytes,3,opt,name=branch" json:"branch,omitempty"`
+	// commit_id is a prefix of the selected or main commit ID
+	CommitId string `protobuf:"bytes,4,opt,name=commit_id,json=commitId" json:"commit_id,omitempty"`
+	// since and until bound the creation time of the deployments
+	Since *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=since" json:"since,omitempty"`
+	Until *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=until" json:"until,omitempty"`
+	// order is "newest-first" or "oldest-first". When empty, the
+	// deployments are listed oldest first, or newest first when
+	// boot_entries is set.
+	Order string `protobuf:"bytes,7,opt,name=order" json:"order,omitempty"`
+	// boot_entries only lists the switched, booted and boot entry
+	// deployments of the state
+	BootEntries bool `protobuf:"varint,8,opt,name=boot_entries,json=bootEntries" json:"boot_entries,omitempty"`
+	// page_size is the maximal number of returned deployments. It is
+	// 100 by default and at most 1000.
+	PageSize int32 `protobuf:"varint,9,opt,name=page_size,json=pageSize" json:"page_size,omitempty"`
+	// page_token is the next_page_token of the previous page
+	PageToken     string `protobuf:"bytes,10,opt,name=page_token,json=pageToken" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDeploymentsRequest) Reset() {
+	*x = ListDeploymentsRequest{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDeploymentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDeploymentsRequest) ProtoMessage() {}
+
+func (x *ListDeploymentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDeploymentsRequest.ProtoReflect.Descriptor instead.
+func (*ListDeploymentsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ListDeploymentsRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ListDeploymentsRequest) GetOperation() string {
+	if x != nil {
+		return x.Operation
+	}
+	return ""
+}
+
+func (x *ListDeploymentsRequest) GetBranch() string {
+	if x != nil {
+		return x.Branch
+	}
+	return ""
+}
+
+func (x *ListDeploymentsRequest) GetCommitId() string {
+	if x != nil {
+		return x.CommitId
+	}
+	return ""
+}
+
+func (x *ListDeploymentsRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *ListDeploymentsRequest) GetUntil() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Until
+	}
+	return nil
+}
+
+func (x *ListDeploymentsRequest) GetOrder() string {
+	if x != nil {
+		return x.Order
+	}
+	return ""
+}
+
+func (x *ListDeploymentsRequest) GetBootEntries() bool {
+	if x != nil {
+		return x.BootEntries
+	}
+	return false
+}
+
+func (x *ListDeploymentsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListDeploymentsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListDeploymentsResponse struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Deployments []*Deployment          `protobuf:"bytes,1,rep,name=deployments" json:"deployments,omitempty"`
+	// next_page_token is empty on the last page
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDeploymentsResponse) Reset() {
+	*x = ListDeploymentsResponse{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDeploymentsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDeploymentsResponse) ProtoMessage() {}
+
+func (x *ListDeploymentsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDeploymentsResponse.ProtoReflect.Descriptor instead.
+func (*ListDeploymentsResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListDeploymentsResponse) GetDeployments() []*Deployment {
+	if x != nil {
+		return x.Deployments
+	}
+	return nil
+}
+
+func (x *ListDeploymentsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type GetDeploymentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Uuid          string                 `protobuf:"bytes,1,opt,name=uuid" json:"uuid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDeploymentRequest) Reset() {
+	*x = GetDeploymentRequest{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDeploymentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeploymentRequest) ProtoMessage() {}
+
+func (x *GetDeploymentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeploymentRequest.ProtoReflect.Descriptor instead.
+func (*GetDeploymentRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetDeploymentRequest) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+type GenerationLogsRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	GenerationUuid string                 `protobuf:"bytes,1,opt,name=generation_uuid,json=generationUuid" json:"generation_uuid,omitempty"`
+	Kind           string                 `protobuf:"bytes,2,opt,name=kind" json:"kind,omitempty"` // possible values: "eval", "build"
+	// If true, logs are streamed until the end of the evaluation or
+	// the build.
+	Follow        *wrapperspb.BoolValue `protobuf:"bytes,3,opt,name=follow" json:"follow,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerationLogsRequest) Reset() {
+	*x = GenerationLogsRequest{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerationLogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerationLogsRequest) ProtoMessage() {}
+
+func (x *GenerationLogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerationLogsRequest.ProtoReflect.Descriptor instead.
+func (*GenerationLogsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GenerationLogsRequest) GetGenerationUuid() string {
+	if x != nil {
+		return x.GenerationUuid
+	}
+	return ""
+}
+
+func (x *GenerationLogsRequest) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *GenerationLogsRequest) GetFollow() *wrapperspb.BoolValue {
+	if x != nil {
+		return x.Follow
+	}
+	return nil
+}
+
+type GenerationLogsChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerationLogsChunk) Reset() {
+	*x = GenerationLogsChunk{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerationLogsChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerationLogsChunk) ProtoMessage() {}
+
+func (x *GenerationLogsChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerationLogsChunk.ProtoReflect.Descriptor instead.
+func (*GenerationLogsChunk) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GenerationLogsChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
 type Operation struct {
 	state              protoimpl.MessageState `protogen:"open.v1"`
 	OperationSubmitted string                 `protobuf:"bytes,1,opt,name=operation_submitted,json=operationSubmitted" json:"operation_submitted,omitempty"`
@@ -36,7 +369,7 @@ type Operation struct {
 
 func (x *Operation) Reset() {
 	*x = Operation{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[0]
+	mi := &file_pkg_protobuf_services_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -48,7 +381,7 @@ func (x *Operation) String() string {
 func (*Operation) ProtoMessage() {}
 
 func (x *Operation) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[0]
+	mi := &file_pkg_protobuf_services_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -61,7 +394,7 @@ func (x *Operation) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Operation.ProtoReflect.Descriptor instead.
 func (*Operation) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{0}
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *Operation) GetOperationSubmitted() string {
@@ -89,6 +422,14 @@ type Event struct {
 	//	*Event_RebootRequired_
 	//	*Event_ManagerState_
 	//	*Event_Fetched_
+	//	*Event_BuildSkippedType
+	//	*Event_BuildProgressType
+	//	*Event_BuildWaitingForCacheType
+	//	*Event_RebootPlannedType
+	//	*Event_RebootCancelledType
+	//	*Event_GarbageCollectedType
+	//	*Event_ClosureDiffComputedType
+	//	*Event_UnitChangesPredictedType
 	Type          isEvent_Type           `protobuf_oneof:"Type"`
 	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,15,opt,name=createdAt" json:"createdAt,omitempty"`
 	unknownFields protoimpl.UnknownFields
@@ -97,7 +438,7 @@ type Event struct {
 
 func (x *Event) Reset() {
 	*x = Event{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[1]
+	mi := &file_pkg_protobuf_services_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -109,7 +450,7 @@ func (x *Event) String() string {
 func (*Event) ProtoMessage() {}
 
 func (x *Event) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[1]
+	mi := &file_pkg_protobuf_services_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -122,7 +463,7 @@ func (x *Event) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Event.ProtoReflect.Descriptor instead.
 func (*Event) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{1}
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *Event) GetType() isEvent_Type {
@@ -258,22 +599,94 @@ func (x *Event) GetFetched() *Event_Fetched {
 	return nil
 }
 
-func (x *Event) GetCreatedAt() *timestamppb.Timestamp {
+func (x *Event) GetBuildSkippedType() *Event_BuildSkipped {
 	if x != nil {
-		return x.CreatedAt
+		if x, ok := x.Type.(*Event_BuildSkippedType); ok {
+			return x.BuildSkippedType
+		}
 	}
 	return nil
 }
 
-type isEvent_Type interface {
-	isEvent_Type()
-}
-
-type Event_EvalStartedType struct {
-	EvalStartedType *Event_EvalStarted `protobuf:"bytes,1,opt,name=evalStartedType,oneof"`
+func (x *Event) GetBuildProgressType() *Event_BuildProgress {
+	if x != nil {
+		if x, ok := x.Type.(*Event_BuildProgressType); ok {
+			return x.BuildProgressType
+		}
+	}
+	return nil
 }
 
-type Event_EvalFinishedType struct {
+func (x *Event) GetBuildWaitingForCacheType() *Event_BuildWaitingForCache {
+	if x != nil {
+		if x, ok := x.Type.(*Event_BuildWaitingForCacheType); ok {
+			return x.BuildWaitingForCacheType
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetRebootPlannedType() *Event_RebootPlanned {
+	if x != nil {
+		if x, ok := x.Type.(*Event_RebootPlannedType); ok {
+			return x.RebootPlannedType
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetRebootCancelledType() *Event_RebootCancelled {
+	if x != nil {
+		if x, ok := x.Type.(*Event_RebootCancelledType); ok {
+			return x.RebootCancelledType
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetGarbageCollectedType() *Event_GarbageCollected {
+	if x != nil {
+		if x, ok := x.Type.(*Event_GarbageCollectedType); ok {
+			return x.GarbageCollectedType
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetClosureDiffComputedType() *Event_ClosureDiffComputed {
+	if x != nil {
+		if x, ok := x.Type.(*Event_ClosureDiffComputedType); ok {
+			return x.ClosureDiffComputedType
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetUnitChangesPredictedType() *Event_UnitChangesPredicted {
+	if x != nil {
+		if x, ok := x.Type.(*Event_UnitChangesPredictedType); ok {
+			return x.UnitChangesPredictedType
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type isEvent_Type interface {
+	isEvent_Type()
+}
+
+type Event_EvalStartedType struct {
+	EvalStartedType *Event_EvalStarted `protobuf:"bytes,1,opt,name=evalStartedType,oneof"`
+}
+
+type Event_EvalFinishedType struct {
 	EvalFinishedType *Event_EvalFinished `protobuf:"bytes,2,opt,name=evalFinishedType,oneof"`
 }
 
@@ -325,6 +738,38 @@ type Event_Fetched_ struct {
 	Fetched *Event_Fetched `protobuf:"bytes,14,opt,name=fetched,oneof"`
 }
 
+type Event_BuildSkippedType struct {
+	BuildSkippedType *Event_BuildSkipped `protobuf:"bytes,16,opt,name=buildSkippedType,oneof"`
+}
+
+type Event_BuildProgressType struct {
+	BuildProgressType *Event_BuildProgress `protobuf:"bytes,17,opt,name=buildProgressType,oneof"`
+}
+
+type Event_BuildWaitingForCacheType struct {
+	BuildWaitingForCacheType *Event_BuildWaitingForCache `protobuf:"bytes,18,opt,name=buildWaitingForCacheType,oneof"`
+}
+
+type Event_RebootPlannedType struct {
+	RebootPlannedType *Event_RebootPlanned `protobuf:"bytes,19,opt,name=rebootPlannedType,oneof"`
+}
+
+type Event_RebootCancelledType struct {
+	RebootCancelledType *Event_RebootCancelled `protobuf:"bytes,20,opt,name=rebootCancelledType,oneof"`
+}
+
+type Event_GarbageCollectedType struct {
+	GarbageCollectedType *Event_GarbageCollected `protobuf:"bytes,21,opt,name=garbageCollectedType,oneof"`
+}
+
+type Event_ClosureDiffComputedType struct {
+	ClosureDiffComputedType *Event_ClosureDiffComputed `protobuf:"bytes,22,opt,name=closureDiffComputedType,oneof"`
+}
+
+type Event_UnitChangesPredictedType struct {
+	UnitChangesPredictedType *Event_UnitChangesPredicted `protobuf:"bytes,23,opt,name=unitChangesPredictedType,oneof"`
+}
+
 func (*Event_EvalStartedType) isEvent_Type() {}
 
 func (*Event_EvalFinishedType) isEvent_Type() {}
@@ -353,6 +798,22 @@ func (*Event_ManagerState_) isEvent_Type() {}
 
 func (*Event_Fetched_) isEvent_Type() {}
 
+func (*Event_BuildSkippedType) isEvent_Type() {}
+
+func (*Event_BuildProgressType) isEvent_Type() {}
+
+func (*Event_BuildWaitingForCacheType) isEvent_Type() {}
+
+func (*Event_RebootPlannedType) isEvent_Type() {}
+
+func (*Event_RebootCancelledType) isEvent_Type() {}
+
+func (*Event_GarbageCollectedType) isEvent_Type() {}
+
+func (*Event_ClosureDiffComputedType) isEvent_Type() {}
+
+func (*Event_UnitChangesPredictedType) isEvent_Type() {}
+
 type ConfirmRequest struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
 	GenerationUuid string                 `protobuf:"bytes,1,opt,name=generationUuid" json:"generationUuid,omitempty"`
@@ -363,7 +824,7 @@ type ConfirmRequest struct {
 
 func (x *ConfirmRequest) Reset() {
 	*x = ConfirmRequest{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[2]
+	mi := &file_pkg_protobuf_services_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -375,7 +836,7 @@ func (x *ConfirmRequest) String() string {
 func (*ConfirmRequest) ProtoMessage() {}
 
 func (x *ConfirmRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[2]
+	mi := &file_pkg_protobuf_services_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -388,7 +849,7 @@ func (x *ConfirmRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ConfirmRequest.ProtoReflect.Descriptor instead.
 func (*ConfirmRequest) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{2}
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *ConfirmRequest) GetGenerationUuid() string {
@@ -427,21 +888,46 @@ type Generation struct {
 	EvalStartedAt           *timestamppb.Timestamp `protobuf:"bytes,14,opt,name=eval_started_at,json=evalStartedAt" json:"eval_started_at,omitempty"`
 	EvalEndedAt             *timestamppb.Timestamp `protobuf:"bytes,15,opt,name=eval_ended_at,json=evalEndedAt" json:"eval_ended_at,omitempty"`
 	EvalErr                 string                 `protobuf:"bytes,16,opt,name=eval_err,json=evalErr" json:"eval_err,omitempty"`
-	OutPath                 string                 `protobuf:"bytes,17,opt,name=out_path,json=outPath" json:"out_path,omitempty"`
-	DrvPath                 string                 `protobuf:"bytes,18,opt,name=drv_path,json=drvPath" json:"drv_path,omitempty"`
-	MachineId               string                 `protobuf:"bytes,19,opt,name=machine_id,json=machineId" json:"machine_id,omitempty"`
-	BuildStatus             string                 `protobuf:"bytes,20,opt,name=build_status,json=buildStatus" json:"build_status,omitempty"`
-	BuildReason             string                 `protobuf:"bytes,27,opt,name=build_reason,json=buildReason" json:"build_reason,omitempty"`
-	BuildStartedAt          *timestamppb.Timestamp `protobuf:"bytes,21,opt,name=build_started_at,json=buildStartedAt" json:"build_started_at,omitempty"`
-	BuildEndedAt            *timestamppb.Timestamp `protobuf:"bytes,22,opt,name=build_ended_at,json=buildEndedAt" json:"build_ended_at,omitempty"`
-	BuildErr                string                 `protobuf:"bytes,23,opt,name=build_err,json=buildErr" json:"build_err,omitempty"`
-	unknownFields           protoimpl.UnknownFields
-	sizeCache               protoimpl.SizeCache
+	// The cause of the evaluation error when it has been interrupted
+	// by comin, such as "timeout". Empty otherwise.
+	EvalErrCause   string                 `protobuf:"bytes,28,opt,name=eval_err_cause,json=evalErrCause" json:"eval_err_cause,omitempty"`
+	OutPath        string                 `protobuf:"bytes,17,opt,name=out_path,json=outPath" json:"out_path,omitempty"`
+	DrvPath        string                 `protobuf:"bytes,18,opt,name=drv_path,json=drvPath" json:"drv_path,omitempty"`
+	MachineId      string                 `protobuf:"bytes,19,opt,name=machine_id,json=machineId" json:"machine_id,omitempty"`
+	BuildStatus    string                 `protobuf:"bytes,20,opt,name=build_status,json=buildStatus" json:"build_status,omitempty"`
+	BuildReason    string                 `protobuf:"bytes,27,opt,name=build_reason,json=buildReason" json:"build_reason,omitempty"`
+	BuildStartedAt *timestamppb.Timestamp `protobuf:"bytes,21,opt,name=build_started_at,json=buildStartedAt" json:"build_started_at,omitempty"`
+	BuildEndedAt   *timestamppb.Timestamp `protobuf:"bytes,22,opt,name=build_ended_at,json=buildEndedAt" json:"build_ended_at,omitempty"`
+	BuildErr       string                 `protobuf:"bytes,23,opt,name=build_err,json=buildErr" json:"build_err,omitempty"`
+	// The cause of the build error when it has been interrupted by
+	// comin, such as "timeout" or "max-silent-time". Empty otherwise.
+	BuildErrCause string `protobuf:"bytes,29,opt,name=build_err_cause,json=buildErrCause" json:"build_err_cause,omitempty"`
+	// The builder which has produced the out path, such as "local"
+	// or a remote store URI. Empty when the out path was already in
+	// the Nix store.
+	BuiltBy string `protobuf:"bytes,30,opt,name=built_by,json=builtBy" json:"built_by,omitempty"`
+	// The source of the out path when the configuration has not
+	// been evaluated, such as "manifest ci/manifest.json". Empty
+	// when the configuration has been evaluated.
+	EvalSource string `protobuf:"bytes,31,opt,name=eval_source,json=evalSource" json:"eval_source,omitempty"`
+	// The package changes between the running system and the out
+	// path, computed once the generation is built
+	ClosureDiff *ClosureDiff `protobuf:"bytes,32,opt,name=closure_diff,json=closureDiff" json:"closure_diff,omitempty"`
+	// The systemd units which would be changed by the activation of
+	// the out path, predicted once the generation is built
+	UnitChanges *UnitChanges `protobuf:"bytes,33,opt,name=unit_changes,json=unitChanges" json:"unit_changes,omitempty"`
+	// hooks are the results of the post-eval and post-build hooks
+	Hooks []*HookResult `protobuf:"bytes,34,rep,name=hooks" json:"hooks,omitempty"`
+	// gc_root is the Nix GC root of the out path, created once the
+	// generation is built and removed with the generation
+	GcRoot        string `protobuf:"bytes,35,opt,name=gc_root,json=gcRoot" json:"gc_root,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Generation) Reset() {
 	*x = Generation{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[3]
+	mi := &file_pkg_protobuf_services_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -453,7 +939,7 @@ func (x *Generation) String() string {
 func (*Generation) ProtoMessage() {}
 
 func (x *Generation) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[3]
+	mi := &file_pkg_protobuf_services_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -466,7 +952,7 @@ func (x *Generation) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Generation.ProtoReflect.Descriptor instead.
 func (*Generation) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{3}
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *Generation) GetUuid() string {
@@ -595,6 +1081,13 @@ func (x *Generation) GetEvalErr() string {
 	return ""
 }
 
+func (x *Generation) GetEvalErrCause() string {
+	if x != nil {
+		return x.EvalErrCause
+	}
+	return ""
+}
+
 func (x *Generation) GetOutPath() string {
 	if x != nil {
 		return x.OutPath
@@ -651,191 +1144,151 @@ func (x *Generation) GetBuildErr() string {
 	return ""
 }
 
-type Deployment struct {
-	state              protoimpl.MessageState `protogen:"open.v1"`
-	Uuid               string                 `protobuf:"bytes,1,opt,name=uuid" json:"uuid,omitempty"`
-	Reason             string                 `protobuf:"bytes,10,opt,name=reason" json:"reason,omitempty"`
-	Generation         *Generation            `protobuf:"bytes,2,opt,name=generation" json:"generation,omitempty"`
-	StartedAt          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=started_at,json=startedAt" json:"started_at,omitempty"`
-	EndedAt            *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=ended_at,json=endedAt" json:"ended_at,omitempty"`
-	ErrorMsg           string                 `protobuf:"bytes,5,opt,name=error_msg,json=errorMsg" json:"error_msg,omitempty"`
-	RestartComin       *wrapperspb.BoolValue  `protobuf:"bytes,6,opt,name=restart_comin,json=restartComin" json:"restart_comin,omitempty"`
-	ProfilePath        string                 `protobuf:"bytes,7,opt,name=profile_path,json=profilePath" json:"profile_path,omitempty"`
-	Status             string                 `protobuf:"bytes,8,opt,name=status" json:"status,omitempty"`
-	OperationSubmitted string                 `protobuf:"bytes,12,opt,name=operation_submitted,json=operationSubmitted" json:"operation_submitted,omitempty"`
-	Operation          string                 `protobuf:"bytes,9,opt,name=operation" json:"operation,omitempty"`
-	OperationReason    string                 `protobuf:"bytes,15,opt,name=operation_reason,json=operationReason" json:"operation_reason,omitempty"`
-	CreatedAt          *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=created_at,json=createdAt" json:"created_at,omitempty"`
-	CurrentInhibitors  map[string]string      `protobuf:"bytes,13,rep,name=current_inhibitors,json=currentInhibitors" json:"current_inhibitors,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	NewInhibitors      map[string]string      `protobuf:"bytes,14,rep,name=new_inhibitors,json=newInhibitors" json:"new_inhibitors,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	unknownFields      protoimpl.UnknownFields
-	sizeCache          protoimpl.SizeCache
-}
-
-func (x *Deployment) Reset() {
-	*x = Deployment{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[4]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *Deployment) String() string {
-	return protoimpl.X.MessageStringOf(x)
-}
-
-func (*Deployment) ProtoMessage() {}
-
-func (x *Deployment) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[4]
+func (x *Generation) GetBuildErrCause() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.BuildErrCause
 	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use Deployment.ProtoReflect.Descriptor instead.
-func (*Deployment) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{4}
+	return ""
 }
 
-func (x *Deployment) GetUuid() string {
+func (x *Generation) GetBuiltBy() string {
 	if x != nil {
-		return x.Uuid
+		return x.BuiltBy
 	}
 	return ""
 }
 
-func (x *Deployment) GetReason() string {
+func (x *Generation) GetEvalSource() string {
 	if x != nil {
-		return x.Reason
+		return x.EvalSource
 	}
 	return ""
 }
 
-func (x *Deployment) GetGeneration() *Generation {
+func (x *Generation) GetClosureDiff() *ClosureDiff {
 	if x != nil {
-		return x.Generation
+		return x.ClosureDiff
 	}
 	return nil
 }
 
-func (x *Deployment) GetStartedAt() *timestamppb.Timestamp {
+func (x *Generation) GetUnitChanges() *UnitChanges {
 	if x != nil {
-		return x.StartedAt
+		return x.UnitChanges
 	}
 	return nil
 }
 
-func (x *Deployment) GetEndedAt() *timestamppb.Timestamp {
+func (x *Generation) GetHooks() []*HookResult {
 	if x != nil {
-		return x.EndedAt
+		return x.Hooks
 	}
 	return nil
 }
 
-func (x *Deployment) GetErrorMsg() string {
+func (x *Generation) GetGcRoot() string {
 	if x != nil {
-		return x.ErrorMsg
+		return x.GcRoot
 	}
 	return ""
 }
 
-func (x *Deployment) GetRestartComin() *wrapperspb.BoolValue {
-	if x != nil {
-		return x.RestartComin
-	}
-	return nil
+type UnitChanges struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stop          []string               `protobuf:"bytes,1,rep,name=stop" json:"stop,omitempty"`
+	Restart       []string               `protobuf:"bytes,2,rep,name=restart" json:"restart,omitempty"`
+	Reload        []string               `protobuf:"bytes,3,rep,name=reload" json:"reload,omitempty"`
+	Start         []string               `protobuf:"bytes,4,rep,name=start" json:"start,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Deployment) GetProfilePath() string {
-	if x != nil {
-		return x.ProfilePath
-	}
-	return ""
+func (x *UnitChanges) Reset() {
+	*x = UnitChanges{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *Deployment) GetStatus() string {
-	if x != nil {
-		return x.Status
-	}
-	return ""
+func (x *UnitChanges) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *Deployment) GetOperationSubmitted() string {
+func (*UnitChanges) ProtoMessage() {}
+
+func (x *UnitChanges) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[9]
 	if x != nil {
-		return x.OperationSubmitted
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *Deployment) GetOperation() string {
-	if x != nil {
-		return x.Operation
-	}
-	return ""
+// Deprecated: Use UnitChanges.ProtoReflect.Descriptor instead.
+func (*UnitChanges) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *Deployment) GetOperationReason() string {
+func (x *UnitChanges) GetStop() []string {
 	if x != nil {
-		return x.OperationReason
+		return x.Stop
 	}
-	return ""
+	return nil
 }
 
-func (x *Deployment) GetCreatedAt() *timestamppb.Timestamp {
+func (x *UnitChanges) GetRestart() []string {
 	if x != nil {
-		return x.CreatedAt
+		return x.Restart
 	}
 	return nil
 }
 
-func (x *Deployment) GetCurrentInhibitors() map[string]string {
+func (x *UnitChanges) GetReload() []string {
 	if x != nil {
-		return x.CurrentInhibitors
+		return x.Reload
 	}
 	return nil
 }
 
-func (x *Deployment) GetNewInhibitors() map[string]string {
+func (x *UnitChanges) GetStart() []string {
 	if x != nil {
-		return x.NewInhibitors
+		return x.Start
 	}
 	return nil
 }
 
-type State struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	NeedToReboot    *wrapperspb.BoolValue  `protobuf:"bytes,1,opt,name=need_to_reboot,json=needToReboot" json:"need_to_reboot,omitempty"`
-	IsSuspended     *wrapperspb.BoolValue  `protobuf:"bytes,2,opt,name=is_suspended,json=isSuspended" json:"is_suspended,omitempty"`
-	Builder         *Builder               `protobuf:"bytes,3,opt,name=builder" json:"builder,omitempty"`
-	Deployer        *Deployer              `protobuf:"bytes,4,opt,name=deployer" json:"deployer,omitempty"`
-	Fetcher         *Fetcher               `protobuf:"bytes,5,opt,name=fetcher" json:"fetcher,omitempty"`
-	Store           *Store                 `protobuf:"bytes,6,opt,name=store" json:"store,omitempty"`
-	BuildConfirmer  *Confirmer             `protobuf:"bytes,7,opt,name=build_confirmer,json=buildConfirmer" json:"build_confirmer,omitempty"`
-	DeployConfirmer *Confirmer             `protobuf:"bytes,8,opt,name=deploy_confirmer,json=deployConfirmer" json:"deploy_confirmer,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+type PackageChange struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// Empty when the package is added
+	OldVersion string `protobuf:"bytes,2,opt,name=old_version,json=oldVersion" json:"old_version,omitempty"`
+	// Empty when the package is removed
+	NewVersion string `protobuf:"bytes,3,opt,name=new_version,json=newVersion" json:"new_version,omitempty"`
+	// The size difference in bytes
+	SizeDelta     int64 `protobuf:"varint,4,opt,name=size_delta,json=sizeDelta" json:"size_delta,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *State) Reset() {
-	*x = State{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[5]
+func (x *PackageChange) Reset() {
+	*x = PackageChange{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *State) String() string {
+func (x *PackageChange) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*State) ProtoMessage() {}
+func (*PackageChange) ProtoMessage() {}
 
-func (x *State) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[5]
+func (x *PackageChange) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -846,94 +1299,67 @@ func (x *State) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use State.ProtoReflect.Descriptor instead.
-func (*State) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use PackageChange.ProtoReflect.Descriptor instead.
+func (*PackageChange) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *State) GetNeedToReboot() *wrapperspb.BoolValue {
+func (x *PackageChange) GetName() string {
 	if x != nil {
-		return x.NeedToReboot
+		return x.Name
 	}
-	return nil
+	return ""
 }
 
-func (x *State) GetIsSuspended() *wrapperspb.BoolValue {
+func (x *PackageChange) GetOldVersion() string {
 	if x != nil {
-		return x.IsSuspended
+		return x.OldVersion
 	}
-	return nil
+	return ""
 }
 
-func (x *State) GetBuilder() *Builder {
+func (x *PackageChange) GetNewVersion() string {
 	if x != nil {
-		return x.Builder
+		return x.NewVersion
 	}
-	return nil
+	return ""
 }
 
-func (x *State) GetDeployer() *Deployer {
+func (x *PackageChange) GetSizeDelta() int64 {
 	if x != nil {
-		return x.Deployer
+		return x.SizeDelta
 	}
-	return nil
+	return 0
 }
 
-func (x *State) GetFetcher() *Fetcher {
-	if x != nil {
-		return x.Fetcher
-	}
-	return nil
-}
-
-func (x *State) GetStore() *Store {
-	if x != nil {
-		return x.Store
-	}
-	return nil
-}
-
-func (x *State) GetBuildConfirmer() *Confirmer {
-	if x != nil {
-		return x.BuildConfirmer
-	}
-	return nil
-}
-
-func (x *State) GetDeployConfirmer() *Confirmer {
-	if x != nil {
-		return x.DeployConfirmer
-	}
-	return nil
-}
-
-type Deployer struct {
-	state              protoimpl.MessageState `protogen:"open.v1"`
-	IsDeploying        *wrapperspb.BoolValue  `protobuf:"bytes,1,opt,name=is_deploying,json=isDeploying" json:"is_deploying,omitempty"`
-	Deployment         *Deployment            `protobuf:"bytes,2,opt,name=deployment" json:"deployment,omitempty"`
-	GenerationToDeploy *Generation            `protobuf:"bytes,3,opt,name=generation_to_deploy,json=generationToDeploy" json:"generation_to_deploy,omitempty"`
-	Operation          string                 `protobuf:"bytes,6,opt,name=operation" json:"operation,omitempty"`
-	PreviousDeployment *Deployment            `protobuf:"bytes,4,opt,name=previous_deployment,json=previousDeployment" json:"previous_deployment,omitempty"`
-	IsSuspended        *wrapperspb.BoolValue  `protobuf:"bytes,5,opt,name=is_suspended,json=isSuspended" json:"is_suspended,omitempty"`
-	unknownFields      protoimpl.UnknownFields
-	sizeCache          protoimpl.SizeCache
+type ClosureDiff struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The out path of the running system
+	CurrentOutPath string           `protobuf:"bytes,1,opt,name=current_out_path,json=currentOutPath" json:"current_out_path,omitempty"`
+	Added          []*PackageChange `protobuf:"bytes,2,rep,name=added" json:"added,omitempty"`
+	Removed        []*PackageChange `protobuf:"bytes,3,rep,name=removed" json:"removed,omitempty"`
+	Upgraded       []*PackageChange `protobuf:"bytes,4,rep,name=upgraded" json:"upgraded,omitempty"`
+	// The closure size difference in bytes
+	ClosureSizeDelta int64 `protobuf:"varint,5,opt,name=closure_size_delta,json=closureSizeDelta" json:"closure_size_delta,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
-func (x *Deployer) Reset() {
-	*x = Deployer{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[6]
+func (x *ClosureDiff) Reset() {
+	*x = ClosureDiff{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Deployer) String() string {
+func (x *ClosureDiff) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Deployer) ProtoMessage() {}
+func (*ClosureDiff) ProtoMessage() {}
 
-func (x *Deployer) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[6]
+func (x *ClosureDiff) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -944,80 +1370,93 @@ func (x *Deployer) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Deployer.ProtoReflect.Descriptor instead.
-func (*Deployer) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use ClosureDiff.ProtoReflect.Descriptor instead.
+func (*ClosureDiff) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *Deployer) GetIsDeploying() *wrapperspb.BoolValue {
+func (x *ClosureDiff) GetCurrentOutPath() string {
 	if x != nil {
-		return x.IsDeploying
+		return x.CurrentOutPath
 	}
-	return nil
+	return ""
 }
 
-func (x *Deployer) GetDeployment() *Deployment {
+func (x *ClosureDiff) GetAdded() []*PackageChange {
 	if x != nil {
-		return x.Deployment
+		return x.Added
 	}
 	return nil
 }
 
-func (x *Deployer) GetGenerationToDeploy() *Generation {
+func (x *ClosureDiff) GetRemoved() []*PackageChange {
 	if x != nil {
-		return x.GenerationToDeploy
+		return x.Removed
 	}
 	return nil
 }
 
-func (x *Deployer) GetOperation() string {
-	if x != nil {
-		return x.Operation
-	}
-	return ""
-}
-
-func (x *Deployer) GetPreviousDeployment() *Deployment {
+func (x *ClosureDiff) GetUpgraded() []*PackageChange {
 	if x != nil {
-		return x.PreviousDeployment
+		return x.Upgraded
 	}
 	return nil
 }
 
-func (x *Deployer) GetIsSuspended() *wrapperspb.BoolValue {
+func (x *ClosureDiff) GetClosureSizeDelta() int64 {
 	if x != nil {
-		return x.IsSuspended
+		return x.ClosureSizeDelta
 	}
-	return nil
+	return 0
 }
 
-type Builder struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	IsEvaluating   *wrapperspb.BoolValue  `protobuf:"bytes,1,opt,name=is_evaluating,json=isEvaluating" json:"is_evaluating,omitempty"`
-	IsBuilding     *wrapperspb.BoolValue  `protobuf:"bytes,2,opt,name=is_building,json=isBuilding" json:"is_building,omitempty"`
-	Generation     *Generation            `protobuf:"bytes,3,opt,name=generation" json:"generation,omitempty"`
-	GenerationUuid string                 `protobuf:"bytes,4,opt,name=generation_uuid,json=generationUuid" json:"generation_uuid,omitempty"`
-	IsSuspended    *wrapperspb.BoolValue  `protobuf:"bytes,5,opt,name=is_suspended,json=isSuspended" json:"is_suspended,omitempty"`
-	Hostname       string                 `protobuf:"bytes,6,opt,name=hostname" json:"hostname,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+type Deployment struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Uuid               string                 `protobuf:"bytes,1,opt,name=uuid" json:"uuid,omitempty"`
+	Reason             string                 `protobuf:"bytes,10,opt,name=reason" json:"reason,omitempty"`
+	Generation         *Generation            `protobuf:"bytes,2,opt,name=generation" json:"generation,omitempty"`
+	StartedAt          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=started_at,json=startedAt" json:"started_at,omitempty"`
+	EndedAt            *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=ended_at,json=endedAt" json:"ended_at,omitempty"`
+	ErrorMsg           string                 `protobuf:"bytes,5,opt,name=error_msg,json=errorMsg" json:"error_msg,omitempty"`
+	RestartComin       *wrapperspb.BoolValue  `protobuf:"bytes,6,opt,name=restart_comin,json=restartComin" json:"restart_comin,omitempty"`
+	ProfilePath        string                 `protobuf:"bytes,7,opt,name=profile_path,json=profilePath" json:"profile_path,omitempty"`
+	Status             string                 `protobuf:"bytes,8,opt,name=status" json:"status,omitempty"`
+	OperationSubmitted string                 `protobuf:"bytes,12,opt,name=operation_submitted,json=operationSubmitted" json:"operation_submitted,omitempty"`
+	Operation          string                 `protobuf:"bytes,9,opt,name=operation" json:"operation,omitempty"`
+	OperationReason    string                 `protobuf:"bytes,15,opt,name=operation_reason,json=operationReason" json:"operation_reason,omitempty"`
+	CreatedAt          *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=created_at,json=createdAt" json:"created_at,omitempty"`
+	CurrentInhibitors  map[string]string      `protobuf:"bytes,13,rep,name=current_inhibitors,json=currentInhibitors" json:"current_inhibitors,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	NewInhibitors      map[string]string      `protobuf:"bytes,14,rep,name=new_inhibitors,json=newInhibitors" json:"new_inhibitors,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// reboot_reasons are the reasons why a reboot is required to take
+	// the deployment into account
+	RebootReasons []string `protobuf:"bytes,16,rep,name=reboot_reasons,json=rebootReasons" json:"reboot_reasons,omitempty"`
+	// hooks are the results of the pre-deploy and post-deploy hooks
+	Hooks []*HookResult `protobuf:"bytes,17,rep,name=hooks" json:"hooks,omitempty"`
+	// inhibitor_changes are the switch inhibitors which differ between
+	// the running system and the generation
+	InhibitorChanges []*InhibitorChange `protobuf:"bytes,18,rep,name=inhibitor_changes,json=inhibitorChanges" json:"inhibitor_changes,omitempty"`
+	// inhibitor_override is the operator override of the switch
+	// inhibitors applied to this deployment, if any
+	InhibitorOverride *InhibitorOverride `protobuf:"bytes,19,opt,name=inhibitor_override,json=inhibitorOverride" json:"inhibitor_override,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
-func (x *Builder) Reset() {
-	*x = Builder{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[7]
+func (x *Deployment) Reset() {
+	*x = Deployment{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Builder) String() string {
+func (x *Deployment) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Builder) ProtoMessage() {}
+func (*Deployment) ProtoMessage() {}
 
-func (x *Builder) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[7]
+func (x *Deployment) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1028,160 +1467,170 @@ func (x *Builder) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Builder.ProtoReflect.Descriptor instead.
-func (*Builder) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use Deployment.ProtoReflect.Descriptor instead.
+func (*Deployment) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *Builder) GetIsEvaluating() *wrapperspb.BoolValue {
+func (x *Deployment) GetUuid() string {
 	if x != nil {
-		return x.IsEvaluating
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *Deployment) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *Deployment) GetGeneration() *Generation {
+	if x != nil {
+		return x.Generation
 	}
 	return nil
 }
 
-func (x *Builder) GetIsBuilding() *wrapperspb.BoolValue {
+func (x *Deployment) GetStartedAt() *timestamppb.Timestamp {
 	if x != nil {
-		return x.IsBuilding
+		return x.StartedAt
 	}
 	return nil
 }
 
-func (x *Builder) GetGeneration() *Generation {
+func (x *Deployment) GetEndedAt() *timestamppb.Timestamp {
 	if x != nil {
-		return x.Generation
+		return x.EndedAt
 	}
 	return nil
 }
 
-func (x *Builder) GetGenerationUuid() string {
+func (x *Deployment) GetErrorMsg() string {
 	if x != nil {
-		return x.GenerationUuid
+		return x.ErrorMsg
 	}
 	return ""
 }
 
-func (x *Builder) GetIsSuspended() *wrapperspb.BoolValue {
+func (x *Deployment) GetRestartComin() *wrapperspb.BoolValue {
 	if x != nil {
-		return x.IsSuspended
+		return x.RestartComin
 	}
 	return nil
 }
 
-func (x *Builder) GetHostname() string {
+func (x *Deployment) GetProfilePath() string {
 	if x != nil {
-		return x.Hostname
+		return x.ProfilePath
 	}
 	return ""
 }
 
-type Confirmer struct {
-	state                protoimpl.MessageState `protogen:"open.v1"`
-	Mode                 int64                  `protobuf:"varint,1,opt,name=mode" json:"mode,omitempty"`
-	Submitted            string                 `protobuf:"bytes,2,opt,name=submitted" json:"submitted,omitempty"`
-	Confirmed            string                 `protobuf:"bytes,3,opt,name=confirmed" json:"confirmed,omitempty"`
-	AutoconfirmDuration  int64                  `protobuf:"varint,4,opt,name=autoconfirm_duration,json=autoconfirmDuration" json:"autoconfirm_duration,omitempty"`
-	AutoconfirmStartedAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=autoconfirm_started_at,json=autoconfirmStartedAt" json:"autoconfirm_started_at,omitempty"`
-	AutoconfirmStarted   *wrapperspb.BoolValue  `protobuf:"bytes,6,opt,name=autoconfirm_started,json=autoconfirmStarted" json:"autoconfirm_started,omitempty"`
-	unknownFields        protoimpl.UnknownFields
-	sizeCache            protoimpl.SizeCache
+func (x *Deployment) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
 }
 
-func (x *Confirmer) Reset() {
-	*x = Confirmer{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[8]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *Deployment) GetOperationSubmitted() string {
+	if x != nil {
+		return x.OperationSubmitted
+	}
+	return ""
 }
 
-func (x *Confirmer) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *Deployment) GetOperation() string {
+	if x != nil {
+		return x.Operation
+	}
+	return ""
 }
 
-func (*Confirmer) ProtoMessage() {}
-
-func (x *Confirmer) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[8]
+func (x *Deployment) GetOperationReason() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.OperationReason
 	}
-	return mi.MessageOf(x)
+	return ""
 }
 
-// Deprecated: Use Confirmer.ProtoReflect.Descriptor instead.
-func (*Confirmer) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{8}
+func (x *Deployment) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
 }
 
-func (x *Confirmer) GetMode() int64 {
+func (x *Deployment) GetCurrentInhibitors() map[string]string {
 	if x != nil {
-		return x.Mode
+		return x.CurrentInhibitors
 	}
-	return 0
+	return nil
 }
 
-func (x *Confirmer) GetSubmitted() string {
+func (x *Deployment) GetNewInhibitors() map[string]string {
 	if x != nil {
-		return x.Submitted
+		return x.NewInhibitors
 	}
-	return ""
+	return nil
 }
 
-func (x *Confirmer) GetConfirmed() string {
+func (x *Deployment) GetRebootReasons() []string {
 	if x != nil {
-		return x.Confirmed
+		return x.RebootReasons
 	}
-	return ""
+	return nil
 }
 
-func (x *Confirmer) GetAutoconfirmDuration() int64 {
+func (x *Deployment) GetHooks() []*HookResult {
 	if x != nil {
-		return x.AutoconfirmDuration
+		return x.Hooks
 	}
-	return 0
+	return nil
 }
 
-func (x *Confirmer) GetAutoconfirmStartedAt() *timestamppb.Timestamp {
+func (x *Deployment) GetInhibitorChanges() []*InhibitorChange {
 	if x != nil {
-		return x.AutoconfirmStartedAt
+		return x.InhibitorChanges
 	}
 	return nil
 }
 
-func (x *Confirmer) GetAutoconfirmStarted() *wrapperspb.BoolValue {
+func (x *Deployment) GetInhibitorOverride() *InhibitorOverride {
 	if x != nil {
-		return x.AutoconfirmStarted
+		return x.InhibitorOverride
 	}
 	return nil
 }
 
-type Fetcher struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	IsFetching       *wrapperspb.BoolValue  `protobuf:"bytes,1,opt,name=is_fetching,json=isFetching" json:"is_fetching,omitempty"`
-	RepositoryStatus *RepositoryStatus      `protobuf:"bytes,2,opt,name=repository_status,json=repositoryStatus" json:"repository_status,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+type InhibitorChange struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Key      string                 `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	OldValue string                 `protobuf:"bytes,2,opt,name=old_value,json=oldValue" json:"old_value,omitempty"`
+	NewValue string                 `protobuf:"bytes,3,opt,name=new_value,json=newValue" json:"new_value,omitempty"`
+	// overridden is true when the operator accepted this change
+	Overridden    bool `protobuf:"varint,4,opt,name=overridden" json:"overridden,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Fetcher) Reset() {
-	*x = Fetcher{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[9]
+func (x *InhibitorChange) Reset() {
+	*x = InhibitorChange{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Fetcher) String() string {
+func (x *InhibitorChange) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Fetcher) ProtoMessage() {}
+func (*InhibitorChange) ProtoMessage() {}
 
-func (x *Fetcher) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[9]
+func (x *InhibitorChange) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1192,51 +1641,66 @@ func (x *Fetcher) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Fetcher.ProtoReflect.Descriptor instead.
-func (*Fetcher) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use InhibitorChange.ProtoReflect.Descriptor instead.
+func (*InhibitorChange) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{13}
 }
 
-func (x *Fetcher) GetIsFetching() *wrapperspb.BoolValue {
+func (x *InhibitorChange) GetKey() string {
 	if x != nil {
-		return x.IsFetching
+		return x.Key
 	}
-	return nil
+	return ""
 }
 
-func (x *Fetcher) GetRepositoryStatus() *RepositoryStatus {
+func (x *InhibitorChange) GetOldValue() string {
 	if x != nil {
-		return x.RepositoryStatus
+		return x.OldValue
 	}
-	return nil
+	return ""
 }
 
-type Branch struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
-	CommitId      string                 `protobuf:"bytes,2,opt,name=commit_id,json=commitId" json:"commit_id,omitempty"`
-	CommitMsg     string                 `protobuf:"bytes,3,opt,name=commit_msg,json=commitMsg" json:"commit_msg,omitempty"`
-	ErrorMsg      string                 `protobuf:"bytes,4,opt,name=error_msg,json=errorMsg" json:"error_msg,omitempty"`
-	OnTopOf       string                 `protobuf:"bytes,5,opt,name=on_top_of,json=onTopOf" json:"on_top_of,omitempty"`
+func (x *InhibitorChange) GetNewValue() string {
+	if x != nil {
+		return x.NewValue
+	}
+	return ""
+}
+
+func (x *InhibitorChange) GetOverridden() bool {
+	if x != nil {
+		return x.Overridden
+	}
+	return false
+}
+
+type InhibitorOverride struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	GenerationUuid string                 `protobuf:"bytes,1,opt,name=generation_uuid,json=generationUuid" json:"generation_uuid,omitempty"`
+	// keys are the overridden switch inhibitors. All switch inhibitors
+	// are overridden when it is empty.
+	Keys          []string               `protobuf:"bytes,2,rep,name=keys" json:"keys,omitempty"`
+	Comment       string                 `protobuf:"bytes,3,opt,name=comment" json:"comment,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt" json:"created_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Branch) Reset() {
-	*x = Branch{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[10]
+func (x *InhibitorOverride) Reset() {
+	*x = InhibitorOverride{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Branch) String() string {
+func (x *InhibitorOverride) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Branch) ProtoMessage() {}
+func (*InhibitorOverride) ProtoMessage() {}
 
-func (x *Branch) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[10]
+func (x *InhibitorOverride) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1247,74 +1711,128 @@ func (x *Branch) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Branch.ProtoReflect.Descriptor instead.
-func (*Branch) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use InhibitorOverride.ProtoReflect.Descriptor instead.
+func (*InhibitorOverride) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{14}
 }
 
-func (x *Branch) GetName() string {
+func (x *InhibitorOverride) GetGenerationUuid() string {
 	if x != nil {
-		return x.Name
+		return x.GenerationUuid
 	}
 	return ""
 }
 
-func (x *Branch) GetCommitId() string {
+func (x *InhibitorOverride) GetKeys() []string {
 	if x != nil {
-		return x.CommitId
+		return x.Keys
 	}
-	return ""
+	return nil
 }
 
-func (x *Branch) GetCommitMsg() string {
+func (x *InhibitorOverride) GetComment() string {
 	if x != nil {
-		return x.CommitMsg
+		return x.Comment
 	}
 	return ""
 }
 
-func (x *Branch) GetErrorMsg() string {
+func (x *InhibitorOverride) GetCreatedAt() *timestamppb.Timestamp {
 	if x != nil {
-		return x.ErrorMsg
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type InhibitorOverrideRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	GenerationUuid string                 `protobuf:"bytes,1,opt,name=generation_uuid,json=generationUuid" json:"generation_uuid,omitempty"`
+	Keys           []string               `protobuf:"bytes,2,rep,name=keys" json:"keys,omitempty"`
+	Comment        string                 `protobuf:"bytes,3,opt,name=comment" json:"comment,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *InhibitorOverrideRequest) Reset() {
+	*x = InhibitorOverrideRequest{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InhibitorOverrideRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InhibitorOverrideRequest) ProtoMessage() {}
+
+func (x *InhibitorOverrideRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InhibitorOverrideRequest.ProtoReflect.Descriptor instead.
+func (*InhibitorOverrideRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *InhibitorOverrideRequest) GetGenerationUuid() string {
+	if x != nil {
+		return x.GenerationUuid
 	}
 	return ""
 }
 
-func (x *Branch) GetOnTopOf() string {
+func (x *InhibitorOverrideRequest) GetKeys() []string {
 	if x != nil {
-		return x.OnTopOf
+		return x.Keys
+	}
+	return nil
+}
+
+func (x *InhibitorOverrideRequest) GetComment() string {
+	if x != nil {
+		return x.Comment
 	}
 	return ""
 }
 
-type Remote struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
-	Url           string                 `protobuf:"bytes,2,opt,name=url" json:"url,omitempty"`
-	FetchErrorMsg string                 `protobuf:"bytes,3,opt,name=fetch_error_msg,json=fetchErrorMsg" json:"fetch_error_msg,omitempty"`
-	Main          *Branch                `protobuf:"bytes,4,opt,name=main" json:"main,omitempty"`
-	Testing       *Branch                `protobuf:"bytes,5,opt,name=testing" json:"testing,omitempty"`
-	FetchedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=fetched_at,json=fetchedAt" json:"fetched_at,omitempty"`
-	Fetched       *wrapperspb.BoolValue  `protobuf:"bytes,7,opt,name=fetched" json:"fetched,omitempty"`
+type HookResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// stage is the lifecycle stage of the hook, such as "post-build"
+	Stage    string `protobuf:"bytes,6,opt,name=stage" json:"stage,omitempty"`
+	ExitCode int32  `protobuf:"varint,2,opt,name=exit_code,json=exitCode" json:"exit_code,omitempty"`
+	// output is the end of the combined stdout and stderr of the hook
+	Output        string                 `protobuf:"bytes,3,opt,name=output" json:"output,omitempty"`
+	StartedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=started_at,json=startedAt" json:"started_at,omitempty"`
+	EndedAt       *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=ended_at,json=endedAt" json:"ended_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Remote) Reset() {
-	*x = Remote{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[11]
+func (x *HookResult) Reset() {
+	*x = HookResult{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Remote) String() string {
+func (x *HookResult) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Remote) ProtoMessage() {}
+func (*HookResult) ProtoMessage() {}
 
-func (x *Remote) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[11]
+func (x *HookResult) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1325,96 +1843,85 @@ func (x *Remote) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Remote.ProtoReflect.Descriptor instead.
-func (*Remote) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use HookResult.ProtoReflect.Descriptor instead.
+func (*HookResult) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{16}
 }
 
-func (x *Remote) GetName() string {
+func (x *HookResult) GetName() string {
 	if x != nil {
 		return x.Name
 	}
 	return ""
 }
 
-func (x *Remote) GetUrl() string {
-	if x != nil {
-		return x.Url
-	}
-	return ""
-}
-
-func (x *Remote) GetFetchErrorMsg() string {
+func (x *HookResult) GetStage() string {
 	if x != nil {
-		return x.FetchErrorMsg
+		return x.Stage
 	}
 	return ""
 }
 
-func (x *Remote) GetMain() *Branch {
+func (x *HookResult) GetExitCode() int32 {
 	if x != nil {
-		return x.Main
+		return x.ExitCode
 	}
-	return nil
+	return 0
 }
 
-func (x *Remote) GetTesting() *Branch {
+func (x *HookResult) GetOutput() string {
 	if x != nil {
-		return x.Testing
+		return x.Output
 	}
-	return nil
+	return ""
 }
 
-func (x *Remote) GetFetchedAt() *timestamppb.Timestamp {
+func (x *HookResult) GetStartedAt() *timestamppb.Timestamp {
 	if x != nil {
-		return x.FetchedAt
+		return x.StartedAt
 	}
 	return nil
 }
 
-func (x *Remote) GetFetched() *wrapperspb.BoolValue {
+func (x *HookResult) GetEndedAt() *timestamppb.Timestamp {
 	if x != nil {
-		return x.Fetched
+		return x.EndedAt
 	}
 	return nil
 }
 
-type RepositoryStatus struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// This is the deployed Main commit ID. It is used to ensure fast forward
-	SelectedCommitId        string                `protobuf:"bytes,1,opt,name=selected_commit_id,json=selectedCommitId" json:"selected_commit_id,omitempty"`
-	SelectedCommitMsg       string                `protobuf:"bytes,2,opt,name=selected_commit_msg,json=selectedCommitMsg" json:"selected_commit_msg,omitempty"`
-	SelectedRemoteName      string                `protobuf:"bytes,3,opt,name=selected_remote_name,json=selectedRemoteName" json:"selected_remote_name,omitempty"`
-	SelectedBranchName      string                `protobuf:"bytes,4,opt,name=selected_branch_name,json=selectedBranchName" json:"selected_branch_name,omitempty"`
-	SelectedBranchIsTesting *wrapperspb.BoolValue `protobuf:"bytes,5,opt,name=selected_branch_is_testing,json=selectedBranchIsTesting" json:"selected_branch_is_testing,omitempty"`
-	SelectedCommitSigned    *wrapperspb.BoolValue `protobuf:"bytes,6,opt,name=selected_commit_signed,json=selectedCommitSigned" json:"selected_commit_signed,omitempty"`
-	SelectedCommitSignedBy  string                `protobuf:"bytes,7,opt,name=selected_commit_signed_by,json=selectedCommitSignedBy" json:"selected_commit_signed_by,omitempty"`
-	// True if public keys were available when the commit has been checked out
-	SelectedCommitShouldBeSigned *wrapperspb.BoolValue `protobuf:"bytes,8,opt,name=selected_commit_should_be_signed,json=selectedCommitShouldBeSigned" json:"selected_commit_should_be_signed,omitempty"`
-	MainCommitId                 string                `protobuf:"bytes,9,opt,name=main_commit_id,json=mainCommitId" json:"main_commit_id,omitempty"`
-	MainRemoteName               string                `protobuf:"bytes,10,opt,name=main_remote_name,json=mainRemoteName" json:"main_remote_name,omitempty"`
-	MainBranchName               string                `protobuf:"bytes,11,opt,name=main_branch_name,json=mainBranchName" json:"main_branch_name,omitempty"`
-	Remotes                      []*Remote             `protobuf:"bytes,12,rep,name=remotes" json:"remotes,omitempty"`
-	ErrorMsg                     string                `protobuf:"bytes,13,opt,name=error_msg,json=errorMsg" json:"error_msg,omitempty"`
-	unknownFields                protoimpl.UnknownFields
-	sizeCache                    protoimpl.SizeCache
+type State struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	NeedToReboot    *wrapperspb.BoolValue  `protobuf:"bytes,1,opt,name=need_to_reboot,json=needToReboot" json:"need_to_reboot,omitempty"`
+	IsSuspended     *wrapperspb.BoolValue  `protobuf:"bytes,2,opt,name=is_suspended,json=isSuspended" json:"is_suspended,omitempty"`
+	Builder         *Builder               `protobuf:"bytes,3,opt,name=builder" json:"builder,omitempty"`
+	Deployer        *Deployer              `protobuf:"bytes,4,opt,name=deployer" json:"deployer,omitempty"`
+	Fetcher         *Fetcher               `protobuf:"bytes,5,opt,name=fetcher" json:"fetcher,omitempty"`
+	Store           *Store                 `protobuf:"bytes,6,opt,name=store" json:"store,omitempty"`
+	BuildConfirmer  *Confirmer             `protobuf:"bytes,7,opt,name=build_confirmer,json=buildConfirmer" json:"build_confirmer,omitempty"`
+	DeployConfirmer *Confirmer             `protobuf:"bytes,8,opt,name=deploy_confirmer,json=deployConfirmer" json:"deploy_confirmer,omitempty"`
+	RebootReasons   []string               `protobuf:"bytes,9,rep,name=reboot_reasons,json=rebootReasons" json:"reboot_reasons,omitempty"`
+	Reboot          *Reboot                `protobuf:"bytes,10,opt,name=reboot" json:"reboot,omitempty"`
+	Rollout         *Rollout               `protobuf:"bytes,11,opt,name=rollout" json:"rollout,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *RepositoryStatus) Reset() {
-	*x = RepositoryStatus{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[12]
+func (x *State) Reset() {
+	*x = State{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RepositoryStatus) String() string {
+func (x *State) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RepositoryStatus) ProtoMessage() {}
+func (*State) ProtoMessage() {}
 
-func (x *RepositoryStatus) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[12]
+func (x *State) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1425,125 +1932,1608 @@ func (x *RepositoryStatus) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RepositoryStatus.ProtoReflect.Descriptor instead.
-func (*RepositoryStatus) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{12}
+// Deprecated: Use State.ProtoReflect.Descriptor instead.
+func (*State) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{17}
 }
 
-func (x *RepositoryStatus) GetSelectedCommitId() string {
+func (x *State) GetNeedToReboot() *wrapperspb.BoolValue {
 	if x != nil {
-		return x.SelectedCommitId
+		return x.NeedToReboot
 	}
-	return ""
+	return nil
 }
 
-func (x *RepositoryStatus) GetSelectedCommitMsg() string {
+func (x *State) GetIsSuspended() *wrapperspb.BoolValue {
 	if x != nil {
-		return x.SelectedCommitMsg
+		return x.IsSuspended
 	}
-	return ""
+	return nil
 }
 
-func (x *RepositoryStatus) GetSelectedRemoteName() string {
+func (x *State) GetBuilder() *Builder {
 	if x != nil {
-		return x.SelectedRemoteName
+		return x.Builder
 	}
-	return ""
+	return nil
 }
 
-func (x *RepositoryStatus) GetSelectedBranchName() string {
+func (x *State) GetDeployer() *Deployer {
 	if x != nil {
-		return x.SelectedBranchName
+		return x.Deployer
 	}
-	return ""
+	return nil
 }
 
-func (x *RepositoryStatus) GetSelectedBranchIsTesting() *wrapperspb.BoolValue {
+func (x *State) GetFetcher() *Fetcher {
 	if x != nil {
-		return x.SelectedBranchIsTesting
+		return x.Fetcher
 	}
 	return nil
 }
 
-func (x *RepositoryStatus) GetSelectedCommitSigned() *wrapperspb.BoolValue {
+func (x *State) GetStore() *Store {
 	if x != nil {
-		return x.SelectedCommitSigned
+		return x.Store
 	}
 	return nil
 }
 
-func (x *RepositoryStatus) GetSelectedCommitSignedBy() string {
+func (x *State) GetBuildConfirmer() *Confirmer {
 	if x != nil {
-		return x.SelectedCommitSignedBy
+		return x.BuildConfirmer
 	}
-	return ""
+	return nil
 }
 
-func (x *RepositoryStatus) GetSelectedCommitShouldBeSigned() *wrapperspb.BoolValue {
+func (x *State) GetDeployConfirmer() *Confirmer {
 	if x != nil {
-		return x.SelectedCommitShouldBeSigned
+		return x.DeployConfirmer
 	}
 	return nil
 }
 
-func (x *RepositoryStatus) GetMainCommitId() string {
+func (x *State) GetRebootReasons() []string {
 	if x != nil {
-		return x.MainCommitId
+		return x.RebootReasons
 	}
-	return ""
+	return nil
 }
 
-func (x *RepositoryStatus) GetMainRemoteName() string {
+func (x *State) GetReboot() *Reboot {
 	if x != nil {
-		return x.MainRemoteName
+		return x.Reboot
 	}
-	return ""
+	return nil
 }
 
-func (x *RepositoryStatus) GetMainBranchName() string {
+func (x *State) GetRollout() *Rollout {
 	if x != nil {
-		return x.MainBranchName
+		return x.Rollout
 	}
-	return ""
+	return nil
 }
 
-func (x *RepositoryStatus) GetRemotes() []*Remote {
-	if x != nil {
-		return x.Remotes
-	}
-	return nil
+// Reboot is the state of the automatic reboots
+type Reboot struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Windows []string               `protobuf:"bytes,1,rep,name=windows" json:"windows,omitempty"`
+	// planned_at is the time of the planned reboot. It is not set when
+	// no reboot is planned.
+	PlannedAt *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=planned_at,json=plannedAt" json:"planned_at,omitempty"`
+	Reasons   []string               `protobuf:"bytes,3,rep,name=reasons" json:"reasons,omitempty"`
+	// inhibited_by is the reason why the planned reboot is postponed
+	InhibitedBy string                `protobuf:"bytes,4,opt,name=inhibited_by,json=inhibitedBy" json:"inhibited_by,omitempty"`
+	Cancelled   *wrapperspb.BoolValue `protobuf:"bytes,5,opt,name=cancelled" json:"cancelled,omitempty"`
+	LastReboot  *RebootRecord         `protobuf:"bytes,6,opt,name=last_reboot,json=lastReboot" json:"last_reboot,omitempty"`
+	// error is the error of the last failed reboot, which is retried
+	Error string `protobuf:"bytes,7,opt,name=error" json:"error,omitempty"`
+	// deployment_uuid is the deployment requiring the reboot
+	DeploymentUuid string `protobuf:"bytes,8,opt,name=deployment_uuid,json=deploymentUuid" json:"deployment_uuid,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
-func (x *RepositoryStatus) GetErrorMsg() string {
-	if x != nil {
-		return x.ErrorMsg
-	}
-	return ""
+func (x *Reboot) Reset() {
+	*x = Reboot{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-type DeployerState struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	IsSuspended   bool                   `protobuf:"varint,1,opt,name=is_suspended,json=isSuspended" json:"is_suspended,omitempty"`
-	SuspendReason string                 `protobuf:"bytes,2,opt,name=suspend_reason,json=suspendReason" json:"suspend_reason,omitempty"`
+func (x *Reboot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reboot) ProtoMessage() {}
+
+func (x *Reboot) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reboot.ProtoReflect.Descriptor instead.
+func (*Reboot) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *Reboot) GetWindows() []string {
+	if x != nil {
+		return x.Windows
+	}
+	return nil
+}
+
+func (x *Reboot) GetPlannedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PlannedAt
+	}
+	return nil
+}
+
+func (x *Reboot) GetReasons() []string {
+	if x != nil {
+		return x.Reasons
+	}
+	return nil
+}
+
+func (x *Reboot) GetInhibitedBy() string {
+	if x != nil {
+		return x.InhibitedBy
+	}
+	return ""
+}
+
+func (x *Reboot) GetCancelled() *wrapperspb.BoolValue {
+	if x != nil {
+		return x.Cancelled
+	}
+	return nil
+}
+
+func (x *Reboot) GetLastReboot() *RebootRecord {
+	if x != nil {
+		return x.LastReboot
+	}
+	return nil
+}
+
+func (x *Reboot) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Reboot) GetDeploymentUuid() string {
+	if x != nil {
+		return x.DeploymentUuid
+	}
+	return ""
+}
+
+// Rollout is the state of the rollout waves
+type Rollout struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Wave   int32                  `protobuf:"varint,1,opt,name=wave" json:"wave,omitempty"`
+	Source string                 `protobuf:"bytes,2,opt,name=source" json:"source,omitempty"`
+	// generation_uuid is the generation waiting for the earlier waves
+	GenerationUuid string `protobuf:"bytes,3,opt,name=generation_uuid,json=generationUuid" json:"generation_uuid,omitempty"`
+	CommitId       string `protobuf:"bytes,4,opt,name=commit_id,json=commitId" json:"commit_id,omitempty"`
+	// waiting_reason is why the generation is not deployed yet
+	WaitingReason string                 `protobuf:"bytes,5,opt,name=waiting_reason,json=waitingReason" json:"waiting_reason,omitempty"`
+	CheckedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=checked_at,json=checkedAt" json:"checked_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Rollout) Reset() {
+	*x = Rollout{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Rollout) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rollout) ProtoMessage() {}
+
+func (x *Rollout) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rollout.ProtoReflect.Descriptor instead.
+func (*Rollout) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *Rollout) GetWave() int32 {
+	if x != nil {
+		return x.Wave
+	}
+	return 0
+}
+
+func (x *Rollout) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Rollout) GetGenerationUuid() string {
+	if x != nil {
+		return x.GenerationUuid
+	}
+	return ""
+}
+
+func (x *Rollout) GetCommitId() string {
+	if x != nil {
+		return x.CommitId
+	}
+	return ""
+}
+
+func (x *Rollout) GetWaitingReason() string {
+	if x != nil {
+		return x.WaitingReason
+	}
+	return ""
+}
+
+func (x *Rollout) GetCheckedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CheckedAt
+	}
+	return nil
+}
+
+type RebootRecord struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RebootedAt    *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=rebooted_at,json=rebootedAt" json:"rebooted_at,omitempty"`
+	Reasons       []string               `protobuf:"bytes,2,rep,name=reasons" json:"reasons,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RebootRecord) Reset() {
+	*x = RebootRecord{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RebootRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RebootRecord) ProtoMessage() {}
+
+func (x *RebootRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RebootRecord.ProtoReflect.Descriptor instead.
+func (*RebootRecord) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *RebootRecord) GetRebootedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RebootedAt
+	}
+	return nil
+}
+
+func (x *RebootRecord) GetReasons() []string {
+	if x != nil {
+		return x.Reasons
+	}
+	return nil
+}
+
+type Deployer struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	IsDeploying        *wrapperspb.BoolValue  `protobuf:"bytes,1,opt,name=is_deploying,json=isDeploying" json:"is_deploying,omitempty"`
+	Deployment         *Deployment            `protobuf:"bytes,2,opt,name=deployment" json:"deployment,omitempty"`
+	GenerationToDeploy *Generation            `protobuf:"bytes,3,opt,name=generation_to_deploy,json=generationToDeploy" json:"generation_to_deploy,omitempty"`
+	Operation          string                 `protobuf:"bytes,6,opt,name=operation" json:"operation,omitempty"`
+	PreviousDeployment *Deployment            `protobuf:"bytes,4,opt,name=previous_deployment,json=previousDeployment" json:"previous_deployment,omitempty"`
+	IsSuspended        *wrapperspb.BoolValue  `protobuf:"bytes,5,opt,name=is_suspended,json=isSuspended" json:"is_suspended,omitempty"`
+	// pending_reason is the reason why the generation to deploy is
+	// postponed, such as a closed deployment window
+	PendingReason string                 `protobuf:"bytes,7,opt,name=pending_reason,json=pendingReason" json:"pending_reason,omitempty"`
+	NextWindowAt  *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=next_window_at,json=nextWindowAt" json:"next_window_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Deployer) Reset() {
+	*x = Deployer{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Deployer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Deployer) ProtoMessage() {}
+
+func (x *Deployer) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Deployer.ProtoReflect.Descriptor instead.
+func (*Deployer) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *Deployer) GetIsDeploying() *wrapperspb.BoolValue {
+	if x != nil {
+		return x.IsDeploying
+	}
+	return nil
+}
+
+func (x *Deployer) GetDeployment() *Deployment {
+	if x != nil {
+		return x.Deployment
+	}
+	return nil
+}
+
+func (x *Deployer) GetGenerationToDeploy() *Generation {
+	if x != nil {
+		return x.GenerationToDeploy
+	}
+	return nil
+}
+
+func (x *Deployer) GetOperation() string {
+	if x != nil {
+		return x.Operation
+	}
+	return ""
+}
+
+func (x *Deployer) GetPreviousDeployment() *Deployment {
+	if x != nil {
+		return x.PreviousDeployment
+	}
+	return nil
+}
+
+func (x *Deployer) GetIsSuspended() *wrapperspb.BoolValue {
+	if x != nil {
+		return x.IsSuspended
+	}
+	return nil
+}
+
+func (x *Deployer) GetPendingReason() string {
+	if x != nil {
+		return x.PendingReason
+	}
+	return ""
+}
+
+func (x *Deployer) GetNextWindowAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NextWindowAt
+	}
+	return nil
+}
+
+type Builder struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	IsEvaluating   *wrapperspb.BoolValue  `protobuf:"bytes,1,opt,name=is_evaluating,json=isEvaluating" json:"is_evaluating,omitempty"`
+	IsBuilding     *wrapperspb.BoolValue  `protobuf:"bytes,2,opt,name=is_building,json=isBuilding" json:"is_building,omitempty"`
+	Generation     *Generation            `protobuf:"bytes,3,opt,name=generation" json:"generation,omitempty"`
+	GenerationUuid string                 `protobuf:"bytes,4,opt,name=generation_uuid,json=generationUuid" json:"generation_uuid,omitempty"`
+	IsSuspended    *wrapperspb.BoolValue  `protobuf:"bytes,5,opt,name=is_suspended,json=isSuspended" json:"is_suspended,omitempty"`
+	Hostname       string                 `protobuf:"bytes,6,opt,name=hostname" json:"hostname,omitempty"`
+	// The progress of the running build
+	BuildProgress *BuildProgress `protobuf:"bytes,7,opt,name=build_progress,json=buildProgress" json:"build_progress,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Builder) Reset() {
+	*x = Builder{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Builder) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Builder) ProtoMessage() {}
+
+func (x *Builder) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Builder.ProtoReflect.Descriptor instead.
+func (*Builder) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *Builder) GetIsEvaluating() *wrapperspb.BoolValue {
+	if x != nil {
+		return x.IsEvaluating
+	}
+	return nil
+}
+
+func (x *Builder) GetIsBuilding() *wrapperspb.BoolValue {
+	if x != nil {
+		return x.IsBuilding
+	}
+	return nil
+}
+
+func (x *Builder) GetGeneration() *Generation {
+	if x != nil {
+		return x.Generation
+	}
+	return nil
+}
+
+func (x *Builder) GetGenerationUuid() string {
+	if x != nil {
+		return x.GenerationUuid
+	}
+	return ""
+}
+
+func (x *Builder) GetIsSuspended() *wrapperspb.BoolValue {
+	if x != nil {
+		return x.IsSuspended
+	}
+	return nil
+}
+
+func (x *Builder) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *Builder) GetBuildProgress() *BuildProgress {
+	if x != nil {
+		return x.BuildProgress
+	}
+	return nil
+}
+
+type BuildProgress struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	DerivationsBuilt    uint64                 `protobuf:"varint,1,opt,name=derivations_built,json=derivationsBuilt" json:"derivations_built,omitempty"`
+	DerivationsExpected uint64                 `protobuf:"varint,2,opt,name=derivations_expected,json=derivationsExpected" json:"derivations_expected,omitempty"`
+	DerivationsRunning  uint64                 `protobuf:"varint,3,opt,name=derivations_running,json=derivationsRunning" json:"derivations_running,omitempty"`
+	DerivationsFailed   uint64                 `protobuf:"varint,4,opt,name=derivations_failed,json=derivationsFailed" json:"derivations_failed,omitempty"`
+	DownloadsDone       uint64                 `protobuf:"varint,5,opt,name=downloads_done,json=downloadsDone" json:"downloads_done,omitempty"`
+	DownloadsExpected   uint64                 `protobuf:"varint,6,opt,name=downloads_expected,json=downloadsExpected" json:"downloads_expected,omitempty"`
+	BytesDownloaded     uint64                 `protobuf:"varint,7,opt,name=bytes_downloaded,json=bytesDownloaded" json:"bytes_downloaded,omitempty"`
+	BytesExpected       uint64                 `protobuf:"varint,8,opt,name=bytes_expected,json=bytesExpected" json:"bytes_expected,omitempty"`
+	// The last phase of a running derivation build, such as
+	// "hello-2.12: buildPhase"
+	Phase         string `protobuf:"bytes,9,opt,name=phase" json:"phase,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BuildProgress) Reset() {
+	*x = BuildProgress{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BuildProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuildProgress) ProtoMessage() {}
+
+func (x *BuildProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuildProgress.ProtoReflect.Descriptor instead.
+func (*BuildProgress) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *BuildProgress) GetDerivationsBuilt() uint64 {
+	if x != nil {
+		return x.DerivationsBuilt
+	}
+	return 0
+}
+
+func (x *BuildProgress) GetDerivationsExpected() uint64 {
+	if x != nil {
+		return x.DerivationsExpected
+	}
+	return 0
+}
+
+func (x *BuildProgress) GetDerivationsRunning() uint64 {
+	if x != nil {
+		return x.DerivationsRunning
+	}
+	return 0
+}
+
+func (x *BuildProgress) GetDerivationsFailed() uint64 {
+	if x != nil {
+		return x.DerivationsFailed
+	}
+	return 0
+}
+
+func (x *BuildProgress) GetDownloadsDone() uint64 {
+	if x != nil {
+		return x.DownloadsDone
+	}
+	return 0
+}
+
+func (x *BuildProgress) GetDownloadsExpected() uint64 {
+	if x != nil {
+		return x.DownloadsExpected
+	}
+	return 0
+}
+
+func (x *BuildProgress) GetBytesDownloaded() uint64 {
+	if x != nil {
+		return x.BytesDownloaded
+	}
+	return 0
+}
+
+func (x *BuildProgress) GetBytesExpected() uint64 {
+	if x != nil {
+		return x.BytesExpected
+	}
+	return 0
+}
+
+func (x *BuildProgress) GetPhase() string {
+	if x != nil {
+		return x.Phase
+	}
+	return ""
+}
+
+type Confirmer struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Mode                 int64                  `protobuf:"varint,1,opt,name=mode" json:"mode,omitempty"`
+	Submitted            string                 `protobuf:"bytes,2,opt,name=submitted" json:"submitted,omitempty"`
+	Confirmed            string                 `protobuf:"bytes,3,opt,name=confirmed" json:"confirmed,omitempty"`
+	AutoconfirmDuration  int64                  `protobuf:"varint,4,opt,name=autoconfirm_duration,json=autoconfirmDuration" json:"autoconfirm_duration,omitempty"`
+	AutoconfirmStartedAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=autoconfirm_started_at,json=autoconfirmStartedAt" json:"autoconfirm_started_at,omitempty"`
+	AutoconfirmStarted   *wrapperspb.BoolValue  `protobuf:"bytes,6,opt,name=autoconfirm_started,json=autoconfirmStarted" json:"autoconfirm_started,omitempty"`
+	// The reason why the submitted generation requires a manual
+	// confirmation whatever the confirmer mode
+	HeldReason    string `protobuf:"bytes,7,opt,name=held_reason,json=heldReason" json:"held_reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Confirmer) Reset() {
+	*x = Confirmer{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Confirmer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Confirmer) ProtoMessage() {}
+
+func (x *Confirmer) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Confirmer.ProtoReflect.Descriptor instead.
+func (*Confirmer) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *Confirmer) GetMode() int64 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
+
+func (x *Confirmer) GetSubmitted() string {
+	if x != nil {
+		return x.Submitted
+	}
+	return ""
+}
+
+func (x *Confirmer) GetConfirmed() string {
+	if x != nil {
+		return x.Confirmed
+	}
+	return ""
+}
+
+func (x *Confirmer) GetAutoconfirmDuration() int64 {
+	if x != nil {
+		return x.AutoconfirmDuration
+	}
+	return 0
+}
+
+func (x *Confirmer) GetAutoconfirmStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AutoconfirmStartedAt
+	}
+	return nil
+}
+
+func (x *Confirmer) GetAutoconfirmStarted() *wrapperspb.BoolValue {
+	if x != nil {
+		return x.AutoconfirmStarted
+	}
+	return nil
+}
+
+func (x *Confirmer) GetHeldReason() string {
+	if x != nil {
+		return x.HeldReason
+	}
+	return ""
+}
+
+type Fetcher struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	IsFetching       *wrapperspb.BoolValue  `protobuf:"bytes,1,opt,name=is_fetching,json=isFetching" json:"is_fetching,omitempty"`
+	RepositoryStatus *RepositoryStatus      `protobuf:"bytes,2,opt,name=repository_status,json=repositoryStatus" json:"repository_status,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Fetcher) Reset() {
+	*x = Fetcher{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Fetcher) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Fetcher) ProtoMessage() {}
+
+func (x *Fetcher) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Fetcher.ProtoReflect.Descriptor instead.
+func (*Fetcher) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *Fetcher) GetIsFetching() *wrapperspb.BoolValue {
+	if x != nil {
+		return x.IsFetching
+	}
+	return nil
+}
+
+func (x *Fetcher) GetRepositoryStatus() *RepositoryStatus {
+	if x != nil {
+		return x.RepositoryStatus
+	}
+	return nil
+}
+
+type Branch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	CommitId      string                 `protobuf:"bytes,2,opt,name=commit_id,json=commitId" json:"commit_id,omitempty"`
+	CommitMsg     string                 `protobuf:"bytes,3,opt,name=commit_msg,json=commitMsg" json:"commit_msg,omitempty"`
+	ErrorMsg      string                 `protobuf:"bytes,4,opt,name=error_msg,json=errorMsg" json:"error_msg,omitempty"`
+	OnTopOf       string                 `protobuf:"bytes,5,opt,name=on_top_of,json=onTopOf" json:"on_top_of,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Branch) Reset() {
+	*x = Branch{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Branch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Branch) ProtoMessage() {}
+
+func (x *Branch) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Branch.ProtoReflect.Descriptor instead.
+func (*Branch) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *Branch) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Branch) GetCommitId() string {
+	if x != nil {
+		return x.CommitId
+	}
+	return ""
+}
+
+func (x *Branch) GetCommitMsg() string {
+	if x != nil {
+		return x.CommitMsg
+	}
+	return ""
+}
+
+func (x *Branch) GetErrorMsg() string {
+	if x != nil {
+		return x.ErrorMsg
+	}
+	return ""
+}
+
+func (x *Branch) GetOnTopOf() string {
+	if x != nil {
+		return x.OnTopOf
+	}
+	return ""
+}
+
+type Remote struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url" json:"url,omitempty"`
+	FetchErrorMsg string                 `protobuf:"bytes,3,opt,name=fetch_error_msg,json=fetchErrorMsg" json:"fetch_error_msg,omitempty"`
+	Main          *Branch                `protobuf:"bytes,4,opt,name=main" json:"main,omitempty"`
+	Testing       *Branch                `protobuf:"bytes,5,opt,name=testing" json:"testing,omitempty"`
+	FetchedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=fetched_at,json=fetchedAt" json:"fetched_at,omitempty"`
+	Fetched       *wrapperspb.BoolValue  `protobuf:"bytes,7,opt,name=fetched" json:"fetched,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Remote) Reset() {
+	*x = Remote{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Remote) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Remote) ProtoMessage() {}
+
+func (x *Remote) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Remote.ProtoReflect.Descriptor instead.
+func (*Remote) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *Remote) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Remote) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Remote) GetFetchErrorMsg() string {
+	if x != nil {
+		return x.FetchErrorMsg
+	}
+	return ""
+}
+
+func (x *Remote) GetMain() *Branch {
+	if x != nil {
+		return x.Main
+	}
+	return nil
+}
+
+func (x *Remote) GetTesting() *Branch {
+	if x != nil {
+		return x.Testing
+	}
+	return nil
+}
+
+func (x *Remote) GetFetchedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FetchedAt
+	}
+	return nil
+}
+
+func (x *Remote) GetFetched() *wrapperspb.BoolValue {
+	if x != nil {
+		return x.Fetched
+	}
+	return nil
+}
+
+type RepositoryStatus struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// This is the deployed Main commit ID. It is used to ensure fast forward
+	SelectedCommitId        string                `protobuf:"bytes,1,opt,name=selected_commit_id,json=selectedCommitId" json:"selected_commit_id,omitempty"`
+	SelectedCommitMsg       string                `protobuf:"bytes,2,opt,name=selected_commit_msg,json=selectedCommitMsg" json:"selected_commit_msg,omitempty"`
+	SelectedRemoteName      string                `protobuf:"bytes,3,opt,name=selected_remote_name,json=selectedRemoteName" json:"selected_remote_name,omitempty"`
+	SelectedBranchName      string                `protobuf:"bytes,4,opt,name=selected_branch_name,json=selectedBranchName" json:"selected_branch_name,omitempty"`
+	SelectedBranchIsTesting *wrapperspb.BoolValue `protobuf:"bytes,5,opt,name=selected_branch_is_testing,json=selectedBranchIsTesting" json:"selected_branch_is_testing,omitempty"`
+	SelectedCommitSigned    *wrapperspb.BoolValue `protobuf:"bytes,6,opt,name=selected_commit_signed,json=selectedCommitSigned" json:"selected_commit_signed,omitempty"`
+	SelectedCommitSignedBy  string                `protobuf:"bytes,7,opt,name=selected_commit_signed_by,json=selectedCommitSignedBy" json:"selected_commit_signed_by,omitempty"`
+	// True if public keys were available when the commit has been checked out
+	SelectedCommitShouldBeSigned *wrapperspb.BoolValue `protobuf:"bytes,8,opt,name=selected_commit_should_be_signed,json=selectedCommitShouldBeSigned" json:"selected_commit_should_be_signed,omitempty"`
+	MainCommitId                 string                `protobuf:"bytes,9,opt,name=main_commit_id,json=mainCommitId" json:"main_commit_id,omitempty"`
+	MainRemoteName               string                `protobuf:"bytes,10,opt,name=main_remote_name,json=mainRemoteName" json:"main_remote_name,omitempty"`
+	MainBranchName               string                `protobuf:"bytes,11,opt,name=main_branch_name,json=mainBranchName" json:"main_branch_name,omitempty"`
+	Remotes                      []*Remote             `protobuf:"bytes,12,rep,name=remotes" json:"remotes,omitempty"`
+	ErrorMsg                     string                `protobuf:"bytes,13,opt,name=error_msg,json=errorMsg" json:"error_msg,omitempty"`
+	unknownFields                protoimpl.UnknownFields
+	sizeCache                    protoimpl.SizeCache
+}
+
+func (x *RepositoryStatus) Reset() {
+	*x = RepositoryStatus{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RepositoryStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RepositoryStatus) ProtoMessage() {}
+
+func (x *RepositoryStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RepositoryStatus.ProtoReflect.Descriptor instead.
+func (*RepositoryStatus) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *RepositoryStatus) GetSelectedCommitId() string {
+	if x != nil {
+		return x.SelectedCommitId
+	}
+	return ""
+}
+
+func (x *RepositoryStatus) GetSelectedCommitMsg() string {
+	if x != nil {
+		return x.SelectedCommitMsg
+	}
+	return ""
+}
+
+func (x *RepositoryStatus) GetSelectedRemoteName() string {
+	if x != nil {
+		return x.SelectedRemoteName
+	}
+	return ""
+}
+
+func (x *RepositoryStatus) GetSelectedBranchName() string {
+	if x != nil {
+		return x.SelectedBranchName
+	}
+	return ""
+}
+
+func (x *RepositoryStatus) GetSelectedBranchIsTesting() *wrapperspb.BoolValue {
+	if x != nil {
+		return x.SelectedBranchIsTesting
+	}
+	return nil
+}
+
+func (x *RepositoryStatus) GetSelectedCommitSigned() *wrapperspb.BoolValue {
+	if x != nil {
+		return x.SelectedCommitSigned
+	}
+	return nil
+}
+
+func (x *RepositoryStatus) GetSelectedCommitSignedBy() string {
+	if x != nil {
+		return x.SelectedCommitSignedBy
+	}
+	return ""
+}
+
+func (x *RepositoryStatus) GetSelectedCommitShouldBeSigned() *wrapperspb.BoolValue {
+	if x != nil {
+		return x.SelectedCommitShouldBeSigned
+	}
+	return nil
+}
+
+func (x *RepositoryStatus) GetMainCommitId() string {
+	if x != nil {
+		return x.MainCommitId
+	}
+	return ""
+}
+
+func (x *RepositoryStatus) GetMainRemoteName() string {
+	if x != nil {
+		return x.MainRemoteName
+	}
+	return ""
+}
+
+func (x *RepositoryStatus) GetMainBranchName() string {
+	if x != nil {
+		return x.MainBranchName
+	}
+	return ""
+}
+
+func (x *RepositoryStatus) GetRemotes() []*Remote {
+	if x != nil {
+		return x.Remotes
+	}
+	return nil
+}
+
+func (x *RepositoryStatus) GetErrorMsg() string {
+	if x != nil {
+		return x.ErrorMsg
+	}
+	return ""
+}
+
+type DeployerState struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IsSuspended   bool                   `protobuf:"varint,1,opt,name=is_suspended,json=isSuspended" json:"is_suspended,omitempty"`
+	SuspendReason string                 `protobuf:"bytes,2,opt,name=suspend_reason,json=suspendReason" json:"suspend_reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeployerState) Reset() {
+	*x = DeployerState{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeployerState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeployerState) ProtoMessage() {}
+
+func (x *DeployerState) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeployerState.ProtoReflect.Descriptor instead.
+func (*DeployerState) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *DeployerState) GetIsSuspended() bool {
+	if x != nil {
+		return x.IsSuspended
+	}
+	return false
+}
+
+func (x *DeployerState) GetSuspendReason() string {
+	if x != nil {
+		return x.SuspendReason
+	}
+	return ""
+}
+
+type Store struct {
+	state                        protoimpl.MessageState `protogen:"open.v1"`
+	Deployments                  []*Deployment          `protobuf:"bytes,1,rep,name=deployments" json:"deployments,omitempty"`
+	Generations                  []*Generation          `protobuf:"bytes,2,rep,name=generations" json:"generations,omitempty"`
+	DeploymentSwitched           string                 `protobuf:"bytes,3,opt,name=deployment_switched,json=deploymentSwitched" json:"deployment_switched,omitempty"`
+	DeploymentBooted             string                 `protobuf:"bytes,4,opt,name=deployment_booted,json=deploymentBooted" json:"deployment_booted,omitempty"`
+	DeploymentsBootEntry         []string               `protobuf:"bytes,5,rep,name=deployments_boot_entry,json=deploymentsBootEntry" json:"deployments_boot_entry,omitempty"`
+	DeploymentsSuccessful        []string               `protobuf:"bytes,6,rep,name=deployments_successful,json=deploymentsSuccessful" json:"deployments_successful,omitempty"`
+	DeploymentsAny               []string               `protobuf:"bytes,7,rep,name=deployments_any,json=deploymentsAny" json:"deployments_any,omitempty"`
+	Deployer                     *DeployerState         `protobuf:"bytes,8,opt,name=deployer" json:"deployer,omitempty"`
+	DeploymentBootEntryCapacity  int32                  `protobuf:"varint,9,opt,name=deployment_boot_entry_capacity,json=deploymentBootEntryCapacity" json:"deployment_boot_entry_capacity,omitempty"`
+	DeploymentSuccessfulCapacity int32                  `protobuf:"varint,10,opt,name=deployment_successful_capacity,json=deploymentSuccessfulCapacity" json:"deployment_successful_capacity,omitempty"`
+	DeploymentAnyCapacity        int32                  `protobuf:"varint,11,opt,name=deployment_any_capacity,json=deploymentAnyCapacity" json:"deployment_any_capacity,omitempty"`
+	LastReboot                   *RebootRecord          `protobuf:"bytes,12,opt,name=last_reboot,json=lastReboot" json:"last_reboot,omitempty"`
+	// hook_results are the last results of the post-fetch and
+	// post-reboot hooks, which are not related to a generation or a
+	// deployment
+	HookResults []*HookResult `protobuf:"bytes,13,rep,name=hook_results,json=hookResults" json:"hook_results,omitempty"`
+	// boot_id is the boot ID of the machine when comin has been
+	// started. It allows to detect reboots.
+	BootId string `protobuf:"bytes,14,opt,name=boot_id,json=bootId" json:"boot_id,omitempty"`
+	// generation_built_capacity is the number of built generations
+	// kept in the store, with their GC roots
+	GenerationBuiltCapacity int32 `protobuf:"varint,15,opt,name=generation_built_capacity,json=generationBuiltCapacity" json:"generation_built_capacity,omitempty"`
+	// schema_version is the version of the store file schema. It is
+	// used to migrate the store files written by older comin versions.
+	SchemaVersion int32 `protobuf:"varint,16,opt,name=schema_version,json=schemaVersion" json:"schema_version,omitempty"`
+	// reboot_cancelled_deployment_uuid is the deployment whose
+	// required reboot has been cancelled. It is not planned again at
+	// startup.
+	RebootCancelledDeploymentUuid string `protobuf:"bytes,17,opt,name=reboot_cancelled_deployment_uuid,json=rebootCancelledDeploymentUuid" json:"reboot_cancelled_deployment_uuid,omitempty"`
+	// inhibitor_overrides are the operator overrides of the switch
+	// inhibitors, one per generation. They are removed with their
+	// generation.
+	InhibitorOverrides []*InhibitorOverride `protobuf:"bytes,18,rep,name=inhibitor_overrides,json=inhibitorOverrides" json:"inhibitor_overrides,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Store) Reset() {
+	*x = Store{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Store) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Store) ProtoMessage() {}
+
+func (x *Store) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Store.ProtoReflect.Descriptor instead.
+func (*Store) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *Store) GetDeployments() []*Deployment {
+	if x != nil {
+		return x.Deployments
+	}
+	return nil
+}
+
+func (x *Store) GetGenerations() []*Generation {
+	if x != nil {
+		return x.Generations
+	}
+	return nil
+}
+
+func (x *Store) GetDeploymentSwitched() string {
+	if x != nil {
+		return x.DeploymentSwitched
+	}
+	return ""
+}
+
+func (x *Store) GetDeploymentBooted() string {
+	if x != nil {
+		return x.DeploymentBooted
+	}
+	return ""
+}
+
+func (x *Store) GetDeploymentsBootEntry() []string {
+	if x != nil {
+		return x.DeploymentsBootEntry
+	}
+	return nil
+}
+
+func (x *Store) GetDeploymentsSuccessful() []string {
+	if x != nil {
+		return x.DeploymentsSuccessful
+	}
+	return nil
+}
+
+func (x *Store) GetDeploymentsAny() []string {
+	if x != nil {
+		return x.DeploymentsAny
+	}
+	return nil
+}
+
+func (x *Store) GetDeployer() *DeployerState {
+	if x != nil {
+		return x.Deployer
+	}
+	return nil
+}
+
+func (x *Store) GetDeploymentBootEntryCapacity() int32 {
+	if x != nil {
+		return x.DeploymentBootEntryCapacity
+	}
+	return 0
+}
+
+func (x *Store) GetDeploymentSuccessfulCapacity() int32 {
+	if x != nil {
+		return x.DeploymentSuccessfulCapacity
+	}
+	return 0
+}
+
+func (x *Store) GetDeploymentAnyCapacity() int32 {
+	if x != nil {
+		return x.DeploymentAnyCapacity
+	}
+	return 0
+}
+
+func (x *Store) GetLastReboot() *RebootRecord {
+	if x != nil {
+		return x.LastReboot
+	}
+	return nil
+}
+
+func (x *Store) GetHookResults() []*HookResult {
+	if x != nil {
+		return x.HookResults
+	}
+	return nil
+}
+
+func (x *Store) GetBootId() string {
+	if x != nil {
+		return x.BootId
+	}
+	return ""
+}
+
+func (x *Store) GetGenerationBuiltCapacity() int32 {
+	if x != nil {
+		return x.GenerationBuiltCapacity
+	}
+	return 0
+}
+
+func (x *Store) GetSchemaVersion() int32 {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return 0
+}
+
+func (x *Store) GetRebootCancelledDeploymentUuid() string {
+	if x != nil {
+		return x.RebootCancelledDeploymentUuid
+	}
+	return ""
+}
+
+func (x *Store) GetInhibitorOverrides() []*InhibitorOverride {
+	if x != nil {
+		return x.InhibitorOverrides
+	}
+	return nil
+}
+
+type Event_EvalStarted struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Generation    *Generation            `protobuf:"bytes,1,opt,name=generation" json:"generation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event_EvalStarted) Reset() {
+	*x = Event_EvalStarted{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event_EvalStarted) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event_EvalStarted) ProtoMessage() {}
+
+func (x *Event_EvalStarted) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event_EvalStarted.ProtoReflect.Descriptor instead.
+func (*Event_EvalStarted) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6, 0}
+}
+
+func (x *Event_EvalStarted) GetGeneration() *Generation {
+	if x != nil {
+		return x.Generation
+	}
+	return nil
+}
+
+type Event_EvalFinished struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Generation    *Generation            `protobuf:"bytes,1,opt,name=generation" json:"generation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event_EvalFinished) Reset() {
+	*x = Event_EvalFinished{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event_EvalFinished) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event_EvalFinished) ProtoMessage() {}
+
+func (x *Event_EvalFinished) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event_EvalFinished.ProtoReflect.Descriptor instead.
+func (*Event_EvalFinished) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6, 1}
+}
+
+func (x *Event_EvalFinished) GetGeneration() *Generation {
+	if x != nil {
+		return x.Generation
+	}
+	return nil
+}
+
+type Event_BuildStarted struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Generation    *Generation            `protobuf:"bytes,1,opt,name=generation" json:"generation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event_BuildStarted) Reset() {
+	*x = Event_BuildStarted{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event_BuildStarted) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event_BuildStarted) ProtoMessage() {}
+
+func (x *Event_BuildStarted) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event_BuildStarted.ProtoReflect.Descriptor instead.
+func (*Event_BuildStarted) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6, 2}
+}
+
+func (x *Event_BuildStarted) GetGeneration() *Generation {
+	if x != nil {
+		return x.Generation
+	}
+	return nil
+}
+
+type Event_BuildFinished struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Generation    *Generation            `protobuf:"bytes,1,opt,name=generation" json:"generation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event_BuildFinished) Reset() {
+	*x = Event_BuildFinished{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event_BuildFinished) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event_BuildFinished) ProtoMessage() {}
+
+func (x *Event_BuildFinished) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event_BuildFinished.ProtoReflect.Descriptor instead.
+func (*Event_BuildFinished) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6, 3}
+}
+
+func (x *Event_BuildFinished) GetGeneration() *Generation {
+	if x != nil {
+		return x.Generation
+	}
+	return nil
+}
+
+type Event_ConfirmationSubmitted struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Mode  string                 `protobuf:"bytes,1,opt,name=mode" json:"mode,omitempty"`
+	Uuid  string                 `protobuf:"bytes,2,opt,name=uuid" json:"uuid,omitempty"`
+	// for is "build" or "deploy"
+	For           string `protobuf:"bytes,3,opt,name=for" json:"for,omitempty"`
+	HeldReason    string `protobuf:"bytes,4,opt,name=held_reason,json=heldReason" json:"held_reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event_ConfirmationSubmitted) Reset() {
+	*x = Event_ConfirmationSubmitted{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event_ConfirmationSubmitted) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event_ConfirmationSubmitted) ProtoMessage() {}
+
+func (x *Event_ConfirmationSubmitted) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event_ConfirmationSubmitted.ProtoReflect.Descriptor instead.
+func (*Event_ConfirmationSubmitted) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6, 4}
+}
+
+func (x *Event_ConfirmationSubmitted) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *Event_ConfirmationSubmitted) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *Event_ConfirmationSubmitted) GetFor() string {
+	if x != nil {
+		return x.For
+	}
+	return ""
+}
+
+func (x *Event_ConfirmationSubmitted) GetHeldReason() string {
+	if x != nil {
+		return x.HeldReason
+	}
+	return ""
+}
+
+type Event_ConfirmationCancelled struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Uuid          string                 `protobuf:"bytes,1,opt,name=uuid" json:"uuid,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeployerState) Reset() {
-	*x = DeployerState{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[13]
+func (x *Event_ConfirmationCancelled) Reset() {
+	*x = Event_ConfirmationCancelled{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[36]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeployerState) String() string {
+func (x *Event_ConfirmationCancelled) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeployerState) ProtoMessage() {}
+func (*Event_ConfirmationCancelled) ProtoMessage() {}
 
-func (x *DeployerState) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[13]
+func (x *Event_ConfirmationCancelled) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[36]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1554,57 +3544,41 @@ func (x *DeployerState) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeployerState.ProtoReflect.Descriptor instead.
-func (*DeployerState) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{13}
-}
-
-func (x *DeployerState) GetIsSuspended() bool {
-	if x != nil {
-		return x.IsSuspended
-	}
-	return false
+// Deprecated: Use Event_ConfirmationCancelled.ProtoReflect.Descriptor instead.
+func (*Event_ConfirmationCancelled) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6, 5}
 }
 
-func (x *DeployerState) GetSuspendReason() string {
+func (x *Event_ConfirmationCancelled) GetUuid() string {
 	if x != nil {
-		return x.SuspendReason
+		return x.Uuid
 	}
 	return ""
 }
 
-type Store struct {
-	state                        protoimpl.MessageState `protogen:"open.v1"`
-	Deployments                  []*Deployment          `protobuf:"bytes,1,rep,name=deployments" json:"deployments,omitempty"`
-	Generations                  []*Generation          `protobuf:"bytes,2,rep,name=generations" json:"generations,omitempty"`
-	DeploymentSwitched           string                 `protobuf:"bytes,3,opt,name=deployment_switched,json=deploymentSwitched" json:"deployment_switched,omitempty"`
-	DeploymentBooted             string                 `protobuf:"bytes,4,opt,name=deployment_booted,json=deploymentBooted" json:"deployment_booted,omitempty"`
-	DeploymentsBootEntry         []string               `protobuf:"bytes,5,rep,name=deployments_boot_entry,json=deploymentsBootEntry" json:"deployments_boot_entry,omitempty"`
-	DeploymentsSuccessful        []string               `protobuf:"bytes,6,rep,name=deployments_successful,json=deploymentsSuccessful" json:"deployments_successful,omitempty"`
-	DeploymentsAny               []string               `protobuf:"bytes,7,rep,name=deployments_any,json=deploymentsAny" json:"deployments_any,omitempty"`
-	Deployer                     *DeployerState         `protobuf:"bytes,8,opt,name=deployer" json:"deployer,omitempty"`
-	DeploymentBootEntryCapacity  int32                  `protobuf:"varint,9,opt,name=deployment_boot_entry_capacity,json=deploymentBootEntryCapacity" json:"deployment_boot_entry_capacity,omitempty"`
-	DeploymentSuccessfulCapacity int32                  `protobuf:"varint,10,opt,name=deployment_successful_capacity,json=deploymentSuccessfulCapacity" json:"deployment_successful_capacity,omitempty"`
-	DeploymentAnyCapacity        int32                  `protobuf:"varint,11,opt,name=deployment_any_capacity,json=deploymentAnyCapacity" json:"deployment_any_capacity,omitempty"`
-	unknownFields                protoimpl.UnknownFields
-	sizeCache                    protoimpl.SizeCache
+type Event_ConfirmationConfirmed struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Origin        string                 `protobuf:"bytes,1,opt,name=origin" json:"origin,omitempty"`
+	Uuid          string                 `protobuf:"bytes,2,opt,name=uuid" json:"uuid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Store) Reset() {
-	*x = Store{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[14]
+func (x *Event_ConfirmationConfirmed) Reset() {
+	*x = Event_ConfirmationConfirmed{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[37]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Store) String() string {
+func (x *Event_ConfirmationConfirmed) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Store) ProtoMessage() {}
+func (*Event_ConfirmationConfirmed) ProtoMessage() {}
 
-func (x *Store) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[14]
+func (x *Event_ConfirmationConfirmed) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[37]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1615,110 +3589,119 @@ func (x *Store) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Store.ProtoReflect.Descriptor instead.
-func (*Store) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{14}
+// Deprecated: Use Event_ConfirmationConfirmed.ProtoReflect.Descriptor instead.
+func (*Event_ConfirmationConfirmed) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6, 6}
 }
 
-func (x *Store) GetDeployments() []*Deployment {
+func (x *Event_ConfirmationConfirmed) GetOrigin() string {
 	if x != nil {
-		return x.Deployments
+		return x.Origin
 	}
-	return nil
+	return ""
 }
 
-func (x *Store) GetGenerations() []*Generation {
+func (x *Event_ConfirmationConfirmed) GetUuid() string {
 	if x != nil {
-		return x.Generations
+		return x.Uuid
 	}
-	return nil
+	return ""
 }
 
-func (x *Store) GetDeploymentSwitched() string {
-	if x != nil {
-		return x.DeploymentSwitched
-	}
-	return ""
+type Event_Resume struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Store) GetDeploymentBooted() string {
-	if x != nil {
-		return x.DeploymentBooted
-	}
-	return ""
+func (x *Event_Resume) Reset() {
+	*x = Event_Resume{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *Store) GetDeploymentsBootEntry() []string {
-	if x != nil {
-		return x.DeploymentsBootEntry
-	}
-	return nil
+func (x *Event_Resume) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *Store) GetDeploymentsSuccessful() []string {
+func (*Event_Resume) ProtoMessage() {}
+
+func (x *Event_Resume) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[38]
 	if x != nil {
-		return x.DeploymentsSuccessful
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *Store) GetDeploymentsAny() []string {
-	if x != nil {
-		return x.DeploymentsAny
-	}
-	return nil
+// Deprecated: Use Event_Resume.ProtoReflect.Descriptor instead.
+func (*Event_Resume) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6, 7}
 }
 
-func (x *Store) GetDeployer() *DeployerState {
-	if x != nil {
-		return x.Deployer
-	}
-	return nil
+type Event_Suspend struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Store) GetDeploymentBootEntryCapacity() int32 {
-	if x != nil {
-		return x.DeploymentBootEntryCapacity
-	}
-	return 0
+func (x *Event_Suspend) Reset() {
+	*x = Event_Suspend{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *Store) GetDeploymentSuccessfulCapacity() int32 {
-	if x != nil {
-		return x.DeploymentSuccessfulCapacity
-	}
-	return 0
+func (x *Event_Suspend) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *Store) GetDeploymentAnyCapacity() int32 {
+func (*Event_Suspend) ProtoMessage() {}
+
+func (x *Event_Suspend) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[39]
 	if x != nil {
-		return x.DeploymentAnyCapacity
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-type Event_EvalStarted struct {
+// Deprecated: Use Event_Suspend.ProtoReflect.Descriptor instead.
+func (*Event_Suspend) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6, 8}
+}
+
+type Event_DeploymentStarted struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Generation    *Generation            `protobuf:"bytes,1,opt,name=generation" json:"generation,omitempty"`
+	Deployment    *Deployment            `protobuf:"bytes,1,opt,name=deployment" json:"deployment,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Event_EvalStarted) Reset() {
-	*x = Event_EvalStarted{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[15]
+func (x *Event_DeploymentStarted) Reset() {
+	*x = Event_DeploymentStarted{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[40]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Event_EvalStarted) String() string {
+func (x *Event_DeploymentStarted) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Event_EvalStarted) ProtoMessage() {}
+func (*Event_DeploymentStarted) ProtoMessage() {}
 
-func (x *Event_EvalStarted) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[15]
+func (x *Event_DeploymentStarted) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[40]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1729,40 +3712,40 @@ func (x *Event_EvalStarted) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Event_EvalStarted.ProtoReflect.Descriptor instead.
-func (*Event_EvalStarted) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{1, 0}
+// Deprecated: Use Event_DeploymentStarted.ProtoReflect.Descriptor instead.
+func (*Event_DeploymentStarted) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6, 9}
 }
 
-func (x *Event_EvalStarted) GetGeneration() *Generation {
+func (x *Event_DeploymentStarted) GetDeployment() *Deployment {
 	if x != nil {
-		return x.Generation
+		return x.Deployment
 	}
 	return nil
 }
 
-type Event_EvalFinished struct {
+type Event_DeploymentFinished struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Generation    *Generation            `protobuf:"bytes,1,opt,name=generation" json:"generation,omitempty"`
+	Deployment    *Deployment            `protobuf:"bytes,1,opt,name=deployment" json:"deployment,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Event_EvalFinished) Reset() {
-	*x = Event_EvalFinished{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[16]
+func (x *Event_DeploymentFinished) Reset() {
+	*x = Event_DeploymentFinished{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[41]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Event_EvalFinished) String() string {
+func (x *Event_DeploymentFinished) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Event_EvalFinished) ProtoMessage() {}
+func (*Event_DeploymentFinished) ProtoMessage() {}
 
-func (x *Event_EvalFinished) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[16]
+func (x *Event_DeploymentFinished) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[41]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1773,40 +3756,41 @@ func (x *Event_EvalFinished) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Event_EvalFinished.ProtoReflect.Descriptor instead.
-func (*Event_EvalFinished) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{1, 1}
+// Deprecated: Use Event_DeploymentFinished.ProtoReflect.Descriptor instead.
+func (*Event_DeploymentFinished) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6, 10}
 }
 
-func (x *Event_EvalFinished) GetGeneration() *Generation {
+func (x *Event_DeploymentFinished) GetDeployment() *Deployment {
 	if x != nil {
-		return x.Generation
+		return x.Deployment
 	}
 	return nil
 }
 
-type Event_BuildStarted struct {
+type Event_RebootRequired struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Generation    *Generation            `protobuf:"bytes,1,opt,name=generation" json:"generation,omitempty"`
+	Deployment    *Deployment            `protobuf:"bytes,1,opt,name=deployment" json:"deployment,omitempty"`
+	Reasons       []string               `protobuf:"bytes,2,rep,name=reasons" json:"reasons,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Event_BuildStarted) Reset() {
-	*x = Event_BuildStarted{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[17]
+func (x *Event_RebootRequired) Reset() {
+	*x = Event_RebootRequired{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[42]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Event_BuildStarted) String() string {
+func (x *Event_RebootRequired) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Event_BuildStarted) ProtoMessage() {}
+func (*Event_RebootRequired) ProtoMessage() {}
 
-func (x *Event_BuildStarted) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[17]
+func (x *Event_RebootRequired) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[42]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1817,40 +3801,47 @@ func (x *Event_BuildStarted) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Event_BuildStarted.ProtoReflect.Descriptor instead.
-func (*Event_BuildStarted) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{1, 2}
+// Deprecated: Use Event_RebootRequired.ProtoReflect.Descriptor instead.
+func (*Event_RebootRequired) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6, 11}
 }
 
-func (x *Event_BuildStarted) GetGeneration() *Generation {
+func (x *Event_RebootRequired) GetDeployment() *Deployment {
 	if x != nil {
-		return x.Generation
+		return x.Deployment
 	}
 	return nil
 }
 
-type Event_BuildFinished struct {
+func (x *Event_RebootRequired) GetReasons() []string {
+	if x != nil {
+		return x.Reasons
+	}
+	return nil
+}
+
+type Event_ManagerState struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Generation    *Generation            `protobuf:"bytes,1,opt,name=generation" json:"generation,omitempty"`
+	State         *State                 `protobuf:"bytes,1,opt,name=state" json:"state,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Event_BuildFinished) Reset() {
-	*x = Event_BuildFinished{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[18]
+func (x *Event_ManagerState) Reset() {
+	*x = Event_ManagerState{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[43]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Event_BuildFinished) String() string {
+func (x *Event_ManagerState) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Event_BuildFinished) ProtoMessage() {}
+func (*Event_ManagerState) ProtoMessage() {}
 
-func (x *Event_BuildFinished) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[18]
+func (x *Event_ManagerState) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[43]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1861,41 +3852,40 @@ func (x *Event_BuildFinished) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Event_BuildFinished.ProtoReflect.Descriptor instead.
-func (*Event_BuildFinished) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{1, 3}
+// Deprecated: Use Event_ManagerState.ProtoReflect.Descriptor instead.
+func (*Event_ManagerState) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6, 12}
 }
 
-func (x *Event_BuildFinished) GetGeneration() *Generation {
+func (x *Event_ManagerState) GetState() *State {
 	if x != nil {
-		return x.Generation
+		return x.State
 	}
 	return nil
 }
 
-type Event_ConfirmationSubmitted struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Mode          string                 `protobuf:"bytes,1,opt,name=mode" json:"mode,omitempty"`
-	Uuid          string                 `protobuf:"bytes,2,opt,name=uuid" json:"uuid,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type Event_Fetched struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	RepositoryStatus *RepositoryStatus      `protobuf:"bytes,1,opt,name=repositoryStatus" json:"repositoryStatus,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
-func (x *Event_ConfirmationSubmitted) Reset() {
-	*x = Event_ConfirmationSubmitted{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[19]
+func (x *Event_Fetched) Reset() {
+	*x = Event_Fetched{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[44]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Event_ConfirmationSubmitted) String() string {
+func (x *Event_Fetched) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Event_ConfirmationSubmitted) ProtoMessage() {}
+func (*Event_Fetched) ProtoMessage() {}
 
-func (x *Event_ConfirmationSubmitted) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[19]
+func (x *Event_Fetched) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[44]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1906,47 +3896,40 @@ func (x *Event_ConfirmationSubmitted) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Event_ConfirmationSubmitted.ProtoReflect.Descriptor instead.
-func (*Event_ConfirmationSubmitted) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{1, 4}
-}
-
-func (x *Event_ConfirmationSubmitted) GetMode() string {
-	if x != nil {
-		return x.Mode
-	}
-	return ""
+// Deprecated: Use Event_Fetched.ProtoReflect.Descriptor instead.
+func (*Event_Fetched) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6, 13}
 }
 
-func (x *Event_ConfirmationSubmitted) GetUuid() string {
+func (x *Event_Fetched) GetRepositoryStatus() *RepositoryStatus {
 	if x != nil {
-		return x.Uuid
+		return x.RepositoryStatus
 	}
-	return ""
+	return nil
 }
 
-type Event_ConfirmationCancelled struct {
+type Event_BuildSkipped struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Uuid          string                 `protobuf:"bytes,1,opt,name=uuid" json:"uuid,omitempty"`
+	Generation    *Generation            `protobuf:"bytes,1,opt,name=generation" json:"generation,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Event_ConfirmationCancelled) Reset() {
-	*x = Event_ConfirmationCancelled{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[20]
+func (x *Event_BuildSkipped) Reset() {
+	*x = Event_BuildSkipped{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[45]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Event_ConfirmationCancelled) String() string {
+func (x *Event_BuildSkipped) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Event_ConfirmationCancelled) ProtoMessage() {}
+func (*Event_BuildSkipped) ProtoMessage() {}
 
-func (x *Event_ConfirmationCancelled) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[20]
+func (x *Event_BuildSkipped) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[45]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1957,41 +3940,40 @@ func (x *Event_ConfirmationCancelled) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Event_ConfirmationCancelled.ProtoReflect.Descriptor instead.
-func (*Event_ConfirmationCancelled) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{1, 5}
+// Deprecated: Use Event_BuildSkipped.ProtoReflect.Descriptor instead.
+func (*Event_BuildSkipped) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6, 14}
 }
 
-func (x *Event_ConfirmationCancelled) GetUuid() string {
+func (x *Event_BuildSkipped) GetGeneration() *Generation {
 	if x != nil {
-		return x.Uuid
+		return x.Generation
 	}
-	return ""
+	return nil
 }
 
-type Event_ConfirmationConfirmed struct {
+type Event_BuildWaitingForCache struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Origin        string                 `protobuf:"bytes,1,opt,name=origin" json:"origin,omitempty"`
-	Uuid          string                 `protobuf:"bytes,2,opt,name=uuid" json:"uuid,omitempty"`
+	Generation    *Generation            `protobuf:"bytes,1,opt,name=generation" json:"generation,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Event_ConfirmationConfirmed) Reset() {
-	*x = Event_ConfirmationConfirmed{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[21]
+func (x *Event_BuildWaitingForCache) Reset() {
+	*x = Event_BuildWaitingForCache{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[46]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Event_ConfirmationConfirmed) String() string {
+func (x *Event_BuildWaitingForCache) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Event_ConfirmationConfirmed) ProtoMessage() {}
+func (*Event_BuildWaitingForCache) ProtoMessage() {}
 
-func (x *Event_ConfirmationConfirmed) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[21]
+func (x *Event_BuildWaitingForCache) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[46]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2002,46 +3984,40 @@ func (x *Event_ConfirmationConfirmed) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Event_ConfirmationConfirmed.ProtoReflect.Descriptor instead.
-func (*Event_ConfirmationConfirmed) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{1, 6}
-}
-
-func (x *Event_ConfirmationConfirmed) GetOrigin() string {
-	if x != nil {
-		return x.Origin
-	}
-	return ""
+// Deprecated: Use Event_BuildWaitingForCache.ProtoReflect.Descriptor instead.
+func (*Event_BuildWaitingForCache) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6, 15}
 }
 
-func (x *Event_ConfirmationConfirmed) GetUuid() string {
+func (x *Event_BuildWaitingForCache) GetGeneration() *Generation {
 	if x != nil {
-		return x.Uuid
+		return x.Generation
 	}
-	return ""
+	return nil
 }
 
-type Event_Resume struct {
+type Event_ClosureDiffComputed struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	Generation    *Generation            `protobuf:"bytes,1,opt,name=generation" json:"generation,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Event_Resume) Reset() {
-	*x = Event_Resume{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[22]
+func (x *Event_ClosureDiffComputed) Reset() {
+	*x = Event_ClosureDiffComputed{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[47]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Event_Resume) String() string {
+func (x *Event_ClosureDiffComputed) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Event_Resume) ProtoMessage() {}
+func (*Event_ClosureDiffComputed) ProtoMessage() {}
 
-func (x *Event_Resume) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[22]
+func (x *Event_ClosureDiffComputed) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[47]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2052,32 +4028,40 @@ func (x *Event_Resume) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Event_Resume.ProtoReflect.Descriptor instead.
-func (*Event_Resume) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{1, 7}
+// Deprecated: Use Event_ClosureDiffComputed.ProtoReflect.Descriptor instead.
+func (*Event_ClosureDiffComputed) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6, 16}
 }
 
-type Event_Suspend struct {
+func (x *Event_ClosureDiffComputed) GetGeneration() *Generation {
+	if x != nil {
+		return x.Generation
+	}
+	return nil
+}
+
+type Event_UnitChangesPredicted struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	Generation    *Generation            `protobuf:"bytes,1,opt,name=generation" json:"generation,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Event_Suspend) Reset() {
-	*x = Event_Suspend{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[23]
+func (x *Event_UnitChangesPredicted) Reset() {
+	*x = Event_UnitChangesPredicted{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[48]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Event_Suspend) String() string {
+func (x *Event_UnitChangesPredicted) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Event_Suspend) ProtoMessage() {}
+func (*Event_UnitChangesPredicted) ProtoMessage() {}
 
-func (x *Event_Suspend) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[23]
+func (x *Event_UnitChangesPredicted) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[48]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2088,33 +4072,41 @@ func (x *Event_Suspend) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Event_Suspend.ProtoReflect.Descriptor instead.
-func (*Event_Suspend) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{1, 8}
+// Deprecated: Use Event_UnitChangesPredicted.ProtoReflect.Descriptor instead.
+func (*Event_UnitChangesPredicted) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6, 17}
 }
 
-type Event_DeploymentStarted struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Deployment    *Deployment            `protobuf:"bytes,1,opt,name=deployment" json:"deployment,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *Event_UnitChangesPredicted) GetGeneration() *Generation {
+	if x != nil {
+		return x.Generation
+	}
+	return nil
 }
 
-func (x *Event_DeploymentStarted) Reset() {
-	*x = Event_DeploymentStarted{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[24]
+type Event_BuildProgress struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	GenerationUuid string                 `protobuf:"bytes,1,opt,name=generation_uuid,json=generationUuid" json:"generation_uuid,omitempty"`
+	Progress       *BuildProgress         `protobuf:"bytes,2,opt,name=progress" json:"progress,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Event_BuildProgress) Reset() {
+	*x = Event_BuildProgress{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[49]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Event_DeploymentStarted) String() string {
+func (x *Event_BuildProgress) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Event_DeploymentStarted) ProtoMessage() {}
+func (*Event_BuildProgress) ProtoMessage() {}
 
-func (x *Event_DeploymentStarted) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[24]
+func (x *Event_BuildProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[49]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2125,40 +4117,47 @@ func (x *Event_DeploymentStarted) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Event_DeploymentStarted.ProtoReflect.Descriptor instead.
-func (*Event_DeploymentStarted) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{1, 9}
+// Deprecated: Use Event_BuildProgress.ProtoReflect.Descriptor instead.
+func (*Event_BuildProgress) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6, 18}
 }
 
-func (x *Event_DeploymentStarted) GetDeployment() *Deployment {
+func (x *Event_BuildProgress) GetGenerationUuid() string {
 	if x != nil {
-		return x.Deployment
+		return x.GenerationUuid
+	}
+	return ""
+}
+
+func (x *Event_BuildProgress) GetProgress() *BuildProgress {
+	if x != nil {
+		return x.Progress
 	}
 	return nil
 }
 
-type Event_DeploymentFinished struct {
+type Event_RebootPlanned struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Deployment    *Deployment            `protobuf:"bytes,1,opt,name=deployment" json:"deployment,omitempty"`
+	Reboot        *Reboot                `protobuf:"bytes,1,opt,name=reboot" json:"reboot,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Event_DeploymentFinished) Reset() {
-	*x = Event_DeploymentFinished{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[25]
+func (x *Event_RebootPlanned) Reset() {
+	*x = Event_RebootPlanned{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[50]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Event_DeploymentFinished) String() string {
+func (x *Event_RebootPlanned) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Event_DeploymentFinished) ProtoMessage() {}
+func (*Event_RebootPlanned) ProtoMessage() {}
 
-func (x *Event_DeploymentFinished) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[25]
+func (x *Event_RebootPlanned) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[50]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2169,40 +4168,39 @@ func (x *Event_DeploymentFinished) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Event_DeploymentFinished.ProtoReflect.Descriptor instead.
-func (*Event_DeploymentFinished) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{1, 10}
+// Deprecated: Use Event_RebootPlanned.ProtoReflect.Descriptor instead.
+func (*Event_RebootPlanned) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6, 19}
 }
 
-func (x *Event_DeploymentFinished) GetDeployment() *Deployment {
+func (x *Event_RebootPlanned) GetReboot() *Reboot {
 	if x != nil {
-		return x.Deployment
+		return x.Reboot
 	}
 	return nil
 }
 
-type Event_RebootRequired struct {
+type Event_RebootCancelled struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Deployment    *Deployment            `protobuf:"bytes,1,opt,name=deployment" json:"deployment,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Event_RebootRequired) Reset() {
-	*x = Event_RebootRequired{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[26]
+func (x *Event_RebootCancelled) Reset() {
+	*x = Event_RebootCancelled{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[51]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Event_RebootRequired) String() string {
+func (x *Event_RebootCancelled) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Event_RebootRequired) ProtoMessage() {}
+func (*Event_RebootCancelled) ProtoMessage() {}
 
-func (x *Event_RebootRequired) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[26]
+func (x *Event_RebootCancelled) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[51]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2213,40 +4211,41 @@ func (x *Event_RebootRequired) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Event_RebootRequired.ProtoReflect.Descriptor instead.
-func (*Event_RebootRequired) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{1, 11}
-}
-
-func (x *Event_RebootRequired) GetDeployment() *Deployment {
-	if x != nil {
-		return x.Deployment
-	}
-	return nil
+// Deprecated: Use Event_RebootCancelled.ProtoReflect.Descriptor instead.
+func (*Event_RebootCancelled) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6, 20}
 }
 
-type Event_ManagerState struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	State         *State                 `protobuf:"bytes,1,opt,name=state" json:"state,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type Event_GarbageCollected struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// trigger is "deployment", "schedule" or "disk-space"
+	Trigger string `protobuf:"bytes,1,opt,name=trigger" json:"trigger,omitempty"`
+	// freed_bytes is the size of the store paths deleted by nix-collect-garbage
+	FreedBytes      int64   `protobuf:"varint,2,opt,name=freed_bytes,json=freedBytes" json:"freed_bytes,omitempty"`
+	DurationSeconds float64 `protobuf:"fixed64,3,opt,name=duration_seconds,json=durationSeconds" json:"duration_seconds,omitempty"`
+	// optimised_bytes is the size freed by nix-store --optimise
+	OptimisedBytes          int64   `protobuf:"varint,4,opt,name=optimised_bytes,json=optimisedBytes" json:"optimised_bytes,omitempty"`
+	OptimiseDurationSeconds float64 `protobuf:"fixed64,5,opt,name=optimise_duration_seconds,json=optimiseDurationSeconds" json:"optimise_duration_seconds,omitempty"`
+	Error                   string  `protobuf:"bytes,6,opt,name=error" json:"error,omitempty"`
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
 }
 
-func (x *Event_ManagerState) Reset() {
-	*x = Event_ManagerState{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[27]
+func (x *Event_GarbageCollected) Reset() {
+	*x = Event_GarbageCollected{}
+	mi := &file_pkg_protobuf_services_proto_msgTypes[52]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Event_ManagerState) String() string {
+func (x *Event_GarbageCollected) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Event_ManagerState) ProtoMessage() {}
+func (*Event_GarbageCollected) ProtoMessage() {}
 
-func (x *Event_ManagerState) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[27]
+func (x *Event_GarbageCollected) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protobuf_services_proto_msgTypes[52]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2257,69 +4256,84 @@ func (x *Event_ManagerState) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Event_ManagerState.ProtoReflect.Descriptor instead.
-func (*Event_ManagerState) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{1, 12}
+// Deprecated: Use Event_GarbageCollected.ProtoReflect.Descriptor instead.
+func (*Event_GarbageCollected) Descriptor() ([]byte, []int) {
+	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{6, 21}
 }
 
-func (x *Event_ManagerState) GetState() *State {
+func (x *Event_GarbageCollected) GetTrigger() string {
 	if x != nil {
-		return x.State
+		return x.Trigger
 	}
-	return nil
-}
-
-type Event_Fetched struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	RepositoryStatus *RepositoryStatus      `protobuf:"bytes,1,opt,name=repositoryStatus" json:"repositoryStatus,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+	return ""
 }
 
-func (x *Event_Fetched) Reset() {
-	*x = Event_Fetched{}
-	mi := &file_pkg_protobuf_services_proto_msgTypes[28]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *Event_GarbageCollected) GetFreedBytes() int64 {
+	if x != nil {
+		return x.FreedBytes
+	}
+	return 0
 }
 
-func (x *Event_Fetched) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *Event_GarbageCollected) GetDurationSeconds() float64 {
+	if x != nil {
+		return x.DurationSeconds
+	}
+	return 0
 }
 
-func (*Event_Fetched) ProtoMessage() {}
-
-func (x *Event_Fetched) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_protobuf_services_proto_msgTypes[28]
+func (x *Event_GarbageCollected) GetOptimisedBytes() int64 {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.OptimisedBytes
 	}
-	return mi.MessageOf(x)
+	return 0
 }
 
-// Deprecated: Use Event_Fetched.ProtoReflect.Descriptor instead.
-func (*Event_Fetched) Descriptor() ([]byte, []int) {
-	return file_pkg_protobuf_services_proto_rawDescGZIP(), []int{1, 13}
+func (x *Event_GarbageCollected) GetOptimiseDurationSeconds() float64 {
+	if x != nil {
+		return x.OptimiseDurationSeconds
+	}
+	return 0
 }
 
-func (x *Event_Fetched) GetRepositoryStatus() *RepositoryStatus {
+func (x *Event_GarbageCollected) GetError() string {
 	if x != nil {
-		return x.RepositoryStatus
+		return x.Error
 	}
-	return nil
+	return ""
 }
 
 var File_pkg_protobuf_services_proto protoreflect.FileDescriptor
 
 const file_pkg_protobuf_services_proto_rawDesc = "" +
 	"\n" +
-	"\x1bpkg/protobuf/services.proto\x12\bprotobuf\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1egoogle/protobuf/wrappers.proto\"<\n" +
+	"\x1bpkg/protobuf/services.proto\x12\bprotobuf\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1egoogle/protobuf/wrappers.proto\"\xdc\x02\n" +
+	"\x16ListDeploymentsRequest\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x1c\n" +
+	"\toperation\x18\x02 \x01(\tR\toperation\x12\x16\n" +
+	"\x06branch\x18\x03 \x01(\tR\x06branch\x12\x1b\n" +
+	"\tcommit_id\x18\x04 \x01(\tR\bcommitId\x120\n" +
+	"\x05since\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x120\n" +
+	"\x05until\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x05until\x12\x14\n" +
+	"\x05order\x18\a \x01(\tR\x05order\x12!\n" +
+	"\fboot_entries\x18\b \x01(\bR\vbootEntries\x12\x1b\n" +
+	"\tpage_size\x18\t \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\n" +
+	" \x01(\tR\tpageToken\"y\n" +
+	"\x17ListDeploymentsResponse\x126\n" +
+	"\vdeployments\x18\x01 \x03(\v2\x14.protobuf.DeploymentR\vdeployments\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"*\n" +
+	"\x14GetDeploymentRequest\x12\x12\n" +
+	"\x04uuid\x18\x01 \x01(\tR\x04uuid\"\x88\x01\n" +
+	"\x15GenerationLogsRequest\x12'\n" +
+	"\x0fgeneration_uuid\x18\x01 \x01(\tR\x0egenerationUuid\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind\x122\n" +
+	"\x06follow\x18\x03 \x01(\v2\x1a.google.protobuf.BoolValueR\x06follow\")\n" +
+	"\x13GenerationLogsChunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\"<\n" +
 	"\tOperation\x12/\n" +
-	"\x13operation_submitted\x18\x01 \x01(\tR\x12operationSubmitted\"\xda\x0f\n" +
+	"\x13operation_submitted\x18\x01 \x01(\tR\x12operationSubmitted\"\xc9\x1b\n" +
 	"\x05Event\x12G\n" +
 	"\x0fevalStartedType\x18\x01 \x01(\v2\x1b.protobuf.Event.EvalStartedH\x00R\x0fevalStartedType\x12J\n" +
 	"\x10evalFinishedType\x18\x02 \x01(\v2\x1c.protobuf.Event.EvalFinishedH\x00R\x10evalFinishedType\x12J\n" +
@@ -2335,7 +4349,15 @@ const file_pkg_protobuf_services_proto_rawDesc = "" +
 	"\x16deploymentFinishedType\x18\v \x01(\v2\".protobuf.Event.DeploymentFinishedH\x00R\x16deploymentFinishedType\x12H\n" +
 	"\x0erebootRequired\x18\f \x01(\v2\x1e.protobuf.Event.RebootRequiredH\x00R\x0erebootRequired\x12B\n" +
 	"\fmanagerState\x18\r \x01(\v2\x1c.protobuf.Event.ManagerStateH\x00R\fmanagerState\x123\n" +
-	"\afetched\x18\x0e \x01(\v2\x17.protobuf.Event.FetchedH\x00R\afetched\x128\n" +
+	"\afetched\x18\x0e \x01(\v2\x17.protobuf.Event.FetchedH\x00R\afetched\x12J\n" +
+	"\x10buildSkippedType\x18\x10 \x01(\v2\x1c.protobuf.Event.BuildSkippedH\x00R\x10buildSkippedType\x12M\n" +
+	"\x11buildProgressType\x18\x11 \x01(\v2\x1d.protobuf.Event.BuildProgressH\x00R\x11buildProgressType\x12b\n" +
+	"\x18buildWaitingForCacheType\x18\x12 \x01(\v2$.protobuf.Event.BuildWaitingForCacheH\x00R\x18buildWaitingForCacheType\x12M\n" +
+	"\x11rebootPlannedType\x18\x13 \x01(\v2\x1d.protobuf.Event.RebootPlannedH\x00R\x11rebootPlannedType\x12S\n" +
+	"\x13rebootCancelledType\x18\x14 \x01(\v2\x1f.protobuf.Event.RebootCancelledH\x00R\x13rebootCancelledType\x12V\n" +
+	"\x14garbageCollectedType\x18\x15 \x01(\v2 .protobuf.Event.GarbageCollectedH\x00R\x14garbageCollectedType\x12_\n" +
+	"\x17closureDiffComputedType\x18\x16 \x01(\v2#.protobuf.Event.ClosureDiffComputedH\x00R\x17closureDiffComputedType\x12b\n" +
+	"\x18unitChangesPredictedType\x18\x17 \x01(\v2$.protobuf.Event.UnitChangesPredictedH\x00R\x18unitChangesPredictedType\x128\n" +
 	"\tcreatedAt\x18\x0f \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x1aC\n" +
 	"\vEvalStarted\x124\n" +
 	"\n" +
@@ -2352,10 +4374,13 @@ const file_pkg_protobuf_services_proto_rawDesc = "" +
 	"\rBuildFinished\x124\n" +
 	"\n" +
 	"generation\x18\x01 \x01(\v2\x14.protobuf.GenerationR\n" +
-	"generation\x1a?\n" +
+	"generation\x1ar\n" +
 	"\x15ConfirmationSubmitted\x12\x12\n" +
 	"\x04mode\x18\x01 \x01(\tR\x04mode\x12\x12\n" +
-	"\x04uuid\x18\x02 \x01(\tR\x04uuid\x1a+\n" +
+	"\x04uuid\x18\x02 \x01(\tR\x04uuid\x12\x10\n" +
+	"\x03for\x18\x03 \x01(\tR\x03for\x12\x1f\n" +
+	"\vheld_reason\x18\x04 \x01(\tR\n" +
+	"heldReason\x1a+\n" +
 	"\x15ConfirmationCancelled\x12\x12\n" +
 	"\x04uuid\x18\x01 \x01(\tR\x04uuid\x1aC\n" +
 	"\x15ConfirmationConfirmed\x12\x16\n" +
@@ -2370,19 +4395,50 @@ const file_pkg_protobuf_services_proto_rawDesc = "" +
 	"\x12DeploymentFinished\x124\n" +
 	"\n" +
 	"deployment\x18\x01 \x01(\v2\x14.protobuf.DeploymentR\n" +
-	"deployment\x1aF\n" +
+	"deployment\x1a`\n" +
 	"\x0eRebootRequired\x124\n" +
 	"\n" +
 	"deployment\x18\x01 \x01(\v2\x14.protobuf.DeploymentR\n" +
-	"deployment\x1a5\n" +
+	"deployment\x12\x18\n" +
+	"\areasons\x18\x02 \x03(\tR\areasons\x1a5\n" +
 	"\fManagerState\x12%\n" +
 	"\x05state\x18\x01 \x01(\v2\x0f.protobuf.StateR\x05state\x1aQ\n" +
 	"\aFetched\x12F\n" +
-	"\x10repositoryStatus\x18\x01 \x01(\v2\x1a.protobuf.RepositoryStatusR\x10repositoryStatusB\x06\n" +
+	"\x10repositoryStatus\x18\x01 \x01(\v2\x1a.protobuf.RepositoryStatusR\x10repositoryStatus\x1aD\n" +
+	"\fBuildSkipped\x124\n" +
+	"\n" +
+	"generation\x18\x01 \x01(\v2\x14.protobuf.GenerationR\n" +
+	"generation\x1aL\n" +
+	"\x14BuildWaitingForCache\x124\n" +
+	"\n" +
+	"generation\x18\x01 \x01(\v2\x14.protobuf.GenerationR\n" +
+	"generation\x1aK\n" +
+	"\x13ClosureDiffComputed\x124\n" +
+	"\n" +
+	"generation\x18\x01 \x01(\v2\x14.protobuf.GenerationR\n" +
+	"generation\x1aL\n" +
+	"\x14UnitChangesPredicted\x124\n" +
+	"\n" +
+	"generation\x18\x01 \x01(\v2\x14.protobuf.GenerationR\n" +
+	"generation\x1am\n" +
+	"\rBuildProgress\x12'\n" +
+	"\x0fgeneration_uuid\x18\x01 \x01(\tR\x0egenerationUuid\x123\n" +
+	"\bprogress\x18\x02 \x01(\v2\x17.protobuf.BuildProgressR\bprogress\x1a9\n" +
+	"\rRebootPlanned\x12(\n" +
+	"\x06reboot\x18\x01 \x01(\v2\x10.protobuf.RebootR\x06reboot\x1a\x11\n" +
+	"\x0fRebootCancelled\x1a\xf3\x01\n" +
+	"\x10GarbageCollected\x12\x18\n" +
+	"\atrigger\x18\x01 \x01(\tR\atrigger\x12\x1f\n" +
+	"\vfreed_bytes\x18\x02 \x01(\x03R\n" +
+	"freedBytes\x12)\n" +
+	"\x10duration_seconds\x18\x03 \x01(\x01R\x0fdurationSeconds\x12'\n" +
+	"\x0foptimised_bytes\x18\x04 \x01(\x03R\x0eoptimisedBytes\x12:\n" +
+	"\x19optimise_duration_seconds\x18\x05 \x01(\x01R\x17optimiseDurationSeconds\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05errorB\x06\n" +
 	"\x04Type\"J\n" +
 	"\x0eConfirmRequest\x12&\n" +
 	"\x0egenerationUuid\x18\x01 \x01(\tR\x0egenerationUuid\x12\x10\n" +
-	"\x03for\x18\x02 \x01(\tR\x03for\"\xf8\b\n" +
+	"\x03for\x18\x02 \x01(\tR\x03for\"\xbb\v\n" +
 	"\n" +
 	"Generation\x12\x12\n" +
 	"\x04uuid\x18\x01 \x01(\tR\x04uuid\x12'\n" +
@@ -2405,7 +4461,8 @@ const file_pkg_protobuf_services_proto_rawDesc = "" +
 	"evalStatus\x12B\n" +
 	"\x0feval_started_at\x18\x0e \x01(\v2\x1a.google.protobuf.TimestampR\revalStartedAt\x12>\n" +
 	"\reval_ended_at\x18\x0f \x01(\v2\x1a.google.protobuf.TimestampR\vevalEndedAt\x12\x19\n" +
-	"\beval_err\x18\x10 \x01(\tR\aevalErr\x12\x19\n" +
+	"\beval_err\x18\x10 \x01(\tR\aevalErr\x12$\n" +
+	"\x0eeval_err_cause\x18\x1c \x01(\tR\fevalErrCause\x12\x19\n" +
 	"\bout_path\x18\x11 \x01(\tR\aoutPath\x12\x19\n" +
 	"\bdrv_path\x18\x12 \x01(\tR\adrvPath\x12\x1d\n" +
 	"\n" +
@@ -2414,7 +4471,34 @@ const file_pkg_protobuf_services_proto_rawDesc = "" +
 	"\fbuild_reason\x18\x1b \x01(\tR\vbuildReason\x12D\n" +
 	"\x10build_started_at\x18\x15 \x01(\v2\x1a.google.protobuf.TimestampR\x0ebuildStartedAt\x12@\n" +
 	"\x0ebuild_ended_at\x18\x16 \x01(\v2\x1a.google.protobuf.TimestampR\fbuildEndedAt\x12\x1b\n" +
-	"\tbuild_err\x18\x17 \x01(\tR\bbuildErr\"\xe2\x06\n" +
+	"\tbuild_err\x18\x17 \x01(\tR\bbuildErr\x12&\n" +
+	"\x0fbuild_err_cause\x18\x1d \x01(\tR\rbuildErrCause\x12\x19\n" +
+	"\bbuilt_by\x18\x1e \x01(\tR\abuiltBy\x12\x1f\n" +
+	"\veval_source\x18\x1f \x01(\tR\n" +
+	"evalSource\x128\n" +
+	"\fclosure_diff\x18  \x01(\v2\x15.protobuf.ClosureDiffR\vclosureDiff\x128\n" +
+	"\funit_changes\x18! \x01(\v2\x15.protobuf.UnitChangesR\vunitChanges\x12*\n" +
+	"\x05hooks\x18\" \x03(\v2\x14.protobuf.HookResultR\x05hooks\x12\x17\n" +
+	"\agc_root\x18# \x01(\tR\x06gcRoot\"i\n" +
+	"\vUnitChanges\x12\x12\n" +
+	"\x04stop\x18\x01 \x03(\tR\x04stop\x12\x18\n" +
+	"\arestart\x18\x02 \x03(\tR\arestart\x12\x16\n" +
+	"\x06reload\x18\x03 \x03(\tR\x06reload\x12\x14\n" +
+	"\x05start\x18\x04 \x03(\tR\x05start\"\x84\x01\n" +
+	"\rPackageChange\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1f\n" +
+	"\vold_version\x18\x02 \x01(\tR\n" +
+	"oldVersion\x12\x1f\n" +
+	"\vnew_version\x18\x03 \x01(\tR\n" +
+	"newVersion\x12\x1d\n" +
+	"\n" +
+	"size_delta\x18\x04 \x01(\x03R\tsizeDelta\"\xfc\x01\n" +
+	"\vClosureDiff\x12(\n" +
+	"\x10current_out_path\x18\x01 \x01(\tR\x0ecurrentOutPath\x12-\n" +
+	"\x05added\x18\x02 \x03(\v2\x17.protobuf.PackageChangeR\x05added\x121\n" +
+	"\aremoved\x18\x03 \x03(\v2\x17.protobuf.PackageChangeR\aremoved\x123\n" +
+	"\bupgraded\x18\x04 \x03(\v2\x17.protobuf.PackageChangeR\bupgraded\x12,\n" +
+	"\x12closure_size_delta\x18\x05 \x01(\x03R\x10closureSizeDelta\"\xc9\b\n" +
 	"\n" +
 	"Deployment\x12\x12\n" +
 	"\x04uuid\x18\x01 \x01(\tR\x04uuid\x12\x16\n" +
@@ -2436,13 +4520,43 @@ const file_pkg_protobuf_services_proto_rawDesc = "" +
 	"\n" +
 	"created_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12Z\n" +
 	"\x12current_inhibitors\x18\r \x03(\v2+.protobuf.Deployment.CurrentInhibitorsEntryR\x11currentInhibitors\x12N\n" +
-	"\x0enew_inhibitors\x18\x0e \x03(\v2'.protobuf.Deployment.NewInhibitorsEntryR\rnewInhibitors\x1aD\n" +
+	"\x0enew_inhibitors\x18\x0e \x03(\v2'.protobuf.Deployment.NewInhibitorsEntryR\rnewInhibitors\x12%\n" +
+	"\x0ereboot_reasons\x18\x10 \x03(\tR\rrebootReasons\x12*\n" +
+	"\x05hooks\x18\x11 \x03(\v2\x14.protobuf.HookResultR\x05hooks\x12F\n" +
+	"\x11inhibitor_changes\x18\x12 \x03(\v2\x19.protobuf.InhibitorChangeR\x10inhibitorChanges\x12J\n" +
+	"\x12inhibitor_override\x18\x13 \x01(\v2\x1b.protobuf.InhibitorOverrideR\x11inhibitorOverride\x1aD\n" +
 	"\x16CurrentInhibitorsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a@\n" +
 	"\x12NewInhibitorsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xb7\x03\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"}\n" +
+	"\x0fInhibitorChange\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x1b\n" +
+	"\told_value\x18\x02 \x01(\tR\boldValue\x12\x1b\n" +
+	"\tnew_value\x18\x03 \x01(\tR\bnewValue\x12\x1e\n" +
+	"\n" +
+	"overridden\x18\x04 \x01(\bR\n" +
+	"overridden\"\xa5\x01\n" +
+	"\x11InhibitorOverride\x12'\n" +
+	"\x0fgeneration_uuid\x18\x01 \x01(\tR\x0egenerationUuid\x12\x12\n" +
+	"\x04keys\x18\x02 \x03(\tR\x04keys\x12\x18\n" +
+	"\acomment\x18\x03 \x01(\tR\acomment\x129\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"q\n" +
+	"\x18InhibitorOverrideRequest\x12'\n" +
+	"\x0fgeneration_uuid\x18\x01 \x01(\tR\x0egenerationUuid\x12\x12\n" +
+	"\x04keys\x18\x02 \x03(\tR\x04keys\x12\x18\n" +
+	"\acomment\x18\x03 \x01(\tR\acomment\"\xdd\x01\n" +
+	"\n" +
+	"HookResult\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05stage\x18\x06 \x01(\tR\x05stage\x12\x1b\n" +
+	"\texit_code\x18\x02 \x01(\x05R\bexitCode\x12\x16\n" +
+	"\x06output\x18\x03 \x01(\tR\x06output\x129\n" +
+	"\n" +
+	"started_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x125\n" +
+	"\bended_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\aendedAt\"\xb5\x04\n" +
 	"\x05State\x12@\n" +
 	"\x0eneed_to_reboot\x18\x01 \x01(\v2\x1a.google.protobuf.BoolValueR\fneedToReboot\x12=\n" +
 	"\fis_suspended\x18\x02 \x01(\v2\x1a.google.protobuf.BoolValueR\visSuspended\x12+\n" +
@@ -2451,7 +4565,34 @@ const file_pkg_protobuf_services_proto_rawDesc = "" +
 	"\afetcher\x18\x05 \x01(\v2\x11.protobuf.FetcherR\afetcher\x12%\n" +
 	"\x05store\x18\x06 \x01(\v2\x0f.protobuf.StoreR\x05store\x12<\n" +
 	"\x0fbuild_confirmer\x18\a \x01(\v2\x13.protobuf.ConfirmerR\x0ebuildConfirmer\x12>\n" +
-	"\x10deploy_confirmer\x18\b \x01(\v2\x13.protobuf.ConfirmerR\x0fdeployConfirmer\"\xeb\x02\n" +
+	"\x10deploy_confirmer\x18\b \x01(\v2\x13.protobuf.ConfirmerR\x0fdeployConfirmer\x12%\n" +
+	"\x0ereboot_reasons\x18\t \x03(\tR\rrebootReasons\x12(\n" +
+	"\x06reboot\x18\n" +
+	" \x01(\v2\x10.protobuf.RebootR\x06reboot\x12+\n" +
+	"\arollout\x18\v \x01(\v2\x11.protobuf.RolloutR\arollout\"\xcc\x02\n" +
+	"\x06Reboot\x12\x18\n" +
+	"\awindows\x18\x01 \x03(\tR\awindows\x129\n" +
+	"\n" +
+	"planned_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\tplannedAt\x12\x18\n" +
+	"\areasons\x18\x03 \x03(\tR\areasons\x12!\n" +
+	"\finhibited_by\x18\x04 \x01(\tR\vinhibitedBy\x128\n" +
+	"\tcancelled\x18\x05 \x01(\v2\x1a.google.protobuf.BoolValueR\tcancelled\x127\n" +
+	"\vlast_reboot\x18\x06 \x01(\v2\x16.protobuf.RebootRecordR\n" +
+	"lastReboot\x12\x14\n" +
+	"\x05error\x18\a \x01(\tR\x05error\x12'\n" +
+	"\x0fdeployment_uuid\x18\b \x01(\tR\x0edeploymentUuid\"\xdd\x01\n" +
+	"\aRollout\x12\x12\n" +
+	"\x04wave\x18\x01 \x01(\x05R\x04wave\x12\x16\n" +
+	"\x06source\x18\x02 \x01(\tR\x06source\x12'\n" +
+	"\x0fgeneration_uuid\x18\x03 \x01(\tR\x0egenerationUuid\x12\x1b\n" +
+	"\tcommit_id\x18\x04 \x01(\tR\bcommitId\x12%\n" +
+	"\x0ewaiting_reason\x18\x05 \x01(\tR\rwaitingReason\x129\n" +
+	"\n" +
+	"checked_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcheckedAt\"e\n" +
+	"\fRebootRecord\x12;\n" +
+	"\vrebooted_at\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"rebootedAt\x12\x18\n" +
+	"\areasons\x18\x02 \x03(\tR\areasons\"\xd4\x03\n" +
 	"\bDeployer\x12=\n" +
 	"\fis_deploying\x18\x01 \x01(\v2\x1a.google.protobuf.BoolValueR\visDeploying\x124\n" +
 	"\n" +
@@ -2460,7 +4601,9 @@ const file_pkg_protobuf_services_proto_rawDesc = "" +
 	"\x14generation_to_deploy\x18\x03 \x01(\v2\x14.protobuf.GenerationR\x12generationToDeploy\x12\x1c\n" +
 	"\toperation\x18\x06 \x01(\tR\toperation\x12E\n" +
 	"\x13previous_deployment\x18\x04 \x01(\v2\x14.protobuf.DeploymentR\x12previousDeployment\x12=\n" +
-	"\fis_suspended\x18\x05 \x01(\v2\x1a.google.protobuf.BoolValueR\visSuspended\"\xc1\x02\n" +
+	"\fis_suspended\x18\x05 \x01(\v2\x1a.google.protobuf.BoolValueR\visSuspended\x12%\n" +
+	"\x0epending_reason\x18\a \x01(\tR\rpendingReason\x12@\n" +
+	"\x0enext_window_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\fnextWindowAt\"\x81\x03\n" +
 	"\aBuilder\x12?\n" +
 	"\ris_evaluating\x18\x01 \x01(\v2\x1a.google.protobuf.BoolValueR\fisEvaluating\x12;\n" +
 	"\vis_building\x18\x02 \x01(\v2\x1a.google.protobuf.BoolValueR\n" +
@@ -2470,14 +4613,27 @@ const file_pkg_protobuf_services_proto_rawDesc = "" +
 	"generation\x12'\n" +
 	"\x0fgeneration_uuid\x18\x04 \x01(\tR\x0egenerationUuid\x12=\n" +
 	"\fis_suspended\x18\x05 \x01(\v2\x1a.google.protobuf.BoolValueR\visSuspended\x12\x1a\n" +
-	"\bhostname\x18\x06 \x01(\tR\bhostname\"\xad\x02\n" +
+	"\bhostname\x18\x06 \x01(\tR\bhostname\x12>\n" +
+	"\x0ebuild_progress\x18\a \x01(\v2\x17.protobuf.BuildProgressR\rbuildProgress\"\x8d\x03\n" +
+	"\rBuildProgress\x12+\n" +
+	"\x11derivations_built\x18\x01 \x01(\x04R\x10derivationsBuilt\x121\n" +
+	"\x14derivations_expected\x18\x02 \x01(\x04R\x13derivationsExpected\x12/\n" +
+	"\x13derivations_running\x18\x03 \x01(\x04R\x12derivationsRunning\x12-\n" +
+	"\x12derivations_failed\x18\x04 \x01(\x04R\x11derivationsFailed\x12%\n" +
+	"\x0edownloads_done\x18\x05 \x01(\x04R\rdownloadsDone\x12-\n" +
+	"\x12downloads_expected\x18\x06 \x01(\x04R\x11downloadsExpected\x12)\n" +
+	"\x10bytes_downloaded\x18\a \x01(\x04R\x0fbytesDownloaded\x12%\n" +
+	"\x0ebytes_expected\x18\b \x01(\x04R\rbytesExpected\x12\x14\n" +
+	"\x05phase\x18\t \x01(\tR\x05phase\"\xce\x02\n" +
 	"\tConfirmer\x12\x12\n" +
 	"\x04mode\x18\x01 \x01(\x03R\x04mode\x12\x1c\n" +
 	"\tsubmitted\x18\x02 \x01(\tR\tsubmitted\x12\x1c\n" +
 	"\tconfirmed\x18\x03 \x01(\tR\tconfirmed\x121\n" +
 	"\x14autoconfirm_duration\x18\x04 \x01(\x03R\x13autoconfirmDuration\x12P\n" +
 	"\x16autoconfirm_started_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x14autoconfirmStartedAt\x12K\n" +
-	"\x13autoconfirm_started\x18\x06 \x01(\v2\x1a.google.protobuf.BoolValueR\x12autoconfirmStarted\"\x8f\x01\n" +
+	"\x13autoconfirm_started\x18\x06 \x01(\v2\x1a.google.protobuf.BoolValueR\x12autoconfirmStarted\x12\x1f\n" +
+	"\vheld_reason\x18\a \x01(\tR\n" +
+	"heldReason\"\x8f\x01\n" +
 	"\aFetcher\x12;\n" +
 	"\vis_fetching\x18\x01 \x01(\v2\x1a.google.protobuf.BoolValueR\n" +
 	"isFetching\x12G\n" +
@@ -2515,7 +4671,7 @@ const file_pkg_protobuf_services_proto_rawDesc = "" +
 	"\terror_msg\x18\r \x01(\tR\berrorMsg\"Y\n" +
 	"\rDeployerState\x12!\n" +
 	"\fis_suspended\x18\x01 \x01(\bR\visSuspended\x12%\n" +
-	"\x0esuspend_reason\x18\x02 \x01(\tR\rsuspendReason\"\xe3\x04\n" +
+	"\x0esuspend_reason\x18\x02 \x01(\tR\rsuspendReason\"\xe8\a\n" +
 	"\x05Store\x126\n" +
 	"\vdeployments\x18\x01 \x03(\v2\x14.protobuf.DeploymentR\vdeployments\x126\n" +
 	"\vgenerations\x18\x02 \x03(\v2\x14.protobuf.GenerationR\vgenerations\x12/\n" +
@@ -2528,7 +4684,15 @@ const file_pkg_protobuf_services_proto_rawDesc = "" +
 	"\x1edeployment_boot_entry_capacity\x18\t \x01(\x05R\x1bdeploymentBootEntryCapacity\x12D\n" +
 	"\x1edeployment_successful_capacity\x18\n" +
 	" \x01(\x05R\x1cdeploymentSuccessfulCapacity\x126\n" +
-	"\x17deployment_any_capacity\x18\v \x01(\x05R\x15deploymentAnyCapacity2\xaf\x03\n" +
+	"\x17deployment_any_capacity\x18\v \x01(\x05R\x15deploymentAnyCapacity\x127\n" +
+	"\vlast_reboot\x18\f \x01(\v2\x16.protobuf.RebootRecordR\n" +
+	"lastReboot\x127\n" +
+	"\fhook_results\x18\r \x03(\v2\x14.protobuf.HookResultR\vhookResults\x12\x17\n" +
+	"\aboot_id\x18\x0e \x01(\tR\x06bootId\x12:\n" +
+	"\x19generation_built_capacity\x18\x0f \x01(\x05R\x17generationBuiltCapacity\x12%\n" +
+	"\x0eschema_version\x18\x10 \x01(\x05R\rschemaVersion\x12G\n" +
+	" reboot_cancelled_deployment_uuid\x18\x11 \x01(\tR\x1drebootCancelledDeploymentUuid\x12L\n" +
+	"\x13inhibitor_overrides\x18\x12 \x03(\v2\x1b.protobuf.InhibitorOverrideR\x12inhibitorOverrides2\x89\a\n" +
 	"\x05Comin\x125\n" +
 	"\bGetState\x12\x16.google.protobuf.Empty\x1a\x0f.protobuf.State\"\x00\x129\n" +
 	"\x05Fetch\x12\x16.google.protobuf.Empty\x1a\x16.google.protobuf.Empty\"\x00\x12;\n" +
@@ -2536,7 +4700,13 @@ const file_pkg_protobuf_services_proto_rawDesc = "" +
 	"\x06Resume\x12\x16.google.protobuf.Empty\x1a\x16.google.protobuf.Empty\"\x00\x12=\n" +
 	"\aConfirm\x12\x18.protobuf.ConfirmRequest\x1a\x16.google.protobuf.Empty\"\x00\x123\n" +
 	"\x06Events\x12\x16.google.protobuf.Empty\x1a\x0f.protobuf.Event0\x01\x12G\n" +
-	"\x16DeploymentLatestSubmit\x12\x13.protobuf.Operation\x1a\x16.google.protobuf.Empty\"\x00B*Z#github.com/nlewo/comin/pkg/protobuf\x92\x03\x02\b\x02b\beditionsp\xe8\a"
+	"\x16DeploymentLatestSubmit\x12\x13.protobuf.Operation\x1a\x16.google.protobuf.Empty\"\x00\x12R\n" +
+	"\x0eGenerationLogs\x12\x1f.protobuf.GenerationLogsRequest\x1a\x1d.protobuf.GenerationLogsChunk0\x01\x12@\n" +
+	"\fRebootCancel\x12\x16.google.protobuf.Empty\x1a\x16.google.protobuf.Empty\"\x00\x12L\n" +
+	"\x18DeploymentWindowOverride\x12\x16.google.protobuf.Empty\x1a\x16.google.protobuf.Empty\"\x00\x12Q\n" +
+	"\x11InhibitorOverride\x12\".protobuf.InhibitorOverrideRequest\x1a\x16.google.protobuf.Empty\"\x00\x12X\n" +
+	"\x0fListDeployments\x12 .protobuf.ListDeploymentsRequest\x1a!.protobuf.ListDeploymentsResponse\"\x00\x12G\n" +
+	"\rGetDeployment\x12\x1e.protobuf.GetDeploymentRequest\x1a\x14.protobuf.Deployment\"\x00B*Z#github.com/nlewo/comin/pkg/protobuf\x92\x03\x02\b\x02b\beditionsp\xe8\a"
 
 var (
 	file_pkg_protobuf_services_proto_rawDescOnce sync.Once
@@ -2550,131 +4720,209 @@ func file_pkg_protobuf_services_proto_rawDescGZIP() []byte {
 	return file_pkg_protobuf_services_proto_rawDescData
 }
 
-var file_pkg_protobuf_services_proto_msgTypes = make([]protoimpl.MessageInfo, 31)
+var file_pkg_protobuf_services_proto_msgTypes = make([]protoimpl.MessageInfo, 55)
 var file_pkg_protobuf_services_proto_goTypes = []any{
-	(*Operation)(nil),                   // 0: protobuf.Operation
-	(*Event)(nil),                       // 1: protobuf.Event
-	(*ConfirmRequest)(nil),              // 2: protobuf.ConfirmRequest
-	(*Generation)(nil),                  // 3: protobuf.Generation
-	(*Deployment)(nil),                  // 4: protobuf.Deployment
-	(*State)(nil),                       // 5: protobuf.State
-	(*Deployer)(nil),                    // 6: protobuf.Deployer
-	(*Builder)(nil),                     // 7: protobuf.Builder
-	(*Confirmer)(nil),                   // 8: protobuf.Confirmer
-	(*Fetcher)(nil),                     // 9: protobuf.Fetcher
-	(*Branch)(nil),                      // 10: protobuf.Branch
-	(*Remote)(nil),                      // 11: protobuf.Remote
-	(*RepositoryStatus)(nil),            // 12: protobuf.RepositoryStatus
-	(*DeployerState)(nil),               // 13: protobuf.DeployerState
-	(*Store)(nil),                       // 14: protobuf.Store
-	(*Event_EvalStarted)(nil),           // 15: protobuf.Event.EvalStarted
-	(*Event_EvalFinished)(nil),          // 16: protobuf.Event.EvalFinished
-	(*Event_BuildStarted)(nil),          // 17: protobuf.Event.BuildStarted
-	(*Event_BuildFinished)(nil),         // 18: protobuf.Event.BuildFinished
-	(*Event_ConfirmationSubmitted)(nil), // 19: protobuf.Event.ConfirmationSubmitted
-	(*Event_ConfirmationCancelled)(nil), // 20: protobuf.Event.ConfirmationCancelled
-	(*Event_ConfirmationConfirmed)(nil), // 21: protobuf.Event.ConfirmationConfirmed
-	(*Event_Resume)(nil),                // 22: protobuf.Event.Resume
-	(*Event_Suspend)(nil),               // 23: protobuf.Event.Suspend
-	(*Event_DeploymentStarted)(nil),     // 24: protobuf.Event.DeploymentStarted
-	(*Event_DeploymentFinished)(nil),    // 25: protobuf.Event.DeploymentFinished
-	(*Event_RebootRequired)(nil),        // 26: protobuf.Event.RebootRequired
-	(*Event_ManagerState)(nil),          // 27: protobuf.Event.ManagerState
-	(*Event_Fetched)(nil),               // 28: protobuf.Event.Fetched
-	nil,                                 // 29: protobuf.Deployment.CurrentInhibitorsEntry
-	nil,                                 // 30: protobuf.Deployment.NewInhibitorsEntry
-	(*timestamppb.Timestamp)(nil),       // 31: google.protobuf.Timestamp
-	(*wrapperspb.BoolValue)(nil),        // 32: google.protobuf.BoolValue
-	(*emptypb.Empty)(nil),               // 33: google.protobuf.Empty
+	(*ListDeploymentsRequest)(nil),      // 0: protobuf.ListDeploymentsRequest
+	(*ListDeploymentsResponse)(nil),     // 1: protobuf.ListDeploymentsResponse
+	(*GetDeploymentRequest)(nil),        // 2: protobuf.GetDeploymentRequest
+	(*GenerationLogsRequest)(nil),       // 3: protobuf.GenerationLogsRequest
+	(*GenerationLogsChunk)(nil),         // 4: protobuf.GenerationLogsChunk
+	(*Operation)(nil),                   // 5: protobuf.Operation
+	(*Event)(nil),                       // 6: protobuf.Event
+	(*ConfirmRequest)(nil),              // 7: protobuf.ConfirmRequest
+	(*Generation)(nil),                  // 8: protobuf.Generation
+	(*UnitChanges)(nil),                 // 9: protobuf.UnitChanges
+	(*PackageChange)(nil),               // 10: protobuf.PackageChange
+	(*ClosureDiff)(nil),                 // 11: protobuf.ClosureDiff
+	(*Deployment)(nil),                  // 12: protobuf.Deployment
+	(*InhibitorChange)(nil),             // 13: protobuf.InhibitorChange
+	(*InhibitorOverride)(nil),           // 14: protobuf.InhibitorOverride
+	(*InhibitorOverrideRequest)(nil),    // 15: protobuf.InhibitorOverrideRequest
+	(*HookResult)(nil),                  // 16: protobuf.HookResult
+	(*State)(nil),                       // 17: protobuf.State
+	(*Reboot)(nil),                      // 18: protobuf.Reboot
+	(*Rollout)(nil),                     // 19: protobuf.Rollout
+	(*RebootRecord)(nil),                // 20: protobuf.RebootRecord
+	(*Deployer)(nil),                    // 21: protobuf.Deployer
+	(*Builder)(nil),                     // 22: protobuf.Builder
+	(*BuildProgress)(nil),               // 23: protobuf.BuildProgress
+	(*Confirmer)(nil),                   // 24: protobuf.Confirmer
+	(*Fetcher)(nil),                     // 25: protobuf.Fetcher
+	(*Branch)(nil),                      // 26: protobuf.Branch
+	(*Remote)(nil),                      // 27: protobuf.Remote
+	(*RepositoryStatus)(nil),            // 28: protobuf.RepositoryStatus
+	(*DeployerState)(nil),               // 29: protobuf.DeployerState
+	(*Store)(nil),                       // 30: protobuf.Store
+	(*Event_EvalStarted)(nil),           // 31: protobuf.Event.EvalStarted
+	(*Event_EvalFinished)(nil),          // 32: protobuf.Event.EvalFinished
+	(*Event_BuildStarted)(nil),          // 33: protobuf.Event.BuildStarted
+	(*Event_BuildFinished)(nil),         // 34: protobuf.Event.BuildFinished
+	(*Event_ConfirmationSubmitted)(nil), // 35: protobuf.Event.ConfirmationSubmitted
+	(*Event_ConfirmationCancelled)(nil), // 36: protobuf.Event.ConfirmationCancelled
+	(*Event_ConfirmationConfirmed)(nil), // 37: protobuf.Event.ConfirmationConfirmed
+	(*Event_Resume)(nil),                // 38: protobuf.Event.Resume
+	(*Event_Suspend)(nil),               // 39: protobuf.Event.Suspend
+	(*Event_DeploymentStarted)(nil),     // 40: protobuf.Event.DeploymentStarted
+	(*Event_DeploymentFinished)(nil),    // 41: protobuf.Event.DeploymentFinished
+	(*Event_RebootRequired)(nil),        // 42: protobuf.Event.RebootRequired
+	(*Event_ManagerState)(nil),          // 43: protobuf.Event.ManagerState
+	(*Event_Fetched)(nil),               // 44: protobuf.Event.Fetched
+	(*Event_BuildSkipped)(nil),          // 45: protobuf.Event.BuildSkipped
+	(*Event_BuildWaitingForCache)(nil),  // 46: protobuf.Event.BuildWaitingForCache
+	(*Event_ClosureDiffComputed)(nil),   // 47: protobuf.Event.ClosureDiffComputed
+	(*Event_UnitChangesPredicted)(nil),  // 48: protobuf.Event.UnitChangesPredicted
+	(*Event_BuildProgress)(nil),         // 49: protobuf.Event.BuildProgress
+	(*Event_RebootPlanned)(nil),         // 50: protobuf.Event.RebootPlanned
+	(*Event_RebootCancelled)(nil),       // 51: protobuf.Event.RebootCancelled
+	(*Event_GarbageCollected)(nil),      // 52: protobuf.Event.GarbageCollected
+	nil,                                 // 53: protobuf.Deployment.CurrentInhibitorsEntry
+	nil,                                 // 54: protobuf.Deployment.NewInhibitorsEntry
+	(*timestamppb.Timestamp)(nil),       // 55: google.protobuf.Timestamp
+	(*wrapperspb.BoolValue)(nil),        // 56: google.protobuf.BoolValue
+	(*emptypb.Empty)(nil),               // 57: google.protobuf.Empty
 }
 var file_pkg_protobuf_services_proto_depIdxs = []int32{
-	15, // 0: protobuf.Event.evalStartedType:type_name -> protobuf.Event.EvalStarted
-	16, // 1: protobuf.Event.evalFinishedType:type_name -> protobuf.Event.EvalFinished
-	17, // 2: protobuf.Event.buildStartedType:type_name -> protobuf.Event.BuildStarted
-	18, // 3: protobuf.Event.buildFinishedType:type_name -> protobuf.Event.BuildFinished
-	19, // 4: protobuf.Event.confirmationSubmittedType:type_name -> protobuf.Event.ConfirmationSubmitted
-	20, // 5: protobuf.Event.confirmationCancelledType:type_name -> protobuf.Event.ConfirmationCancelled
-	21, // 6: protobuf.Event.confirmationConfirmedType:type_name -> protobuf.Event.ConfirmationConfirmed
-	22, // 7: protobuf.Event.resume:type_name -> protobuf.Event.Resume
-	23, // 8: protobuf.Event.suspend:type_name -> protobuf.Event.Suspend
-	24, // 9: protobuf.Event.deploymentStartedType:type_name -> protobuf.Event.DeploymentStarted
-	25, // 10: protobuf.Event.deploymentFinishedType:type_name -> protobuf.Event.DeploymentFinished
-	26, // 11: protobuf.Event.rebootRequired:type_name -> protobuf.Event.RebootRequired
-	27, // 12: protobuf.Event.managerState:type_name -> protobuf.Event.ManagerState
-	28, // 13: protobuf.Event.fetched:type_name -> protobuf.Event.Fetched
-	31, // 14: protobuf.Event.createdAt:type_name -> google.protobuf.Timestamp
-	32, // 15: protobuf.Generation.selected_branch_is_testing:type_name -> google.protobuf.BoolValue
-	31, // 16: protobuf.Generation.eval_started_at:type_name -> google.protobuf.Timestamp
-	31, // 17: protobuf.Generation.eval_ended_at:type_name -> google.protobuf.Timestamp
-	31, // 18: protobuf.Generation.build_started_at:type_name -> google.protobuf.Timestamp
-	31, // 19: protobuf.Generation.build_ended_at:type_name -> google.protobuf.Timestamp
-	3,  // 20: protobuf.Deployment.generation:type_name -> protobuf.Generation
-	31, // 21: protobuf.Deployment.started_at:type_name -> google.protobuf.Timestamp
-	31, // 22: protobuf.Deployment.ended_at:type_name -> google.protobuf.Timestamp
-	32, // 23: protobuf.Deployment.restart_comin:type_name -> google.protobuf.BoolValue
-	31, // 24: protobuf.Deployment.created_at:type_name -> google.protobuf.Timestamp
-	29, // 25: protobuf.Deployment.current_inhibitors:type_name -> protobuf.Deployment.CurrentInhibitorsEntry
-	30, // 26: protobuf.Deployment.new_inhibitors:type_name -> protobuf.Deployment.NewInhibitorsEntry
-	32, // 27: protobuf.State.need_to_reboot:type_name -> google.protobuf.BoolValue
-	32, // 28: protobuf.State.is_suspended:type_name -> google.protobuf.BoolValue
-	7,  // 29: protobuf.State.builder:type_name -> protobuf.Builder
-	6,  // 30: protobuf.State.deployer:type_name -> protobuf.Deployer
-	9,  // 31: protobuf.State.fetcher:type_name -> protobuf.Fetcher
-	14, // 32: protobuf.State.store:type_name -> protobuf.Store
-	8,  // 33: protobuf.State.build_confirmer:type_name -> protobuf.Confirmer
-	8,  // 34: protobuf.State.deploy_confirmer:type_name -> protobuf.Confirmer
-	32, // 35: protobuf.Deployer.is_deploying:type_name -> google.protobuf.BoolValue
-	4,  // 36: protobuf.Deployer.deployment:type_name -> protobuf.Deployment
-	3,  // 37: protobuf.Deployer.generation_to_deploy:type_name -> protobuf.Generation
-	4,  // 38: protobuf.Deployer.previous_deployment:type_name -> protobuf.Deployment
-	32, // 39: protobuf.Deployer.is_suspended:type_name -> google.protobuf.BoolValue
-	32, // 40: protobuf.Builder.is_evaluating:type_name -> google.protobuf.BoolValue
-	32, // 41: protobuf.Builder.is_building:type_name -> google.protobuf.BoolValue
-	3,  // 42: protobuf.Builder.generation:type_name -> protobuf.Generation
-	32, // 43: protobuf.Builder.is_suspended:type_name -> google.protobuf.BoolValue
-	31, // 44: protobuf.Confirmer.autoconfirm_started_at:type_name -> google.protobuf.Timestamp
-	32, // 45: protobuf.Confirmer.autoconfirm_started:type_name -> google.protobuf.BoolValue
-	32, // 46: protobuf.Fetcher.is_fetching:type_name -> google.protobuf.BoolValue
-	12, // 47: protobuf.Fetcher.repository_status:type_name -> protobuf.RepositoryStatus
-	10, // 48: protobuf.Remote.main:type_name -> protobuf.Branch
-	10, // 49: protobuf.Remote.testing:type_name -> protobuf.Branch
-	31, // 50: protobuf.Remote.fetched_at:type_name -> google.protobuf.Timestamp
-	32, // 51: protobuf.Remote.fetched:type_name -> google.protobuf.BoolValue
-	32, // 52: protobuf.RepositoryStatus.selected_branch_is_testing:type_name -> google.protobuf.BoolValue
-	32, // 53: protobuf.RepositoryStatus.selected_commit_signed:type_name -> google.protobuf.BoolValue
-	32, // 54: protobuf.RepositoryStatus.selected_commit_should_be_signed:type_name -> google.protobuf.BoolValue
-	11, // 55: protobuf.RepositoryStatus.remotes:type_name -> protobuf.Remote
-	4,  // 56: protobuf.Store.deployments:type_name -> protobuf.Deployment
-	3,  // 57: protobuf.Store.generations:type_name -> protobuf.Generation
-	13, // 58: protobuf.Store.deployer:type_name -> protobuf.DeployerState
-	3,  // 59: protobuf.Event.EvalStarted.generation:type_name -> protobuf.Generation
-	3,  // 60: protobuf.Event.EvalFinished.generation:type_name -> protobuf.Generation
-	3,  // 61: protobuf.Event.BuildStarted.generation:type_name -> protobuf.Generation
-	3,  // 62: protobuf.Event.BuildFinished.generation:type_name -> protobuf.Generation
-	4,  // 63: protobuf.Event.DeploymentStarted.deployment:type_name -> protobuf.Deployment
-	4,  // 64: protobuf.Event.DeploymentFinished.deployment:type_name -> protobuf.Deployment
-	4,  // 65: protobuf.Event.RebootRequired.deployment:type_name -> protobuf.Deployment
-	5,  // 66: protobuf.Event.ManagerState.state:type_name -> protobuf.State
-	12, // 67: protobuf.Event.Fetched.repositoryStatus:type_name -> protobuf.RepositoryStatus
-	33, // 68: protobuf.Comin.GetState:input_type -> google.protobuf.Empty
-	33, // 69: protobuf.Comin.Fetch:input_type -> google.protobuf.Empty
-	33, // 70: protobuf.Comin.Suspend:input_type -> google.protobuf.Empty
-	33, // 71: protobuf.Comin.Resume:input_type -> google.protobuf.Empty
-	2,  // 72: protobuf.Comin.Confirm:input_type -> protobuf.ConfirmRequest
-	33, // 73: protobuf.Comin.Events:input_type -> google.protobuf.Empty
-	0,  // 74: protobuf.Comin.DeploymentLatestSubmit:input_type -> protobuf.Operation
-	5,  // 75: protobuf.Comin.GetState:output_type -> protobuf.State
-	33, // 76: protobuf.Comin.Fetch:output_type -> google.protobuf.Empty
-	33, // 77: protobuf.Comin.Suspend:output_type -> google.protobuf.Empty
-	33, // 78: protobuf.Comin.Resume:output_type -> google.protobuf.Empty
-	33, // 79: protobuf.Comin.Confirm:output_type -> google.protobuf.Empty
-	1,  // 80: protobuf.Comin.Events:output_type -> protobuf.Event
-	33, // 81: protobuf.Comin.DeploymentLatestSubmit:output_type -> google.protobuf.Empty
-	75, // [75:82] is the sub-list for method output_type
-	68, // [68:75] is the sub-list for method input_type
-	68, // [68:68] is the sub-list for extension type_name
-	68, // [68:68] is the sub-list for extension extendee
-	0,  // [0:68] is the sub-list for field type_name
+	55,  // 0: protobuf.ListDeploymentsRequest.since:type_name -> google.protobuf.Timestamp
+	55,  // 1: protobuf.ListDeploymentsRequest.until:type_name -> google.protobuf.Timestamp
+	12,  // 2: protobuf.ListDeploymentsResponse.deployments:type_name -> protobuf.Deployment
+	56,  // 3: protobuf.GenerationLogsRequest.follow:type_name -> google.protobuf.BoolValue
+	31,  // 4: protobuf.Event.evalStartedType:type_name -> protobuf.Event.EvalStarted
+	32,  // 5: protobuf.Event.evalFinishedType:type_name -> protobuf.Event.EvalFinished
+	33,  // 6: protobuf.Event.buildStartedType:type_name -> protobuf.Event.BuildStarted
+	34,  // 7: protobuf.Event.buildFinishedType:type_name -> protobuf.Event.BuildFinished
+	35,  // 8: protobuf.Event.confirmationSubmittedType:type_name -> protobuf.Event.ConfirmationSubmitted
+	36,  // 9: protobuf.Event.confirmationCancelledType:type_name -> protobuf.Event.ConfirmationCancelled
+	37,  // 10: protobuf.Event.confirmationConfirmedType:type_name -> protobuf.Event.ConfirmationConfirmed
+	38,  // 11: protobuf.Event.resume:type_name -> protobuf.Event.Resume
+	39,  // 12: protobuf.Event.suspend:type_name -> protobuf.Event.Suspend
+	40,  // 13: protobuf.Event.deploymentStartedType:type_name -> protobuf.Event.DeploymentStarted
+	41,  // 14: protobuf.Event.deploymentFinishedType:type_name -> protobuf.Event.DeploymentFinished
+	42,  // 15: protobuf.Event.rebootRequired:type_name -> protobuf.Event.RebootRequired
+	43,  // 16: protobuf.Event.managerState:type_name -> protobuf.Event.ManagerState
+	44,  // 17: protobuf.Event.fetched:type_name -> protobuf.Event.Fetched
+	45,  // 18: protobuf.Event.buildSkippedType:type_name -> protobuf.Event.BuildSkipped
+	49,  // 19: protobuf.Event.buildProgressType:type_name -> protobuf.Event.BuildProgress
+	46,  // 20: protobuf.Event.buildWaitingForCacheType:type_name -> protobuf.Event.BuildWaitingForCache
+	50,  // 21: protobuf.Event.rebootPlannedType:type_name -> protobuf.Event.RebootPlanned
+	51,  // 22: protobuf.Event.rebootCancelledType:type_name -> protobuf.Event.RebootCancelled
+	52,  // 23: protobuf.Event.garbageCollectedType:type_name -> protobuf.Event.GarbageCollected
+	47,  // 24: protobuf.Event.closureDiffComputedType:type_name -> protobuf.Event.ClosureDiffComputed
+	48,  // 25: protobuf.Event.unitChangesPredictedType:type_name -> protobuf.Event.UnitChangesPredicted
+	55,  // 26: protobuf.Event.createdAt:type_name -> google.protobuf.Timestamp
+	56,  // 27: protobuf.Generation.selected_branch_is_testing:type_name -> google.protobuf.BoolValue
+	55,  // 28: protobuf.Generation.eval_started_at:type_name -> google.protobuf.Timestamp
+	55,  // 29: protobuf.Generation.eval_ended_at:type_name -> google.protobuf.Timestamp
+	55,  // 30: protobuf.Generation.build_started_at:type_name -> google.protobuf.Timestamp
+	55,  // 31: protobuf.Generation.build_ended_at:type_name -> google.protobuf.Timestamp
+	11,  // 32: protobuf.Generation.closure_diff:type_name -> protobuf.ClosureDiff
+	9,   // 33: protobuf.Generation.unit_changes:type_name -> protobuf.UnitChanges
+	16,  // 34: protobuf.Generation.hooks:type_name -> protobuf.HookResult
+	10,  // 35: protobuf.ClosureDiff.added:type_name -> protobuf.PackageChange
+	10,  // 36: protobuf.ClosureDiff.removed:type_name -> protobuf.PackageChange
+	10,  // 37: protobuf.ClosureDiff.upgraded:type_name -> protobuf.PackageChange
+	8,   // 38: protobuf.Deployment.generation:type_name -> protobuf.Generation
+	55,  // 39: protobuf.Deployment.started_at:type_name -> google.protobuf.Timestamp
+	55,  // 40: protobuf.Deployment.ended_at:type_name -> google.protobuf.Timestamp
+	56,  // 41: protobuf.Deployment.restart_comin:type_name -> google.protobuf.BoolValue
+	55,  // 42: protobuf.Deployment.created_at:type_name -> google.protobuf.Timestamp
+	53,  // 43: protobuf.Deployment.current_inhibitors:type_name -> protobuf.Deployment.CurrentInhibitorsEntry
+	54,  // 44: protobuf.Deployment.new_inhibitors:type_name -> protobuf.Deployment.NewInhibitorsEntry
+	16,  // 45: protobuf.Deployment.hooks:type_name -> protobuf.HookResult
+	13,  // 46: protobuf.Deployment.inhibitor_changes:type_name -> protobuf.InhibitorChange
+	14,  // 47: protobuf.Deployment.inhibitor_override:type_name -> protobuf.InhibitorOverride
+	55,  // 48: protobuf.InhibitorOverride.created_at:type_name -> google.protobuf.Timestamp
+	55,  // 49: protobuf.HookResult.started_at:type_name -> google.protobuf.Timestamp
+	55,  // 50: protobuf.HookResult.ended_at:type_name -> google.protobuf.Timestamp
+	56,  // 51: protobuf.State.need_to_reboot:type_name -> google.protobuf.BoolValue
+	56,  // 52: protobuf.State.is_suspended:type_name -> google.protobuf.BoolValue
+	22,  // 53: protobuf.State.builder:type_name -> protobuf.Builder
+	21,  // 54: protobuf.State.deployer:type_name -> protobuf.Deployer
+	25,  // 55: protobuf.State.fetcher:type_name -> protobuf.Fetcher
+	30,  // 56: protobuf.State.store:type_name -> protobuf.Store
+	24,  // 57: protobuf.State.build_confirmer:type_name -> protobuf.Confirmer
+	24,  // 58: protobuf.State.deploy_confirmer:type_name -> protobuf.Confirmer
+	18,  // 59: protobuf.State.reboot:type_name -> protobuf.Reboot
+	19,  // 60: protobuf.State.rollout:type_name -> protobuf.Rollout
+	55,  // 61: protobuf.Reboot.planned_at:type_name -> google.protobuf.Timestamp
+	56,  // 62: protobuf.Reboot.cancelled:type_name -> google.protobuf.BoolValue
+	20,  // 63: protobuf.Reboot.last_reboot:type_name -> protobuf.RebootRecord
+	55,  // 64: protobuf.Rollout.checked_at:type_name -> google.protobuf.Timestamp
+	55,  // 65: protobuf.RebootRecord.rebooted_at:type_name -> google.protobuf.Timestamp
+	56,  // 66: protobuf.Deployer.is_deploying:type_name -> google.protobuf.BoolValue
+	12,  // 67: protobuf.Deployer.deployment:type_name -> protobuf.Deployment
+	8,   // 68: protobuf.Deployer.generation_to_deploy:type_name -> protobuf.Generation
+	12,  // 69: protobuf.Deployer.previous_deployment:type_name -> protobuf.Deployment
+	56,  // 70: protobuf.Deployer.is_suspended:type_name -> google.protobuf.BoolValue
+	55,  // 71: protobuf.Deployer.next_window_at:type_name -> google.protobuf.Timestamp
+	56,  // 72: protobuf.Builder.is_evaluating:type_name -> google.protobuf.BoolValue
+	56,  // 73: protobuf.Builder.is_building:type_name -> google.protobuf.BoolValue
+	8,   // 74: protobuf.Builder.generation:type_name -> protobuf.Generation
+	56,  // 75: protobuf.Builder.is_suspended:type_name -> google.protobuf.BoolValue
+	23,  // 76: protobuf.Builder.build_progress:type_name -> protobuf.BuildProgress
+	55,  // 77: protobuf.Confirmer.autoconfirm_started_at:type_name -> google.protobuf.Timestamp
+	56,  // 78: protobuf.Confirmer.autoconfirm_started:type_name -> google.protobuf.BoolValue
+	56,  // 79: protobuf.Fetcher.is_fetching:type_name -> google.protobuf.BoolValue
+	28,  // 80: protobuf.Fetcher.repository_status:type_name -> protobuf.RepositoryStatus
+	26,  // 81: protobuf.Remote.main:type_name -> protobuf.Branch
+	26,  // 82: protobuf.Remote.testing:type_name -> protobuf.Branch
+	55,  // 83: protobuf.Remote.fetched_at:type_name -> google.protobuf.Timestamp
+	56,  // 84: protobuf.Remote.fetched:type_name -> google.protobuf.BoolValue
+	56,  // 85: protobuf.RepositoryStatus.selected_branch_is_testing:type_name -> google.protobuf.BoolValue
+	56,  // 86: protobuf.RepositoryStatus.selected_commit_signed:type_name -> google.protobuf.BoolValue
+	56,  // 87: protobuf.RepositoryStatus.selected_commit_should_be_signed:type_name -> google.protobuf.BoolValue
+	27,  // 88: protobuf.RepositoryStatus.remotes:type_name -> protobuf.Remote
+	12,  // 89: protobuf.Store.deployments:type_name -> protobuf.Deployment
+	8,   // 90: protobuf.Store.generations:type_name -> protobuf.Generation
+	29,  // 91: protobuf.Store.deployer:type_name -> protobuf.DeployerState
+	20,  // 92: protobuf.Store.last_reboot:type_name -> protobuf.RebootRecord
+	16,  // 93: protobuf.Store.hook_results:type_name -> protobuf.HookResult
+	14,  // 94: protobuf.Store.inhibitor_overrides:type_name -> protobuf.InhibitorOverride
+	8,   // 95: protobuf.Event.EvalStarted.generation:type_name -> protobuf.Generation
+	8,   // 96: protobuf.Event.EvalFinished.generation:type_name -> protobuf.Generation
+	8,   // 97: protobuf.Event.BuildStarted.generation:type_name -> protobuf.Generation
+	8,   // 98: protobuf.Event.BuildFinished.generation:type_name -> protobuf.Generation
+	12,  // 99: protobuf.Event.DeploymentStarted.deployment:type_name -> protobuf.Deployment
+	12,  // 100: protobuf.Event.DeploymentFinished.deployment:type_name -> protobuf.Deployment
+	12,  // 101: protobuf.Event.RebootRequired.deployment:type_name -> protobuf.Deployment
+	17,  // 102: protobuf.Event.ManagerState.state:type_name -> protobuf.State
+	28,  // 103: protobuf.Event.Fetched.repositoryStatus:type_name -> protobuf.RepositoryStatus
+	8,   // 104: protobuf.Event.BuildSkipped.generation:type_name -> protobuf.Generation
+	8,   // 105: protobuf.Event.BuildWaitingForCache.generation:type_name -> protobuf.Generation
+	8,   // 106: protobuf.Event.ClosureDiffComputed.generation:type_name -> protobuf.Generation
+	8,   // 107: protobuf.Event.UnitChangesPredicted.generation:type_name -> protobuf.Generation
+	23,  // 108: protobuf.Event.BuildProgress.progress:type_name -> protobuf.BuildProgress
+	18,  // 109: protobuf.Event.RebootPlanned.reboot:type_name -> protobuf.Reboot
+	57,  // 110: protobuf.Comin.GetState:input_type -> google.protobuf.Empty
+	57,  // 111: protobuf.Comin.Fetch:input_type -> google.protobuf.Empty
+	57,  // 112: protobuf.Comin.Suspend:input_type -> google.protobuf.Empty
+	57,  // 113: protobuf.Comin.Resume:input_type -> google.protobuf.Empty
+	7,   // 114: protobuf.Comin.Confirm:input_type -> protobuf.ConfirmRequest
+	57,  // 115: protobuf.Comin.Events:input_type -> google.protobuf.Empty
+	5,   // 116: protobuf.Comin.DeploymentLatestSubmit:input_type -> protobuf.Operation
+	3,   // 117: protobuf.Comin.GenerationLogs:input_type -> protobuf.GenerationLogsRequest
+	57,  // 118: protobuf.Comin.RebootCancel:input_type -> google.protobuf.Empty
+	57,  // 119: protobuf.Comin.DeploymentWindowOverride:input_type -> google.protobuf.Empty
+	15,  // 120: protobuf.Comin.InhibitorOverride:input_type -> protobuf.InhibitorOverrideRequest
+	0,   // 121: protobuf.Comin.ListDeployments:input_type -> protobuf.ListDeploymentsRequest
+	2,   // 122: protobuf.Comin.GetDeployment:input_type -> protobuf.GetDeploymentRequest
+	17,  // 123: protobuf.Comin.GetState:output_type -> protobuf.State
+	57,  // 124: protobuf.Comin.Fetch:output_type -> google.protobuf.Empty
+	57,  // 125: protobuf.Comin.Suspend:output_type -> google.protobuf.Empty
+	57,  // 126: protobuf.Comin.Resume:output_type -> google.protobuf.Empty
+	57,  // 127: protobuf.Comin.Confirm:output_type -> google.protobuf.Empty
+	6,   // 128: protobuf.Comin.Events:output_type -> protobuf.Event
+	57,  // 129: protobuf.Comin.DeploymentLatestSubmit:output_type -> google.protobuf.Empty
+	4,   // 130: protobuf.Comin.GenerationLogs:output_type -> protobuf.GenerationLogsChunk
+	57,  // 131: protobuf.Comin.RebootCancel:output_type -> google.protobuf.Empty
+	57,  // 132: protobuf.Comin.DeploymentWindowOverride:output_type -> google.protobuf.Empty
+	57,  // 133: protobuf.Comin.InhibitorOverride:output_type -> google.protobuf.Empty
+	1,   // 134: protobuf.Comin.ListDeployments:output_type -> protobuf.ListDeploymentsResponse
+	12,  // 135: protobuf.Comin.GetDeployment:output_type -> protobuf.Deployment
+	123, // [123:136] is the sub-list for method output_type
+	110, // [110:123] is the sub-list for method input_type
+	110, // [110:110] is the sub-list for extension type_name
+	110, // [110:110] is the sub-list for extension extendee
+	0,   // [0:110] is the sub-list for field type_name
 }
 
 func init() { file_pkg_protobuf_services_proto_init() }
@@ -2682,7 +4930,7 @@ func file_pkg_protobuf_services_proto_init() {
 	if File_pkg_protobuf_services_proto != nil {
 		return
 	}
-	file_pkg_protobuf_services_proto_msgTypes[1].OneofWrappers = []any{
+	file_pkg_protobuf_services_proto_msgTypes[6].OneofWrappers = []any{
 		(*Event_EvalStartedType)(nil),
 		(*Event_EvalFinishedType)(nil),
 		(*Event_BuildStartedType)(nil),
@@ -2697,6 +4945,14 @@ func file_pkg_protobuf_services_proto_init() {
 		(*Event_RebootRequired_)(nil),
 		(*Event_ManagerState_)(nil),
 		(*Event_Fetched_)(nil),
+		(*Event_BuildSkippedType)(nil),
+		(*Event_BuildProgressType)(nil),
+		(*Event_BuildWaitingForCacheType)(nil),
+		(*Event_RebootPlannedType)(nil),
+		(*Event_RebootCancelledType)(nil),
+		(*Event_GarbageCollectedType)(nil),
+		(*Event_ClosureDiffComputedType)(nil),
+		(*Event_UnitChangesPredictedType)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -2704,7 +4960,7 @@ func file_pkg_protobuf_services_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_protobuf_services_proto_rawDesc), len(file_pkg_protobuf_services_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   31,
+			NumMessages:   55,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -23,13 +23,19 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Comin_GetState_FullMethodName               = "/protobuf.Comin/GetState"
-	Comin_Fetch_FullMethodName                  = "/protobuf.Comin/Fetch"
-	Comin_Suspend_FullMethodName                = "/protobuf.Comin/Suspend"
-	Comin_Resume_FullMethodName                 = "/protobuf.Comin/Resume"
-	Comin_Confirm_FullMethodName                = "/protobuf.Comin/Confirm"
-	Comin_Events_FullMethodName                 = "/protobuf.Comin/Events"
-	Comin_DeploymentLatestSubmit_FullMethodName = "/protobuf.Comin/DeploymentLatestSubmit"
+	Comin_GetState_FullMethodName                 = "/protobuf.Comin/GetState"
+	Comin_Fetch_FullMethodName                    = "/protobuf.Comin/Fetch"
+	Comin_Suspend_FullMethodName                  = "/protobuf.Comin/Suspend"
+	Comin_Resume_FullMethodName                   = "/protobuf.Comin/Resume"
+	Comin_Confirm_FullMethodName                  = "/protobuf.Comin/Confirm"
+	Comin_Events_FullMethodName                   = "/protobuf.Comin/Events"
+	Comin_DeploymentLatestSubmit_FullMethodName   = "/protobuf.Comin/DeploymentLatestSubmit"
+	Comin_GenerationLogs_FullMethodName           = "/protobuf.Comin/GenerationLogs"
+	Comin_RebootCancel_FullMethodName             = "/protobuf.Comin/RebootCancel"
+	Comin_DeploymentWindowOverride_FullMethodName = "/protobuf.Comin/DeploymentWindowOverride"
+	Comin_InhibitorOverride_FullMethodName        = "/protobuf.Comin/InhibitorOverride"
+	Comin_ListDeployments_FullMethodName          = "/protobuf.Comin/ListDeployments"
+	Comin_GetDeployment_FullMethodName            = "/protobuf.Comin/GetDeployment"
 )
 
 // CominClient is the client API for Comin service.
@@ -43,6 +49,12 @@ type CominClient interface {
 	Confirm(ctx context.Context, in *ConfirmRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	Events(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
 	DeploymentLatestSubmit(ctx context.Context, in *Operation, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	GenerationLogs(ctx context.Context, in *GenerationLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GenerationLogsChunk], error)
+	RebootCancel(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	DeploymentWindowOverride(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	InhibitorOverride(ctx context.Context, in *InhibitorOverrideRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListDeployments(ctx context.Context, in *ListDeploymentsRequest, opts ...grpc.CallOption) (*ListDeploymentsResponse, error)
+	GetDeployment(ctx context.Context, in *GetDeploymentRequest, opts ...grpc.CallOption) (*Deployment, error)
 }
 
 type cominClient struct {
@@ -132,6 +144,75 @@ func (c *cominClient) DeploymentLatestSubmit(ctx context.Context, in *Operation,
 	return out, nil
 }
 
+func (c *cominClient) GenerationLogs(ctx context.Context, in *GenerationLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GenerationLogsChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Comin_ServiceDesc.Streams[1], Comin_GenerationLogs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GenerationLogsRequest, GenerationLogsChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Comin_GenerationLogsClient = grpc.ServerStreamingClient[GenerationLogsChunk]
+
+func (c *cominClient) RebootCancel(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Comin_RebootCancel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cominClient) DeploymentWindowOverride(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Comin_DeploymentWindowOverride_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cominClient) InhibitorOverride(ctx context.Context, in *InhibitorOverrideRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Comin_InhibitorOverride_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cominClient) ListDeployments(ctx context.Context, in *ListDeploymentsRequest, opts ...grpc.CallOption) (*ListDeploymentsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDeploymentsResponse)
+	err := c.cc.Invoke(ctx, Comin_ListDeployments_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cominClient) GetDeployment(ctx context.Context, in *GetDeploymentRequest, opts ...grpc.CallOption) (*Deployment, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Deployment)
+	err := c.cc.Invoke(ctx, Comin_GetDeployment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CominServer is the server API for Comin service.
 // All implementations must embed UnimplementedCominServer
 // for forward compatibility.
@@ -143,6 +224,12 @@ type CominServer interface {
 	Confirm(context.Context, *ConfirmRequest) (*emptypb.Empty, error)
 	Events(*emptypb.Empty, grpc.ServerStreamingServer[Event]) error
 	DeploymentLatestSubmit(context.Context, *Operation) (*emptypb.Empty, error)
+	GenerationLogs(*GenerationLogsRequest, grpc.ServerStreamingServer[GenerationLogsChunk]) error
+	RebootCancel(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	DeploymentWindowOverride(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	InhibitorOverride(context.Context, *InhibitorOverrideRequest) (*emptypb.Empty, error)
+	ListDeployments(context.Context, *ListDeploymentsRequest) (*ListDeploymentsResponse, error)
+	GetDeployment(context.Context, *GetDeploymentRequest) (*Deployment, error)
 	mustEmbedUnimplementedCominServer()
 }
 
@@ -174,6 +261,24 @@ func (UnimplementedCominServer) Events(*emptypb.Empty, grpc.ServerStreamingServe
 func (UnimplementedCominServer) DeploymentLatestSubmit(context.Context, *Operation) (*emptypb.Empty, error) {
 	return nil, status.Error(codes.Unimplemented, "method DeploymentLatestSubmit not implemented")
 }
+func (UnimplementedCominServer) GenerationLogs(*GenerationLogsRequest, grpc.ServerStreamingServer[GenerationLogsChunk]) error {
+	return status.Error(codes.Unimplemented, "method GenerationLogs not implemented")
+}
+func (UnimplementedCominServer) RebootCancel(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method RebootCancel not implemented")
+}
+func (UnimplementedCominServer) DeploymentWindowOverride(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeploymentWindowOverride not implemented")
+}
+func (UnimplementedCominServer) InhibitorOverride(context.Context, *InhibitorOverrideRequest) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method InhibitorOverride not implemented")
+}
+func (UnimplementedCominServer) ListDeployments(context.Context, *ListDeploymentsRequest) (*ListDeploymentsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListDeployments not implemented")
+}
+func (UnimplementedCominServer) GetDeployment(context.Context, *GetDeploymentRequest) (*Deployment, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetDeployment not implemented")
+}
 func (UnimplementedCominServer) mustEmbedUnimplementedCominServer() {}
 func (UnimplementedCominServer) testEmbeddedByValue()               {}
 
@@ -314,6 +419,107 @@ func _Comin_DeploymentLatestSubmit_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Comin_GenerationLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GenerationLogsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CominServer).GenerationLogs(m, &grpc.GenericServerStream[GenerationLogsRequest, GenerationLogsChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Comin_GenerationLogsServer = grpc.ServerStreamingServer[GenerationLogsChunk]
+
+func _Comin_RebootCancel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CominServer).RebootCancel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Comin_RebootCancel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CominServer).RebootCancel(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Comin_DeploymentWindowOverride_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CominServer).DeploymentWindowOverride(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Comin_DeploymentWindowOverride_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CominServer).DeploymentWindowOverride(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Comin_InhibitorOverride_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InhibitorOverrideRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CominServer).InhibitorOverride(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Comin_InhibitorOverride_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CominServer).InhibitorOverride(ctx, req.(*InhibitorOverrideRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Comin_ListDeployments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDeploymentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CominServer).ListDeployments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Comin_ListDeployments_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CominServer).ListDeployments(ctx, req.(*ListDeploymentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Comin_GetDeployment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDeploymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CominServer).GetDeployment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Comin_GetDeployment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CominServer).GetDeployment(ctx, req.(*GetDeploymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Comin_ServiceDesc is the grpc.ServiceDesc for Comin service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -345,6 +551,26 @@ var Comin_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeploymentLatestSubmit",
 			Handler:    _Comin_DeploymentLatestSubmit_Handler,
 		},
+		{
+			MethodName: "RebootCancel",
+			Handler:    _Comin_RebootCancel_Handler,
+		},
+		{
+			MethodName: "DeploymentWindowOverride",
+			Handler:    _Comin_DeploymentWindowOverride_Handler,
+		},
+		{
+			MethodName: "InhibitorOverride",
+			Handler:    _Comin_InhibitorOverride_Handler,
+		},
+		{
+			MethodName: "ListDeployments",
+			Handler:    _Comin_ListDeployments_Handler,
+		},
+		{
+			MethodName: "GetDeployment",
+			Handler:    _Comin_GetDeployment_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -352,6 +578,11 @@ var Comin_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Comin_Events_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "GenerationLogs",
+			Handler:       _Comin_GenerationLogs_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "pkg/protobuf/services.proto",
 }
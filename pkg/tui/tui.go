@@ -7,6 +7,7 @@ import (
 
 	lipgloss "charm.land/lipgloss/v2"
 	"github.com/dustin/go-humanize"
+	"github.com/nlewo/comin/internal/builder"
 	"github.com/nlewo/comin/internal/store"
 	"github.com/nlewo/comin/pkg/protobuf"
 )
@@ -101,12 +102,17 @@ func (fm FetcherModel) View() string {
 	return b.String()
 }
 
+// maxClosureDiffLines is the maximal number of package changes shown
+// by the builder view.
+const maxClosureDiffLines = 10
+
 // BuilderModel holds the current builder state and renders it.
 type BuilderModel struct {
 	IsEvaluating bool
 	IsBuilding   bool
 	IsSuspended  bool
 	Generation   *protobuf.Generation
+	Progress     *protobuf.BuildProgress
 }
 
 func (bm BuilderModel) View() string {
@@ -118,6 +124,9 @@ func (bm BuilderModel) View() string {
 		status = activeStyle.Render("evaluating...")
 	} else if bm.IsBuilding {
 		status = activeStyle.Render("building...")
+		if summary := builder.ProgressSummary(bm.Progress); summary != "" {
+			status = activeStyle.Render("building") + " " + summary
+		}
 	} else {
 		status = dimStyle.Render("idle")
 	}
@@ -155,14 +164,40 @@ func (bm BuilderModel) View() string {
 			b.WriteString("  " + labelStyle.Render("Build:   ") +
 				activeStyle.Render("running") +
 				fmt.Sprintf(" since %s\n", formatTime(g.BuildStartedAt.AsTime())))
+			if bm.Progress != nil && bm.Progress.Phase != "" {
+				b.WriteString("  " + labelStyle.Render("Phase:   ") + bm.Progress.Phase + "\n")
+			}
+		case store.BuildWaitingForCache.String():
+			b.WriteString("  " + labelStyle.Render("Build:   ") +
+				warnStyle.Render("waiting for cache") +
+				fmt.Sprintf(" since %s\n", formatTime(g.BuildStartedAt.AsTime())))
 		case store.Built.String():
 			b.WriteString("  " + labelStyle.Render("Build:   ") +
 				successStyle.Render("succeeded") +
 				fmt.Sprintf(" %s\n", formatTime(g.BuildEndedAt.AsTime())))
+			if g.ClosureDiff != nil {
+				b.WriteString("  " + labelStyle.Render("Changes: ") + store.ClosureDiffSummary(g.ClosureDiff) + "\n")
+				lines := store.ClosureDiffLines(g.ClosureDiff)
+				for i, l := range lines {
+					if i == maxClosureDiffLines {
+						b.WriteString("    " + dimStyle.Render(fmt.Sprintf("... %d more", len(lines)-i)) + "\n")
+						break
+					}
+					b.WriteString("    " + l + "\n")
+				}
+			}
+			for _, l := range store.UnitChangesLines(g.UnitChanges) {
+				b.WriteString("  " + labelStyle.Render("Units:   ") + l + "\n")
+			}
 		case store.BuildFailed.String():
 			b.WriteString("  " + labelStyle.Render("Build:   ") +
 				errorStyle.Render("failed") +
 				fmt.Sprintf(" %s\n", formatTime(g.BuildEndedAt.AsTime())))
+		case store.BuildSkipped.String():
+			b.WriteString("  " + labelStyle.Render("Build:   ") +
+				warnStyle.Render("skipped") +
+				fmt.Sprintf(" %s\n", formatTime(g.BuildEndedAt.AsTime())))
+			b.WriteString("    " + warnStyle.Render(g.BuildReason) + "\n")
 		}
 	}
 	return b.String()
@@ -173,6 +208,9 @@ type DeployerModel struct {
 	IsDeploying bool
 	IsSuspended bool
 	Deployment  *protobuf.Deployment
+	// PendingReason is the reason why the generation to deploy is
+	// postponed
+	PendingReason string
 }
 
 func (dm DeployerModel) View() string {
@@ -186,6 +224,9 @@ func (dm DeployerModel) View() string {
 		status = dimStyle.Render("idle")
 	}
 	b.WriteString(sectionStyle.Render("Deployer") + "  " + status + "\n")
+	if dm.PendingReason != "" {
+		b.WriteString("  " + labelStyle.Render("Pending:   ") + warnStyle.Render(dm.PendingReason) + "\n")
+	}
 
 	if dm.Deployment != nil {
 		d := dm.Deployment
@@ -225,12 +266,16 @@ func (dm DeployerModel) View() string {
 // ManagerModel holds the current manager state and renders it.
 type ManagerModel struct {
 	NeedToReboot  bool
+	RebootReasons []string
 	IsSuspended   bool
 	Hostname      string
 	ConnectionMsg string
 	Fetcher       FetcherModel
 	Builder       BuilderModel
 	Deployer      DeployerModel
+
+	// RebootPlannedAt is the time of the planned automatic reboot
+	RebootPlannedAt time.Time
 }
 
 func (mm ManagerModel) View() string {
@@ -247,6 +292,12 @@ func (mm ManagerModel) View() string {
 	}
 	b.WriteString("  " + dimStyle.Render("Connected") + "\n")
 	b.WriteString("  " + labelStyle.Render("Reboot required: ") + boolToString(mm.NeedToReboot) + "\n")
+	for _, r := range mm.RebootReasons {
+		b.WriteString("    " + dimStyle.Render(r) + "\n")
+	}
+	if !mm.RebootPlannedAt.IsZero() {
+		b.WriteString("  " + labelStyle.Render("Reboot planned:  ") + humanize.Time(mm.RebootPlannedAt) + "\n")
+	}
 	b.WriteString("  " + labelStyle.Render("Suspended:       ") + boolToString(mm.IsSuspended) + "\n")
 	b.WriteString("\n")
 	b.WriteString(mm.Fetcher.View())
@@ -263,6 +314,11 @@ func UpdateManager(manager *ManagerModel, event *protobuf.Event) {
 	case *protobuf.Event_ManagerState_:
 		state := e.ManagerState.State
 		manager.NeedToReboot = state.NeedToReboot.GetValue()
+		manager.RebootReasons = state.RebootReasons
+		manager.RebootPlannedAt = time.Time{}
+		if state.Reboot != nil && state.Reboot.PlannedAt != nil {
+			manager.RebootPlannedAt = state.Reboot.PlannedAt.AsTime()
+		}
 		manager.IsSuspended = state.IsSuspended.GetValue()
 		if state.Builder != nil {
 			manager.Hostname = state.Builder.Hostname
@@ -270,11 +326,13 @@ func UpdateManager(manager *ManagerModel, event *protobuf.Event) {
 			manager.Builder.IsBuilding = state.Builder.IsBuilding.GetValue()
 			manager.Builder.IsSuspended = state.Builder.IsSuspended.GetValue()
 			manager.Builder.Generation = state.Builder.Generation
+			manager.Builder.Progress = state.Builder.BuildProgress
 		}
 		if state.Deployer != nil {
 			manager.Deployer.IsDeploying = state.Deployer.IsDeploying.GetValue()
 			manager.Deployer.IsSuspended = state.Deployer.IsSuspended.GetValue()
 			manager.Deployer.Deployment = state.Deployer.Deployment
+			manager.Deployer.PendingReason = state.Deployer.PendingReason
 		}
 		if state.Fetcher != nil {
 			manager.Fetcher.IsFetching = state.Fetcher.IsFetching.GetValue()
@@ -289,11 +347,30 @@ func UpdateManager(manager *ManagerModel, event *protobuf.Event) {
 	case *protobuf.Event_BuildStartedType:
 		manager.Builder.IsBuilding = true
 		manager.Builder.Generation = e.BuildStartedType.Generation
+		manager.Builder.Progress = nil
+	case *protobuf.Event_BuildProgressType:
+		if manager.Builder.Generation != nil && manager.Builder.Generation.Uuid == e.BuildProgressType.GenerationUuid {
+			manager.Builder.Progress = e.BuildProgressType.Progress
+		}
+	case *protobuf.Event_BuildWaitingForCacheType:
+		manager.Builder.Generation = e.BuildWaitingForCacheType.Generation
+	case *protobuf.Event_ClosureDiffComputedType:
+		if manager.Builder.Generation != nil && manager.Builder.Generation.Uuid == e.ClosureDiffComputedType.Generation.Uuid {
+			manager.Builder.Generation = e.ClosureDiffComputedType.Generation
+		}
+	case *protobuf.Event_UnitChangesPredictedType:
+		if manager.Builder.Generation != nil && manager.Builder.Generation.Uuid == e.UnitChangesPredictedType.Generation.Uuid {
+			manager.Builder.Generation = e.UnitChangesPredictedType.Generation
+		}
 	case *protobuf.Event_BuildFinishedType:
 		manager.Builder.IsBuilding = false
 		manager.Builder.Generation = e.BuildFinishedType.Generation
+	case *protobuf.Event_BuildSkippedType:
+		manager.Builder.IsBuilding = false
+		manager.Builder.Generation = e.BuildSkippedType.Generation
 	case *protobuf.Event_DeploymentStartedType:
 		manager.Deployer.IsDeploying = true
+		manager.Deployer.PendingReason = ""
 		manager.Deployer.Deployment = e.DeploymentStartedType.Deployment
 	case *protobuf.Event_DeploymentFinishedType:
 		manager.Deployer.IsDeploying = false
@@ -307,5 +384,10 @@ func UpdateManager(manager *ManagerModel, event *protobuf.Event) {
 		manager.IsSuspended = false
 	case *protobuf.Event_RebootRequired_:
 		manager.NeedToReboot = true
+		manager.RebootReasons = e.RebootRequired.Reasons
+	case *protobuf.Event_RebootPlannedType:
+		manager.RebootPlannedAt = e.RebootPlannedType.Reboot.PlannedAt.AsTime()
+	case *protobuf.Event_RebootCancelledType:
+		manager.RebootPlannedAt = time.Time{}
 	}
 }